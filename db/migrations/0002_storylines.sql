@@ -34,7 +34,7 @@ CREATE TABLE IF NOT EXISTS storyline_observations (
     obs_date      DATE NOT NULL,
     message_count INT  NOT NULL DEFAULT 0,    -- сколько сообщений легло в сюжет в этот день
     importance    INT  NOT NULL DEFAULT 1,    -- важность сюжета в этот день
-    change_type   TEXT NOT NULL,              -- new|escalation|ongoing|deescalation|recurring_noise
+    change_type   TEXT NOT NULL,              -- new|escalation|ongoing|deescalation|recurring_noise|revived
     delta_summary TEXT,                       -- что именно нового в этот день
     source_message_ids BIGINT[],              -- реальные message_id из messages
     created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -0,0 +1,20 @@
+-- db/migrations/0004_storylines_hnsw_index.sql
+-- HNSW-индекс по эмбеддингам сюжетов (замена ivfflat, удалённого в 0003).
+--
+-- В отличие от ivfflat, HNSW не зависит от данных на момент создания и не требует
+-- перестроения после наполнения. Используется ли он, решает StorylineRepository:
+-- STORYLINE_SEARCH_MODE = exact | ann | auto (по умолчанию auto — HNSW от
+-- config.ANNMinRows сюжетов с эмбеддингом). В режиме exact индекс запрещён через
+-- SET LOCAL enable_indexscan = off; в режиме ann на время запроса выставляется
+-- SET LOCAL hnsw.ef_search = config.HNSWEfSearch.
+--
+-- HNSW сначала выбирает ef_search ближайших по всей таблице и лишь потом применяет
+-- фильтр по channel_id/status, поэтому при многих каналах recall может проседать.
+-- Если ANN вернул меньше запрошенных сюжетов канала, SearchNearest повторяет
+-- поиск точным перебором. StorylineIndexMonitor раз в сутки сравнивает ANN с
+-- точным перебором и пишет деградацию в лог.
+--
+-- Требует pgvector >= 0.5. Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE INDEX IF NOT EXISTS idx_storylines_embedding_hnsw
+    ON storylines USING hnsw (embedding vector_cosine_ops) WITH (m = 16, ef_construction = 64);
@@ -0,0 +1,24 @@
+-- db/migrations/0005_period_summaries.sql
+-- Дайджесты за период (/week, /month), собранные из storyline_observations и storylines.state.
+--
+-- Один дайджест на (канал, период, границы). input_hash — hex SHA-256 входа
+-- RenderPeriodDigest (сюжеты периода с дугами): если к моменту запроса хэш не
+-- совпадает (досчитался вчерашний день, бэкфилл, пересчёт дня, переименование,
+-- слияние или разделение сюжета), PeriodDigestService перегенерирует текст и
+-- перезаписывает строку. observations_count — сколько наблюдений легло в
+-- дайджест, справочно.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS period_summaries (
+    id                 SERIAL PRIMARY KEY,
+    channel_id         BIGINT NOT NULL,
+    period             TEXT   NOT NULL,           -- week | month
+    period_start       DATE   NOT NULL,           -- включительно
+    period_end         DATE   NOT NULL,           -- не включительно
+    summary            TEXT   NOT NULL,
+    observations_count INT    NOT NULL DEFAULT 0,
+    input_hash         TEXT   NOT NULL DEFAULT '',
+    created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (channel_id, period, period_start, period_end)
+);
@@ -0,0 +1,28 @@
+-- db/migrations/0006_ml_calls.sql
+-- Учёт вызовов LLM и эмбеддингов: токены, стоимость, задержка, успех.
+--
+-- Одна строка на вызов провайдера (повторные запросы исправления ответа — отдельные строки).
+-- channel_id/call_date — канал и день, для которого строился дайджест (0 и дата вызова,
+-- если вызов не привязан к каналу). cost считается при записи по config.ModelPricePer1K,
+-- поэтому смена цен не переписывает историю. Месячный бюджет (ML_MONTHLY_BUDGET)
+-- сравнивается с суммой cost по created_at за текущий месяц.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS ml_calls (
+    id                BIGSERIAL PRIMARY KEY,
+    stage             TEXT    NOT NULL,           -- extract | match | delta | render | embed_doc | embed_query
+    channel_id        BIGINT  NOT NULL DEFAULT 0,
+    call_date         DATE    NOT NULL,
+    provider          TEXT    NOT NULL,
+    model             TEXT    NOT NULL,
+    prompt_tokens     INT     NOT NULL DEFAULT 0,
+    completion_tokens INT     NOT NULL DEFAULT 0,
+    cost              NUMERIC(12, 4) NOT NULL DEFAULT 0,
+    latency_ms        INT     NOT NULL DEFAULT 0,
+    success           BOOLEAN NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ml_calls_created_at_idx ON ml_calls (created_at);
+CREATE INDEX IF NOT EXISTS ml_calls_channel_date_idx ON ml_calls (channel_id, call_date);
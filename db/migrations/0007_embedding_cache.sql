@@ -0,0 +1,17 @@
+-- db/migrations/0007_embedding_cache.sql
+-- Общий кэш эмбеддингов: (модель, SHA-256 текста) -> вектор.
+--
+-- MLRepository.embed сначала ищет векторы пачкой по хешам и отправляет провайдеру
+-- только промахи, поэтому регенерации из админки, бэкфилл и scripts/threshold_calibration
+-- не платят повторно за те же тексты. model — полный URI/имя модели стадии: при смене
+-- модели кэш естественно не используется. Размерность совпадает с storylines.embedding.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    model      TEXT        NOT NULL,
+    text_hash  CHAR(64)    NOT NULL,           -- hex SHA-256 текста
+    embedding  vector(256) NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model, text_hash)
+);
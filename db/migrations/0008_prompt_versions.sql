@@ -0,0 +1,25 @@
+-- db/migrations/0008_prompt_versions.sql
+-- Версии системных промптов и подпись промптов на сводках и наблюдениях.
+--
+-- Встроенные версии лежат в src/repository/prompts/<имя>.<версия>.tmpl. Здесь — версии,
+-- добавленные без деплоя (scripts/prompts add). Активная версия (не больше одной на имя)
+-- заменяет последнюю встроенную при старте бота; нет активной — используется встроенная.
+--
+-- prompt_version в summaries и storyline_observations — подпись набора промптов дневного
+-- конвейера вида "extract@v1,match@v1,delta@v2,render@v1"; NULL у строк до этой миграции.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    name       TEXT    NOT NULL,           -- extract | match | delta | render | period_render | legacy_extract | legacy_render
+    version    TEXT    NOT NULL,
+    template   TEXT    NOT NULL,           -- text/template, поля см. repository.promptData
+    active     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS prompt_versions_active_idx ON prompt_versions (name) WHERE active;
+
+ALTER TABLE summaries ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+ALTER TABLE storyline_observations ADD COLUMN IF NOT EXISTS prompt_version TEXT;
@@ -0,0 +1,25 @@
+-- db/migrations/0009_summary_feedback.sql
+-- Отзывы читателей на дневные дайджесты: 👍/👎 и "ошибка в сюжете".
+--
+-- Кнопки висят под последней частью новостей (NewsHandler, MailingService).
+-- Оценка up/down — одна на читателя и дайджест, повторное нажатие её перезаписывает.
+-- Ошибки (rating = 'error') копятся по сюжетам: storyline_id — сюжет дня, который выбрал
+-- читатель, NULL — ошибка без привязки к сюжету. Сводка по каналам и версиям промптов
+-- (summaries.prompt_version) — в админке, кнопка "Отзывы читателей".
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS summary_feedback (
+    id           SERIAL PRIMARY KEY,
+    summary_id   INT    NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+    chat_id      BIGINT NOT NULL,
+    rating       TEXT   NOT NULL,          -- up | down | error
+    storyline_id INT    REFERENCES storylines(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS summary_feedback_vote_idx
+    ON summary_feedback (summary_id, chat_id) WHERE rating <> 'error';
+CREATE UNIQUE INDEX IF NOT EXISTS summary_feedback_error_idx
+    ON summary_feedback (summary_id, chat_id, (COALESCE(storyline_id, 0))) WHERE rating = 'error';
+CREATE INDEX IF NOT EXISTS summary_feedback_storyline_idx ON summary_feedback (storyline_id) WHERE storyline_id IS NOT NULL;
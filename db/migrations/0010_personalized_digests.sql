@@ -0,0 +1,29 @@
+-- db/migrations/0010_personalized_digests.sql
+-- Персональные дайджесты: читатель глушит рубрики и поднимает интересные.
+--
+-- summaries.digest_groups — группы сюжетов (repository.DigestGroups), из которых отрендерен
+-- дайджест; NULL у дайджестов, собранных до этой миграции, их персонализация не трогает.
+-- user_category_preferences — настройки читателя по рубрикам (config.Categories):
+-- mute убирает сюжеты рубрики, boost поднимает их в начало каждой группы.
+-- personalized_summaries — кэш перерендеренных вариантов: один на дайджест и набор
+-- настроек (preference_key), поэтому LLM вызывается на набор настроек, а не на читателя.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+ALTER TABLE summaries ADD COLUMN IF NOT EXISTS digest_groups JSONB;
+
+CREATE TABLE IF NOT EXISTS user_category_preferences (
+    chat_id    BIGINT NOT NULL,
+    category   TEXT   NOT NULL,
+    preference TEXT   NOT NULL,           -- mute | boost
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, category)
+);
+
+CREATE TABLE IF NOT EXISTS personalized_summaries (
+    summary_id     INT  NOT NULL REFERENCES summaries(id) ON DELETE CASCADE,
+    preference_key TEXT NOT NULL,         -- CategoryPreferences.Key()
+    summary        TEXT NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (summary_id, preference_key)
+);
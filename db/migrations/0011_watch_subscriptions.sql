@@ -0,0 +1,18 @@
+-- db/migrations/0011_watch_subscriptions.sql
+-- Подписки читателей на ключевые слова и регулярки (/watch).
+--
+-- pattern — слово/фраза (сравнивается по основам слов, см. textutil.StemRussian) или
+-- регулярное выражение без ограничивающих слэшей (is_regex). WatchService проверяет
+-- каждое новое сообщение, сохранённое MessageService, и шлёт отрывок со ссылкой на пост;
+-- сообщения старше created_at подписки не проверяются.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS watch_subscriptions (
+    id         SERIAL PRIMARY KEY,
+    chat_id    BIGINT  NOT NULL,
+    pattern    TEXT    NOT NULL,
+    is_regex   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (chat_id, pattern, is_regex)
+);
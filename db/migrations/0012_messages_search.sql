@@ -0,0 +1,15 @@
+-- db/migrations/0012_messages_search.sql
+-- Полнотекстовый поиск по архиву сообщений каналов (/search).
+--
+-- search_vector — генерируемая колонка с русской конфигурацией: Postgres сам пересчитывает
+-- её при вставке и правке message_text, MessageRepository ничего не пишет. Запросы идут
+-- через websearch_to_tsquery('russian', ...), поэтому индекс строится по той же конфигурации.
+-- На большой таблице ALTER перепишет её целиком — применять в окне обслуживания.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(message_text, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_messages_date ON messages (message_date DESC);
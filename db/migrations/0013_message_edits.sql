@@ -0,0 +1,21 @@
+-- db/migrations/0013_message_edits.sql
+-- Правки и удаления постов в исходных каналах.
+--
+-- MessageService каждый проход перечитывает историю канала за последние
+-- config.MessageSyncLookbackHours часов: у отредактированных постов обновляются
+-- message_text и edit_date, посты, пропавшие из истории, помечаются deleted_at
+-- (строка остаётся для истории, но дайджесты, /search и /ask её не читают).
+-- Наблюдения сюжетов, чьи source_message_ids ссылаются на изменённый или удалённый
+-- пост, помечаются needs_regeneration; флаг снимается, когда наблюдение за этот день
+-- пересчитывается (перегенерация суммаризации из /admin или бэкфилл).
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edit_date TIMESTAMP;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE storyline_observations
+    ADD COLUMN IF NOT EXISTS needs_regeneration BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_storyline_observations_needs_regeneration
+    ON storyline_observations (channel_id, obs_date) WHERE needs_regeneration;
@@ -0,0 +1,15 @@
+-- db/migrations/0014_message_meta.sql
+-- Медиа-посты, альбомы, пересылки и метрики постов.
+--
+-- MessageService сохраняет и посты без текста (фото/видео без подписи). Альбом
+-- (сообщения с общим grouped_id) сохраняется одной строкой: message_id первого
+-- элемента, подписи всех элементов, id остальных - в meta.album_message_ids.
+-- meta хранит тип медиа, происхождение пересылки, id поста, на который отвечает
+-- сообщение, и метрики (views, forwards, reactions, replies); метрики обновляются
+-- каждый проход синхронизации, пока пост в окне config.MessageSyncLookbackHours.
+-- Стадия A получает метрики как сигнал важности.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS grouped_id BIGINT;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS meta JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,16 @@
+-- db/migrations/0015_channel_cursors.sql
+-- Курсор загрузки постов по каналам.
+--
+-- MessageService берёт новые посты по message_id: всё, что больше last_message_id,
+-- но не старше config.MessageBackfillMaxHours, поэтому простой бота короче этого
+-- окна и посты с одинаковым временем не теряются. Курсор сдвигается, только если
+-- проход сохранил все посты. Для канала без курсора берётся максимальный
+-- сохранённый message_id. Более старые пропуски догружаются /backfill.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS channel_cursors (
+    channel_id      BIGINT PRIMARY KEY,
+    last_message_id BIGINT NOT NULL,
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,26 @@
+-- db/migrations/0016_mtproto_updates_state.sql
+-- Состояние потока обновлений MTProto для менеджера updates из gotd.
+--
+-- MessageService держит постоянное подключение и получает посты каналов по мере
+-- публикации. Чтобы после перезапуска догнать пропущенное через getDifference и
+-- getChannelDifference, менеджер хранит pts/qts/date/seq аккаунта (mtproto_state),
+-- pts и access_hash каналов (mtproto_channels). Строки привязаны к user_id
+-- аккаунта, от имени которого читаются каналы.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS mtproto_state (
+    user_id BIGINT PRIMARY KEY,
+    pts     INT NOT NULL,
+    qts     INT NOT NULL,
+    date    INT NOT NULL,
+    seq     INT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mtproto_channels (
+    user_id     BIGINT NOT NULL,
+    channel_id  BIGINT NOT NULL,
+    pts         INT,    -- NULL, пока известен только access_hash
+    access_hash BIGINT, -- NULL, пока известен только pts
+    PRIMARY KEY (user_id, channel_id)
+);
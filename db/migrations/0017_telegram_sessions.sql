@@ -0,0 +1,17 @@
+-- db/migrations/0017_telegram_sessions.sql
+-- Сессия MTProto-аккаунта, от имени которого бот читает каналы.
+--
+-- Раньше сессия лежала в session/telegram-session/session.json и создавалась
+-- только scripts/auth из консоли. Теперь, если задан SESSION_ENCRYPTION_KEY
+-- (32 байта в base64), она хранится здесь, зашифрованная AES-256-GCM (nonce
+-- перед шифртекстом), и пересоздаётся входом из бота (/admin -> «Вход в
+-- Telegram»). При первом запуске с ключом существующий session.json переносится
+-- в таблицу.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE TABLE IF NOT EXISTS telegram_sessions (
+    name       TEXT PRIMARY KEY,
+    data       BYTEA NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
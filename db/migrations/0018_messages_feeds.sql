@@ -0,0 +1,18 @@
+-- db/migrations/0018_messages_feeds.sql
+-- Посты RSS/Atom-лент в messages.
+--
+-- Ленты из config.Feeds хранятся в messages рядом с каналами Telegram, с
+-- отрицательными channel_id. У записей лент нет числовых id: message_id -
+-- 63-битный хэш ключа записи (guid, иначе ссылка), сам ключ лежит в meta.guid,
+-- адрес материала - в meta.url. Уникальный индекс гарантирует одну строку на
+-- запись, даже если хэши двух ключей совпадут: SaveMessage вставляет с
+-- ON CONFLICT DO NOTHING без цели и пропускает такую запись. У постов Telegram
+-- meta.guid нет, индекс их не касается.
+--
+-- Курсор ленты в channel_cursors - Unix-время самой свежей записи.
+--
+-- Применяется вручную, как 0001/0002 (см. AGENTS.md).
+
+CREATE UNIQUE INDEX IF NOT EXISTS messages_feed_guid_idx
+    ON messages (channel_id, (meta->>'guid'))
+    WHERE meta ? 'guid';
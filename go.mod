@@ -17,6 +17,7 @@ require (
 	github.com/yandex-cloud/go-sdk v0.27.0
 	go.uber.org/mock v0.5.0
 	go.uber.org/zap v1.28.0
+	golang.org/x/net v0.55.0
 	gopkg.in/telebot.v4 v4.0.0-beta.4
 )
 
@@ -58,7 +59,6 @@ require (
 	golang.org/x/crypto v0.51.0 // indirect
 	golang.org/x/exp v0.0.0-20230725093048-515e97ebf090 // indirect
 	golang.org/x/mod v0.35.0 // indirect
-	golang.org/x/net v0.55.0 // indirect
 	golang.org/x/sync v0.20.0 // indirect
 	golang.org/x/sys v0.45.0 // indirect
 	golang.org/x/term v0.43.0 // indirect
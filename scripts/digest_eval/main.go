@@ -0,0 +1,440 @@
+// Офлайн-оценка качества дневных дайджестов.
+//
+// Для каждого сохранённого дня канала берёт дайджест из summaries, наблюдения
+// сюжетов этого дня из storyline_observations и сообщения по их source_message_ids.
+// Считает:
+//   - faithfulness: 1 - доля утверждений дайджеста, которых нет в исходных
+//     сообщениях (LLM-судья JudgeDigest, промпт digest_judge);
+//   - coverage: доля top-N сюжетов дня по важности, отражённых в дайджесте (тот же судья);
+//   - grounding: доля наблюдений, чьи source_message_ids нашлись в messages;
+//   - длину дайджеста и влезает ли он в одно сообщение Telegram.
+//
+// Пишет построчный отчёт в CSV и JSON и печатает средние по prompt_version,
+// чтобы сравнивать смену промптов и порогов на одних и тех же днях.
+// Вердикты судьи кэшируются в JSON-файл по id дайджеста — повторный запуск бесплатен.
+//
+// Дайджест дня D бот сохраняет с created_at в D+1 (строит за вчера),
+// storyline_backfill с -write-summaries — ровно в полночь D; берётся последний из них.
+//
+// Запуск:
+//
+//	DATABASE_URL=postgres://... go run ./scripts/digest_eval \
+//	  [-days 7] [-end-date 2026-06-20] [-channel 123] [-top 5] \
+//	  [-csv /tmp/digest_eval.csv] [-json /tmp/digest_eval.json] [-cache /tmp/digest_eval_cache.json]
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	"github.com/joho/godotenv"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+type observation struct {
+	Title            string
+	DeltaSummary     string
+	Importance       int
+	SourceMessageIDs []int64
+}
+
+type dayReport struct {
+	ChannelID            int64    `json:"channel_id"`
+	Channel              string   `json:"channel"`
+	Day                  string   `json:"day"`
+	SummaryID            int64    `json:"summary_id"`
+	PromptVersion        string   `json:"prompt_version"`
+	Observations         int      `json:"observations"`
+	GroundedObservations int      `json:"grounded_observations"`
+	Grounding            float64  `json:"grounding"`
+	Sources              int      `json:"sources"`
+	Claims               int      `json:"claims"`
+	Unsupported          []string `json:"unsupported"`
+	Faithfulness         float64  `json:"faithfulness"`
+	KeyItems             int      `json:"key_items"`
+	Covered              int      `json:"covered"`
+	Coverage             float64  `json:"coverage"`
+	LengthChars          int      `json:"length_chars"`
+	FitsMessage          bool     `json:"fits_message"`
+}
+
+type cache struct {
+	Judgements map[string]repository.DigestJudgement `json:"judgements"` // "summaryID/top" -> вердикт
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	days := flag.Int("days", 7, "сколько прошлых дней оценить")
+	endDateStr := flag.String("end-date", "", "последний оцениваемый день в формате YYYY-MM-DD (по умолчанию вчера)")
+	channelFlag := flag.Int64("channel", 0, "ID канала (по умолчанию все каналы и ленты из config.ChannelNames())")
+	top := flag.Int("top", 5, "сколько главных сюжетов дня должен покрыть дайджест")
+	csvPath := flag.String("csv", "/tmp/digest_eval.csv", "куда писать отчёт в CSV")
+	jsonPath := flag.String("json", "/tmp/digest_eval.json", "куда писать отчёт в JSON")
+	cachePath := flag.String("cache", "/tmp/digest_eval_cache.json", "файл кэша вердиктов судьи")
+	flag.Parse()
+
+	endDate := time.Now().UTC().AddDate(0, 0, -1)
+	if *endDateStr != "" {
+		parsed, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("invalid -end-date: %v", err)
+		}
+		endDate = parsed.UTC()
+	}
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ml, err := repository.NewMLRepository()
+	if err != nil {
+		log.Fatalf("failed to init ML repository: %v", err)
+	}
+	ml.WithUsage(repository.NewMLUsageRepository(db))
+	if err := ml.LoadPrompts(repository.NewPromptRepository(db)); err != nil {
+		log.Fatalf("failed to load prompts: %v", err)
+	}
+
+	c := loadCache(*cachePath)
+
+	var reports []dayReport
+	for _, channelID := range selectChannels(*channelFlag) {
+		for d := endDate.AddDate(0, 0, -(*days - 1)); !d.After(endDate); d = d.AddDate(0, 0, 1) {
+			report, ok, err := evaluateDay(db, ml, c, channelID, d, *top)
+			if err != nil {
+				log.Errorf("channel %d %s: %v", channelID, d.Format("2006-01-02"), err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			reports = append(reports, report)
+			saveCache(*cachePath, c)
+			log.Infof("channel %d %s: faithfulness %.2f, coverage %.2f, grounding %.2f, %d chars",
+				channelID, report.Day, report.Faithfulness, report.Coverage, report.Grounding, report.LengthChars)
+		}
+	}
+	log.Infof("Evaluated %d digests", len(reports))
+
+	if err := writeCSV(*csvPath, reports); err != nil {
+		log.Fatalf("failed to write csv: %v", err)
+	}
+	if err := writeJSON(*jsonPath, reports); err != nil {
+		log.Fatalf("failed to write json: %v", err)
+	}
+	log.Infof("Reports written to %s and %s", *csvPath, *jsonPath)
+
+	printReport(reports)
+}
+
+func selectChannels(channelFlag int64) []int64 {
+	if channelFlag != 0 {
+		return []int64{channelFlag}
+	}
+	names := config.ChannelNames()
+	channels := make([]int64, 0, len(names))
+	for id := range names {
+		channels = append(channels, id)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
+
+// evaluateDay оценивает дайджест дня; ok = false, если дайджеста за день нет.
+func evaluateDay(db *sql.DB, ml repository.MLRepositoryInterface, c *cache, channelID int64, day time.Time, top int) (dayReport, bool, error) {
+	summaryID, digest, promptVersion, err := loadSummary(db, channelID, day)
+	if err != nil {
+		return dayReport{}, false, fmt.Errorf("failed to load summary: %w", err)
+	}
+	if digest == "" {
+		return dayReport{}, false, nil
+	}
+	observations, err := loadObservations(db, channelID, day)
+	if err != nil {
+		return dayReport{}, false, fmt.Errorf("failed to load observations: %w", err)
+	}
+	messages, err := loadMessages(db, channelID, observations)
+	if err != nil {
+		return dayReport{}, false, fmt.Errorf("failed to load source messages: %w", err)
+	}
+
+	report := dayReport{
+		ChannelID:     channelID,
+		Channel:       config.ChannelNames()[channelID],
+		Day:           day.Format("2006-01-02"),
+		SummaryID:     summaryID,
+		PromptVersion: promptVersion,
+		Observations:  len(observations),
+		LengthChars:   len([]rune(digest)),
+		FitsMessage:   len([]rune(digest)) <= telegramutil.MaxMessageLength,
+	}
+
+	// Источники - сообщения наблюдений в порядке сюжетов, без повторов.
+	var sources []string
+	seen := make(map[int64]bool)
+	for _, o := range observations {
+		grounded := false
+		for _, id := range o.SourceMessageIDs {
+			text, ok := messages[id]
+			if !ok {
+				continue
+			}
+			grounded = true
+			if !seen[id] {
+				seen[id] = true
+				sources = append(sources, text)
+			}
+		}
+		if grounded {
+			report.GroundedObservations++
+		}
+	}
+	report.Sources = len(sources)
+	report.Grounding = ratio(report.GroundedObservations, report.Observations)
+
+	// observations уже отсортированы по важности.
+	var keyItems []string
+	for _, o := range observations[:min(top, len(observations))] {
+		keyItems = append(keyItems, o.Title+": "+o.DeltaSummary)
+	}
+	report.KeyItems = len(keyItems)
+
+	key := fmt.Sprintf("%d/%d", summaryID, top)
+	judgement, ok := c.Judgements[key]
+	if !ok {
+		judgement, err = ml.ForDay(channelID, day).JudgeDigest(repository.DigestJudgeInput{
+			Digest:   digest,
+			Sources:  sources,
+			KeyItems: keyItems,
+		})
+		if err != nil {
+			return dayReport{}, false, err
+		}
+		c.Judgements[key] = judgement
+	}
+
+	report.Claims = judgement.Claims
+	report.Unsupported = judgement.Unsupported
+	report.Faithfulness = 1
+	if judgement.Claims > 0 {
+		report.Faithfulness = 1 - ratio(len(judgement.Unsupported), judgement.Claims)
+	}
+	report.Covered = len(judgement.CoveredItems)
+	report.Coverage = 1
+	if report.KeyItems > 0 {
+		report.Coverage = ratio(report.Covered, report.KeyItems)
+	}
+	return report, true, nil
+}
+
+// loadSummary возвращает последний дайджест дня day (см. комментарий пакета о датах).
+func loadSummary(db *sql.DB, channelID int64, day time.Time) (int64, string, string, error) {
+	var (
+		id            int64
+		summary       string
+		promptVersion sql.NullString
+	)
+	err := db.QueryRow(`
+		SELECT id, summary, prompt_version
+		FROM summaries
+		WHERE channel_id = $1
+		AND (created_at = $2 OR (created_at >= $3 AND created_at < $4))
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		channelID, day, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2),
+	).Scan(&id, &summary, &promptVersion)
+	if err == sql.ErrNoRows {
+		return 0, "", "", nil
+	}
+	if err != nil {
+		return 0, "", "", err
+	}
+	return id, summary, promptVersion.String, nil
+}
+
+func loadObservations(db *sql.DB, channelID int64, day time.Time) ([]observation, error) {
+	rows, err := db.Query(`
+		SELECT s.title, COALESCE(o.delta_summary, ''), o.importance, COALESCE(o.source_message_ids, '{}')
+		FROM storyline_observations o
+		JOIN storylines s ON s.id = o.storyline_id
+		WHERE o.channel_id = $1 AND o.obs_date = $2 AND o.change_type <> 'recurring_noise'
+		ORDER BY o.importance DESC, o.message_count DESC, o.storyline_id`,
+		channelID, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []observation
+	for rows.Next() {
+		var o observation
+		if err := rows.Scan(&o.Title, &o.DeltaSummary, &o.Importance, pq.Array(&o.SourceMessageIDs)); err != nil {
+			return nil, err
+		}
+		result = append(result, o)
+	}
+	return result, rows.Err()
+}
+
+// loadMessages возвращает тексты сообщений, на которые ссылаются наблюдения, по message_id.
+func loadMessages(db *sql.DB, channelID int64, observations []observation) (map[int64]string, error) {
+	var ids []int64
+	for _, o := range observations {
+		ids = append(ids, o.SourceMessageIDs...)
+	}
+	result := make(map[int64]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT message_id, COALESCE(message_text, '')
+		FROM messages
+		WHERE channel_id = $1 AND message_id = ANY($2)`,
+		channelID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return nil, err
+		}
+		result[id] = text
+	}
+	return result, rows.Err()
+}
+
+func loadCache(path string) *cache {
+	c := &cache{Judgements: map[string]repository.DigestJudgement{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		log.Warnf("failed to parse cache %s, starting fresh: %v", path, err)
+		return &cache{Judgements: map[string]repository.DigestJudgement{}}
+	}
+	if c.Judgements == nil {
+		c.Judgements = map[string]repository.DigestJudgement{}
+	}
+	return c
+}
+
+func saveCache(path string, c *cache) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		log.Warnf("failed to marshal cache: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Warnf("failed to write cache: %v", err)
+	}
+}
+
+func writeCSV(path string, reports []dayReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	_ = w.Write([]string{"channel_id", "channel", "day", "summary_id", "prompt_version", "observations", "grounded_observations", "grounding",
+		"sources", "claims", "unsupported_count", "faithfulness", "key_items", "covered", "coverage", "length_chars", "fits_message", "unsupported"})
+	for _, r := range reports {
+		_ = w.Write([]string{
+			strconv.FormatInt(r.ChannelID, 10),
+			r.Channel,
+			r.Day,
+			strconv.FormatInt(r.SummaryID, 10),
+			r.PromptVersion,
+			strconv.Itoa(r.Observations),
+			strconv.Itoa(r.GroundedObservations),
+			fmt.Sprintf("%.3f", r.Grounding),
+			strconv.Itoa(r.Sources),
+			strconv.Itoa(r.Claims),
+			strconv.Itoa(len(r.Unsupported)),
+			fmt.Sprintf("%.3f", r.Faithfulness),
+			strconv.Itoa(r.KeyItems),
+			strconv.Itoa(r.Covered),
+			fmt.Sprintf("%.3f", r.Coverage),
+			strconv.Itoa(r.LengthChars),
+			strconv.FormatBool(r.FitsMessage),
+			strings.Join(r.Unsupported, " | "),
+		})
+	}
+	return nil
+}
+
+func writeJSON(path string, reports []dayReport) error {
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// printReport печатает средние метрики по версиям промптов.
+func printReport(reports []dayReport) {
+	byVersion := make(map[string][]dayReport)
+	for _, r := range reports {
+		version := r.PromptVersion
+		if version == "" {
+			version = "(не записана)"
+		}
+		byVersion[version] = append(byVersion[version], r)
+	}
+	versions := make([]string, 0, len(byVersion))
+	for v := range byVersion {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	fmt.Printf("\n%-45s %5s %13s %9s %10s %10s %12s\n", "prompt_version", "дней", "faithfulness", "coverage", "grounding", "длина", "влезает, %")
+	for _, v := range versions {
+		rs := byVersion[v]
+		var faithfulness, coverage, grounding, length float64
+		fits := 0
+		for _, r := range rs {
+			faithfulness += r.Faithfulness
+			coverage += r.Coverage
+			grounding += r.Grounding
+			length += float64(r.LengthChars)
+			if r.FitsMessage {
+				fits++
+			}
+		}
+		n := float64(len(rs))
+		fmt.Printf("%-45s %5d %13.3f %9.3f %10.3f %10.0f %12.1f\n",
+			v, len(rs), faithfulness/n, coverage/n, grounding/n, length/n, 100*float64(fits)/n)
+	}
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
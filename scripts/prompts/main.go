@@ -0,0 +1,255 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const usage = `Использование: go run ./scripts/prompts <команда> [флаги]
+
+Команды:
+  list                                         встроенные и сохранённые версии промптов
+  add -name N -version V -file F [-activate]   сохранить шаблон из файла как версию V промпта N
+  activate -name N -version V                  сделать версию активной (бот подхватит после перезапуска)
+  compare -channel C -date D -a "delta=v2" -b "delta=v3"
+                                               пересобрать дайджест дня двумя наборами промптов без записи сюжетов
+`
+
+func loadEnv() {
+	if err := godotenv.Load(); err != nil {
+		log.Warn("Error loading .env file")
+	}
+}
+
+func main() {
+	loadEnv()
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to open database connection"))
+	}
+	defer db.Close()
+	promptRepo := repository.NewPromptRepository(db)
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "list":
+		err = listPrompts(promptRepo)
+	case "add":
+		err = addPrompt(promptRepo, args)
+	case "activate":
+		err = activatePrompt(promptRepo, args)
+	case "compare":
+		err = comparePrompts(db, promptRepo, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func listPrompts(promptRepo repository.PromptRepositoryInterface) error {
+	stored, err := promptRepo.ListPromptVersions()
+	if err != nil {
+		return errors.Wrap(err, "failed to list prompt versions")
+	}
+	byName := make(map[string][]repository.PromptVersion)
+	for _, p := range stored {
+		byName[p.Name] = append(byName[p.Name], p)
+	}
+
+	for _, name := range repository.PromptNames {
+		fmt.Printf("%s\n", name)
+		active := ""
+		for _, p := range byName[name] {
+			if p.Active {
+				active = p.Version
+			}
+		}
+		builtin := repository.BuiltinPromptVersions(name)
+		for _, v := range builtin {
+			mark := ""
+			if active == "" && v == builtin[len(builtin)-1] {
+				mark = " (активна)"
+			}
+			fmt.Printf("  %s встроенная%s\n", v, mark)
+		}
+		for _, p := range byName[name] {
+			mark := ""
+			if p.Active {
+				mark = " (активна)"
+			}
+			fmt.Printf("  %s из базы, %s%s\n", p.Version, p.CreatedAt.Format("2006-01-02 15:04"), mark)
+		}
+	}
+	return nil
+}
+
+func addPrompt(promptRepo repository.PromptRepositoryInterface, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	name := fs.String("name", "", "имя промпта (extract, match, delta, render, ...)")
+	version := fs.String("version", "", "версия, например v2")
+	file := fs.String("file", "", "файл с шаблоном text/template")
+	activate := fs.Bool("activate", false, "сразу сделать версию активной")
+	fs.Parse(args)
+
+	if err := checkPromptName(*name); err != nil {
+		return err
+	}
+	if *version == "" || *file == "" {
+		return errors.New("-version and -file are required")
+	}
+	if isBuiltin(*name, *version) {
+		return errors.Errorf("%s@%s is a builtin version, pick another", *name, *version)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read template")
+	}
+	// Шаблон проверяется до записи, чтобы бот не упал на нём при старте.
+	if _, err := repository.RenderPrompt(*name, *version, string(data)); err != nil {
+		return err
+	}
+	if err := promptRepo.SavePromptVersion(&repository.PromptVersion{Name: *name, Version: *version, Template: string(data)}); err != nil {
+		return errors.Wrap(err, "failed to save prompt version")
+	}
+	log.Infof("Saved %s@%s", *name, *version)
+
+	if *activate {
+		return setActive(promptRepo, *name, *version)
+	}
+	return nil
+}
+
+func activatePrompt(promptRepo repository.PromptRepositoryInterface, args []string) error {
+	fs := flag.NewFlagSet("activate", flag.ExitOnError)
+	name := fs.String("name", "", "имя промпта")
+	version := fs.String("version", "", "версия из базы или встроенная (встроенная снимает активность с версий из базы)")
+	fs.Parse(args)
+
+	if err := checkPromptName(*name); err != nil {
+		return err
+	}
+	if *version == "" {
+		return errors.New("-version is required")
+	}
+	if !isBuiltin(*name, *version) {
+		stored, err := promptRepo.GetPromptVersion(*name, *version)
+		if err != nil {
+			return errors.Wrap(err, "failed to get prompt version")
+		}
+		if stored == nil {
+			return errors.Errorf("prompt %s@%s not found", *name, *version)
+		}
+	}
+	return setActive(promptRepo, *name, *version)
+}
+
+func setActive(promptRepo repository.PromptRepositoryInterface, name, version string) error {
+	if err := promptRepo.SetActivePromptVersion(name, version); err != nil {
+		return errors.Wrap(err, "failed to activate prompt version")
+	}
+	log.Infof("Activated %s@%s; restart the bot to apply it", name, version)
+	return nil
+}
+
+func comparePrompts(db *sql.DB, promptRepo repository.PromptRepositoryInterface, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	channelID := fs.Int64("channel", 0, "ID канала")
+	dateStr := fs.String("date", "", "день в формате YYYY-MM-DD")
+	aFlag := fs.String("a", "", `версии варианта A, например "delta=v2,render=v1" (пусто - активные)`)
+	bFlag := fs.String("b", "", "версии варианта B")
+	fs.Parse(args)
+
+	if *channelID == 0 || *dateStr == "" {
+		return errors.New("-channel and -date are required")
+	}
+	date, err := time.Parse("2006-01-02", *dateStr)
+	if err != nil {
+		return errors.Wrap(err, "invalid -date")
+	}
+	a, err := parseVersions(*aFlag)
+	if err != nil {
+		return errors.Wrap(err, "invalid -a")
+	}
+	b, err := parseVersions(*bFlag)
+	if err != nil {
+		return errors.Wrap(err, "invalid -b")
+	}
+
+	mlRepo, err := repository.NewMLRepository()
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize ML repository")
+	}
+	mlRepo.WithUsage(repository.NewMLUsageRepository(db)).WithEmbeddingCache(repository.NewEmbeddingCacheRepository(db))
+	if err := mlRepo.LoadPrompts(promptRepo); err != nil {
+		return errors.Wrap(err, "failed to load prompts")
+	}
+
+	compareService := service.NewPromptCompareService(repository.NewSummaryRepository(db), repository.NewStorylineRepository(db), mlRepo)
+	result, err := compareService.Compare(*channelID, date, a, b)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("===== A: %s =====\n%s\n\n", result.A.PromptVersion, result.A.Digest)
+	fmt.Printf("===== B: %s =====\n%s\n", result.B.PromptVersion, result.B.Digest)
+	return nil
+}
+
+// parseVersions разбирает "delta=v2,render=v1" в map имя -> версия.
+func parseVersions(s string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, version, ok := strings.Cut(part, "=")
+		if !ok || version == "" {
+			return nil, errors.Errorf("expected name=version, got %q", part)
+		}
+		if err := checkPromptName(name); err != nil {
+			return nil, err
+		}
+		result[name] = version
+	}
+	return result, nil
+}
+
+func checkPromptName(name string) error {
+	for _, n := range repository.PromptNames {
+		if n == name {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown prompt %q, expected one of %s", name, strings.Join(repository.PromptNames, ", "))
+}
+
+func isBuiltin(name, version string) bool {
+	for _, v := range repository.BuiltinPromptVersions(name) {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
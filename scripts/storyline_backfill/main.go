@@ -26,7 +26,7 @@ func main() {
 
 	days := flag.Int("days", 7, "сколько прошлых дней прогнать")
 	endDateStr := flag.String("end-date", "", "последний день replay в формате YYYY-MM-DD (по умолчанию вчера)")
-	channelFlag := flag.Int64("channel", 0, "ID канала (по умолчанию все из config.Channels)")
+	channelFlag := flag.Int64("channel", 0, "ID канала (по умолчанию все каналы и ленты из config.ChannelNames())")
 	reset := flag.Bool("reset", true, "очистить storylines/observations канала перед прогоном")
 	writeSummaries := flag.Bool("write-summaries", false, "писать ли дневной текст в summaries")
 	flag.Parse()
@@ -53,12 +53,18 @@ func main() {
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Failed to initialize ML repository"))
 	}
+	// Расходы бэкфилла учитываются в ml_calls и месячном бюджете наравне с ботом,
+	// эмбеддинги повторных прогонов берутся из общего кэша.
+	mlRepo.WithUsage(repository.NewMLUsageRepository(db)).WithEmbeddingCache(repository.NewEmbeddingCacheRepository(db))
+	if err := mlRepo.LoadPrompts(repository.NewPromptRepository(db)); err != nil {
+		log.Fatal(errors.Wrap(err, "Failed to load prompts"))
+	}
 	processor := service.NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
 
 	channels := selectChannels(*channelFlag)
 
 	for _, channelID := range channels {
-		log.Infof("Backfilling channel %d (%s)", channelID, config.Channels[channelID])
+		log.Infof("Backfilling channel %d (%s)", channelID, config.ChannelNames()[channelID])
 
 		if *reset {
 			if err := storylineRepo.ResetChannel(channelID); err != nil {
@@ -81,8 +87,9 @@ func selectChannels(channelFlag int64) []int64 {
 	if channelFlag != 0 {
 		return []int64{channelFlag}
 	}
-	channels := make([]int64, 0, len(config.Channels))
-	for id := range config.Channels {
+	names := config.ChannelNames()
+	channels := make([]int64, 0, len(names))
+	for id := range names {
 		channels = append(channels, id)
 	}
 	return channels
@@ -101,7 +108,7 @@ func processDay(processor *service.StorylineProcessor, summaryRepo repository.Su
 		return
 	}
 
-	digest, err := processor.ProcessDay(channelID, day, msgs)
+	digest, groups, err := processor.ProcessDayWithGroups(channelID, day, msgs)
 	if err != nil {
 		log.Errorf("channel %d %s: ProcessDay failed: %v", channelID, dayStr, err)
 		return
@@ -111,9 +118,11 @@ func processDay(processor *service.StorylineProcessor, summaryRepo repository.Su
 
 	if writeSummaries {
 		if err := summaryRepo.SaveSummary(&repository.Summary{
-			ChannelID: channelID,
-			Summary:   digest,
-			CreatedAt: day,
+			ChannelID:     channelID,
+			Summary:       digest,
+			PromptVersion: processor.PromptVersion(),
+			Groups:        &groups,
+			CreatedAt:     day,
 		}); err != nil {
 			log.Errorf("channel %d %s: failed to save summary: %v", channelID, dayStr, err)
 		}
@@ -11,7 +11,8 @@
 // вариантом): "тот же сюжет" или нет. По разметке печатает precision/recall
 // по сетке порогов для обеих схем.
 //
-// Эмбеддинги и вердикты LLM кэшируются в JSON-файл, повторный запуск бесплатен.
+// Query-эмбеддинги берутся из общего кэша embedding_cache (миграция 0007),
+// вердикты LLM кэшируются в JSON-файл — повторный запуск бесплатен.
 //
 // Запуск:
 //
@@ -44,6 +45,7 @@ const (
 	labelFloorQuery = 0.30
 	labelFloorDoc   = 0.55
 	llmWorkers      = 4
+	embedBatchSize  = 50
 )
 
 type storyline struct {
@@ -65,14 +67,13 @@ type pair struct {
 }
 
 type cache struct {
-	QueryEmbeds map[string][]float32 `json:"query_embeds"` // storyline id -> query-эмбеддинг title+state
-	Labels      map[string]bool      `json:"labels"`       // "candID->priorID" -> тот же сюжет
+	Labels map[string]bool `json:"labels"` // "candID->priorID" -> тот же сюжет
 }
 
 func main() {
 	_ = godotenv.Load()
 
-	cachePath := flag.String("cache", "/tmp/calib_cache.json", "файл кэша эмбеддингов и LLM-вердиктов")
+	cachePath := flag.String("cache", "/tmp/calib_cache.json", "файл кэша LLM-вердиктов")
 	pairsCSV := flag.String("pairs-csv", "/tmp/calib_pairs.csv", "куда писать размеченные пары")
 	topK := flag.Int("topk", 5, "сколько ближайших сюжетов рассматривать на кандидата")
 	flag.Parse()
@@ -87,6 +88,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to init ML repository: %v", err)
 	}
+	ml.WithEmbeddingCache(repository.NewEmbeddingCacheRepository(db))
 
 	storylines, err := loadStorylines(db)
 	if err != nil {
@@ -96,12 +98,13 @@ func main() {
 
 	c := loadCache(*cachePath)
 
-	// Query-эмбеддинги всех кандидатов (с кэшем).
-	if err := ensureQueryEmbeds(ml, storylines, c, *cachePath); err != nil {
+	// Query-эмбеддинги всех кандидатов (через кэш эмбеддингов).
+	queryEmbeds, err := embedQueries(ml, storylines)
+	if err != nil {
 		log.Fatalf("failed to embed candidates: %v", err)
 	}
 
-	pairs := buildPairs(storylines, c, *topK)
+	pairs := buildPairs(storylines, queryEmbeds, *topK)
 	log.Infof("Built %d candidate-prior pairs (top-%d union by query-doc and doc-doc)", len(pairs), *topK)
 
 	if err := labelPairs(ml, storylines, pairs, c, *cachePath); err != nil {
@@ -141,17 +144,14 @@ func loadStorylines(db *sql.DB) ([]storyline, error) {
 }
 
 func loadCache(path string) *cache {
-	c := &cache{QueryEmbeds: map[string][]float32{}, Labels: map[string]bool{}}
+	c := &cache{Labels: map[string]bool{}}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return c
 	}
 	if err := json.Unmarshal(data, c); err != nil {
 		log.Warnf("failed to parse cache %s, starting fresh: %v", path, err)
-		return &cache{QueryEmbeds: map[string][]float32{}, Labels: map[string]bool{}}
-	}
-	if c.QueryEmbeds == nil {
-		c.QueryEmbeds = map[string][]float32{}
+		return &cache{Labels: map[string]bool{}}
 	}
 	if c.Labels == nil {
 		c.Labels = map[string]bool{}
@@ -170,48 +170,35 @@ func saveCache(path string, c *cache) {
 	}
 }
 
-func ensureQueryEmbeds(ml repository.MLRepositoryInterface, storylines []storyline, c *cache, cachePath string) error {
-	missing := 0
-	for _, s := range storylines {
-		if _, ok := c.QueryEmbeds[strconv.FormatInt(s.ID, 10)]; !ok {
-			missing++
+// embedQueries возвращает query-эмбеддинги title+state всех сюжетов по id.
+// Уже посчитанные векторы MLRepository берёт из embedding_cache.
+func embedQueries(ml repository.MLRepositoryInterface, storylines []storyline) (map[int64][]float32, error) {
+	result := make(map[int64][]float32, len(storylines))
+	for from := 0; from < len(storylines); from += embedBatchSize {
+		batch := storylines[from:min(from+embedBatchSize, len(storylines))]
+		texts := make([]string, len(batch))
+		for i, s := range batch {
+			texts[i] = s.Title + "\n" + s.State
 		}
-	}
-	if missing == 0 {
-		log.Info("All query embeddings found in cache")
-		return nil
-	}
-	log.Infof("Embedding %d candidates with query model", missing)
-
-	done := 0
-	for _, s := range storylines {
-		key := strconv.FormatInt(s.ID, 10)
-		if _, ok := c.QueryEmbeds[key]; ok {
-			continue
-		}
-		vecs, err := ml.EmbedQueries([]string{s.Title + "\n" + s.State})
+		vecs, err := ml.EmbedQueries(texts)
 		if err != nil {
-			saveCache(cachePath, c)
-			return fmt.Errorf("embed storyline %d: %w", s.ID, err)
+			return nil, fmt.Errorf("embed storylines %d..%d: %w", batch[0].ID, batch[len(batch)-1].ID, err)
 		}
-		c.QueryEmbeds[key] = vecs[0]
-		done++
-		if done%25 == 0 {
-			log.Infof("Embedded %d/%d", done, missing)
-			saveCache(cachePath, c)
+		for i, s := range batch {
+			result[s.ID] = vecs[i]
 		}
+		log.Infof("Embedded %d/%d", from+len(batch), len(storylines))
 	}
-	saveCache(cachePath, c)
-	return nil
+	return result, nil
 }
 
 // buildPairs: для каждого кандидата берёт top-K прошлых сюжетов по query-doc
 // и top-K по doc-doc, объединяет.
-func buildPairs(storylines []storyline, c *cache, topK int) []pair {
+func buildPairs(storylines []storyline, queryEmbeds map[int64][]float32, topK int) []pair {
 	var pairs []pair
 	for i := range storylines {
 		cand := storylines[i]
-		qvec, ok := c.QueryEmbeds[strconv.FormatInt(cand.ID, 10)]
+		qvec, ok := queryEmbeds[cand.ID]
 		if !ok {
 			continue
 		}
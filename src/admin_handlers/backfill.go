@@ -0,0 +1,41 @@
+package adminhandlers
+
+import (
+	"context"
+	"fmt"
+
+	tele "gopkg.in/telebot.v4"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// HandleBackfill - /backfill <канал> <с> [по]: догружает посты канала за период,
+// например после простоя дольше config.MessageBackfillMaxHours.
+func (h *AdminHandler) HandleBackfill(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	channelID, from, to, err := service.ParseBackfillRequest(c.Message().Payload)
+	if err != nil {
+		return c.Send(err.Error(), keyboard.GetStartKeyboard())
+	}
+	period := fmt.Sprintf("%s - %s", from.Format("02.01.2006"), to.AddDate(0, 0, -1).Format("02.01.2006"))
+
+	if err := c.Send(fmt.Sprintf("Загружаю посты %s за %s...", service.ChannelLabel(channelID), period)); err != nil {
+		return err
+	}
+
+	result, err := h.messageService.Backfill(context.Background(), channelID, from, to)
+	if err != nil {
+		log.Errorf("error backfilling channel %d for %s: %v", channelID, period, err)
+		return c.Send("Не удалось загрузить посты, подробности в логе", keyboard.GetStartKeyboard())
+	}
+
+	return c.Send(fmt.Sprintf(
+		"Готово: постов за период - %d, новых сохранено - %d.\n\nСюжеты за эти дни пересчитывает scripts/storyline_backfill.",
+		result.Fetched, result.Saved,
+	), keyboard.GetStartKeyboard())
+}
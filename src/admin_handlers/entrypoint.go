@@ -3,13 +3,18 @@ package adminhandlers
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	tele "gopkg.in/telebot.v4"
 
 	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
 	"github.com/Ra1ze505/goNewsBot/src/keyboard"
 	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,13 +22,37 @@ type AdminHandler struct {
 	ForceRegenerateChannel chan struct{}
 	userRepo               repository.UserRepositoryInterface
 	summaryRepo            repository.SummaryRepositoryInterface
+	storylineRepo          repository.StorylineRepositoryInterface
+	storylineEditor        *service.StorylineEditor
+	mlUsageRepo            repository.MLUsageRepositoryInterface
+	feedbackRepo           repository.FeedbackRepositoryInterface
+	messageService         *service.MessageService
+	telegramLogin          *service.TelegramLogin
+	stateStorage           *handlers.StateStorage
 }
 
-func NewAdminHandler(userRepo repository.UserRepositoryInterface, summaryRepo repository.SummaryRepositoryInterface) *AdminHandler {
+func NewAdminHandler(
+	userRepo repository.UserRepositoryInterface,
+	summaryRepo repository.SummaryRepositoryInterface,
+	storylineRepo repository.StorylineRepositoryInterface,
+	storylineEditor *service.StorylineEditor,
+	mlUsageRepo repository.MLUsageRepositoryInterface,
+	feedbackRepo repository.FeedbackRepositoryInterface,
+	messageService *service.MessageService,
+	telegramLogin *service.TelegramLogin,
+	stateStorage *handlers.StateStorage,
+) *AdminHandler {
 	return &AdminHandler{
 		ForceRegenerateChannel: make(chan struct{}),
 		userRepo:               userRepo,
 		summaryRepo:            summaryRepo,
+		storylineRepo:          storylineRepo,
+		storylineEditor:        storylineEditor,
+		mlUsageRepo:            mlUsageRepo,
+		feedbackRepo:           feedbackRepo,
+		messageService:         messageService,
+		telegramLogin:          telegramLogin,
+		stateStorage:           stateStorage,
 	}
 }
 
@@ -37,13 +66,29 @@ func (h *AdminHandler) Handle(c tele.Context) error {
 		Text: "Перенерировать суммаризацию",
 		Data: "admin_regenerate_summary",
 	}})
+	rows = append(rows, tele.Row{tele.Btn{
+		Text: "Сюжеты: слить, разделить, переименовать",
+		Data: "admin_storylines",
+	}})
+	rows = append(rows, tele.Row{tele.Btn{
+		Text: "Расходы на ML",
+		Data: "admin_ml_usage",
+	}})
+	rows = append(rows, tele.Row{tele.Btn{
+		Text: "Отзывы читателей",
+		Data: "admin_feedback",
+	}})
+	rows = append(rows, tele.Row{tele.Btn{
+		Text: "Вход в Telegram",
+		Data: "admin_telegram_login",
+	}})
 
 	k := &tele.ReplyMarkup{
 		ResizeKeyboard: true,
 	}
 	k.Inline(rows...)
 
-	return c.Send("Выберите действие", k)
+	return c.Send("Выберите действие\n\nДогрузить посты канала за период: /backfill <канал> <с> [по]", k)
 }
 
 func (h *AdminHandler) HandleRegenerateSummary(c tele.Context) error {
@@ -52,12 +97,17 @@ func (h *AdminHandler) HandleRegenerateSummary(c tele.Context) error {
 	}
 
 	var rows []tele.Row
-	for channelID, channelName := range config.Channels {
+	var stale []string
+	for channelID, channelName := range config.ChannelNames() {
 		btn := tele.Btn{
 			Text: channelName,
 			Data: fmt.Sprintf("regenerate_summary_%d", channelID),
 		}
 		rows = append(rows, tele.Row{btn})
+
+		if line := h.staleObservationsLine(channelID, channelName); line != "" {
+			stale = append(stale, line)
+		}
 	}
 
 	k := &tele.ReplyMarkup{
@@ -65,7 +115,37 @@ func (h *AdminHandler) HandleRegenerateSummary(c tele.Context) error {
 	}
 	k.Inline(rows...)
 
-	return c.Send("Выберите канал", k)
+	text := "Выберите канал"
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		text = "Исходные посты отредактированы или удалены, сюжеты устарели:\n" + strings.Join(stale, "\n") +
+			"\n\nПерегенерация пересчитывает последний день, более ранние - scripts/storyline_backfill.\n\n" + text
+	}
+	return c.Send(text, k)
+}
+
+// staleObservationsLine - строка о наблюдениях канала, помеченных needs_regeneration; пусто, если их нет.
+func (h *AdminHandler) staleObservationsLine(channelID int64, channelName string) string {
+	counts, err := h.storylineRepo.CountObservationsNeedingRegeneration(channelID)
+	if err != nil {
+		log.Errorf("error counting stale observations: %v", err)
+		return ""
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+	days := make([]time.Time, 0, len(counts))
+	total := 0
+	for day, n := range counts {
+		days = append(days, day)
+		total += n
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	labels := make([]string, len(days))
+	for i, day := range days {
+		labels[i] = day.Format("02.01")
+	}
+	return fmt.Sprintf("%s: %d наблюд. за %s", channelName, total, strings.Join(labels, ", "))
 }
 
 func (h *AdminHandler) HandleRegenerateSummaryChannel(c tele.Context) error {
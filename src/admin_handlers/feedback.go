@@ -0,0 +1,76 @@
+package adminhandlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// окно, за которое показываются отзывы читателей
+	feedbackWindow = 30 * 24 * time.Hour
+	// сколько сюжетов с отмеченными ошибками показывать
+	feedbackTopErrors = 5
+)
+
+// HandleFeedback показывает оценки дайджестов за последние 30 дней по каналам
+// и версиям промптов и сюжеты, в которых читатели чаще всего отмечали ошибки.
+func (h *AdminHandler) HandleFeedback(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	since := time.Now().UTC().Add(-feedbackWindow)
+	scores, err := h.feedbackRepo.ScoresByChannelAndPrompt(since)
+	if err != nil {
+		log.Errorf("error getting feedback scores: %v", err)
+		return c.Send("Не удалось получить отзывы", keyboard.GetStartKeyboard())
+	}
+	errorStorylines, err := h.feedbackRepo.TopErrorStorylines(since, feedbackTopErrors)
+	if err != nil {
+		log.Errorf("error getting storylines with reported errors: %v", err)
+		return c.Send("Не удалось получить отзывы", keyboard.GetStartKeyboard())
+	}
+
+	var b strings.Builder
+	b.WriteString("Отзывы за 30 дней\n")
+	if len(scores) == 0 {
+		b.WriteString("  отзывов не было\n")
+	}
+	for _, s := range scores {
+		version := s.PromptVersion
+		if version == "" {
+			version = "без версии"
+		}
+		line := fmt.Sprintf("  %s, %s — дайджестов: %d, 👍 %d, 👎 %d, ошибок: %d",
+			channelLabel(strconv.FormatInt(s.ChannelID, 10)), version, s.Summaries, s.Up, s.Down, s.Errors)
+		// оценка от -1 до 1: доля лайков минус доля дизлайков
+		if votes := s.Up + s.Down; votes > 0 {
+			line += fmt.Sprintf(", оценка %+.2f", float64(s.Up-s.Down)/float64(votes))
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\nСюжеты с ошибками:\n")
+	if len(errorStorylines) == 0 {
+		b.WriteString("  не отмечались\n")
+	}
+	for _, r := range errorStorylines {
+		b.WriteString(fmt.Sprintf("  #%d %s (%s) — %d\n",
+			r.StorylineID, r.Title, channelLabel(strconv.FormatInt(r.ChannelID, 10)), r.Reports))
+	}
+
+	for _, part := range telegramutil.SplitMessage(b.String()) {
+		if err := c.Send(part, keyboard.GetStartKeyboard()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
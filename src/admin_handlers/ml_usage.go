@@ -0,0 +1,115 @@
+package adminhandlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// HandleMLUsage показывает расходы на ML за сегодня и с начала месяца (UTC)
+// по каналам и стадиям, а также состояние месячного бюджета.
+func (h *AdminHandler) HandleMLUsage(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var b strings.Builder
+	for _, period := range []struct {
+		title    string
+		from, to time.Time
+	}{
+		{"Сегодня", today, today.AddDate(0, 0, 1)},
+		{"С начала месяца", monthStart, monthStart.AddDate(0, 1, 0)},
+	} {
+		byChannel, err := h.mlUsageRepo.TotalsByChannel(period.from, period.to)
+		if err != nil {
+			log.Errorf("error getting ML usage by channel: %v", err)
+			return c.Send("Не удалось получить расходы на ML", keyboard.GetStartKeyboard())
+		}
+		byStage, err := h.mlUsageRepo.TotalsByStage(period.from, period.to)
+		if err != nil {
+			log.Errorf("error getting ML usage by stage: %v", err)
+			return c.Send("Не удалось получить расходы на ML", keyboard.GetStartKeyboard())
+		}
+
+		b.WriteString(fmt.Sprintf("%s, итого %.2f ₽\n", period.title, totalCost(byStage)))
+		b.WriteString("По каналам:\n")
+		writeUsageTotals(&b, byChannel, channelLabel)
+		b.WriteString("По стадиям:\n")
+		writeUsageTotals(&b, byStage, func(stage string) string { return stage })
+		b.WriteString("\n")
+
+		if period.from.Equal(monthStart) {
+			b.WriteString(formatBudget(totalCost(byStage)))
+		}
+	}
+
+	for _, part := range telegramutil.SplitMessage(b.String()) {
+		if err := c.Send(part, keyboard.GetStartKeyboard()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUsageTotals(b *strings.Builder, totals []repository.MLUsageTotal, label func(key string) string) {
+	if len(totals) == 0 {
+		b.WriteString("  вызовов не было\n")
+		return
+	}
+	for _, t := range totals {
+		line := fmt.Sprintf("  %s — %d выз., %d+%d ток., %.2f ₽", label(t.Key), t.Calls, t.PromptTokens, t.CompletionTokens, t.Cost)
+		if t.Failures > 0 {
+			line += fmt.Sprintf(", ошибок: %d", t.Failures)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// channelLabel - имя канала или ленты из config.ChannelNames(); 0 - вызовы вне канала (правка сюжетов, скрипты).
+func channelLabel(key string) string {
+	channelID, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return key
+	}
+	if channelID == 0 {
+		return "без канала"
+	}
+	if name, ok := config.ChannelNames()[channelID]; ok {
+		return name
+	}
+	return key
+}
+
+func totalCost(totals []repository.MLUsageTotal) float64 {
+	var sum float64
+	for _, t := range totals {
+		sum += t.Cost
+	}
+	return sum
+}
+
+func formatBudget(spent float64) string {
+	budget := config.MLMonthlyBudget()
+	if budget <= 0 {
+		return "Месячный бюджет не задан (ML_MONTHLY_BUDGET)\n"
+	}
+	s := fmt.Sprintf("Бюджет: %.2f из %.2f ₽ (%.0f%%)\n", spent, budget, spent/budget*100)
+	if spent >= budget {
+		s += "Бюджет исчерпан: стадии на дешёвых моделях, подтверждение матчинга LLM отключено\n"
+	}
+	return s
+}
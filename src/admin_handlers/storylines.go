@@ -0,0 +1,231 @@
+package adminhandlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	log "github.com/sirupsen/logrus"
+)
+
+const storylineListLimit = 30
+
+// Админские действия над сюжетами, ожидающие текстового ввода.
+const (
+	actionStorylineMerge    = "storyline_merge"
+	actionStorylineSplit    = "storyline_split"
+	actionStorylineRename   = "storyline_rename"
+	actionStorylineCategory = "storyline_category"
+)
+
+var storylineActionPrompts = map[string]string{
+	actionStorylineMerge:    "Введите через пробел: <id основного сюжета> <id сюжета, который влить в него>\nНапример: 42 57",
+	actionStorylineSplit:    "Введите: <id сюжета> <даты наблюдений через запятую> <новый заголовок>\nНапример: 42 2026-10-01,2026-10-03 Забастовка в порту",
+	actionStorylineRename:   "Введите: <id сюжета> <новый заголовок>\nНапример: 42 Переговоры по газу",
+	actionStorylineCategory: "Введите: <id сюжета> <рубрика>\nРубрики: " + strings.Join(config.Categories, ", "),
+}
+
+// HandleStorylines показывает меню ручной правки сюжетов.
+func (h *AdminHandler) HandleStorylines(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	var rows []tele.Row
+	for channelID, channelName := range config.ChannelNames() {
+		rows = append(rows, tele.Row{tele.Btn{
+			Text: "Список: " + channelName,
+			Data: fmt.Sprintf("admin_storylines_list_%d", channelID),
+		}})
+	}
+	rows = append(rows,
+		tele.Row{tele.Btn{Text: "Слить два сюжета", Data: "admin_storyline_" + actionStorylineMerge}},
+		tele.Row{tele.Btn{Text: "Разделить сюжет", Data: "admin_storyline_" + actionStorylineSplit}},
+		tele.Row{tele.Btn{Text: "Переименовать сюжет", Data: "admin_storyline_" + actionStorylineRename}},
+		tele.Row{tele.Btn{Text: "Сменить рубрику", Data: "admin_storyline_" + actionStorylineCategory}},
+	)
+
+	k := &tele.ReplyMarkup{
+		ResizeKeyboard: true,
+	}
+	k.Inline(rows...)
+
+	return c.Send("Выберите действие с сюжетами", k)
+}
+
+// HandleStorylineList выводит незакрытые сюжеты канала с их id.
+func (h *AdminHandler) HandleStorylineList(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	var channelID int64
+	if _, err := fmt.Sscanf(c.Callback().Data, "admin_storylines_list_%d", &channelID); err != nil {
+		log.Infof("error parsing channel ID: %v", err)
+		return c.Send("Некорректный канал", keyboard.GetStartKeyboard())
+	}
+
+	storylines, err := h.storylineRepo.ListStorylines(channelID, storylineListLimit)
+	if err != nil {
+		log.Errorf("error listing storylines: %v", err)
+		return c.Send("Не удалось получить список сюжетов", keyboard.GetStartKeyboard())
+	}
+	if len(storylines) == 0 {
+		return c.Send("У канала нет активных сюжетов", keyboard.GetStartKeyboard())
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Сюжеты %s:\n", config.ChannelNames()[channelID]))
+	for _, s := range storylines {
+		b.WriteString(formatStorylineLine(s))
+	}
+
+	for _, part := range telegramutil.SplitMessage(b.String()) {
+		if err := c.Send(part, keyboard.GetStartKeyboard()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleStorylineAction переводит админа в режим ввода параметров действия.
+func (h *AdminHandler) HandleStorylineAction(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+	action := strings.TrimPrefix(c.Callback().Data, "admin_storyline_")
+	prompt, ok := storylineActionPrompts[action]
+	if !ok {
+		return c.Send("Неизвестное действие", keyboard.GetStartKeyboard())
+	}
+
+	h.stateStorage.SetState(user.ChatID, &handlers.UserState{AdminAction: action})
+	return c.Send(prompt+"\n\nДля отмены отправьте «Отмена».", keyboard.GetStartKeyboard())
+}
+
+// HandleStorylineInput выполняет ожидаемое действие по введённому тексту.
+func (h *AdminHandler) HandleStorylineInput(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+	state := h.stateStorage.GetState(user.ChatID)
+	if state == nil || state.AdminAction == "" {
+		return nil
+	}
+	if !isAdmin(c) {
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	text := strings.TrimSpace(c.Text())
+	if text == keyboard.CancelBtn.Text {
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Действие отменено", keyboard.GetStartKeyboard())
+	}
+
+	reply, err := h.applyStorylineAction(state.AdminAction, text)
+	if err != nil {
+		log.Errorf("storyline admin action %s failed: %v", state.AdminAction, err)
+		return c.Send(fmt.Sprintf("Не удалось выполнить действие: %v\n\n%s", err, storylineActionPrompts[state.AdminAction]), keyboard.GetStartKeyboard())
+	}
+
+	h.stateStorage.ClearState(user.ChatID)
+	return c.Send(reply, keyboard.GetStartKeyboard())
+}
+
+func (h *AdminHandler) applyStorylineAction(action, text string) (string, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("не хватает параметров")
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("некорректный id %q", fields[0])
+	}
+
+	switch action {
+	case actionStorylineMerge:
+		sourceID, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("некорректный id %q", fields[1])
+		}
+		merged, err := h.storylineEditor.Merge(id, sourceID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Сюжет #%d влит в #%d «%s»", sourceID, merged.ID, merged.Title), nil
+
+	case actionStorylineSplit:
+		if len(fields) < 3 {
+			return "", fmt.Errorf("не указан заголовок нового сюжета")
+		}
+		dates, err := parseDates(fields[1])
+		if err != nil {
+			return "", err
+		}
+		title := strings.Join(fields[2:], " ")
+		newID, err := h.storylineEditor.Split(id, dates, title)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Наблюдения за %d дн. вынесены в новый сюжет #%d «%s»", len(dates), newID, title), nil
+
+	case actionStorylineRename:
+		title := strings.Join(fields[1:], " ")
+		if err := h.storylineEditor.Rename(id, title); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Сюжет #%d переименован в «%s»", id, title), nil
+
+	case actionStorylineCategory:
+		if err := h.storylineEditor.ChangeCategory(id, fields[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Рубрика сюжета #%d изменена на «%s»", id, strings.ToLower(fields[1])), nil
+	}
+
+	return "", fmt.Errorf("неизвестное действие %q", action)
+}
+
+func parseDates(s string) ([]time.Time, error) {
+	var dates []time.Time
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", part)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная дата %q, нужен формат ГГГГ-ММ-ДД", part)
+		}
+		dates = append(dates, d)
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("не указаны даты")
+	}
+	return dates, nil
+}
+
+func formatStorylineLine(s repository.Storyline) string {
+	category := s.Category
+	if category == "" {
+		category = "без рубрики"
+	}
+	return fmt.Sprintf("#%d [%s] %s (%s, %s — %s)\n",
+		s.ID, s.Status, s.Title, category,
+		s.FirstSeen.Format("2006-01-02"), s.LastSeen.Format("2006-01-02"))
+}
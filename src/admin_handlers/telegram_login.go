@@ -0,0 +1,107 @@
+package adminhandlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// Шаги входа в MTProto-аккаунт, читающий каналы (UserState.AdminAction).
+const (
+	actionLoginPhone    = "login_phone"
+	actionLoginCode     = "login_code"
+	actionLoginPassword = "login_password"
+)
+
+var loginPrompts = map[string]string{
+	actionLoginPhone: "Вход в аккаунт, от имени которого бот читает каналы.\n" +
+		"Отправьте номер телефона в международном формате, например +79991234567.",
+	actionLoginCode: "Отправьте код из Telegram, разделив цифры пробелами, например 1 2 3 4 5: " +
+		"код, присланный в чат целиком, Telegram сразу аннулирует.",
+	actionLoginPassword: "Аккаунт защищён двухэтапной проверкой. Отправьте пароль - бот удалит сообщение с ним.",
+}
+
+// IsTelegramLoginAction - ожидаемый ввод относится ко входу в аккаунт (см. HandleLoginInput).
+func IsTelegramLoginAction(action string) bool {
+	return strings.HasPrefix(action, "login_")
+}
+
+// HandleTelegramLogin начинает вход: после перезапуска без сессии или её
+// отзыва аккаунт восстанавливается из бота, без scripts/auth.
+func (h *AdminHandler) HandleTelegramLogin(c tele.Context) error {
+	if !isAdmin(c) {
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+	h.stateStorage.SetState(user.ChatID, &handlers.UserState{AdminAction: actionLoginPhone})
+	return c.Send(loginPrompts[actionLoginPhone]+"\n\nДля отмены отправьте «Отмена».", keyboard.GetStartKeyboard())
+}
+
+// HandleLoginInput принимает телефон, код или пароль и переводит к следующему шагу.
+func (h *AdminHandler) HandleLoginInput(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+	state := h.stateStorage.GetState(user.ChatID)
+	if state == nil || !IsTelegramLoginAction(state.AdminAction) {
+		return nil
+	}
+	if !isAdmin(c) {
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Вы не являетесь администратором", keyboard.GetStartKeyboard())
+	}
+
+	text := strings.TrimSpace(c.Text())
+	if text == keyboard.CancelBtn.Text {
+		h.telegramLogin.Cancel()
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Вход отменён", keyboard.GetStartKeyboard())
+	}
+
+	var step string
+	var err error
+	switch state.AdminAction {
+	case actionLoginPhone:
+		step, err = h.telegramLogin.Start(text)
+	default:
+		// код и пароль не остаются в переписке
+		if delErr := c.Delete(); delErr != nil {
+			log.Warnf("error deleting login input: %v", delErr)
+		}
+		step, err = h.telegramLogin.Submit(text)
+	}
+
+	if errors.Is(err, service.ErrLoginInvalidCode) || errors.Is(err, service.ErrLoginInvalidPass) {
+		return c.Send(fmt.Sprintf("%v\n\n%s", err, loginPrompts[state.AdminAction]), keyboard.GetStartKeyboard())
+	}
+	if err != nil {
+		log.Errorf("telegram login step %s failed: %v", state.AdminAction, err)
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send(fmt.Sprintf("Не удалось войти: %v\n\nНачните заново из /admin.", err), keyboard.GetStartKeyboard())
+	}
+
+	switch step {
+	case service.LoginStepCode:
+		h.stateStorage.SetState(user.ChatID, &handlers.UserState{AdminAction: actionLoginCode})
+		return c.Send(loginPrompts[actionLoginCode], keyboard.GetStartKeyboard())
+	case service.LoginStepPassword:
+		h.stateStorage.SetState(user.ChatID, &handlers.UserState{AdminAction: actionLoginPassword})
+		return c.Send(loginPrompts[actionLoginPassword], keyboard.GetStartKeyboard())
+	default:
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Вход выполнен, сессия сохранена. Загрузка постов переподключится сама.", keyboard.GetStartKeyboard())
+	}
+}
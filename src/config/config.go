@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +13,30 @@ var Channels = map[int64]string{
 	1754252633: "topor_live",
 }
 
+// Feed - RSS- или Atom-лента сайта без Telegram-канала.
+type Feed struct {
+	Name string // короткое имя латиницей, как username канала
+	URL  string
+}
+
+// Feeds - ленты для мониторинга. Ключ - channel_id постов ленты в messages:
+// отрицательный, чтобы не пересекаться с id каналов Telegram. Например:
+//
+//	-1: {Name: "rbc_news", URL: "https://rssexport.rbc.ru/rbcnews/news/30/full.rss"},
+var Feeds = map[int64]Feed{}
+
+// ChannelNames - все источники дайджестов: каналы Telegram и ленты.
+func ChannelNames() map[int64]string {
+	names := make(map[int64]string, len(Channels)+len(Feeds))
+	for id, name := range Channels {
+		names[id] = name
+	}
+	for id, feed := range Feeds {
+		names[id] = feed.Name
+	}
+	return names
+}
+
 const SessionDir = "session/telegram-session"
 
 // Константы Storyline Tracking / TDT.
@@ -36,10 +61,130 @@ const (
 	DormantAfterDays = 7
 	ClosedAfterDays  = 30
 
+	// Возвращение сюжетов: dormant и недавно закрытые сюжеты тоже участвуют в матчинге,
+	// но их similarity штрафуется, чтобы при равенстве побеждал активный сюжет.
+	RevivalDormantPenalty   = 0.03
+	RevivalClosedPenalty    = 0.06
+	RevivalClosedWithinDays = 90 // 0 — закрытые сюжеты не воскрешаются
+	// Сколько кандидатов сверх MatchTopK брать из поиска: штраф может опустить
+	// dormant/closed сюжеты ниже активных, которые не попали в исходный top-k.
+	MatchRevivalMargin = 5
+
+	// Поиск ближайших сюжетов: точный перебор или HNSW-индекс (миграция 0004).
+	ANNMinRows          = 20000 // в режиме auto HNSW используется от стольких сюжетов с эмбеддингом
+	HNSWEfSearch        = 100   // hnsw.ef_search на время запроса (дефолт pgvector — 40)
+	ANNRecallSampleSize = 50    // сколько сюжетов-запросов в самопроверке recall
+	ANNRecallMinRatio   = 0.9   // recall ниже — предупреждение в лог
+
+	// Проверка JSON-ответов стадий A, C, D.
+	LLMRepairAttempts      = 2   // сколько раз переспрашивать модель, передав ей ошибки валидации
+	StorylineStateMaxRunes = 600 // ограничение длины состояния сюжета (стадия D)
+
+	// Бюджет на ML (ML_MONTHLY_BUDGET). Когда он исчерпан, стадия C не вызывается:
+	// кандидат из серой зоны привязывается, если sim >= MatchSimBudget, иначе - новый сюжет.
+	MatchSimBudget        = (MatchSimLow + MatchSimHigh) / 2
+	MLBudgetRefreshPeriod = 60 // секунд между перечитываниями потраченного за месяц из ml_calls
+
+	// Синхронизация правок и удалений постов: за сколько часов назад MessageService
+	// перечитывает историю канала.
+	MessageSyncLookbackHours = 48
+	// Новые посты берутся после курсора канала, но не старше этого окна: после
+	// более долгого простоя пропуск догружается админской командой /backfill.
+	MessageBackfillMaxHours     = 7 * 24
+	MessageAdminBackfillMaxDays = 31 // дней в одном /backfill
+	// Поток обновлений MTProto (MessageService.StartUpdatesListener).
+	MessageUpdateFlushSeconds  = 2 // сколько копить новые посты, чтобы собрать альбом целиком
+	MessageUpdatesRetryMinutes = 5 // пауза перед переподключением; опрос в это время продолжается
+	// Вход в MTProto-аккаунт из бота (/admin -> «Вход в Telegram»).
+	TelegramLoginTimeoutMinutes = 10 // после этого незавершённый вход отменяется
+	// RSS/Atom-ленты (Feeds).
+	FeedFetchTimeoutSeconds = 30
+	FeedMaxBytes            = 5 << 20 // ответ длиннее считается ошибкой
+
+	// Подписки на ключевые слова (/watch).
+	WatchMaxPerUser    = 20  // подписок на читателя
+	WatchMaxPatternLen = 100 // символов в слове/фразе или регулярке
+	WatchMaxPerHour    = 10  // уведомлений читателю за скользящий час, остальные пропускаются
+	WatchExcerptRunes  = 300 // длина отрывка сообщения в уведомлении
+
+	// Поиск по архиву сообщений (/search).
+	SearchPageSize      = 5   // результатов на страницу
+	SearchMaxQueryLen   = 200 // символов в запросе
+	SearchQueryTTLHours = 24  // сколько работают кнопки листания результатов
+
+	// Поиск сюжета читателем по свободному тексту (кнопка «Найти сюжет»).
+	// Запрос читателя короче топика-кандидата, поэтому порог ниже MatchSimLow:
+	// отсекаем только явно нерелевантное.
+	StorylineSearchTopK        = 5
+	StorylineSearchMinSim      = 0.3
+	StorylineSearchMaxQueryLen = 200
+	StorylineTimelineMaxDays   = 30 // дней в хронике сюжета, старые дни отбрасываются
+
+	// Вопросы по архиву (/ask): сюжеты ищутся по эмбеддингу вопроса, сообщения -
+	// по их source_message_ids и полнотекстовым поиском.
+	AskWindowDays           = 30   // период по умолчанию, если в вопросе нет с:/по:
+	AskCandidateStorylines  = 20   // ближайших сюжетов до фильтра по периоду и каналу
+	AskMaxStorylines        = 5    // сюжетов в материалах ответа
+	AskMinSim               = 0.35 // ниже - сюжет не считается относящимся к вопросу
+	AskMessagesPerStoryline = 3    // последних исходных сообщений на сюжет
+	AskSearchMessages       = 5    // сообщений из полнотекстового поиска
+	AskMessageMaxRunes      = 800  // длина текста сообщения в материалах
+
 	// Размерность эмбеддингов Yandex text-search-doc/query.
 	EmbeddingDim = 256
 )
 
+// Режимы поиска ближайших сюжетов (STORYLINE_SEARCH_MODE).
+const (
+	SearchModeExact = "exact" // точный перебор, индекс запрещён
+	SearchModeANN   = "ann"   // HNSW-индекс
+	SearchModeAuto  = "auto"  // HNSW от ANNMinRows сюжетов, иначе точный перебор
+)
+
+// LLM-провайдеры (LLM_PROVIDER, LLM_<STAGE>_PROVIDER).
+const (
+	LLMProviderYandex = "yandex" // Yandex AI Studio, OpenAI-совместимый API
+	LLMProviderOpenAI = "openai" // любой OpenAI-совместимый endpoint (OPENAI_BASE_URL)
+	LLMProviderOllama = "ollama" // локальный сервер в стиле Ollama (OLLAMA_BASE_URL)
+)
+
+// Стадии конвейера, для каждой из которых настраиваются провайдер и модель.
+const (
+	LLMStageExtract    = "extract"     // стадия A: извлечение топиков
+	LLMStageMatch      = "match"       // стадия C: подтверждение матчинга
+	LLMStageDelta      = "delta"       // стадия D: дельта и состояние сюжета
+	LLMStageRender     = "render"      // стадия F и дайджесты за период
+	LLMStageEmbedDoc   = "embed_doc"   // эмбеддинги состояний сюжетов
+	LLMStageEmbedQuery = "embed_query" // эмбеддинги топиков-кандидатов
+)
+
+// LLMStages - все стадии конвейера в порядке их выполнения.
+var LLMStages = []string{LLMStageExtract, LLMStageEmbedDoc, LLMStageEmbedQuery, LLMStageMatch, LLMStageDelta, LLMStageRender}
+
+// Categories - допустимые рубрики сюжетов (стадия A и ручная правка в админке).
+var Categories = []string{"военное", "происшествия", "экономика", "политика", "общество", "другое"}
+
+// IsCategory сообщает, входит ли рубрика в список Categories.
+func IsCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// StorylineSearchMode возвращает режим поиска ближайших сюжетов.
+// Использует STORYLINE_SEARCH_MODE, иначе (и при неизвестном значении) SearchModeAuto.
+func StorylineSearchMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("STORYLINE_SEARCH_MODE"))); mode {
+	case SearchModeExact, SearchModeANN:
+		return mode
+	default:
+		return SearchModeAuto
+	}
+}
+
 // EmbedDocURI возвращает URI модели эмбеддинга документов.
 // Использует YANDEX_EMBED_DOC_URI, иначе дефолт из folderID.
 func EmbedDocURI(folderID string) string {
@@ -57,3 +202,99 @@ func EmbedQueryURI(folderID string) string {
 	}
 	return fmt.Sprintf("emb://%s/text-search-query/latest", folderID)
 }
+
+// LLMStageProvider возвращает провайдера для стадии конвейера.
+// Использует LLM_<STAGE>_PROVIDER, иначе LLM_PROVIDER, иначе LLMProviderYandex.
+func LLMStageProvider(stage string) string {
+	if provider := strings.ToLower(strings.TrimSpace(os.Getenv(llmStageEnv(stage, "PROVIDER")))); provider != "" {
+		return provider
+	}
+	if provider := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER"))); provider != "" {
+		return provider
+	}
+	return LLMProviderYandex
+}
+
+// LLMStageModel возвращает модель для стадии конвейера из LLM_<STAGE>_MODEL.
+// Пустая строка - дефолт провайдера (есть только у Yandex).
+func LLMStageModel(stage string) string {
+	return strings.TrimSpace(os.Getenv(llmStageEnv(stage, "MODEL")))
+}
+
+// structuredOutputDefaults - поддерживает ли провайдер response_format/format с JSON-схемой.
+var structuredOutputDefaults = map[string]bool{
+	LLMProviderYandex: false,
+	LLMProviderOpenAI: true,
+	LLMProviderOllama: true,
+}
+
+// LLMStructuredOutput сообщает, передавать ли провайдеру JSON-схему ответа.
+// Использует <PROVIDER>_STRUCTURED_OUTPUT (true/false), иначе structuredOutputDefaults.
+func LLMStructuredOutput(provider string) bool {
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(strings.ToUpper(provider) + "_STRUCTURED_OUTPUT"))); err == nil {
+		return v
+	}
+	return structuredOutputDefaults[provider]
+}
+
+// LLMStageBudgetModel возвращает модель стадии на случай исчерпания бюджета
+// из LLM_<STAGE>_BUDGET_MODEL. Пустая строка - стадия остаётся на своей модели.
+// Для стадий эмбеддингов не применяется: сменить модель значит сменить пространство векторов.
+func LLMStageBudgetModel(stage string) string {
+	return strings.TrimSpace(os.Getenv(llmStageEnv(stage, "BUDGET_MODEL")))
+}
+
+// MLMonthlyBudget - месячный бюджет на вызовы ML в рублях из ML_MONTHLY_BUDGET; 0 - без ограничения.
+func MLMonthlyBudget() float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("ML_MONTHLY_BUDGET")), 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
+
+// ModelPrice - цена 1000 токенов (вход и выход вместе) для моделей, URI которых содержит Fragment.
+type ModelPrice struct {
+	Fragment string
+	Per1K    float64 // ₽
+}
+
+// DefaultModelPrices - тарифы Yandex AI Studio (синхронный режим), проверяются по порядку.
+var DefaultModelPrices = []ModelPrice{
+	{Fragment: "yandexgpt-lite/", Per1K: 0.2},
+	{Fragment: "yandexgpt/", Per1K: 1.2},
+	{Fragment: "text-search-", Per1K: 0.01},
+}
+
+// ModelPricePer1K возвращает цену 1000 токенов модели. ML_MODEL_PRICES
+// ("фрагмент=цена;фрагмент=цена") проверяется раньше DefaultModelPrices;
+// неизвестная модель (локальная, например) стоит 0.
+func ModelPricePer1K(model string) float64 {
+	for _, p := range append(parseModelPrices(os.Getenv("ML_MODEL_PRICES")), DefaultModelPrices...) {
+		if strings.Contains(model, p.Fragment) {
+			return p.Per1K
+		}
+	}
+	return 0
+}
+
+func parseModelPrices(raw string) []ModelPrice {
+	var prices []ModelPrice
+	for _, item := range strings.Split(raw, ";") {
+		fragment, price, ok := strings.Cut(item, "=")
+		fragment = strings.TrimSpace(fragment)
+		if !ok || fragment == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(price), 64)
+		if err != nil {
+			continue
+		}
+		prices = append(prices, ModelPrice{Fragment: fragment, Per1K: v})
+	}
+	return prices
+}
+
+func llmStageEnv(stage, suffix string) string {
+	return fmt.Sprintf("LLM_%s_%s", strings.ToUpper(stage), suffix)
+}
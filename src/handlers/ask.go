@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const askUsage = "Вопрос по архиву новостей:\n" +
+	"/ask что решил ЦБ по ключевой ставке?\n" +
+	"Фильтры: канал:topor_live с:01.10.2026 по:15.10.2026 (по умолчанию - последние 30 дней)"
+
+type AskHandler struct {
+	askService *service.AskService
+}
+
+func NewAskHandler(askService *service.AskService) *AskHandler {
+	return &AskHandler{askService: askService}
+}
+
+func (h *AskHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	q, err := service.ParseSearchQuery(c.Message().Payload)
+	if errors.Is(err, service.ErrEmptySearchQuery) {
+		return c.Send(askUsage, keyboard.GetStartKeyboard())
+	}
+	if err != nil {
+		return c.Send(fmt.Sprintf("Не удалось разобрать вопрос: %v\n\n%s", err, askUsage), keyboard.GetStartKeyboard())
+	}
+
+	if err := c.Notify(tele.Typing); err != nil {
+		log.Warnf("Error sending typing action to user %d: %v", user.ChatID, err)
+	}
+
+	answer, err := h.askService.Ask(q, time.Now())
+	if errors.Is(err, service.ErrNoRelevantNews) {
+		return c.Send("В архиве не нашлось ничего, что отвечало бы на этот вопрос. "+
+			"Попробуйте переформулировать его или указать другой период (с:/по:).", keyboard.GetStartKeyboard())
+	}
+	if err != nil {
+		log.Errorf("Error answering question %q for user %d: %v", q.Text, user.ChatID, err)
+		return c.Send("Произошла ошибка при поиске ответа. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	parts := telegramutil.SplitMessage(answer)
+	for i, part := range parts {
+		var markup *tele.ReplyMarkup
+		if i == len(parts)-1 {
+			markup = keyboard.GetStartKeyboard()
+		}
+		err = c.Send(part, makeMarkupSendOptions(markup)...)
+		if err != nil {
+			log.Errorf("Error sending answer part %d/%d to user %d: %v", i+1, len(parts), user.ChatID, err)
+			log.Info("Try send plain text message")
+			text, entities := telegramutil.LinkEntities(part)
+			err = c.Send(text, append(makeMarkupPlainSendOptions(markup), entities)...)
+			if err != nil {
+				log.Errorf("Error sending plain text answer part %d/%d to user %d: %v", i+1, len(parts), user.ChatID, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
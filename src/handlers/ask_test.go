@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func newTestAskHandler(ctrl *gomock.Controller) (*handlers.AskHandler, *mock_repository.MockMLRepositoryInterface, *mock_repository.MockStorylineRepositoryInterface, *mock_repository.MockSearchRepositoryInterface) {
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	searchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	askService := service.NewAskService(mlRepo, storylineRepo, mock_repository.NewMockMessageRepositoryInterface(ctrl), searchRepo)
+	return handlers.NewAskHandler(askService), mlRepo, storylineRepo, searchRepo
+}
+
+func TestAskHandler_Usage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _, _, _ := newTestAskHandler(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: ""})
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		assert.Contains(t, what.(string), "/ask")
+		return nil
+	})
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
+
+func TestAskHandler_NothingRelevant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mlRepo, storylineRepo, searchRepo := newTestAskHandler(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "кто выиграл матч?"})
+	mockContext.EXPECT().Notify(tele.Typing).Return(nil)
+	mlRepo.EXPECT().EmbedQueries([]string{"кто выиграл матч?"}).Return([][]float32{{0.1}}, nil)
+	storylineRepo.EXPECT().SearchNearestAll(gomock.Any(), gomock.Any()).Return(nil, nil)
+	searchRepo.EXPECT().SearchMessages(gomock.Any()).Return(nil, 0, nil)
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		assert.Contains(t, what.(string), "В архиве не нашлось ничего")
+		return nil
+	})
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const categoriesMessage = "Рубрики дайджеста. Нажмите на рубрику, чтобы переключить: " +
+	"обычная → 🔇 скрыть → ⬆️ в начало.\nНастройки применяются к «Новостям» и ежедневной рассылке."
+
+// CategoriesHandler настраивает рубрики персонального дайджеста (service.DigestPersonalizer).
+type CategoriesHandler struct {
+	prefRepo repository.CategoryPreferenceRepositoryInterface
+}
+
+func NewCategoriesHandler(prefRepo repository.CategoryPreferenceRepositoryInterface) *CategoriesHandler {
+	return &CategoriesHandler{prefRepo: prefRepo}
+}
+
+func (h *CategoriesHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	prefs, err := h.prefRepo.GetPreferences(user.ChatID)
+	if err != nil {
+		log.Errorf("Error getting category preferences for user %d: %v", user.ChatID, err)
+		return c.Send("Не удалось получить настройки рубрик. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	return c.Send(categoriesMessage, categoriesKeyboard(prefs))
+}
+
+// HandleCallback переключает рубрику из callback data category_pref_{индекс в config.Categories}.
+func (h *CategoriesHandler) HandleCallback(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	var idx int
+	if _, err := fmt.Sscanf(c.Callback().Data, "category_pref_%d", &idx); err != nil || idx < 0 || idx >= len(config.Categories) {
+		return fmt.Errorf("invalid category callback data %q", c.Callback().Data)
+	}
+	category := config.Categories[idx]
+
+	prefs, err := h.prefRepo.GetPreferences(user.ChatID)
+	if err == nil {
+		err = h.prefRepo.SetPreference(user.ChatID, category, nextCategoryPreference(prefs.Preference(category)))
+	}
+	if err == nil {
+		prefs, err = h.prefRepo.GetPreferences(user.ChatID)
+	}
+	if err != nil {
+		log.Errorf("Error updating category %q for user %d: %v", category, user.ChatID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось сохранить настройку, попробуйте позже"})
+	}
+
+	if err := c.Respond(); err != nil {
+		log.Warnf("Error answering category callback: %v", err)
+	}
+	return c.Edit(categoriesMessage, categoriesKeyboard(prefs))
+}
+
+// nextCategoryPreference: обычная -> скрыть -> в начало -> обычная.
+func nextCategoryPreference(current string) string {
+	switch current {
+	case "":
+		return repository.PreferenceMute
+	case repository.PreferenceMute:
+		return repository.PreferenceBoost
+	default:
+		return ""
+	}
+}
+
+func categoriesKeyboard(prefs *repository.CategoryPreferences) *tele.ReplyMarkup {
+	var rows []tele.Row
+	for i, category := range config.Categories {
+		text := category
+		switch prefs.Preference(category) {
+		case repository.PreferenceMute:
+			text = "🔇 " + category
+		case repository.PreferenceBoost:
+			text = "⬆️ " + category
+		}
+		rows = append(rows, tele.Row{tele.Btn{
+			Text: text,
+			Data: fmt.Sprintf("category_pref_%d", i),
+		}})
+	}
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(rows...)
+	return markup
+}
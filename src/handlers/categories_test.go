@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestCategoriesHandler_Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewCategoriesHandler(mockPrefRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockPrefRepo.EXPECT().GetPreferences(int64(555)).Return(&repository.CategoryPreferences{Muted: []string{"происшествия"}}, nil)
+	mockContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Len(t, markup.InlineKeyboard, len(config.Categories))
+		assert.Equal(t, "🔇 происшествия", markup.InlineKeyboard[1][0].Text)
+		assert.Equal(t, "category_pref_1", markup.InlineKeyboard[1][0].Data)
+		return nil
+	})
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
+
+func TestCategoriesHandler_HandleCallbackCyclesPreference(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPrefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewCategoriesHandler(mockPrefRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "category_pref_1"}).AnyTimes()
+	gomock.InOrder(
+		mockPrefRepo.EXPECT().GetPreferences(int64(555)).Return(&repository.CategoryPreferences{Muted: []string{"происшествия"}}, nil),
+		// скрытая рубрика становится избранной
+		mockPrefRepo.EXPECT().SetPreference(int64(555), "происшествия", repository.PreferenceBoost).Return(nil),
+		mockPrefRepo.EXPECT().GetPreferences(int64(555)).Return(&repository.CategoryPreferences{Boosted: []string{"происшествия"}}, nil),
+	)
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Equal(t, "⬆️ происшествия", markup.InlineKeyboard[1][0].Text)
+		return nil
+	})
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
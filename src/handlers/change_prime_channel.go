@@ -26,7 +26,7 @@ func (h *ChangePrimeChannelHandler) Handle(c tele.Context) error {
 	}
 
 	// Get current channel name
-	currentChannelName := config.Channels[user.PreferredChannelID]
+	currentChannelName := config.ChannelNames()[user.PreferredChannelID]
 	if currentChannelName == "" {
 		currentChannelName = "неизвестный канал"
 	}
@@ -36,7 +36,7 @@ func (h *ChangePrimeChannelHandler) Handle(c tele.Context) error {
 
 	// Create keyboard with available channels
 	var rows []tele.Row
-	for channelID, channelName := range config.Channels {
+	for channelID, channelName := range config.ChannelNames() {
 		btn := tele.Btn{
 			Text: channelName,
 			Data: fmt.Sprintf("channel_%d", channelID),
@@ -75,7 +75,7 @@ func (h *ChangePrimeChannelHandler) HandleChannelSelection(c tele.Context) error
 		return fmt.Errorf("failed to update preferred channel: %w", err)
 	}
 
-	channelName := config.Channels[channelID]
+	channelName := config.ChannelNames()[channelID]
 	if channelName == "" {
 		channelName = "неизвестный канал"
 	}
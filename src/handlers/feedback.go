@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// сколько сюжетов дня предлагать на выбор при отметке ошибки
+	feedbackStorylinesLimit = 8
+	// длина названия сюжета на кнопке
+	feedbackTitleMaxRunes = 40
+)
+
+// FeedbackHandler принимает оценки дайджестов с кнопок keyboard.GetFeedbackKeyboard.
+type FeedbackHandler struct {
+	feedbackRepo repository.FeedbackRepositoryInterface
+}
+
+func NewFeedbackHandler(feedbackRepo repository.FeedbackRepositoryInterface) *FeedbackHandler {
+	return &FeedbackHandler{feedbackRepo: feedbackRepo}
+}
+
+// HandleCallback разбирает feedback_{up|down|error}_{summaryID} и
+// feedback_storyline_{summaryID}_{storylineID} (0 - ошибка без привязки к сюжету).
+func (h *FeedbackHandler) HandleCallback(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	action, args, _ := strings.Cut(strings.TrimPrefix(c.Callback().Data, "feedback_"), "_")
+	ids, err := parseFeedbackIDs(args)
+	if err != nil || len(ids) == 0 {
+		log.Errorf("Invalid feedback callback data %q: %v", c.Callback().Data, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось сохранить отзыв"})
+	}
+	summaryID := ids[0]
+
+	switch {
+	case action == repository.FeedbackUp || action == repository.FeedbackDown:
+		return h.save(c, &repository.SummaryFeedback{SummaryID: summaryID, ChatID: user.ChatID, Rating: action}, "Спасибо за оценку!")
+	case action == repository.FeedbackError && len(ids) == 1:
+		return h.askStoryline(c, summaryID)
+	case action == "storyline" && len(ids) == 2:
+		return h.save(c, &repository.SummaryFeedback{
+			SummaryID:   summaryID,
+			ChatID:      user.ChatID,
+			Rating:      repository.FeedbackError,
+			StorylineID: ids[1],
+		}, "Спасибо, передали редактору")
+	default:
+		log.Errorf("Unknown feedback callback data %q", c.Callback().Data)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось сохранить отзыв"})
+	}
+}
+
+func (h *FeedbackHandler) save(c tele.Context, feedback *repository.SummaryFeedback, thanks string) error {
+	feedback.CreatedAt = time.Now().UTC()
+	if err := h.feedbackRepo.SaveFeedback(feedback); err != nil {
+		log.Errorf("Error saving feedback for summary %d: %v", feedback.SummaryID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось сохранить отзыв, попробуйте позже"})
+	}
+	return c.Respond(&tele.CallbackResponse{Text: thanks})
+}
+
+// askStoryline предлагает выбрать сюжет дня, в котором ошибка.
+func (h *FeedbackHandler) askStoryline(c tele.Context, summaryID int64) error {
+	storylines, err := h.feedbackRepo.GetSummaryStorylines(summaryID, feedbackStorylinesLimit)
+	if err != nil {
+		log.Errorf("Error getting storylines for summary %d: %v", summaryID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось получить сюжеты, попробуйте позже"})
+	}
+
+	var rows []tele.Row
+	for _, s := range storylines {
+		rows = append(rows, tele.Row{tele.Btn{
+			Text: truncateRunes(s.Title, feedbackTitleMaxRunes),
+			Data: fmt.Sprintf("feedback_storyline_%d_%d", summaryID, s.ID),
+		}})
+	}
+	rows = append(rows, tele.Row{tele.Btn{
+		Text: "Другое",
+		Data: fmt.Sprintf("feedback_storyline_%d_0", summaryID),
+	}})
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(rows...)
+
+	if err := c.Respond(); err != nil {
+		log.Warnf("Error answering feedback callback: %v", err)
+	}
+	return c.Send("В каком сюжете ошибка?", markup)
+}
+
+func parseFeedbackIDs(args string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(args, "_") {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
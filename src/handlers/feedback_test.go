@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestFeedbackHandler_HandleCallback_Vote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFeedbackRepo := mock_repository.NewMockFeedbackRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewFeedbackHandler(mockFeedbackRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "feedback_down_42"}).AnyTimes()
+	mockFeedbackRepo.EXPECT().SaveFeedback(gomock.Any()).DoAndReturn(func(f *repository.SummaryFeedback) error {
+		assert.Equal(t, int64(42), f.SummaryID)
+		assert.Equal(t, int64(555), f.ChatID)
+		assert.Equal(t, repository.FeedbackDown, f.Rating)
+		assert.Zero(t, f.StorylineID)
+		assert.False(t, f.CreatedAt.IsZero())
+		return nil
+	})
+	mockContext.EXPECT().Respond(&tele.CallbackResponse{Text: "Спасибо за оценку!"}).Return(nil)
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
+
+func TestFeedbackHandler_HandleCallback_ErrorAsksStoryline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFeedbackRepo := mock_repository.NewMockFeedbackRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewFeedbackHandler(mockFeedbackRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "feedback_error_42"}).AnyTimes()
+	mockFeedbackRepo.EXPECT().GetSummaryStorylines(int64(42), gomock.Any()).Return([]repository.Storyline{
+		{ID: 7, Title: "Переговоры о перемирии"},
+	}, nil)
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Send("В каком сюжете ошибка?", gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Len(t, markup.InlineKeyboard, 2)
+		assert.Equal(t, "Переговоры о перемирии", markup.InlineKeyboard[0][0].Text)
+		assert.Equal(t, "feedback_storyline_42_7", markup.InlineKeyboard[0][0].Data)
+		assert.Equal(t, "feedback_storyline_42_0", markup.InlineKeyboard[1][0].Data)
+		return nil
+	})
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
+
+func TestFeedbackHandler_HandleCallback_Storyline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFeedbackRepo := mock_repository.NewMockFeedbackRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewFeedbackHandler(mockFeedbackRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "feedback_storyline_42_7"}).AnyTimes()
+	mockFeedbackRepo.EXPECT().SaveFeedback(gomock.Any()).DoAndReturn(func(f *repository.SummaryFeedback) error {
+		assert.Equal(t, repository.FeedbackError, f.Rating)
+		assert.Equal(t, int64(7), f.StorylineID)
+		return errors.New("db down")
+	})
+	mockContext.EXPECT().Respond(&tele.CallbackResponse{Text: "Не удалось сохранить отзыв, попробуйте позже"}).Return(nil)
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	log "github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v4"
+)
+
+const storylineSearchCancelData = "storyline_search_cancel"
+
+// FindStorylineHandler - кнопка «Найти сюжет»: читатель описывает сюжет своими словами,
+// в ответ - ближайшие сюжеты всех каналов с кнопками хроники.
+type FindStorylineHandler struct {
+	searchService *service.StorylineSearchService
+	stateStorage  *StateStorage
+}
+
+func NewFindStorylineHandler(searchService *service.StorylineSearchService, stateStorage *StateStorage) *FindStorylineHandler {
+	return &FindStorylineHandler{
+		searchService: searchService,
+		stateStorage:  stateStorage,
+	}
+}
+
+func (h *FindStorylineHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	h.stateStorage.SetState(user.ChatID, &UserState{SearchingStoryline: true})
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(tele.Btn{Text: "Отмена", Data: storylineSearchCancelData}))
+	return c.Send("Опишите сюжет своими словами, например: «переговоры о перемирии» или «цены на бензин»", markup)
+}
+
+func (h *FindStorylineHandler) HandleQueryInput(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	state := h.stateStorage.GetState(user.ChatID)
+	if state == nil || !state.SearchingStoryline {
+		return nil
+	}
+
+	query := c.Text()
+	results, err := h.searchService.Find(query)
+	if errors.Is(err, service.ErrEmptySearchQuery) {
+		return c.Send("Напишите, какой сюжет найти")
+	}
+	if err != nil {
+		log.Errorf("Error finding storylines for user %d: %v", user.ChatID, err)
+		h.stateStorage.ClearState(user.ChatID)
+		return c.Send("Не удалось найти сюжеты. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+	h.stateStorage.ClearState(user.ChatID)
+
+	text := service.FormatStorylineResults(query, results)
+	if len(results) == 0 {
+		return c.Send(text, keyboard.GetStartKeyboard())
+	}
+	return c.Send(text, storylineResultsMarkup(results))
+}
+
+// HandleCallback обрабатывает storyline_timeline_{id} и отмену поиска.
+func (h *FindStorylineHandler) HandleCallback(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	data := c.Callback().Data
+	if data == storylineSearchCancelData {
+		h.stateStorage.ClearState(user.ChatID)
+		if err := c.Respond(); err != nil {
+			log.Warnf("Error answering storyline search callback: %v", err)
+		}
+		return c.Edit("Поиск сюжета отменён")
+	}
+
+	var storylineID int64
+	if _, err := fmt.Sscanf(data, "storyline_timeline_%d", &storylineID); err != nil {
+		return fmt.Errorf("invalid storyline callback data %q", data)
+	}
+
+	timeline, err := h.searchService.Timeline(storylineID)
+	if errors.Is(err, service.ErrStorylineNotFound) {
+		return c.Respond(&tele.CallbackResponse{Text: "Сюжет больше не существует"})
+	}
+	if err != nil {
+		log.Errorf("Error getting timeline of storyline %d: %v", storylineID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Ошибка, попробуйте позже"})
+	}
+
+	if err := c.Respond(); err != nil {
+		log.Warnf("Error answering storyline timeline callback: %v", err)
+	}
+	parts := telegramutil.SplitMessage(timeline.Format())
+	for i, part := range parts {
+		opts := []interface{}{&tele.SendOptions{DisableWebPagePreview: true}}
+		if i == len(parts)-1 {
+			opts = append([]interface{}{keyboard.GetStartKeyboard()}, opts...)
+		}
+		if err := c.Send(part, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storylineResultsMarkup - кнопки хроники с номерами сюжетов из списка.
+func storylineResultsMarkup(results []repository.ScoredStoryline) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	var row tele.Row
+	for i, r := range results {
+		row = append(row, tele.Btn{
+			Text: fmt.Sprintf("%d", i+1),
+			Data: fmt.Sprintf("storyline_timeline_%d", r.Storyline.ID),
+		})
+	}
+	markup.Inline(row)
+	return markup
+}
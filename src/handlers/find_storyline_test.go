@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestFindStorylineHandler_QueryInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	stateStorage := handlers.NewStateStorage()
+	handler := handlers.NewFindStorylineHandler(service.NewStorylineSearchService(mlRepo, storylineRepo, nil), stateStorage)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+	stateStorage.SetState(user.ChatID, &handlers.UserState{SearchingStoryline: true})
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Text().Return("бензин")
+	mlRepo.EXPECT().EmbedQueries([]string{"бензин"}).Return([][]float32{{0.1}}, nil)
+	storylineRepo.EXPECT().SearchNearestAll([]float32{0.1}, config.StorylineSearchTopK).Return([]repository.ScoredStoryline{
+		{Storyline: repository.Storyline{ID: 11, Title: "Цены на бензин", Status: "active"}, Similarity: 0.5},
+		{Storyline: repository.Storyline{ID: 12, Title: "Экспорт топлива", Status: "closed"}, Similarity: 0.4},
+	}, nil)
+	mockContext.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		assert.Contains(t, what.(string), "1. Цены на бензин")
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Equal(t, "storyline_timeline_11", markup.InlineKeyboard[0][0].Data)
+		assert.Equal(t, "2", markup.InlineKeyboard[0][1].Text)
+		assert.Equal(t, "storyline_timeline_12", markup.InlineKeyboard[0][1].Data)
+		return nil
+	})
+
+	assert.NoError(t, handler.HandleQueryInput(mockContext))
+	assert.Nil(t, stateStorage.GetState(user.ChatID))
+}
+
+func TestFindStorylineHandler_Timeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewFindStorylineHandler(
+		service.NewStorylineSearchService(mock_repository.NewMockMLRepositoryInterface(ctrl), storylineRepo, nil),
+		handlers.NewStateStorage(),
+	)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "storyline_timeline_11"}).AnyTimes()
+	storylineRepo.EXPECT().GetStoryline(int64(11)).Return(&repository.Storyline{ID: 11, Title: "Цены на бензин", Status: "active"}, nil)
+	storylineRepo.EXPECT().GetObservations(int64(11)).Return([]repository.Observation{{StorylineID: 11, ChangeType: "new", MessageCount: 2}}, nil)
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard(), &tele.SendOptions{DisableWebPagePreview: true}).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		assert.Contains(t, what.(string), "Хроника:")
+		assert.Contains(t, what.(string), "новый сюжет, сообщений: 2")
+		return nil
+	})
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
+
+func TestFindStorylineHandler_Cancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	stateStorage := handlers.NewStateStorage()
+	handler := handlers.NewFindStorylineHandler(nil, stateStorage)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+	stateStorage.SetState(user.ChatID, &handlers.UserState{SearchingStoryline: true})
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "storyline_search_cancel"}).AnyTimes()
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Edit("Поиск сюжета отменён").Return(nil)
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+	assert.Nil(t, stateStorage.GetState(user.ChatID))
+}
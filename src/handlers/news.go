@@ -5,6 +5,7 @@ import (
 
 	"github.com/Ra1ze505/goNewsBot/src/keyboard"
 	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
 	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
 	tele "gopkg.in/telebot.v4"
 
@@ -13,10 +14,12 @@ import (
 
 type NewsHandler struct {
 	summaryRepo repository.SummaryRepositoryInterface
+	// personalizer подстраивает дайджест под рубрики читателя; nil - общий дайджест.
+	personalizer *service.DigestPersonalizer
 }
 
-func NewNewsHandler(summaryRepo repository.SummaryRepositoryInterface) *NewsHandler {
-	return &NewsHandler{summaryRepo: summaryRepo}
+func NewNewsHandler(summaryRepo repository.SummaryRepositoryInterface, personalizer *service.DigestPersonalizer) *NewsHandler {
+	return &NewsHandler{summaryRepo: summaryRepo, personalizer: personalizer}
 }
 
 func (h *NewsHandler) Handle(c tele.Context) error {
@@ -35,17 +38,29 @@ func (h *NewsHandler) Handle(c tele.Context) error {
 		return c.Send("Новостей пока нет. Проверьте позже.", keyboard.GetStartKeyboard())
 	}
 
+	if h.personalizer != nil {
+		summary = h.personalizer.Personalize(user.ChatID, summary)
+	}
+
 	message := summary.GetFormattedSummary()
 	parts := telegramutil.SplitMessage(message)
 
 	for i, part := range parts {
-		opts := makeSendOptions(i == len(parts)-1)
+		// Под последней частью - кнопки оценки дайджеста; основная клавиатура
+		// остаётся от предыдущих сообщений.
+		var markup *tele.ReplyMarkup
+		if i == len(parts)-1 {
+			markup = keyboard.GetFeedbackKeyboard(summary.ID)
+		}
+		opts := makeMarkupSendOptions(markup)
 
 		err = c.Send(part, opts...)
 		if err != nil {
 			log.Errorf("Error sending news message part %d/%d to user %d: %v", i+1, len(parts), c.Sender().ID, err)
 			log.Info("Try send plain text message")
-			err = c.Send(part, makePlainSendOptions(i == len(parts)-1)...)
+			// Без Markdown ссылки на источники передаём сущностями text_link.
+			text, entities := telegramutil.LinkEntities(part)
+			err = c.Send(text, append(makeMarkupPlainSendOptions(markup), entities)...)
 			if err != nil {
 				log.Errorf("Error sending plain text message part %d/%d to user %d: %v", i+1, len(parts), c.Sender().ID, err)
 				return err
@@ -57,18 +72,33 @@ func (h *NewsHandler) Handle(c tele.Context) error {
 }
 
 func makeSendOptions(withKeyboard bool) []interface{} {
+	if withKeyboard {
+		return makeMarkupSendOptions(keyboard.GetStartKeyboard())
+	}
+	return makeMarkupSendOptions(nil)
+}
+
+func makePlainSendOptions(withKeyboard bool) []interface{} {
+	if withKeyboard {
+		return makeMarkupPlainSendOptions(keyboard.GetStartKeyboard())
+	}
+	return nil
+}
+
+// makeMarkupSendOptions - Markdown-опции с клавиатурой markup (nil - без клавиатуры).
+func makeMarkupSendOptions(markup *tele.ReplyMarkup) []interface{} {
 	opts := []interface{}{
 		&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 	}
-	if withKeyboard {
-		opts = append([]interface{}{keyboard.GetStartKeyboard()}, opts...)
+	if markup != nil {
+		opts = append([]interface{}{markup}, opts...)
 	}
 	return opts
 }
 
-func makePlainSendOptions(withKeyboard bool) []interface{} {
-	if withKeyboard {
-		return []interface{}{keyboard.GetStartKeyboard()}
+func makeMarkupPlainSendOptions(markup *tele.ReplyMarkup) []interface{} {
+	if markup != nil {
+		return []interface{}{markup}
 	}
 	return nil
 }
@@ -51,7 +51,7 @@ func TestNewsHandler_Handle(t *testing.T) {
 				mockSummaryRepo.EXPECT().GetLatestSummary(testUser.PreferredChannelID).Return(testSummary, nil)
 				mockContext.EXPECT().Send(
 					"Последние новости:\nTest summary content\n\nСуммаризация от "+testSummary.CreatedAt.Format("2006-01-02 15:04:05")+" UTC",
-					keyboard.GetStartKeyboard(),
+					keyboard.GetFeedbackKeyboard(testSummary.ID),
 					&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 				).Return(nil)
 			},
@@ -115,7 +115,7 @@ func TestNewsHandler_Handle(t *testing.T) {
 					if withKeyboard {
 						mockContext.EXPECT().Send(
 							part,
-							keyboard.GetStartKeyboard(),
+							keyboard.GetFeedbackKeyboard(longSummary.ID),
 							&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 						).Return(nil)
 					} else {
@@ -133,7 +133,7 @@ func TestNewsHandler_Handle(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			handler := NewNewsHandler(mockSummaryRepo)
+			handler := NewNewsHandler(mockSummaryRepo, nil)
 
 			err := handler.Handle(mockContext)
 
@@ -177,7 +177,7 @@ func TestNewsHandler_HandleLongSummarySplit(t *testing.T) {
 		if withKeyboard {
 			mockContext.EXPECT().Send(
 				part,
-				keyboard.GetStartKeyboard(),
+				keyboard.GetFeedbackKeyboard(longSummary.ID),
 				&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 			).DoAndReturn(func(what any, opts ...any) error {
 				sentParts = append(sentParts, what.(string))
@@ -194,7 +194,7 @@ func TestNewsHandler_HandleLongSummarySplit(t *testing.T) {
 		}
 	}
 
-	handler := NewNewsHandler(mockSummaryRepo)
+	handler := NewNewsHandler(mockSummaryRepo, nil)
 	if err := handler.Handle(mockContext); err != nil {
 		t.Fatalf("Handle() error = %v", err)
 	}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type PeriodDigestHandler struct {
+	periodDigestService *service.PeriodDigestService
+}
+
+func NewPeriodDigestHandler(periodDigestService *service.PeriodDigestService) *PeriodDigestHandler {
+	return &PeriodDigestHandler{periodDigestService: periodDigestService}
+}
+
+// HandleWeek отвечает на /week дайджестом за последние 7 дней.
+func (h *PeriodDigestHandler) HandleWeek(c tele.Context) error {
+	return h.handle(c, service.PeriodWeek)
+}
+
+// HandleMonth отвечает на /month дайджестом за последние 30 дней.
+func (h *PeriodDigestHandler) HandleMonth(c tele.Context) error {
+	return h.handle(c, service.PeriodMonth)
+}
+
+func (h *PeriodDigestHandler) handle(c tele.Context, period string) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	summary, err := h.periodDigestService.Get(user.PreferredChannelID, period, time.Now())
+	if err != nil {
+		log.Errorf("Error getting %s digest: %v", period, err)
+		return c.Send("Произошла ошибка при подготовке дайджеста. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	parts := telegramutil.SplitMessage(summary.GetFormattedSummary())
+	for i, part := range parts {
+		err = c.Send(part, makeSendOptions(i == len(parts)-1)...)
+		if err != nil {
+			log.Errorf("Error sending %s digest part %d/%d to user %d: %v", period, i+1, len(parts), c.Sender().ID, err)
+			log.Info("Try send plain text message")
+			err = c.Send(part, makePlainSendOptions(i == len(parts)-1)...)
+			if err != nil {
+				log.Errorf("Error sending plain text %s digest part %d/%d to user %d: %v", period, i+1, len(parts), c.Sender().ID, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
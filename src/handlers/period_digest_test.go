@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestPeriodDigestHandler_HandleWeek(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPeriodRepo := mock_repository.NewMockPeriodSummaryRepositoryInterface(ctrl)
+	mockMLRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mockMLRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mockMLRepo).AnyTimes()
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := NewPeriodDigestHandler(service.NewPeriodDigestService(mockPeriodRepo, mockMLRepo))
+
+	var start, end time.Time
+	mockContext.EXPECT().Get("user").Return(&repository.User{PreferredChannelID: 123})
+	mockPeriodRepo.EXPECT().GetPeriodObservations(int64(123), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ int64, from, to time.Time) ([]repository.PeriodObservation, error) {
+			start, end = from, to
+			return nil, nil
+		})
+	mockPeriodRepo.EXPECT().GetPeriodSummary(int64(123), service.PeriodWeek, gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockMLRepo.EXPECT().RenderPeriodDigest(gomock.Any()).Return("Итоги недели", nil)
+	mockPeriodRepo.EXPECT().SavePeriodSummary(gomock.Any()).Return(nil)
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard(), &tele.SendOptions{ParseMode: tele.ModeMarkdown}).DoAndReturn(
+		func(what interface{}, _ ...interface{}) error {
+			want := "Главное за неделю (" + start.Format("02.01") + "–" + end.AddDate(0, 0, -1).Format("02.01") + "):\nИтоги недели"
+			if what != want {
+				t.Errorf("sent %q, want %q", what, want)
+			}
+			return nil
+		})
+
+	if err := handler.HandleWeek(mockContext); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
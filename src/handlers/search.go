@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const searchUsage = "Поиск по архиву каналов:\n" +
+	"/search ключевая ставка\n" +
+	"/search \"точная фраза\" -исключить\n" +
+	"Фильтры: канал:topor_live с:01.10.2026 по:15.10.2026"
+
+// SearchHandler отвечает на /search. Запросы хранятся в памяти для кнопок
+// листания (callback data не вмещает сам запрос): у каждого свой номер, поэтому
+// кнопки старого сообщения листают свой запрос, а не последний. Запросы старше
+// config.SearchQueryTTLHours удаляются.
+type SearchHandler struct {
+	searchService *service.SearchService
+
+	mu      sync.Mutex
+	nextID  int64
+	queries map[int64]storedSearch
+}
+
+type storedSearch struct {
+	chatID    int64
+	query     repository.SearchQuery
+	createdAt time.Time
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		// номера не повторяются после перезапуска: кнопки старых сообщений не
+		// подхватят новый запрос того же читателя
+		nextID:  time.Now().UnixNano(),
+		queries: make(map[int64]storedSearch),
+	}
+}
+
+func (h *SearchHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	q, err := service.ParseSearchQuery(c.Message().Payload)
+	if errors.Is(err, service.ErrEmptySearchQuery) {
+		return c.Send(searchUsage, keyboard.GetStartKeyboard())
+	}
+	if err != nil {
+		return c.Send(fmt.Sprintf("Не удалось разобрать запрос: %v\n\n%s", err, searchUsage), keyboard.GetStartKeyboard())
+	}
+
+	page, err := h.searchService.Search(q, 0)
+	if err != nil {
+		log.Errorf("Error searching %q for user %d: %v", q.Text, user.ChatID, err)
+		return c.Send("Произошла ошибка при поиске. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	queryID := h.storeQuery(user.ChatID, q, time.Now())
+	return c.Send(page.Format(), searchPageMarkup(queryID, page), &tele.SendOptions{DisableWebPagePreview: true})
+}
+
+// HandleCallback листает результаты по callback data
+// search_page_{номер запроса}_{номер страницы с нуля}.
+func (h *SearchHandler) HandleCallback(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	var queryID int64
+	var pageNum int
+	if _, err := fmt.Sscanf(c.Callback().Data, "search_page_%d_%d", &queryID, &pageNum); err != nil || pageNum < 0 {
+		return fmt.Errorf("invalid search callback data %q", c.Callback().Data)
+	}
+
+	q, ok := h.loadQuery(queryID, user.ChatID, time.Now())
+	if !ok {
+		return c.Respond(&tele.CallbackResponse{Text: "Поиск устарел, повторите /search"})
+	}
+
+	page, err := h.searchService.Search(q, pageNum)
+	if err != nil {
+		log.Errorf("Error searching %q for user %d: %v", q.Text, user.ChatID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Ошибка поиска, попробуйте позже"})
+	}
+
+	if err := c.Respond(); err != nil {
+		log.Warnf("Error answering search callback: %v", err)
+	}
+	return c.Edit(page.Format(), searchPageMarkup(queryID, page), &tele.SendOptions{DisableWebPagePreview: true})
+}
+
+// storeQuery запоминает запрос и возвращает его номер; заодно удаляет устаревшие.
+func (h *SearchHandler) storeQuery(chatID int64, q repository.SearchQuery, now time.Time) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, stored := range h.queries {
+		if now.Sub(stored.createdAt) > config.SearchQueryTTLHours*time.Hour {
+			delete(h.queries, id)
+		}
+	}
+	h.nextID++
+	h.queries[h.nextID] = storedSearch{chatID: chatID, query: q, createdAt: now}
+	return h.nextID
+}
+
+// loadQuery - запрос по номеру из кнопки; чужой или устаревший не находится.
+func (h *SearchHandler) loadQuery(queryID, chatID int64, now time.Time) (repository.SearchQuery, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stored, ok := h.queries[queryID]
+	if !ok || stored.chatID != chatID || now.Sub(stored.createdAt) > config.SearchQueryTTLHours*time.Hour {
+		return repository.SearchQuery{}, false
+	}
+	return stored.query, true
+}
+
+func searchPageMarkup(queryID int64, page *service.SearchPage) *tele.ReplyMarkup {
+	var row tele.Row
+	if page.Page > 0 {
+		row = append(row, tele.Btn{Text: "← Назад", Data: fmt.Sprintf("search_page_%d_%d", queryID, page.Page-1)})
+	}
+	if page.Page+1 < page.Pages {
+		row = append(row, tele.Btn{Text: "Далее →", Data: fmt.Sprintf("search_page_%d_%d", queryID, page.Page+1)})
+	}
+
+	markup := &tele.ReplyMarkup{}
+	if len(row) > 0 {
+		markup.Inline(row)
+	}
+	return markup
+}
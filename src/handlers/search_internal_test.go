@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchHandler_StoredQueriesExpire(t *testing.T) {
+	handler := NewSearchHandler(nil)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ttl := config.SearchQueryTTLHours * time.Hour
+
+	oldID := handler.storeQuery(555, repository.SearchQuery{Text: "нефть"}, now)
+
+	q, ok := handler.loadQuery(oldID, 555, now.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "нефть", q.Text)
+
+	// чужой читатель не листает чужой запрос
+	_, ok = handler.loadQuery(oldID, 777, now.Add(time.Hour))
+	assert.False(t, ok)
+
+	_, ok = handler.loadQuery(oldID, 555, now.Add(ttl+time.Minute))
+	assert.False(t, ok)
+
+	// новый запрос вычищает устаревшие
+	newID := handler.storeQuery(555, repository.SearchQuery{Text: "газ"}, now.Add(ttl+time.Minute))
+	assert.NotEqual(t, oldID, newID)
+	assert.Len(t, handler.queries, 1)
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestSearchHandler_SearchAndPaginate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSearchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewSearchHandler(service.NewSearchService(mockSearchRepo))
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	results := []repository.SearchResult{{ChannelID: 1754252633, MessageID: 9001, Headline: "«нефть»"}}
+
+	mockContext.EXPECT().Get("user").Return(user).Times(2)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "нефть с:01.10.2026"})
+	gomock.InOrder(
+		mockSearchRepo.EXPECT().SearchMessages(repository.SearchQuery{Text: "нефть", From: from, Limit: 5}).Return(results, 12, nil),
+		mockSearchRepo.EXPECT().SearchMessages(repository.SearchQuery{Text: "нефть", From: from, Limit: 5, Offset: 5}).Return(results, 12, nil),
+	)
+	var queryID int64
+	mockContext.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Len(t, markup.InlineKeyboard[0], 1)
+		_, err := fmt.Sscanf(markup.InlineKeyboard[0][0].Data, "search_page_%d_1", &queryID)
+		assert.NoError(t, err)
+		assert.True(t, opts[1].(*tele.SendOptions).DisableWebPagePreview)
+		return nil
+	})
+	assert.NoError(t, handler.Handle(mockContext))
+
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: fmt.Sprintf("search_page_%d_1", queryID)}).AnyTimes()
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Edit(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		assert.Contains(t, what.(string), "страница 2 из 3")
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Equal(t, fmt.Sprintf("search_page_%d_0", queryID), markup.InlineKeyboard[0][0].Data)
+		assert.Equal(t, fmt.Sprintf("search_page_%d_2", queryID), markup.InlineKeyboard[0][1].Data)
+		return nil
+	})
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
+
+func TestSearchHandler_EmptyQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSearchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewSearchHandler(service.NewSearchService(mockSearchRepo))
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: ""})
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard()).Return(nil)
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
+
+func TestSearchHandler_StaleCallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSearchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewSearchHandler(service.NewSearchService(mockSearchRepo))
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "search_page_1_2"}).AnyTimes()
+	mockContext.EXPECT().Respond(&tele.CallbackResponse{Text: "Поиск устарел, повторите /search"}).Return(nil)
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
+
+func TestSearchHandler_OldMessagePagesItsOwnQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSearchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewSearchHandler(service.NewSearchService(mockSearchRepo))
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+	results := []repository.SearchResult{{ChannelID: 1754252633, MessageID: 9001, Headline: "«нефть»"}}
+
+	mockContext.EXPECT().Get("user").Return(user).AnyTimes()
+	var buttons []string
+	mockContext.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		buttons = append(buttons, opts[0].(*tele.ReplyMarkup).InlineKeyboard[0][0].Data)
+		return nil
+	}).Times(2)
+
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "нефть"})
+	mockSearchRepo.EXPECT().SearchMessages(repository.SearchQuery{Text: "нефть", Limit: 5}).Return(results, 12, nil)
+	assert.NoError(t, handler.Handle(mockContext))
+
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "газ"})
+	mockSearchRepo.EXPECT().SearchMessages(repository.SearchQuery{Text: "газ", Limit: 5}).Return(results, 12, nil)
+	assert.NoError(t, handler.Handle(mockContext))
+
+	// кнопка первого сообщения листает «нефть», а не последний запрос
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: buttons[0]}).AnyTimes()
+	mockSearchRepo.EXPECT().SearchMessages(repository.SearchQuery{Text: "нефть", Limit: 5, Offset: 5}).Return(results, 12, nil)
+	mockContext.EXPECT().Respond().Return(nil)
+	mockContext.EXPECT().Edit(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
@@ -3,6 +3,10 @@ package handlers
 type UserState struct {
 	ChangingCity bool
 	ChangingTime bool
+	// SearchingStoryline - следующий текст читателя - запрос «Найти сюжет».
+	SearchingStoryline bool
+	// AdminAction - ожидаемый текстовый ввод админского действия (см. admin_handlers).
+	AdminAction string
 }
 
 type StateStorage struct {
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type TrendsHandler struct {
+	trendService *service.TrendService
+}
+
+func NewTrendsHandler(trendService *service.TrendService) *TrendsHandler {
+	return &TrendsHandler{trendService: trendService}
+}
+
+// Handle отвечает на /trends недельным отчётом по предпочитаемому каналу.
+func (h *TrendsHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	message, err := h.trendService.WeeklyReportText(user.PreferredChannelID, time.Now())
+	if err != nil {
+		log.Errorf("Error building weekly trends: %v", err)
+		return c.Send("Произошла ошибка при подготовке отчёта. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	parts := telegramutil.SplitMessage(message)
+	for i, part := range parts {
+		err = c.Send(part, makeSendOptions(i == len(parts)-1)...)
+		if err != nil {
+			log.Errorf("Error sending trends part %d/%d to user %d: %v", i+1, len(parts), c.Sender().ID, err)
+			log.Info("Try send plain text message")
+			err = c.Send(part, makePlainSendOptions(i == len(parts)-1)...)
+			if err != nil {
+				log.Errorf("Error sending plain text trends part %d/%d to user %d: %v", i+1, len(parts), c.Sender().ID, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
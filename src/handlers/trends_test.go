@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	"go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestTrendsHandler_Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTrendRepo := mock_repository.NewMockTrendRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := NewTrendsHandler(service.NewTrendService(mockTrendRepo))
+
+	testUser := &repository.User{PreferredChannelID: 123}
+
+	mockContext.EXPECT().Get("user").Return(testUser)
+	mockTrendRepo.EXPECT().TopStorylines(int64(123), gomock.Any(), gomock.Any(), 5).Return([]repository.StorylineTrend{
+		{Title: "Переговоры", TotalMessages: 5, DaysSeen: 2, MaxImportance: 3},
+	}, nil)
+	mockTrendRepo.EXPECT().TopEscalations(int64(123), gomock.Any(), gomock.Any(), 3).Return(nil, nil)
+	mockTrendRepo.EXPECT().CategoryShares(int64(123), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	mockTrendRepo.EXPECT().ClosedStorylines(int64(123), gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockContext.EXPECT().Send(
+		gomock.Cond(func(x any) bool { return strings.Contains(x.(string), "1. Переговоры — 5 сообщ.") }),
+		keyboard.GetStartKeyboard(),
+		&tele.SendOptions{ParseMode: tele.ModeMarkdown},
+	).Return(nil)
+
+	if err := handler.Handle(mockContext); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTrendsHandler_HandleError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTrendRepo := mock_repository.NewMockTrendRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := NewTrendsHandler(service.NewTrendService(mockTrendRepo))
+
+	mockContext.EXPECT().Get("user").Return(&repository.User{PreferredChannelID: 123})
+	mockTrendRepo.EXPECT().TopStorylines(int64(123), gomock.Any(), gomock.Any(), 5).Return(nil, errors.New("database error"))
+	mockContext.EXPECT().Send("Произошла ошибка при подготовке отчёта. Попробуйте позже.", keyboard.GetStartKeyboard()).Return(nil)
+
+	if err := handler.Handle(mockContext); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/service"
+	tele "gopkg.in/telebot.v4"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const watchUsage = "Подписка на упоминания в каналах:\n" +
+	"/watch газпром - слово или фраза в любой форме (Газпрома, Газпромом...)\n" +
+	"/watch /регулярное выражение/ - например /watch /сбер(банк)?/\n" +
+	"Уведомление приходит сразу, как бот заберёт сообщение из канала."
+
+// WatchHandler управляет подписками на ключевые слова; сами совпадения шлёт service.WatchService.
+type WatchHandler struct {
+	watchRepo repository.WatchRepositoryInterface
+}
+
+func NewWatchHandler(watchRepo repository.WatchRepositoryInterface) *WatchHandler {
+	return &WatchHandler{watchRepo: watchRepo}
+}
+
+// Handle: /watch без аргумента показывает подписки, с аргументом - добавляет подписку.
+func (h *WatchHandler) Handle(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		return h.sendList(c, user.ChatID, false)
+	}
+
+	pattern, isRegex, err := service.ParseWatchPattern(payload)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Не удалось добавить подписку: %v\n\n%s", err, watchUsage), keyboard.GetStartKeyboard())
+	}
+
+	count, err := h.watchRepo.CountSubscriptions(user.ChatID)
+	if err != nil {
+		log.Errorf("Error counting watch subscriptions for user %d: %v", user.ChatID, err)
+		return c.Send("Не удалось добавить подписку. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+	if count >= config.WatchMaxPerUser {
+		return c.Send(fmt.Sprintf("Можно держать не больше %d подписок. Удалите лишние: /watch", config.WatchMaxPerUser), keyboard.GetStartKeyboard())
+	}
+
+	sub := &repository.WatchSubscription{
+		ChatID:    user.ChatID,
+		Pattern:   pattern,
+		IsRegex:   isRegex,
+		CreatedAt: time.Now().UTC(),
+	}
+	added, err := h.watchRepo.AddSubscription(sub)
+	if err != nil {
+		log.Errorf("Error adding watch subscription for user %d: %v", user.ChatID, err)
+		return c.Send("Не удалось добавить подписку. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+	if !added {
+		return c.Send(fmt.Sprintf("Подписка %s уже есть", service.FormatWatchPattern(*sub)), keyboard.GetStartKeyboard())
+	}
+	return c.Send(fmt.Sprintf("Подписка %s добавлена. Все подписки: /watch", service.FormatWatchPattern(*sub)), keyboard.GetStartKeyboard())
+}
+
+// HandleCallback удаляет подписку по callback data watch_del_{id}.
+func (h *WatchHandler) HandleCallback(c tele.Context) error {
+	user, ok := c.Get("user").(*repository.User)
+	if !ok {
+		return fmt.Errorf("user not found in context")
+	}
+
+	var id int64
+	if _, err := fmt.Sscanf(c.Callback().Data, "watch_del_%d", &id); err != nil {
+		return fmt.Errorf("invalid watch callback data %q: %w", c.Callback().Data, err)
+	}
+
+	if _, err := h.watchRepo.DeleteSubscription(user.ChatID, id); err != nil {
+		log.Errorf("Error deleting watch subscription %d for user %d: %v", id, user.ChatID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось удалить подписку, попробуйте позже"})
+	}
+	if err := c.Respond(&tele.CallbackResponse{Text: "Подписка удалена"}); err != nil {
+		log.Warnf("Error answering watch callback: %v", err)
+	}
+	return h.sendList(c, user.ChatID, true)
+}
+
+// sendList показывает подписки с кнопками удаления; edit - обновить сообщение со списком.
+func (h *WatchHandler) sendList(c tele.Context, chatID int64, edit bool) error {
+	subs, err := h.watchRepo.ListSubscriptions(chatID)
+	if err != nil {
+		log.Errorf("Error listing watch subscriptions for user %d: %v", chatID, err)
+		return c.Send("Не удалось получить подписки. Попробуйте позже.", keyboard.GetStartKeyboard())
+	}
+
+	if len(subs) == 0 {
+		if edit {
+			return c.Edit("Подписок нет.\n\n" + watchUsage)
+		}
+		return c.Send("Подписок нет.\n\n"+watchUsage, keyboard.GetStartKeyboard())
+	}
+
+	var rows []tele.Row
+	for _, s := range subs {
+		rows = append(rows, tele.Row{tele.Btn{
+			Text: "❌ " + service.FormatWatchPattern(s),
+			Data: fmt.Sprintf("watch_del_%d", s.ID),
+		}})
+	}
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(rows...)
+
+	message := "Ваши подписки (нажмите, чтобы удалить):\n\n" + watchUsage
+	if edit {
+		return c.Edit(message, markup)
+	}
+	return c.Send(message, markup)
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/handlers"
+	"github.com/Ra1ze505/goNewsBot/src/keyboard"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestWatchHandler_AddSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatchRepo := mock_repository.NewMockWatchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewWatchHandler(mockWatchRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "/сбер(банк)?/"})
+	mockWatchRepo.EXPECT().CountSubscriptions(int64(555)).Return(2, nil)
+	mockWatchRepo.EXPECT().AddSubscription(gomock.Any()).DoAndReturn(func(s *repository.WatchSubscription) (bool, error) {
+		assert.Equal(t, "сбер(банк)?", s.Pattern)
+		assert.True(t, s.IsRegex)
+		return true, nil
+	})
+	mockContext.EXPECT().Send("Подписка /сбер(банк)?/ добавлена. Все подписки: /watch", keyboard.GetStartKeyboard()).Return(nil)
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
+
+func TestWatchHandler_LimitReached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatchRepo := mock_repository.NewMockWatchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewWatchHandler(mockWatchRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Message().Return(&tele.Message{Payload: "газпром"})
+	mockWatchRepo.EXPECT().CountSubscriptions(int64(555)).Return(config.WatchMaxPerUser, nil)
+	mockContext.EXPECT().Send(gomock.Any(), keyboard.GetStartKeyboard()).Return(nil)
+
+	assert.NoError(t, handler.Handle(mockContext))
+}
+
+func TestWatchHandler_ListAndDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWatchRepo := mock_repository.NewMockWatchRepositoryInterface(ctrl)
+	mockContext := mock_telebot.NewMockContext(ctrl)
+	handler := handlers.NewWatchHandler(mockWatchRepo)
+
+	user := &repository.User{ID: &[]int{1}[0], ChatID: 555}
+
+	mockContext.EXPECT().Get("user").Return(user)
+	mockContext.EXPECT().Callback().Return(&tele.Callback{Data: "watch_del_3"}).AnyTimes()
+	mockWatchRepo.EXPECT().DeleteSubscription(int64(555), int64(3)).Return(true, nil)
+	mockContext.EXPECT().Respond(&tele.CallbackResponse{Text: "Подписка удалена"}).Return(nil)
+	mockWatchRepo.EXPECT().ListSubscriptions(int64(555)).Return([]repository.WatchSubscription{
+		{ID: 4, ChatID: 555, Pattern: "нефть"},
+	}, nil)
+	mockContext.EXPECT().Edit(gomock.Any(), gomock.Any()).DoAndReturn(func(what interface{}, opts ...interface{}) error {
+		markup := opts[0].(*tele.ReplyMarkup)
+		assert.Len(t, markup.InlineKeyboard, 1)
+		assert.Equal(t, "❌ «нефть»", markup.InlineKeyboard[0][0].Text)
+		assert.Equal(t, "watch_del_4", markup.InlineKeyboard[0][0].Data)
+		return nil
+	})
+
+	assert.NoError(t, handler.HandleCallback(mockContext))
+}
@@ -1,6 +1,10 @@
 package keyboard
 
-import tele "gopkg.in/telebot.v4"
+import (
+	"fmt"
+
+	tele "gopkg.in/telebot.v4"
+)
 
 var (
 	WeatherBtn    = tele.Btn{Text: "Погода"}
@@ -17,6 +21,8 @@ var (
 	CancelCityBtn = tele.Btn{Text: "Отмена"}
 
 	ChangePrimeChannelBtn = tele.Btn{Text: "Изменить канал"}
+	CategoriesBtn         = tele.Btn{Text: "Рубрики"}
+	FindStorylineBtn      = tele.Btn{Text: "Найти сюжет"}
 
 	Time8Btn  = tele.Btn{Text: "08:00"}
 	Time9Btn  = tele.Btn{Text: "09:00"}
@@ -35,8 +41,9 @@ func GetStartKeyboard() *tele.ReplyMarkup {
 
 	keyboard.Reply(
 		tele.Row{WeatherBtn, RateBtn, NewsBtn},
+		tele.Row{FindStorylineBtn},
 		tele.Row{ChangePrimeChannelBtn, ChangeCityBtn},
-		tele.Row{ChangeTimeBtn},
+		tele.Row{ChangeTimeBtn, CategoriesBtn},
 		tele.Row{AboutBtn, ContactBtn},
 	)
 
@@ -68,3 +75,19 @@ func GetTimeSelectionKeyboard() *tele.ReplyMarkup {
 
 	return keyboard
 }
+
+// GetFeedbackKeyboard - оценка дайджеста под последней частью новостей.
+// Callback data: feedback_{up|down|error}_{summaryID}.
+func GetFeedbackKeyboard(summaryID int64) *tele.ReplyMarkup {
+	keyboard := &tele.ReplyMarkup{}
+
+	keyboard.Inline(
+		tele.Row{
+			tele.Btn{Text: "👍", Data: fmt.Sprintf("feedback_up_%d", summaryID)},
+			tele.Btn{Text: "👎", Data: fmt.Sprintf("feedback_down_%d", summaryID)},
+			tele.Btn{Text: "Ошибка в сюжете", Data: fmt.Sprintf("feedback_error_%d", summaryID)},
+		},
+	)
+
+	return keyboard
+}
@@ -30,14 +30,22 @@ func loadEnv() {
 }
 
 type Repositories struct {
-	UserRepository      repository.UserRepositoryInterface
-	RateRepository      repository.RateRepositoryInterface
-	SummaryRepository   repository.SummaryRepositoryInterface
-	StorylineRepository repository.StorylineRepositoryInterface
-	MessageRepository   repository.MessageRepositoryInterface
-	MLRepository        repository.MLRepositoryInterface
-	WeatherRepository   repository.WeatherRepositoryInterface
-	StateStorage        *handlers.StateStorage
+	UserRepository                repository.UserRepositoryInterface
+	RateRepository                repository.RateRepositoryInterface
+	SummaryRepository             repository.SummaryRepositoryInterface
+	StorylineRepository           repository.StorylineRepositoryInterface
+	TrendRepository               repository.TrendRepositoryInterface
+	PeriodSummaryRepository       repository.PeriodSummaryRepositoryInterface
+	MessageRepository             repository.MessageRepositoryInterface
+	MLRepository                  repository.MLRepositoryInterface
+	MLUsageRepository             repository.MLUsageRepositoryInterface
+	FeedbackRepository            repository.FeedbackRepositoryInterface
+	CategoryPreferenceRepository  repository.CategoryPreferenceRepositoryInterface
+	PersonalizedSummaryRepository repository.PersonalizedSummaryRepositoryInterface
+	WatchRepository               repository.WatchRepositoryInterface
+	SearchRepository              repository.SearchRepositoryInterface
+	WeatherRepository             repository.WeatherRepositoryInterface
+	StateStorage                  *handlers.StateStorage
 }
 
 func NewRepositories(db *sql.DB) *Repositories {
@@ -45,15 +53,27 @@ func NewRepositories(db *sql.DB) *Repositories {
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Failed to initialize ML repository"))
 	}
+	if err := mlRepo.LoadPrompts(repository.NewPromptRepository(db)); err != nil {
+		log.Fatal(errors.Wrap(err, "Failed to load prompts"))
+	}
+	mlUsageRepo := repository.NewMLUsageRepository(db)
 	return &Repositories{
-		UserRepository:      repository.NewUserRepository(db),
-		RateRepository:      repository.NewRateRepository(db),
-		SummaryRepository:   repository.NewSummaryRepository(db),
-		StorylineRepository: repository.NewStorylineRepository(db),
-		MessageRepository:   repository.NewMessageRepository(db),
-		MLRepository:        mlRepo,
-		WeatherRepository:   repository.NewWeatherRepository(),
-		StateStorage:        handlers.NewStateStorage(),
+		UserRepository:                repository.NewUserRepository(db),
+		RateRepository:                repository.NewRateRepository(db),
+		SummaryRepository:             repository.NewSummaryRepository(db),
+		StorylineRepository:           repository.NewStorylineRepository(db),
+		TrendRepository:               repository.NewTrendRepository(db),
+		PeriodSummaryRepository:       repository.NewPeriodSummaryRepository(db),
+		MessageRepository:             repository.NewMessageRepository(db),
+		MLRepository:                  mlRepo.WithUsage(mlUsageRepo).WithEmbeddingCache(repository.NewEmbeddingCacheRepository(db)),
+		MLUsageRepository:             mlUsageRepo,
+		FeedbackRepository:            repository.NewFeedbackRepository(db),
+		CategoryPreferenceRepository:  repository.NewCategoryPreferenceRepository(db),
+		PersonalizedSummaryRepository: repository.NewPersonalizedSummaryRepository(db),
+		WatchRepository:               repository.NewWatchRepository(db),
+		SearchRepository:              repository.NewSearchRepository(db),
+		WeatherRepository:             repository.NewWeatherRepository(),
+		StateStorage:                  handlers.NewStateStorage(),
 	}
 }
 
@@ -84,45 +104,106 @@ func main() {
 	rateService.StartRateFetcher()
 
 	ctx := context.Background()
-	messageService, err := service.InitAndStartMessageService(ctx, db)
+	watchService := service.NewWatchService(repositories.WatchRepository, bot)
+	sessions, err := service.NewSessionStorage(ctx, db)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Failed to initialize Telegram session storage"))
+	}
+	messageService, err := service.InitAndStartMessageService(ctx, db, sessions, watchService)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Failed to initialize message service"))
 	}
+	telegramLogin := service.NewTelegramLogin(sessions, messageService.SessionRestored)
 
-	adminHandler := adminhandlers.NewAdminHandler(repositories.UserRepository, repositories.SummaryRepository)
+	storylineEditor := service.NewStorylineEditor(repositories.StorylineRepository, repositories.MLRepository)
+	adminHandler := adminhandlers.NewAdminHandler(
+		repositories.UserRepository,
+		repositories.SummaryRepository,
+		repositories.StorylineRepository,
+		storylineEditor,
+		repositories.MLUsageRepository,
+		repositories.FeedbackRepository,
+		messageService,
+		telegramLogin,
+		repositories.StateStorage,
+	)
 
 	storylineProcessor := service.NewStorylineProcessor(repositories.SummaryRepository, repositories.StorylineRepository, repositories.MLRepository)
 	summaryService := service.NewSummaryService(repositories.SummaryRepository, repositories.StorylineRepository, storylineProcessor, messageService.MessagesFetched, adminHandler.ForceRegenerateChannel)
 	summaryService.StartSummaryFetcher(ctx)
 
+	storylineIndexMonitor := service.NewStorylineIndexMonitor(repositories.StorylineRepository)
+	storylineIndexMonitor.StartRecallChecker()
+
+	trendService := service.NewTrendService(repositories.TrendRepository)
+	digestPersonalizer := service.NewDigestPersonalizer(
+		repositories.SummaryRepository,
+		repositories.CategoryPreferenceRepository,
+		repositories.PersonalizedSummaryRepository,
+		repositories.MLRepository,
+	)
 	mailingService := service.NewMailingService(
 		repositories.UserRepository,
 		repositories.RateRepository,
 		repositories.SummaryRepository,
 		repositories.WeatherRepository,
+		trendService,
+		digestPersonalizer,
 		bot,
 	)
 	mailingService.StartMailingService(ctx)
 
 	bot.Use(middleware.MessageLogger())
 	bot.Use(middleware.CreateOrUpdateUser(repositories.UserRepository))
-	addHandlers(bot, repositories, adminHandler)
+	periodDigestService := service.NewPeriodDigestService(repositories.PeriodSummaryRepository, repositories.MLRepository)
+	addHandlers(bot, repositories, adminHandler, trendService, periodDigestService, digestPersonalizer)
 	bot.Start()
 }
 
-func addHandlers(bot *tele.Bot, repositories *Repositories, adminHandler *adminhandlers.AdminHandler) {
+func addHandlers(bot *tele.Bot, repositories *Repositories, adminHandler *adminhandlers.AdminHandler, trendService *service.TrendService, periodDigestService *service.PeriodDigestService, digestPersonalizer *service.DigestPersonalizer) {
 	// Start command
 	bot.Handle("/start", handlers.HelloHandle)
 
 	// Admin command
 	bot.Handle("/admin", adminHandler.Handle)
+	bot.Handle("/backfill", adminHandler.HandleBackfill)
 
 	// Initialize handlers
 	changeCityHandler := handlers.NewChangeCityHandler(repositories.UserRepository, repositories.WeatherRepository, repositories.StateStorage)
 	rateHandler := handlers.NewRateHandler(repositories.RateRepository)
-	newsHandler := handlers.NewNewsHandler(repositories.SummaryRepository)
+	newsHandler := handlers.NewNewsHandler(repositories.SummaryRepository, digestPersonalizer)
 	changePrimeChannelHandler := handlers.NewChangePrimeChannelHandler(repositories.UserRepository)
 	changeTimeHandler := handlers.NewChangeTimeHandler(repositories.UserRepository, repositories.StateStorage)
+	trendsHandler := handlers.NewTrendsHandler(trendService)
+	periodDigestHandler := handlers.NewPeriodDigestHandler(periodDigestService)
+	feedbackHandler := handlers.NewFeedbackHandler(repositories.FeedbackRepository)
+	categoriesHandler := handlers.NewCategoriesHandler(repositories.CategoryPreferenceRepository)
+	watchHandler := handlers.NewWatchHandler(repositories.WatchRepository)
+	searchHandler := handlers.NewSearchHandler(service.NewSearchService(repositories.SearchRepository))
+	askHandler := handlers.NewAskHandler(service.NewAskService(
+		repositories.MLRepository,
+		repositories.StorylineRepository,
+		repositories.MessageRepository,
+		repositories.SearchRepository,
+	))
+	findStorylineHandler := handlers.NewFindStorylineHandler(
+		service.NewStorylineSearchService(repositories.MLRepository, repositories.StorylineRepository, repositories.MessageRepository),
+		repositories.StateStorage,
+	)
+
+	// Weekly trends and period digest commands
+	bot.Handle("/trends", trendsHandler.Handle)
+	bot.Handle("/week", periodDigestHandler.HandleWeek)
+	bot.Handle("/month", periodDigestHandler.HandleMonth)
+
+	// Keyword watch subscriptions
+	bot.Handle("/watch", watchHandler.Handle)
+
+	// Full-text search over archived messages
+	bot.Handle("/search", searchHandler.Handle)
+
+	// Questions over the news archive
+	bot.Handle("/ask", askHandler.Handle)
 
 	// Button handlers
 	bot.Handle(&keyboard.WeatherBtn, handlers.WeatherHandle)
@@ -133,6 +214,8 @@ func addHandlers(bot *tele.Bot, repositories *Repositories, adminHandler *adminh
 	bot.Handle(&keyboard.AboutBtn, handlers.AboutHandle)
 	bot.Handle(&keyboard.ContactBtn, handlers.ContactHandle)
 	bot.Handle(&keyboard.ChangePrimeChannelBtn, changePrimeChannelHandler.Handle)
+	bot.Handle(&keyboard.CategoriesBtn, categoriesHandler.Handle)
+	bot.Handle(&keyboard.FindStorylineBtn, findStorylineHandler.Handle)
 
 	// Handle callback queries
 	bot.Handle(tele.OnCallback, func(c tele.Context) error {
@@ -148,6 +231,50 @@ func addHandlers(bot *tele.Bot, repositories *Repositories, adminHandler *adminh
 			return adminHandler.HandleRegenerateSummaryChannel(c)
 		}
 
+		if c.Callback().Data == "admin_storylines" {
+			return adminHandler.HandleStorylines(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "admin_storylines_list_") {
+			return adminHandler.HandleStorylineList(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "admin_storyline_") {
+			return adminHandler.HandleStorylineAction(c)
+		}
+
+		if c.Callback().Data == "admin_ml_usage" {
+			return adminHandler.HandleMLUsage(c)
+		}
+
+		if c.Callback().Data == "admin_feedback" {
+			return adminHandler.HandleFeedback(c)
+		}
+
+		if c.Callback().Data == "admin_telegram_login" {
+			return adminHandler.HandleTelegramLogin(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "feedback_") {
+			return feedbackHandler.HandleCallback(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "category_pref_") {
+			return categoriesHandler.HandleCallback(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "watch_del_") {
+			return watchHandler.HandleCallback(c)
+		}
+
+		if strings.HasPrefix(c.Callback().Data, "search_page_") {
+			return searchHandler.HandleCallback(c)
+		}
+
+		if c.Callback().Data == "storyline_search_cancel" || strings.HasPrefix(c.Callback().Data, "storyline_timeline_") {
+			return findStorylineHandler.HandleCallback(c)
+		}
+
 		return nil
 	})
 
@@ -170,6 +297,15 @@ func addHandlers(bot *tele.Bot, repositories *Repositories, adminHandler *adminh
 		if state.ChangingTime {
 			return changeTimeHandler.HandleTimeInput(c)
 		}
+		if state.SearchingStoryline {
+			return findStorylineHandler.HandleQueryInput(c)
+		}
+		if adminhandlers.IsTelegramLoginAction(state.AdminAction) {
+			return adminHandler.HandleLoginInput(c)
+		}
+		if state.AdminAction != "" {
+			return adminHandler.HandleStorylineInput(c)
+		}
 
 		return nil
 	})
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: category_preference.go
+//
+// Generated by this command:
+//
+//	mockgen -source=category_preference.go -destination=../mocks/repository/category_preference_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCategoryPreferenceRepositoryInterface is a mock of CategoryPreferenceRepositoryInterface interface.
+type MockCategoryPreferenceRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryPreferenceRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCategoryPreferenceRepositoryInterfaceMockRecorder is the mock recorder for MockCategoryPreferenceRepositoryInterface.
+type MockCategoryPreferenceRepositoryInterfaceMockRecorder struct {
+	mock *MockCategoryPreferenceRepositoryInterface
+}
+
+// NewMockCategoryPreferenceRepositoryInterface creates a new mock instance.
+func NewMockCategoryPreferenceRepositoryInterface(ctrl *gomock.Controller) *MockCategoryPreferenceRepositoryInterface {
+	mock := &MockCategoryPreferenceRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockCategoryPreferenceRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategoryPreferenceRepositoryInterface) EXPECT() *MockCategoryPreferenceRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetPreferences mocks base method.
+func (m *MockCategoryPreferenceRepositoryInterface) GetPreferences(chatID int64) (*repository.CategoryPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferences", chatID)
+	ret0, _ := ret[0].(*repository.CategoryPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferences indicates an expected call of GetPreferences.
+func (mr *MockCategoryPreferenceRepositoryInterfaceMockRecorder) GetPreferences(chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferences", reflect.TypeOf((*MockCategoryPreferenceRepositoryInterface)(nil).GetPreferences), chatID)
+}
+
+// SetPreference mocks base method.
+func (m *MockCategoryPreferenceRepositoryInterface) SetPreference(chatID int64, category, preference string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreference", chatID, category, preference)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPreference indicates an expected call of SetPreference.
+func (mr *MockCategoryPreferenceRepositoryInterfaceMockRecorder) SetPreference(chatID, category, preference any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreference", reflect.TypeOf((*MockCategoryPreferenceRepositoryInterface)(nil).SetPreference), chatID, category, preference)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: embedding_cache.go
+//
+// Generated by this command:
+//
+//	mockgen -source=embedding_cache.go -destination=../mocks/repository/embedding_cache_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmbeddingCacheRepositoryInterface is a mock of EmbeddingCacheRepositoryInterface interface.
+type MockEmbeddingCacheRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmbeddingCacheRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockEmbeddingCacheRepositoryInterfaceMockRecorder is the mock recorder for MockEmbeddingCacheRepositoryInterface.
+type MockEmbeddingCacheRepositoryInterfaceMockRecorder struct {
+	mock *MockEmbeddingCacheRepositoryInterface
+}
+
+// NewMockEmbeddingCacheRepositoryInterface creates a new mock instance.
+func NewMockEmbeddingCacheRepositoryInterface(ctrl *gomock.Controller) *MockEmbeddingCacheRepositoryInterface {
+	mock := &MockEmbeddingCacheRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockEmbeddingCacheRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmbeddingCacheRepositoryInterface) EXPECT() *MockEmbeddingCacheRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetEmbeddings mocks base method.
+func (m *MockEmbeddingCacheRepositoryInterface) GetEmbeddings(model string, hashes []string) (map[string][]float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmbeddings", model, hashes)
+	ret0, _ := ret[0].(map[string][]float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmbeddings indicates an expected call of GetEmbeddings.
+func (mr *MockEmbeddingCacheRepositoryInterfaceMockRecorder) GetEmbeddings(model, hashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddings", reflect.TypeOf((*MockEmbeddingCacheRepositoryInterface)(nil).GetEmbeddings), model, hashes)
+}
+
+// SaveEmbeddings mocks base method.
+func (m *MockEmbeddingCacheRepositoryInterface) SaveEmbeddings(model string, embeddings map[string][]float32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEmbeddings", model, embeddings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEmbeddings indicates an expected call of SaveEmbeddings.
+func (mr *MockEmbeddingCacheRepositoryInterfaceMockRecorder) SaveEmbeddings(model, embeddings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEmbeddings", reflect.TypeOf((*MockEmbeddingCacheRepositoryInterface)(nil).SaveEmbeddings), model, embeddings)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: feedback.go
+//
+// Generated by this command:
+//
+//	mockgen -source=feedback.go -destination=../mocks/repository/feedback_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFeedbackRepositoryInterface is a mock of FeedbackRepositoryInterface interface.
+type MockFeedbackRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedbackRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockFeedbackRepositoryInterfaceMockRecorder is the mock recorder for MockFeedbackRepositoryInterface.
+type MockFeedbackRepositoryInterfaceMockRecorder struct {
+	mock *MockFeedbackRepositoryInterface
+}
+
+// NewMockFeedbackRepositoryInterface creates a new mock instance.
+func NewMockFeedbackRepositoryInterface(ctrl *gomock.Controller) *MockFeedbackRepositoryInterface {
+	mock := &MockFeedbackRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockFeedbackRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedbackRepositoryInterface) EXPECT() *MockFeedbackRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetSummaryStorylines mocks base method.
+func (m *MockFeedbackRepositoryInterface) GetSummaryStorylines(summaryID int64, limit int) ([]repository.Storyline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummaryStorylines", summaryID, limit)
+	ret0, _ := ret[0].([]repository.Storyline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummaryStorylines indicates an expected call of GetSummaryStorylines.
+func (mr *MockFeedbackRepositoryInterfaceMockRecorder) GetSummaryStorylines(summaryID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummaryStorylines", reflect.TypeOf((*MockFeedbackRepositoryInterface)(nil).GetSummaryStorylines), summaryID, limit)
+}
+
+// SaveFeedback mocks base method.
+func (m *MockFeedbackRepositoryInterface) SaveFeedback(f *repository.SummaryFeedback) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFeedback", f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFeedback indicates an expected call of SaveFeedback.
+func (mr *MockFeedbackRepositoryInterfaceMockRecorder) SaveFeedback(f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFeedback", reflect.TypeOf((*MockFeedbackRepositoryInterface)(nil).SaveFeedback), f)
+}
+
+// ScoresByChannelAndPrompt mocks base method.
+func (m *MockFeedbackRepositoryInterface) ScoresByChannelAndPrompt(since time.Time) ([]repository.FeedbackScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScoresByChannelAndPrompt", since)
+	ret0, _ := ret[0].([]repository.FeedbackScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScoresByChannelAndPrompt indicates an expected call of ScoresByChannelAndPrompt.
+func (mr *MockFeedbackRepositoryInterfaceMockRecorder) ScoresByChannelAndPrompt(since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScoresByChannelAndPrompt", reflect.TypeOf((*MockFeedbackRepositoryInterface)(nil).ScoresByChannelAndPrompt), since)
+}
+
+// TopErrorStorylines mocks base method.
+func (m *MockFeedbackRepositoryInterface) TopErrorStorylines(since time.Time, limit int) ([]repository.StorylineErrorReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopErrorStorylines", since, limit)
+	ret0, _ := ret[0].([]repository.StorylineErrorReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopErrorStorylines indicates an expected call of TopErrorStorylines.
+func (mr *MockFeedbackRepositoryInterfaceMockRecorder) TopErrorStorylines(since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopErrorStorylines", reflect.TypeOf((*MockFeedbackRepositoryInterface)(nil).TopErrorStorylines), since, limit)
+}
@@ -0,0 +1,159 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message.go -destination=../mocks/repository/message_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageRepositoryInterface is a mock of MessageRepositoryInterface interface.
+type MockMessageRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageRepositoryInterfaceMockRecorder is the mock recorder for MockMessageRepositoryInterface.
+type MockMessageRepositoryInterfaceMockRecorder struct {
+	mock *MockMessageRepositoryInterface
+}
+
+// NewMockMessageRepositoryInterface creates a new mock instance.
+func NewMockMessageRepositoryInterface(ctrl *gomock.Controller) *MockMessageRepositoryInterface {
+	mock := &MockMessageRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockMessageRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageRepositoryInterface) EXPECT() *MockMessageRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetChannelCursor mocks base method.
+func (m *MockMessageRepositoryInterface) GetChannelCursor(channelID int64, fallback repository.CursorFallback) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannelCursor", channelID, fallback)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChannelCursor indicates an expected call of GetChannelCursor.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) GetChannelCursor(channelID, fallback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannelCursor", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetChannelCursor), channelID, fallback)
+}
+
+// GetMessageIDsSince mocks base method.
+func (m *MockMessageRepositoryInterface) GetMessageIDsSince(channelID int64, since time.Time) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageIDsSince", channelID, since)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageIDsSince indicates an expected call of GetMessageIDsSince.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) GetMessageIDsSince(channelID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageIDsSince", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetMessageIDsSince), channelID, since)
+}
+
+// GetMessagesByIDs mocks base method.
+func (m *MockMessageRepositoryInterface) GetMessagesByIDs(channelID int64, messageIDs []int64) ([]repository.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesByIDs", channelID, messageIDs)
+	ret0, _ := ret[0].([]repository.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessagesByIDs indicates an expected call of GetMessagesByIDs.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) GetMessagesByIDs(channelID, messageIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesByIDs", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetMessagesByIDs), channelID, messageIDs)
+}
+
+// MarkMessagesDeleted mocks base method.
+func (m *MockMessageRepositoryInterface) MarkMessagesDeleted(channelID int64, messageIDs []int64, deletedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMessagesDeleted", channelID, messageIDs, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkMessagesDeleted indicates an expected call of MarkMessagesDeleted.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) MarkMessagesDeleted(channelID, messageIDs, deletedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessagesDeleted", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).MarkMessagesDeleted), channelID, messageIDs, deletedAt)
+}
+
+// SaveChannelCursor mocks base method.
+func (m *MockMessageRepositoryInterface) SaveChannelCursor(channelID int64, lastMessageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChannelCursor", channelID, lastMessageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChannelCursor indicates an expected call of SaveChannelCursor.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) SaveChannelCursor(channelID, lastMessageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChannelCursor", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).SaveChannelCursor), channelID, lastMessageID)
+}
+
+// SaveMessage mocks base method.
+func (m *MockMessageRepositoryInterface) SaveMessage(message *repository.Message) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMessage", message)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveMessage indicates an expected call of SaveMessage.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) SaveMessage(message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMessage", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).SaveMessage), message)
+}
+
+// UpdateEditedMessage mocks base method.
+func (m *MockMessageRepositoryInterface) UpdateEditedMessage(message *repository.Message) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEditedMessage", message)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateEditedMessage indicates an expected call of UpdateEditedMessage.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateEditedMessage(message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEditedMessage", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateEditedMessage), message)
+}
+
+// UpdateMessageMetrics mocks base method.
+func (m *MockMessageRepositoryInterface) UpdateMessageMetrics(channelID int64, messages []*repository.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessageMetrics", channelID, messages)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMessageMetrics indicates an expected call of UpdateMessageMetrics.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateMessageMetrics(channelID, messages any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageMetrics", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateMessageMetrics), channelID, messages)
+}
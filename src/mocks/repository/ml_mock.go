@@ -10,8 +10,8 @@
 package mock_repository
 
 import (
-	context "context"
 	reflect "reflect"
+	time "time"
 
 	repository "github.com/Ra1ze505/goNewsBot/src/repository"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +41,21 @@ func (m *MockMLRepositoryInterface) EXPECT() *MockMLRepositoryInterfaceMockRecor
 	return m.recorder
 }
 
+// AnswerQuestion mocks base method.
+func (m *MockMLRepositoryInterface) AnswerQuestion(in repository.QuestionInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnswerQuestion", in)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnswerQuestion indicates an expected call of AnswerQuestion.
+func (mr *MockMLRepositoryInterfaceMockRecorder) AnswerQuestion(in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnswerQuestion", reflect.TypeOf((*MockMLRepositoryInterface)(nil).AnswerQuestion), in)
+}
+
 // ConfirmMatch mocks base method.
 func (m *MockMLRepositoryInterface) ConfirmMatch(cand repository.CandidateTopic, options []repository.StorylineBrief) (int64, bool, error) {
 	m.ctrl.T.Helper()
@@ -102,6 +117,49 @@ func (mr *MockMLRepositoryInterfaceMockRecorder) ExtractTopics(messages any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractTopics", reflect.TypeOf((*MockMLRepositoryInterface)(nil).ExtractTopics), messages)
 }
 
+// ForDay mocks base method.
+func (m *MockMLRepositoryInterface) ForDay(channelID int64, date time.Time) repository.MLRepositoryInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForDay", channelID, date)
+	ret0, _ := ret[0].(repository.MLRepositoryInterface)
+	return ret0
+}
+
+// ForDay indicates an expected call of ForDay.
+func (mr *MockMLRepositoryInterfaceMockRecorder) ForDay(channelID, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForDay", reflect.TypeOf((*MockMLRepositoryInterface)(nil).ForDay), channelID, date)
+}
+
+// JudgeDigest mocks base method.
+func (m *MockMLRepositoryInterface) JudgeDigest(in repository.DigestJudgeInput) (repository.DigestJudgement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JudgeDigest", in)
+	ret0, _ := ret[0].(repository.DigestJudgement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JudgeDigest indicates an expected call of JudgeDigest.
+func (mr *MockMLRepositoryInterfaceMockRecorder) JudgeDigest(in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JudgeDigest", reflect.TypeOf((*MockMLRepositoryInterface)(nil).JudgeDigest), in)
+}
+
+// PromptVersion mocks base method.
+func (m *MockMLRepositoryInterface) PromptVersion() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PromptVersion")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// PromptVersion indicates an expected call of PromptVersion.
+func (mr *MockMLRepositoryInterfaceMockRecorder) PromptVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PromptVersion", reflect.TypeOf((*MockMLRepositoryInterface)(nil).PromptVersion))
+}
+
 // RenderDigest mocks base method.
 func (m *MockMLRepositoryInterface) RenderDigest(groups repository.DigestGroups) (string, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +175,21 @@ func (mr *MockMLRepositoryInterfaceMockRecorder) RenderDigest(groups any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderDigest", reflect.TypeOf((*MockMLRepositoryInterface)(nil).RenderDigest), groups)
 }
 
+// RenderPeriodDigest mocks base method.
+func (m *MockMLRepositoryInterface) RenderPeriodDigest(in repository.PeriodDigestInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderPeriodDigest", in)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderPeriodDigest indicates an expected call of RenderPeriodDigest.
+func (mr *MockMLRepositoryInterfaceMockRecorder) RenderPeriodDigest(in any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderPeriodDigest", reflect.TypeOf((*MockMLRepositoryInterface)(nil).RenderPeriodDigest), in)
+}
+
 // SummarizeMessages mocks base method.
 func (m *MockMLRepositoryInterface) SummarizeMessages(messages []string) (string, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +205,21 @@ func (mr *MockMLRepositoryInterfaceMockRecorder) SummarizeMessages(messages any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SummarizeMessages", reflect.TypeOf((*MockMLRepositoryInterface)(nil).SummarizeMessages), messages)
 }
 
+// WithPromptVersions mocks base method.
+func (m *MockMLRepositoryInterface) WithPromptVersions(versions map[string]string) (repository.MLRepositoryInterface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithPromptVersions", versions)
+	ret0, _ := ret[0].(repository.MLRepositoryInterface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithPromptVersions indicates an expected call of WithPromptVersions.
+func (mr *MockMLRepositoryInterfaceMockRecorder) WithPromptVersions(versions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPromptVersions", reflect.TypeOf((*MockMLRepositoryInterface)(nil).WithPromptVersions), versions)
+}
+
 // WriteDelta mocks base method.
 func (m *MockMLRepositoryInterface) WriteDelta(in repository.DeltaInput) (string, string, error) {
 	m.ctrl.T.Helper()
@@ -147,42 +235,3 @@ func (mr *MockMLRepositoryInterfaceMockRecorder) WriteDelta(in any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteDelta", reflect.TypeOf((*MockMLRepositoryInterface)(nil).WriteDelta), in)
 }
-
-// MocktokenProvider is a mock of tokenProvider interface.
-type MocktokenProvider struct {
-	ctrl     *gomock.Controller
-	recorder *MocktokenProviderMockRecorder
-	isgomock struct{}
-}
-
-// MocktokenProviderMockRecorder is the mock recorder for MocktokenProvider.
-type MocktokenProviderMockRecorder struct {
-	mock *MocktokenProvider
-}
-
-// NewMocktokenProvider creates a new mock instance.
-func NewMocktokenProvider(ctrl *gomock.Controller) *MocktokenProvider {
-	mock := &MocktokenProvider{ctrl: ctrl}
-	mock.recorder = &MocktokenProviderMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MocktokenProvider) EXPECT() *MocktokenProviderMockRecorder {
-	return m.recorder
-}
-
-// Token mocks base method.
-func (m *MocktokenProvider) Token(ctx context.Context) (string, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Token", ctx)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// Token indicates an expected call of Token.
-func (mr *MocktokenProviderMockRecorder) Token(ctx any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MocktokenProvider)(nil).Token), ctx)
-}
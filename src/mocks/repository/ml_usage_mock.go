@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ml_usage.go
+//
+// Generated by this command:
+//
+//	mockgen -source=ml_usage.go -destination=../mocks/repository/ml_usage_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMLUsageRepositoryInterface is a mock of MLUsageRepositoryInterface interface.
+type MockMLUsageRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMLUsageRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMLUsageRepositoryInterfaceMockRecorder is the mock recorder for MockMLUsageRepositoryInterface.
+type MockMLUsageRepositoryInterfaceMockRecorder struct {
+	mock *MockMLUsageRepositoryInterface
+}
+
+// NewMockMLUsageRepositoryInterface creates a new mock instance.
+func NewMockMLUsageRepositoryInterface(ctrl *gomock.Controller) *MockMLUsageRepositoryInterface {
+	mock := &MockMLUsageRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockMLUsageRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMLUsageRepositoryInterface) EXPECT() *MockMLUsageRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// SaveCall mocks base method.
+func (m *MockMLUsageRepositoryInterface) SaveCall(call *repository.MLCall) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCall", call)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCall indicates an expected call of SaveCall.
+func (mr *MockMLUsageRepositoryInterfaceMockRecorder) SaveCall(call any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCall", reflect.TypeOf((*MockMLUsageRepositoryInterface)(nil).SaveCall), call)
+}
+
+// TotalCost mocks base method.
+func (m *MockMLUsageRepositoryInterface) TotalCost(from, to time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalCost", from, to)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalCost indicates an expected call of TotalCost.
+func (mr *MockMLUsageRepositoryInterfaceMockRecorder) TotalCost(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalCost", reflect.TypeOf((*MockMLUsageRepositoryInterface)(nil).TotalCost), from, to)
+}
+
+// TotalsByChannel mocks base method.
+func (m *MockMLUsageRepositoryInterface) TotalsByChannel(from, to time.Time) ([]repository.MLUsageTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalsByChannel", from, to)
+	ret0, _ := ret[0].([]repository.MLUsageTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalsByChannel indicates an expected call of TotalsByChannel.
+func (mr *MockMLUsageRepositoryInterfaceMockRecorder) TotalsByChannel(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalsByChannel", reflect.TypeOf((*MockMLUsageRepositoryInterface)(nil).TotalsByChannel), from, to)
+}
+
+// TotalsByStage mocks base method.
+func (m *MockMLUsageRepositoryInterface) TotalsByStage(from, to time.Time) ([]repository.MLUsageTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalsByStage", from, to)
+	ret0, _ := ret[0].([]repository.MLUsageTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TotalsByStage indicates an expected call of TotalsByStage.
+func (mr *MockMLUsageRepositoryInterfaceMockRecorder) TotalsByStage(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalsByStage", reflect.TypeOf((*MockMLUsageRepositoryInterface)(nil).TotalsByStage), from, to)
+}
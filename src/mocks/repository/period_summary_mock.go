@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: period_summary.go
+//
+// Generated by this command:
+//
+//	mockgen -source=period_summary.go -destination=../mocks/repository/period_summary_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPeriodSummaryRepositoryInterface is a mock of PeriodSummaryRepositoryInterface interface.
+type MockPeriodSummaryRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPeriodSummaryRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockPeriodSummaryRepositoryInterfaceMockRecorder is the mock recorder for MockPeriodSummaryRepositoryInterface.
+type MockPeriodSummaryRepositoryInterfaceMockRecorder struct {
+	mock *MockPeriodSummaryRepositoryInterface
+}
+
+// NewMockPeriodSummaryRepositoryInterface creates a new mock instance.
+func NewMockPeriodSummaryRepositoryInterface(ctrl *gomock.Controller) *MockPeriodSummaryRepositoryInterface {
+	mock := &MockPeriodSummaryRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockPeriodSummaryRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPeriodSummaryRepositoryInterface) EXPECT() *MockPeriodSummaryRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetPeriodObservations mocks base method.
+func (m *MockPeriodSummaryRepositoryInterface) GetPeriodObservations(channelID int64, from, to time.Time) ([]repository.PeriodObservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeriodObservations", channelID, from, to)
+	ret0, _ := ret[0].([]repository.PeriodObservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeriodObservations indicates an expected call of GetPeriodObservations.
+func (mr *MockPeriodSummaryRepositoryInterfaceMockRecorder) GetPeriodObservations(channelID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeriodObservations", reflect.TypeOf((*MockPeriodSummaryRepositoryInterface)(nil).GetPeriodObservations), channelID, from, to)
+}
+
+// GetPeriodSummary mocks base method.
+func (m *MockPeriodSummaryRepositoryInterface) GetPeriodSummary(channelID int64, period string, start, end time.Time) (*repository.PeriodSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeriodSummary", channelID, period, start, end)
+	ret0, _ := ret[0].(*repository.PeriodSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeriodSummary indicates an expected call of GetPeriodSummary.
+func (mr *MockPeriodSummaryRepositoryInterfaceMockRecorder) GetPeriodSummary(channelID, period, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeriodSummary", reflect.TypeOf((*MockPeriodSummaryRepositoryInterface)(nil).GetPeriodSummary), channelID, period, start, end)
+}
+
+// SavePeriodSummary mocks base method.
+func (m *MockPeriodSummaryRepositoryInterface) SavePeriodSummary(s *repository.PeriodSummary) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePeriodSummary", s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePeriodSummary indicates an expected call of SavePeriodSummary.
+func (mr *MockPeriodSummaryRepositoryInterfaceMockRecorder) SavePeriodSummary(s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePeriodSummary", reflect.TypeOf((*MockPeriodSummaryRepositoryInterface)(nil).SavePeriodSummary), s)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: personalized_summary.go
+//
+// Generated by this command:
+//
+//	mockgen -source=personalized_summary.go -destination=../mocks/repository/personalized_summary_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPersonalizedSummaryRepositoryInterface is a mock of PersonalizedSummaryRepositoryInterface interface.
+type MockPersonalizedSummaryRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPersonalizedSummaryRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockPersonalizedSummaryRepositoryInterfaceMockRecorder is the mock recorder for MockPersonalizedSummaryRepositoryInterface.
+type MockPersonalizedSummaryRepositoryInterfaceMockRecorder struct {
+	mock *MockPersonalizedSummaryRepositoryInterface
+}
+
+// NewMockPersonalizedSummaryRepositoryInterface creates a new mock instance.
+func NewMockPersonalizedSummaryRepositoryInterface(ctrl *gomock.Controller) *MockPersonalizedSummaryRepositoryInterface {
+	mock := &MockPersonalizedSummaryRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockPersonalizedSummaryRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPersonalizedSummaryRepositoryInterface) EXPECT() *MockPersonalizedSummaryRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetVariant mocks base method.
+func (m *MockPersonalizedSummaryRepositoryInterface) GetVariant(summaryID int64, preferenceKey string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariant", summaryID, preferenceKey)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetVariant indicates an expected call of GetVariant.
+func (mr *MockPersonalizedSummaryRepositoryInterfaceMockRecorder) GetVariant(summaryID, preferenceKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariant", reflect.TypeOf((*MockPersonalizedSummaryRepositoryInterface)(nil).GetVariant), summaryID, preferenceKey)
+}
+
+// SaveVariant mocks base method.
+func (m *MockPersonalizedSummaryRepositoryInterface) SaveVariant(summaryID int64, preferenceKey, summary string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveVariant", summaryID, preferenceKey, summary)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveVariant indicates an expected call of SaveVariant.
+func (mr *MockPersonalizedSummaryRepositoryInterfaceMockRecorder) SaveVariant(summaryID, preferenceKey, summary any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVariant", reflect.TypeOf((*MockPersonalizedSummaryRepositoryInterface)(nil).SaveVariant), summaryID, preferenceKey, summary)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: prompt.go
+//
+// Generated by this command:
+//
+//	mockgen -source=prompt.go -destination=../mocks/repository/prompt_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPromptRepositoryInterface is a mock of PromptRepositoryInterface interface.
+type MockPromptRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromptRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockPromptRepositoryInterfaceMockRecorder is the mock recorder for MockPromptRepositoryInterface.
+type MockPromptRepositoryInterfaceMockRecorder struct {
+	mock *MockPromptRepositoryInterface
+}
+
+// NewMockPromptRepositoryInterface creates a new mock instance.
+func NewMockPromptRepositoryInterface(ctrl *gomock.Controller) *MockPromptRepositoryInterface {
+	mock := &MockPromptRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockPromptRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromptRepositoryInterface) EXPECT() *MockPromptRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetPromptVersion mocks base method.
+func (m *MockPromptRepositoryInterface) GetPromptVersion(name, version string) (*repository.PromptVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromptVersion", name, version)
+	ret0, _ := ret[0].(*repository.PromptVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromptVersion indicates an expected call of GetPromptVersion.
+func (mr *MockPromptRepositoryInterfaceMockRecorder) GetPromptVersion(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromptVersion", reflect.TypeOf((*MockPromptRepositoryInterface)(nil).GetPromptVersion), name, version)
+}
+
+// ListPromptVersions mocks base method.
+func (m *MockPromptRepositoryInterface) ListPromptVersions() ([]repository.PromptVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPromptVersions")
+	ret0, _ := ret[0].([]repository.PromptVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPromptVersions indicates an expected call of ListPromptVersions.
+func (mr *MockPromptRepositoryInterfaceMockRecorder) ListPromptVersions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPromptVersions", reflect.TypeOf((*MockPromptRepositoryInterface)(nil).ListPromptVersions))
+}
+
+// SavePromptVersion mocks base method.
+func (m *MockPromptRepositoryInterface) SavePromptVersion(p *repository.PromptVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePromptVersion", p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePromptVersion indicates an expected call of SavePromptVersion.
+func (mr *MockPromptRepositoryInterfaceMockRecorder) SavePromptVersion(p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePromptVersion", reflect.TypeOf((*MockPromptRepositoryInterface)(nil).SavePromptVersion), p)
+}
+
+// SetActivePromptVersion mocks base method.
+func (m *MockPromptRepositoryInterface) SetActivePromptVersion(name, version string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetActivePromptVersion", name, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetActivePromptVersion indicates an expected call of SetActivePromptVersion.
+func (mr *MockPromptRepositoryInterfaceMockRecorder) SetActivePromptVersion(name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetActivePromptVersion", reflect.TypeOf((*MockPromptRepositoryInterface)(nil).SetActivePromptVersion), name, version)
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: search.go
+//
+// Generated by this command:
+//
+//	mockgen -source=search.go -destination=../mocks/repository/search_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSearchRepositoryInterface is a mock of SearchRepositoryInterface interface.
+type MockSearchRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSearchRepositoryInterfaceMockRecorder is the mock recorder for MockSearchRepositoryInterface.
+type MockSearchRepositoryInterfaceMockRecorder struct {
+	mock *MockSearchRepositoryInterface
+}
+
+// NewMockSearchRepositoryInterface creates a new mock instance.
+func NewMockSearchRepositoryInterface(ctrl *gomock.Controller) *MockSearchRepositoryInterface {
+	mock := &MockSearchRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockSearchRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearchRepositoryInterface) EXPECT() *MockSearchRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// SearchMessages mocks base method.
+func (m *MockSearchRepositoryInterface) SearchMessages(q repository.SearchQuery) ([]repository.SearchResult, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMessages", q)
+	ret0, _ := ret[0].([]repository.SearchResult)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchMessages indicates an expected call of SearchMessages.
+func (mr *MockSearchRepositoryInterfaceMockRecorder) SearchMessages(q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMessages", reflect.TypeOf((*MockSearchRepositoryInterface)(nil).SearchMessages), q)
+}
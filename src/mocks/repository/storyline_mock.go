@@ -41,6 +41,36 @@ func (m *MockStorylineRepositoryInterface) EXPECT() *MockStorylineRepositoryInte
 	return m.recorder
 }
 
+// CheckANNRecall mocks base method.
+func (m *MockStorylineRepositoryInterface) CheckANNRecall(sampleSize, k int) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckANNRecall", sampleSize, k)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckANNRecall indicates an expected call of CheckANNRecall.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) CheckANNRecall(sampleSize, k any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckANNRecall", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).CheckANNRecall), sampleSize, k)
+}
+
+// CountObservationsNeedingRegeneration mocks base method.
+func (m *MockStorylineRepositoryInterface) CountObservationsNeedingRegeneration(channelID int64) (map[time.Time]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountObservationsNeedingRegeneration", channelID)
+	ret0, _ := ret[0].(map[time.Time]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountObservationsNeedingRegeneration indicates an expected call of CountObservationsNeedingRegeneration.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) CountObservationsNeedingRegeneration(channelID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountObservationsNeedingRegeneration", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).CountObservationsNeedingRegeneration), channelID)
+}
+
 // CreateStoryline mocks base method.
 func (m *MockStorylineRepositoryInterface) CreateStoryline(s *repository.Storyline) (int64, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +115,21 @@ func (mr *MockStorylineRepositoryInterfaceMockRecorder) GetActive(channelID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).GetActive), channelID)
 }
 
+// GetObservations mocks base method.
+func (m *MockStorylineRepositoryInterface) GetObservations(storylineID int64) ([]repository.Observation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetObservations", storylineID)
+	ret0, _ := ret[0].([]repository.Observation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetObservations indicates an expected call of GetObservations.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) GetObservations(storylineID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetObservations", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).GetObservations), storylineID)
+}
+
 // GetStats mocks base method.
 func (m *MockStorylineRepositoryInterface) GetStats(storylineID int64, before time.Time, windowDays int) (repository.StorylineStats, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +145,36 @@ func (mr *MockStorylineRepositoryInterfaceMockRecorder) GetStats(storylineID, be
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).GetStats), storylineID, before, windowDays)
 }
 
+// GetStoryline mocks base method.
+func (m *MockStorylineRepositoryInterface) GetStoryline(id int64) (*repository.Storyline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStoryline", id)
+	ret0, _ := ret[0].(*repository.Storyline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStoryline indicates an expected call of GetStoryline.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) GetStoryline(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStoryline", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).GetStoryline), id)
+}
+
+// ListStorylines mocks base method.
+func (m *MockStorylineRepositoryInterface) ListStorylines(channelID int64, limit int) ([]repository.Storyline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStorylines", channelID, limit)
+	ret0, _ := ret[0].([]repository.Storyline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStorylines indicates an expected call of ListStorylines.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) ListStorylines(channelID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStorylines", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).ListStorylines), channelID, limit)
+}
+
 // MarkClosed mocks base method.
 func (m *MockStorylineRepositoryInterface) MarkClosed(channelID int64, lastSeenBefore time.Time) error {
 	m.ctrl.T.Helper()
@@ -128,6 +203,34 @@ func (mr *MockStorylineRepositoryInterfaceMockRecorder) MarkDormant(channelID, l
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDormant", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).MarkDormant), channelID, lastSeenBefore)
 }
 
+// MergeStorylines mocks base method.
+func (m *MockStorylineRepositoryInterface) MergeStorylines(target *repository.Storyline, sourceID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeStorylines", target, sourceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeStorylines indicates an expected call of MergeStorylines.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) MergeStorylines(target, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeStorylines", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).MergeStorylines), target, sourceID)
+}
+
+// RenameStoryline mocks base method.
+func (m *MockStorylineRepositoryInterface) RenameStoryline(id int64, title string, embedding []float32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameStoryline", id, title, embedding)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameStoryline indicates an expected call of RenameStoryline.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) RenameStoryline(id, title, embedding any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameStoryline", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).RenameStoryline), id, title, embedding)
+}
+
 // ResetChannel mocks base method.
 func (m *MockStorylineRepositoryInterface) ResetChannel(channelID int64) error {
 	m.ctrl.T.Helper()
@@ -157,18 +260,62 @@ func (mr *MockStorylineRepositoryInterfaceMockRecorder) SaveObservation(o any) *
 }
 
 // SearchNearest mocks base method.
-func (m *MockStorylineRepositoryInterface) SearchNearest(channelID int64, query []float32, k int) ([]repository.ScoredStoryline, error) {
+func (m *MockStorylineRepositoryInterface) SearchNearest(channelID int64, query []float32, k int, closedSince time.Time) ([]repository.ScoredStoryline, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchNearest", channelID, query, k)
+	ret := m.ctrl.Call(m, "SearchNearest", channelID, query, k, closedSince)
 	ret0, _ := ret[0].([]repository.ScoredStoryline)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchNearest indicates an expected call of SearchNearest.
-func (mr *MockStorylineRepositoryInterfaceMockRecorder) SearchNearest(channelID, query, k any) *gomock.Call {
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) SearchNearest(channelID, query, k, closedSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchNearest", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).SearchNearest), channelID, query, k, closedSince)
+}
+
+// SearchNearestAll mocks base method.
+func (m *MockStorylineRepositoryInterface) SearchNearestAll(query []float32, k int) ([]repository.ScoredStoryline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchNearestAll", query, k)
+	ret0, _ := ret[0].([]repository.ScoredStoryline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchNearestAll indicates an expected call of SearchNearestAll.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) SearchNearestAll(query, k any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchNearestAll", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).SearchNearestAll), query, k)
+}
+
+// SplitStoryline mocks base method.
+func (m *MockStorylineRepositoryInterface) SplitStoryline(source *repository.Storyline, dates []time.Time, s *repository.Storyline) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SplitStoryline", source, dates, s)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SplitStoryline indicates an expected call of SplitStoryline.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) SplitStoryline(source, dates, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SplitStoryline", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).SplitStoryline), source, dates, s)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockStorylineRepositoryInterface) UpdateCategory(id int64, category string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", id, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockStorylineRepositoryInterfaceMockRecorder) UpdateCategory(id, category any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchNearest", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).SearchNearest), channelID, query, k)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockStorylineRepositoryInterface)(nil).UpdateCategory), id, category)
 }
 
 // UpdateStoryline mocks base method.
@@ -55,6 +55,21 @@ func (mr *MockSummaryRepositoryInterfaceMockRecorder) DeleteLastSummary(channelI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLastSummary", reflect.TypeOf((*MockSummaryRepositoryInterface)(nil).DeleteLastSummary), channelID)
 }
 
+// GetDigestGroups mocks base method.
+func (m *MockSummaryRepositoryInterface) GetDigestGroups(summaryID int64) (*repository.DigestGroups, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigestGroups", summaryID)
+	ret0, _ := ret[0].(*repository.DigestGroups)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigestGroups indicates an expected call of GetDigestGroups.
+func (mr *MockSummaryRepositoryInterfaceMockRecorder) GetDigestGroups(summaryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigestGroups", reflect.TypeOf((*MockSummaryRepositoryInterface)(nil).GetDigestGroups), summaryID)
+}
+
 // GetLatestSummary mocks base method.
 func (m *MockSummaryRepositoryInterface) GetLatestSummary(channelID int64) (*repository.Summary, error) {
 	m.ctrl.T.Helper()
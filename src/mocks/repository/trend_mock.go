@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: trend.go
+//
+// Generated by this command:
+//
+//	mockgen -source=trend.go -destination=../mocks/repository/trend_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTrendRepositoryInterface is a mock of TrendRepositoryInterface interface.
+type MockTrendRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrendRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTrendRepositoryInterfaceMockRecorder is the mock recorder for MockTrendRepositoryInterface.
+type MockTrendRepositoryInterfaceMockRecorder struct {
+	mock *MockTrendRepositoryInterface
+}
+
+// NewMockTrendRepositoryInterface creates a new mock instance.
+func NewMockTrendRepositoryInterface(ctrl *gomock.Controller) *MockTrendRepositoryInterface {
+	mock := &MockTrendRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockTrendRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrendRepositoryInterface) EXPECT() *MockTrendRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CategoryShares mocks base method.
+func (m *MockTrendRepositoryInterface) CategoryShares(channelID int64, from, to time.Time) ([]repository.CategoryShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CategoryShares", channelID, from, to)
+	ret0, _ := ret[0].([]repository.CategoryShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CategoryShares indicates an expected call of CategoryShares.
+func (mr *MockTrendRepositoryInterfaceMockRecorder) CategoryShares(channelID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CategoryShares", reflect.TypeOf((*MockTrendRepositoryInterface)(nil).CategoryShares), channelID, from, to)
+}
+
+// ClosedStorylines mocks base method.
+func (m *MockTrendRepositoryInterface) ClosedStorylines(channelID int64, lastSeenFrom, lastSeenTo time.Time) ([]repository.StorylineTrend, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClosedStorylines", channelID, lastSeenFrom, lastSeenTo)
+	ret0, _ := ret[0].([]repository.StorylineTrend)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClosedStorylines indicates an expected call of ClosedStorylines.
+func (mr *MockTrendRepositoryInterfaceMockRecorder) ClosedStorylines(channelID, lastSeenFrom, lastSeenTo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClosedStorylines", reflect.TypeOf((*MockTrendRepositoryInterface)(nil).ClosedStorylines), channelID, lastSeenFrom, lastSeenTo)
+}
+
+// TopEscalations mocks base method.
+func (m *MockTrendRepositoryInterface) TopEscalations(channelID int64, from, to time.Time, limit int) ([]repository.StorylineTrend, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopEscalations", channelID, from, to, limit)
+	ret0, _ := ret[0].([]repository.StorylineTrend)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopEscalations indicates an expected call of TopEscalations.
+func (mr *MockTrendRepositoryInterfaceMockRecorder) TopEscalations(channelID, from, to, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopEscalations", reflect.TypeOf((*MockTrendRepositoryInterface)(nil).TopEscalations), channelID, from, to, limit)
+}
+
+// TopStorylines mocks base method.
+func (m *MockTrendRepositoryInterface) TopStorylines(channelID int64, from, to time.Time, limit int) ([]repository.StorylineTrend, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopStorylines", channelID, from, to, limit)
+	ret0, _ := ret[0].([]repository.StorylineTrend)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopStorylines indicates an expected call of TopStorylines.
+func (mr *MockTrendRepositoryInterfaceMockRecorder) TopStorylines(channelID, from, to, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopStorylines", reflect.TypeOf((*MockTrendRepositoryInterface)(nil).TopStorylines), channelID, from, to, limit)
+}
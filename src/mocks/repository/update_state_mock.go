@@ -0,0 +1,216 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: update_state.go
+//
+// Generated by this command:
+//
+//	mockgen -source=update_state.go -destination=../mocks/repository/update_state_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	updates "github.com/gotd/td/telegram/updates"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUpdateStateRepositoryInterface is a mock of UpdateStateRepositoryInterface interface.
+type MockUpdateStateRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdateStateRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockUpdateStateRepositoryInterfaceMockRecorder is the mock recorder for MockUpdateStateRepositoryInterface.
+type MockUpdateStateRepositoryInterfaceMockRecorder struct {
+	mock *MockUpdateStateRepositoryInterface
+}
+
+// NewMockUpdateStateRepositoryInterface creates a new mock instance.
+func NewMockUpdateStateRepositoryInterface(ctrl *gomock.Controller) *MockUpdateStateRepositoryInterface {
+	mock := &MockUpdateStateRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockUpdateStateRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdateStateRepositoryInterface) EXPECT() *MockUpdateStateRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ForEachChannels mocks base method.
+func (m *MockUpdateStateRepositoryInterface) ForEachChannels(ctx context.Context, userID int64, f func(context.Context, int64, int) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachChannels", ctx, userID, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachChannels indicates an expected call of ForEachChannels.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) ForEachChannels(ctx, userID, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachChannels", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).ForEachChannels), ctx, userID, f)
+}
+
+// GetChannelAccessHash mocks base method.
+func (m *MockUpdateStateRepositoryInterface) GetChannelAccessHash(ctx context.Context, userID, channelID int64) (int64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannelAccessHash", ctx, userID, channelID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChannelAccessHash indicates an expected call of GetChannelAccessHash.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) GetChannelAccessHash(ctx, userID, channelID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannelAccessHash", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).GetChannelAccessHash), ctx, userID, channelID)
+}
+
+// GetChannelPts mocks base method.
+func (m *MockUpdateStateRepositoryInterface) GetChannelPts(ctx context.Context, userID, channelID int64) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannelPts", ctx, userID, channelID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChannelPts indicates an expected call of GetChannelPts.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) GetChannelPts(ctx, userID, channelID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannelPts", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).GetChannelPts), ctx, userID, channelID)
+}
+
+// GetState mocks base method.
+func (m *MockUpdateStateRepositoryInterface) GetState(ctx context.Context, userID int64) (updates.State, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetState", ctx, userID)
+	ret0, _ := ret[0].(updates.State)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetState indicates an expected call of GetState.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) GetState(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).GetState), ctx, userID)
+}
+
+// SetChannelAccessHash mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetChannelAccessHash(ctx context.Context, userID, channelID, accessHash int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChannelAccessHash", ctx, userID, channelID, accessHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChannelAccessHash indicates an expected call of SetChannelAccessHash.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetChannelAccessHash(ctx, userID, channelID, accessHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannelAccessHash", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetChannelAccessHash), ctx, userID, channelID, accessHash)
+}
+
+// SetChannelPts mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetChannelPts(ctx context.Context, userID, channelID int64, pts int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChannelPts", ctx, userID, channelID, pts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChannelPts indicates an expected call of SetChannelPts.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetChannelPts(ctx, userID, channelID, pts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannelPts", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetChannelPts), ctx, userID, channelID, pts)
+}
+
+// SetDate mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetDate(ctx context.Context, userID int64, date int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDate", ctx, userID, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDate indicates an expected call of SetDate.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetDate(ctx, userID, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDate", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetDate), ctx, userID, date)
+}
+
+// SetDateSeq mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetDateSeq(ctx context.Context, userID int64, date, seq int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDateSeq", ctx, userID, date, seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDateSeq indicates an expected call of SetDateSeq.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetDateSeq(ctx, userID, date, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDateSeq", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetDateSeq), ctx, userID, date, seq)
+}
+
+// SetPts mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetPts(ctx context.Context, userID int64, pts int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPts", ctx, userID, pts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPts indicates an expected call of SetPts.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetPts(ctx, userID, pts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPts", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetPts), ctx, userID, pts)
+}
+
+// SetQts mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetQts(ctx context.Context, userID int64, qts int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQts", ctx, userID, qts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetQts indicates an expected call of SetQts.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetQts(ctx, userID, qts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQts", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetQts), ctx, userID, qts)
+}
+
+// SetSeq mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetSeq(ctx context.Context, userID int64, seq int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSeq", ctx, userID, seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSeq indicates an expected call of SetSeq.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetSeq(ctx, userID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSeq", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetSeq), ctx, userID, seq)
+}
+
+// SetState mocks base method.
+func (m *MockUpdateStateRepositoryInterface) SetState(ctx context.Context, userID int64, state updates.State) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetState", ctx, userID, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetState indicates an expected call of SetState.
+func (mr *MockUpdateStateRepositoryInterfaceMockRecorder) SetState(ctx, userID, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetState", reflect.TypeOf((*MockUpdateStateRepositoryInterface)(nil).SetState), ctx, userID, state)
+}
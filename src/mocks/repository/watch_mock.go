@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: watch.go
+//
+// Generated by this command:
+//
+//	mockgen -source=watch.go -destination=../mocks/repository/watch_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+
+	repository "github.com/Ra1ze505/goNewsBot/src/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWatchRepositoryInterface is a mock of WatchRepositoryInterface interface.
+type MockWatchRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockWatchRepositoryInterfaceMockRecorder is the mock recorder for MockWatchRepositoryInterface.
+type MockWatchRepositoryInterfaceMockRecorder struct {
+	mock *MockWatchRepositoryInterface
+}
+
+// NewMockWatchRepositoryInterface creates a new mock instance.
+func NewMockWatchRepositoryInterface(ctrl *gomock.Controller) *MockWatchRepositoryInterface {
+	mock := &MockWatchRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockWatchRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchRepositoryInterface) EXPECT() *MockWatchRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddSubscription mocks base method.
+func (m *MockWatchRepositoryInterface) AddSubscription(s *repository.WatchSubscription) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSubscription", s)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSubscription indicates an expected call of AddSubscription.
+func (mr *MockWatchRepositoryInterfaceMockRecorder) AddSubscription(s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSubscription", reflect.TypeOf((*MockWatchRepositoryInterface)(nil).AddSubscription), s)
+}
+
+// CountSubscriptions mocks base method.
+func (m *MockWatchRepositoryInterface) CountSubscriptions(chatID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountSubscriptions", chatID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountSubscriptions indicates an expected call of CountSubscriptions.
+func (mr *MockWatchRepositoryInterfaceMockRecorder) CountSubscriptions(chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountSubscriptions", reflect.TypeOf((*MockWatchRepositoryInterface)(nil).CountSubscriptions), chatID)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockWatchRepositoryInterface) DeleteSubscription(chatID, id int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", chatID, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockWatchRepositoryInterfaceMockRecorder) DeleteSubscription(chatID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockWatchRepositoryInterface)(nil).DeleteSubscription), chatID, id)
+}
+
+// GetAllSubscriptions mocks base method.
+func (m *MockWatchRepositoryInterface) GetAllSubscriptions() ([]repository.WatchSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllSubscriptions")
+	ret0, _ := ret[0].([]repository.WatchSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSubscriptions indicates an expected call of GetAllSubscriptions.
+func (mr *MockWatchRepositoryInterfaceMockRecorder) GetAllSubscriptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSubscriptions", reflect.TypeOf((*MockWatchRepositoryInterface)(nil).GetAllSubscriptions))
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockWatchRepositoryInterface) ListSubscriptions(chatID int64) ([]repository.WatchSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", chatID)
+	ret0, _ := ret[0].([]repository.WatchSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockWatchRepositoryInterfaceMockRecorder) ListSubscriptions(chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockWatchRepositoryInterface)(nil).ListSubscriptions), chatID)
+}
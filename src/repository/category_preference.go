@@ -0,0 +1,107 @@
+package repository
+
+//go:generate mockgen -source=category_preference.go -destination=../mocks/repository/category_preference_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Настройки рубрик в user_category_preferences.preference.
+const (
+	PreferenceMute  = "mute"  // сюжеты рубрики не попадают в дайджест
+	PreferenceBoost = "boost" // сюжеты рубрики идут первыми в своей группе
+)
+
+// CategoryPreferences - настройки рубрик читателя, списки отсортированы.
+type CategoryPreferences struct {
+	Muted   []string
+	Boosted []string
+}
+
+// IsEmpty - читатель ничего не настраивал, ему достаётся общий дайджест.
+func (p *CategoryPreferences) IsEmpty() bool {
+	return p == nil || (len(p.Muted) == 0 && len(p.Boosted) == 0)
+}
+
+// Preference возвращает настройку рубрики: PreferenceMute, PreferenceBoost или "".
+func (p *CategoryPreferences) Preference(category string) string {
+	if p == nil {
+		return ""
+	}
+	if slices.Contains(p.Muted, category) {
+		return PreferenceMute
+	}
+	if slices.Contains(p.Boosted, category) {
+		return PreferenceBoost
+	}
+	return ""
+}
+
+// Key - канонический ключ набора настроек для кэша personalized_summaries:
+// у читателей с одинаковыми настройками он совпадает.
+func (p *CategoryPreferences) Key() string {
+	if p.IsEmpty() {
+		return ""
+	}
+	return "mute=" + strings.Join(p.Muted, ",") + ";boost=" + strings.Join(p.Boosted, ",")
+}
+
+type CategoryPreferenceRepositoryInterface interface {
+	// настройки читателя; пустые, если он ничего не менял
+	GetPreferences(chatID int64) (*CategoryPreferences, error)
+	// preference - PreferenceMute, PreferenceBoost или "" (сбросить настройку рубрики)
+	SetPreference(chatID int64, category, preference string) error
+}
+
+type CategoryPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryPreferenceRepository(db *sql.DB) CategoryPreferenceRepositoryInterface {
+	return &CategoryPreferenceRepository{db: db}
+}
+
+func (r *CategoryPreferenceRepository) GetPreferences(chatID int64) (*CategoryPreferences, error) {
+	rows, err := r.db.Query(`
+		SELECT category, preference
+		FROM user_category_preferences
+		WHERE chat_id = $1
+		ORDER BY category
+	`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := &CategoryPreferences{}
+	for rows.Next() {
+		var category, preference string
+		if err := rows.Scan(&category, &preference); err != nil {
+			return nil, err
+		}
+		switch preference {
+		case PreferenceMute:
+			prefs.Muted = append(prefs.Muted, category)
+		case PreferenceBoost:
+			prefs.Boosted = append(prefs.Boosted, category)
+		}
+	}
+	return prefs, rows.Err()
+}
+
+func (r *CategoryPreferenceRepository) SetPreference(chatID int64, category, preference string) error {
+	if preference == "" {
+		_, err := r.db.Exec(`DELETE FROM user_category_preferences WHERE chat_id = $1 AND category = $2`, chatID, category)
+		return err
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO user_category_preferences (chat_id, category, preference, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat_id, category)
+		DO UPDATE SET preference = EXCLUDED.preference, updated_at = EXCLUDED.updated_at
+	`, chatID, category, preference, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryPreferenceRepository_GetPreferences(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCategoryPreferenceRepository(db)
+
+	mock.ExpectQuery("FROM user_category_preferences\\s+WHERE chat_id = \\$1").
+		WithArgs(int64(555)).
+		WillReturnRows(sqlmock.NewRows([]string{"category", "preference"}).
+			AddRow("военное", PreferenceMute).
+			AddRow("происшествия", PreferenceMute).
+			AddRow("экономика", PreferenceBoost))
+
+	prefs, err := repo.GetPreferences(555)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"военное", "происшествия"}, prefs.Muted)
+	assert.Equal(t, []string{"экономика"}, prefs.Boosted)
+	assert.Equal(t, PreferenceMute, prefs.Preference("происшествия"))
+	assert.Equal(t, "", prefs.Preference("общество"))
+	assert.Equal(t, "mute=военное,происшествия;boost=экономика", prefs.Key())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCategoryPreferences_EmptyKey(t *testing.T) {
+	var prefs *CategoryPreferences
+	assert.True(t, prefs.IsEmpty())
+	assert.Equal(t, "", prefs.Key())
+	assert.Equal(t, "", (&CategoryPreferences{}).Key())
+}
+
+func TestCategoryPreferenceRepository_SetPreference(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewCategoryPreferenceRepository(db)
+
+	mock.ExpectExec("INSERT INTO user_category_preferences.*ON CONFLICT \\(chat_id, category\\)").
+		WithArgs(int64(555), "экономика", PreferenceBoost, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM user_category_preferences WHERE chat_id = \\$1 AND category = \\$2").
+		WithArgs(int64(555), "военное").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SetPreference(555, "экономика", PreferenceBoost))
+	require.NoError(t, repo.SetPreference(555, "военное", ""))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+package repository
+
+//go:generate mockgen -source=embedding_cache.go -destination=../mocks/repository/embedding_cache_mock.go -package=mock_repository
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+)
+
+// EmbeddingCacheRepositoryInterface - кэш эмбеддингов по (модель, хеш текста), см. EmbeddingTextHash.
+type EmbeddingCacheRepositoryInterface interface {
+	// найденные векторы по хешам; хешей, которых нет в кэше, нет и в ответе
+	GetEmbeddings(model string, hashes []string) (map[string][]float32, error)
+	// сохраняет векторы по хешам, уже закэшированные не перезаписываются
+	SaveEmbeddings(model string, embeddings map[string][]float32) error
+}
+
+// EmbeddingTextHash - ключ текста в кэше: hex SHA-256.
+func EmbeddingTextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+type EmbeddingCacheRepository struct {
+	db *sql.DB
+}
+
+func NewEmbeddingCacheRepository(db *sql.DB) EmbeddingCacheRepositoryInterface {
+	return &EmbeddingCacheRepository{db: db}
+}
+
+func (r *EmbeddingCacheRepository) GetEmbeddings(model string, hashes []string) (map[string][]float32, error) {
+	result := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return result, nil
+	}
+
+	q := `SELECT text_hash, embedding FROM embedding_cache WHERE model = $1 AND text_hash = ANY($2)`
+	rows, err := r.db.Query(q, model, pq.Array(hashes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		var vec pgvector.Vector
+		if err := rows.Scan(&hash, &vec); err != nil {
+			return nil, err
+		}
+		result[hash] = vec.Slice()
+	}
+	return result, rows.Err()
+}
+
+func (r *EmbeddingCacheRepository) SaveEmbeddings(model string, embeddings map[string][]float32) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	// Порядок строк детерминирован, чтобы параллельные вставки не ловили дедлок.
+	hashes := make([]string, 0, len(embeddings))
+	for h := range embeddings {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	values := make([]string, len(hashes))
+	args := make([]any, 0, 1+2*len(hashes))
+	args = append(args, model)
+	for i, h := range hashes {
+		values[i] = fmt.Sprintf("($1, $%d, $%d)", 2*i+2, 2*i+3)
+		args = append(args, h, pgvector.NewVector(embeddings[h]))
+	}
+
+	q := `
+		INSERT INTO embedding_cache (model, text_hash, embedding)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (model, text_hash) DO NOTHING
+	`
+	_, err := r.db.Exec(q, args...)
+	return err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingTextHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", EmbeddingTextHash(""))
+	assert.Len(t, EmbeddingTextHash("Переговоры в Женеве"), 64)
+	assert.NotEqual(t, EmbeddingTextHash("a"), EmbeddingTextHash("a "))
+}
+
+func TestEmbeddingCacheRepository_GetEmbeddings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEmbeddingCacheRepository(db)
+
+	mock.ExpectQuery("FROM embedding_cache WHERE model = \\$1 AND text_hash = ANY").
+		WithArgs("emb://f/text-search-doc/latest", pq.Array([]string{"h1", "h2"})).
+		WillReturnRows(sqlmock.NewRows([]string{"text_hash", "embedding"}).AddRow("h2", "[0.5,0.25]"))
+
+	cached, err := repo.GetEmbeddings("emb://f/text-search-doc/latest", []string{"h1", "h2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float32{"h2": {0.5, 0.25}}, cached)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmbeddingCacheRepository_SaveEmbeddings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEmbeddingCacheRepository(db)
+
+	mock.ExpectExec("INSERT INTO embedding_cache .* VALUES \\(\\$1, \\$2, \\$3\\), \\(\\$1, \\$4, \\$5\\)\\s+ON CONFLICT \\(model, text_hash\\) DO NOTHING").
+		WithArgs("m", "a", pgvector.NewVector([]float32{1}), "b", pgvector.NewVector([]float32{2})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.SaveEmbeddings("m", map[string][]float32{"b": {2}, "a": {1}})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,178 @@
+package repository
+
+//go:generate mockgen -source=feedback.go -destination=../mocks/repository/feedback_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Оценки читателей в summary_feedback.rating.
+const (
+	FeedbackUp    = "up"
+	FeedbackDown  = "down"
+	FeedbackError = "error" // ошибка в сюжете дайджеста
+)
+
+// SummaryFeedback - отзыв читателя на дайджест.
+type SummaryFeedback struct {
+	SummaryID   int64
+	ChatID      int64
+	Rating      string // FeedbackUp | FeedbackDown | FeedbackError
+	StorylineID int64  // для FeedbackError: сюжет с ошибкой, 0 - без привязки
+	CreatedAt   time.Time
+}
+
+// FeedbackScore - отзывы на дайджесты канала, собранные одной версией промптов.
+type FeedbackScore struct {
+	ChannelID     int64
+	PromptVersion string // пусто - дайджесты до записи версий
+	Summaries     int    // дайджестов, получивших хотя бы один отзыв
+	Up            int
+	Down          int
+	Errors        int
+}
+
+// StorylineErrorReport - сюжет, в котором читатели отметили ошибку.
+type StorylineErrorReport struct {
+	StorylineID int64
+	ChannelID   int64
+	Title       string
+	Reports     int
+}
+
+type FeedbackRepositoryInterface interface {
+	// оценка up/down перезаписывает прежнюю оценку читателя, ошибки копятся по сюжетам
+	SaveFeedback(f *SummaryFeedback) error
+	// сюжеты дня дайджеста (без фона) по убыванию важности - для выбора сюжета с ошибкой
+	GetSummaryStorylines(summaryID int64, limit int) ([]Storyline, error)
+	// отзывы на дайджесты, созданные начиная с since, по каналам и версиям промптов
+	ScoresByChannelAndPrompt(since time.Time) ([]FeedbackScore, error)
+	// сюжеты с наибольшим числом отметок об ошибке начиная с since
+	TopErrorStorylines(since time.Time, limit int) ([]StorylineErrorReport, error)
+}
+
+type FeedbackRepository struct {
+	db *sql.DB
+}
+
+func NewFeedbackRepository(db *sql.DB) FeedbackRepositoryInterface {
+	return &FeedbackRepository{db: db}
+}
+
+func (r *FeedbackRepository) SaveFeedback(f *SummaryFeedback) error {
+	if f.Rating == FeedbackError {
+		q := `
+			INSERT INTO summary_feedback (summary_id, chat_id, rating, storyline_id, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (summary_id, chat_id, (COALESCE(storyline_id, 0))) WHERE rating = 'error' DO NOTHING
+		`
+		_, err := r.db.Exec(q, f.SummaryID, f.ChatID, f.Rating, nullInt64(f.StorylineID), f.CreatedAt)
+		return err
+	}
+
+	q := `
+		INSERT INTO summary_feedback (summary_id, chat_id, rating, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (summary_id, chat_id) WHERE rating <> 'error'
+		DO UPDATE SET rating = EXCLUDED.rating, created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(q, f.SummaryID, f.ChatID, f.Rating, f.CreatedAt)
+	return err
+}
+
+// GetSummaryStorylines ищет наблюдения дня, за который построен дайджест: бот сохраняет
+// дайджест дня D в D+1, storyline_backfill с -write-summaries - ровно в полночь D.
+func (r *FeedbackRepository) GetSummaryStorylines(summaryID int64, limit int) ([]Storyline, error) {
+	q := `
+		SELECT st.id, st.channel_id, st.title, COALESCE(st.category, '')
+		FROM summaries sm
+		JOIN storyline_observations o ON o.channel_id = sm.channel_id
+			AND o.obs_date = CASE
+				WHEN sm.created_at = date_trunc('day', sm.created_at) THEN sm.created_at::date
+				ELSE (sm.created_at - INTERVAL '1 day')::date
+			END
+		JOIN storylines st ON st.id = o.storyline_id
+		WHERE sm.id = $1 AND o.change_type <> 'recurring_noise'
+		ORDER BY o.importance DESC, o.message_count DESC, st.id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(q, summaryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Storyline
+	for rows.Next() {
+		var s Storyline
+		if err := rows.Scan(&s.ID, &s.ChannelID, &s.Title, &s.Category); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func (r *FeedbackRepository) ScoresByChannelAndPrompt(since time.Time) ([]FeedbackScore, error) {
+	q := `
+		SELECT sm.channel_id, COALESCE(sm.prompt_version, ''), COUNT(DISTINCT sm.id),
+			COUNT(*) FILTER (WHERE f.rating = 'up'),
+			COUNT(*) FILTER (WHERE f.rating = 'down'),
+			COUNT(*) FILTER (WHERE f.rating = 'error')
+		FROM summary_feedback f
+		JOIN summaries sm ON sm.id = f.summary_id
+		WHERE sm.created_at >= $1
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.Query(q, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []FeedbackScore
+	for rows.Next() {
+		var s FeedbackScore
+		if err := rows.Scan(&s.ChannelID, &s.PromptVersion, &s.Summaries, &s.Up, &s.Down, &s.Errors); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func (r *FeedbackRepository) TopErrorStorylines(since time.Time, limit int) ([]StorylineErrorReport, error) {
+	q := `
+		SELECT st.id, st.channel_id, st.title, COUNT(*)
+		FROM summary_feedback f
+		JOIN storylines st ON st.id = f.storyline_id
+		WHERE f.rating = 'error' AND f.created_at >= $1
+		GROUP BY st.id, st.channel_id, st.title
+		ORDER BY COUNT(*) DESC, st.id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(q, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StorylineErrorReport
+	for rows.Next() {
+		var s StorylineErrorReport
+		if err := rows.Scan(&s.StorylineID, &s.ChannelID, &s.Title, &s.Reports); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	if v == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: v, Valid: true}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedbackRepository_SaveVoteUpserts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFeedbackRepository(db)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO summary_feedback \\(summary_id, chat_id, rating, created_at\\).*ON CONFLICT \\(summary_id, chat_id\\) WHERE rating <> 'error'.*DO UPDATE SET rating").
+		WithArgs(int64(7), int64(555), FeedbackDown, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SaveFeedback(&SummaryFeedback{SummaryID: 7, ChatID: 555, Rating: FeedbackDown, CreatedAt: now})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeedbackRepository_SaveErrorWithoutStoryline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFeedbackRepository(db)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO summary_feedback \\(summary_id, chat_id, rating, storyline_id, created_at\\).*DO NOTHING").
+		WithArgs(int64(7), int64(555), FeedbackError, sql.NullInt64{}, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SaveFeedback(&SummaryFeedback{SummaryID: 7, ChatID: 555, Rating: FeedbackError, CreatedAt: now})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeedbackRepository_GetSummaryStorylines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFeedbackRepository(db)
+
+	mock.ExpectQuery("FROM summaries sm\\s+JOIN storyline_observations o").
+		WithArgs(int64(7), 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel_id", "title", "category"}).
+			AddRow(int64(42), int64(123), "Переговоры", "политика").
+			AddRow(int64(43), int64(123), "Паводок", ""))
+
+	storylines, err := repo.GetSummaryStorylines(7, 8)
+	require.NoError(t, err)
+	require.Len(t, storylines, 2)
+	assert.Equal(t, Storyline{ID: 42, ChannelID: 123, Title: "Переговоры", Category: "политика"}, storylines[0])
+	assert.Equal(t, "Паводок", storylines[1].Title)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeedbackRepository_ScoresByChannelAndPrompt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFeedbackRepository(db)
+	since := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM summary_feedback f\\s+JOIN summaries sm").
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"channel_id", "prompt_version", "summaries", "up", "down", "errors"}).
+			AddRow(int64(123), "", 2, 3, 1, 0).
+			AddRow(int64(123), "extract@v1,match@v1,delta@v1,render@v2", 5, 10, 2, 1))
+
+	scores, err := repo.ScoresByChannelAndPrompt(since)
+	require.NoError(t, err)
+	require.Len(t, scores, 2)
+	assert.Equal(t, FeedbackScore{ChannelID: 123, PromptVersion: "extract@v1,match@v1,delta@v1,render@v2", Summaries: 5, Up: 10, Down: 2, Errors: 1}, scores[1])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeedbackRepository_TopErrorStorylines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewFeedbackRepository(db)
+	since := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("WHERE f.rating = 'error' AND f.created_at >= \\$1").
+		WithArgs(since, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel_id", "title", "count"}).
+			AddRow(int64(42), int64(123), "Переговоры", 4))
+
+	reports, err := repo.TopErrorStorylines(since, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []StorylineErrorReport{{StorylineID: 42, ChannelID: 123, Title: "Переговоры", Reports: 4}}, reports)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
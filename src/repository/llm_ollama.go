@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+)
+
+// ollamaProvider - локальный сервер в стиле Ollama: нативные /api/chat и /api/embed.
+type ollamaProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	// structuredOutput - передавать ChatRequest.Schema в поле format.
+	structuredOutput bool
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   map[string]any  `json:"format,omitempty"`
+	Options  map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+func (p *ollamaProvider) Name() string {
+	return config.LLMProviderOllama
+}
+
+func (p *ollamaProvider) ChatCompletion(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	var format map[string]any
+	if p.structuredOutput && req.Schema != nil {
+		format = req.Schema.Schema
+	}
+
+	var resp ollamaChatResponse
+	err := p.post(ctx, "api/chat", ollamaChatRequest{
+		Model:  req.Model,
+		Format: format,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: req.SystemPrompt},
+			{Role: "user", Content: req.UserPrompt},
+		},
+		Options: map[string]any{
+			"temperature": req.Temperature,
+			"num_predict": req.MaxTokens,
+		},
+	}, &resp)
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to call ollama chat: %w", err)
+	}
+	usage := Usage{PromptTokens: resp.PromptEvalCount, CompletionTokens: resp.EvalCount}
+	if strings.TrimSpace(resp.Message.Content) == "" {
+		return ChatResponse{Usage: usage}, fmt.Errorf("received empty chat completion response")
+	}
+	return ChatResponse{Content: resp.Message.Content, Usage: usage}, nil
+}
+
+func (p *ollamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, Usage, error) {
+	var resp ollamaEmbedResponse
+	if err := p.post(ctx, "api/embed", ollamaEmbedRequest{Model: model, Input: texts}, &resp); err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	usage := Usage{PromptTokens: resp.PromptEvalCount}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, usage, fmt.Errorf("received %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, usage, nil
+}
+
+func (p *ollamaProvider) post(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/param"
+	"github.com/openai/openai-go/shared"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+	"github.com/yandex-cloud/go-sdk/iamkey"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434/"
+
+// LLMProvider - транспорт к конкретному LLM-бэкенду. MLRepository выбирает
+// провайдера и модель для каждой стадии конвейера (см. config.LLMStageProvider).
+type LLMProvider interface {
+	Name() string
+	ChatCompletion(ctx context.Context, req ChatRequest) (ChatResponse, error)
+	// Embed возвращает по эмбеддингу на каждый текст, в том же порядке.
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, Usage, error)
+}
+
+// ChatRequest - один запрос чат-комплишена: системный и пользовательский промпты.
+type ChatRequest struct {
+	Model        string
+	SystemPrompt string
+	UserPrompt   string
+	MaxTokens    int
+	Temperature  float64
+	Schema       *JSONSchema // nil - ответ в свободной форме
+}
+
+// ChatResponse - текст ответа модели и израсходованные токены.
+type ChatResponse struct {
+	Content string
+	Usage   Usage
+}
+
+// Usage - токены одного вызова, как их сообщил провайдер (0, если не сообщил).
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// modelResolver реализуют провайдеры, у которых есть модели по умолчанию
+// или короткие имена моделей (Yandex: "yandexgpt/latest" -> gpt://<folder>/yandexgpt/latest).
+type modelResolver interface {
+	resolveModel(stage, model string) string
+}
+
+// newLLMProvider создаёт провайдера по имени из config.LLMProvider*.
+func newLLMProvider(ctx context.Context, name string) (LLMProvider, error) {
+	switch name {
+	case config.LLMProviderYandex:
+		return newYandexProvider(ctx)
+	case config.LLMProviderOpenAI:
+		baseURL := strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
+		if baseURL == "" {
+			return nil, fmt.Errorf("OPENAI_BASE_URL environment variable is required for provider %s", name)
+		}
+		return &openAICompatibleProvider{
+			name:             config.LLMProviderOpenAI,
+			httpClient:       &http.Client{Timeout: llmRequestTimeout},
+			tokenProvider:    staticTokenProvider{token: os.Getenv("OPENAI_API_KEY")},
+			baseURL:          withTrailingSlash(baseURL),
+			batchEmbeddings:  true,
+			structuredOutput: config.LLMStructuredOutput(config.LLMProviderOpenAI),
+		}, nil
+	case config.LLMProviderOllama:
+		baseURL := strings.TrimSpace(os.Getenv("OLLAMA_BASE_URL"))
+		if baseURL == "" {
+			baseURL = defaultOllamaBaseURL
+		}
+		return &ollamaProvider{
+			httpClient:       &http.Client{Timeout: llmRequestTimeout},
+			baseURL:          withTrailingSlash(baseURL),
+			structuredOutput: config.LLMStructuredOutput(config.LLMProviderOllama),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown LLM provider %q", name)
+}
+
+func withTrailingSlash(baseURL string) string {
+	if !strings.HasSuffix(baseURL, "/") {
+		return baseURL + "/"
+	}
+	return baseURL
+}
+
+// openAICompatibleProvider ходит в любой OpenAI-совместимый API (/chat/completions, /embeddings).
+type openAICompatibleProvider struct {
+	name          string
+	httpClient    *http.Client
+	tokenProvider tokenProvider
+	baseURL       string
+	headers       map[string]string
+	// batchEmbeddings - API принимает несколько текстов в одном запросе эмбеддингов.
+	batchEmbeddings bool
+	// structuredOutput - передавать ChatRequest.Schema как response_format: json_schema.
+	structuredOutput bool
+}
+
+func (p *openAICompatibleProvider) Name() string {
+	return p.name
+}
+
+func (p *openAICompatibleProvider) newClient(ctx context.Context) (openai.Client, error) {
+	token, err := p.tokenProvider.Token(ctx)
+	if err != nil {
+		return openai.Client{}, err
+	}
+	opts := []option.RequestOption{
+		option.WithBaseURL(p.baseURL),
+		option.WithHTTPClient(p.httpClient),
+		option.WithRequestTimeout(llmRequestTimeout),
+	}
+	if token != "" {
+		opts = append(opts, option.WithAPIKey(token))
+	}
+	for k, v := range p.headers {
+		opts = append(opts, option.WithHeader(k, v))
+	}
+	return openai.NewClient(opts...), nil
+}
+
+func (p *openAICompatibleProvider) ChatCompletion(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	client, err := p.newClient(ctx)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Model: shared.ChatModel(req.Model),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(req.SystemPrompt),
+			openai.UserMessage(req.UserPrompt),
+		},
+		MaxTokens:   param.NewOpt(int64(req.MaxTokens)),
+		Temperature: param.NewOpt(req.Temperature),
+	}
+	if p.structuredOutput && req.Schema != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   req.Schema.Name,
+					Schema: req.Schema.Schema,
+				},
+			},
+		}
+	}
+
+	completion, err := client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to call %s chat completions: %w", p.name, err)
+	}
+	usage := Usage{
+		PromptTokens:     int(completion.Usage.PromptTokens),
+		CompletionTokens: int(completion.Usage.CompletionTokens),
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return ChatResponse{Usage: usage}, fmt.Errorf("received empty chat completion response")
+	}
+
+	return ChatResponse{Content: completion.Choices[0].Message.Content, Usage: usage}, nil
+}
+
+func (p *openAICompatibleProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, Usage, error) {
+	client, err := p.newClient(ctx)
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
+	var usage Usage
+	if p.batchEmbeddings {
+		resp, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+			Model: openai.EmbeddingModel(model),
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		})
+		if err != nil {
+			return nil, usage, fmt.Errorf("failed to create embeddings: %w", err)
+		}
+		usage.PromptTokens = int(resp.Usage.PromptTokens)
+		if len(resp.Data) != len(texts) {
+			return nil, usage, fmt.Errorf("received %d embeddings for %d texts", len(resp.Data), len(texts))
+		}
+		result := make([][]float32, len(texts))
+		for _, d := range resp.Data {
+			if d.Index < 0 || int(d.Index) >= len(texts) {
+				return nil, usage, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			result[d.Index] = float64sToFloat32s(d.Embedding)
+		}
+		return result, usage, nil
+	}
+
+	result := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		resp, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+			Model: openai.EmbeddingModel(model),
+			Input: openai.EmbeddingNewParamsInputUnion{OfString: param.NewOpt(text)},
+		})
+		if err != nil {
+			return nil, usage, fmt.Errorf("failed to create embedding: %w", err)
+		}
+		usage.PromptTokens += int(resp.Usage.PromptTokens)
+		if len(resp.Data) == 0 {
+			return nil, usage, fmt.Errorf("received empty embedding response")
+		}
+		result = append(result, float64sToFloat32s(resp.Data[0].Embedding))
+	}
+	return result, usage, nil
+}
+
+// yandexProvider - Yandex AI Studio через OpenAI-совместимый API: IAM/API-ключ,
+// заголовок x-folder-id, модели gpt://<folder>/... и emb://<folder>/...
+type yandexProvider struct {
+	openAICompatibleProvider
+	folderID string
+}
+
+func newYandexProvider(ctx context.Context) (*yandexProvider, error) {
+	folderID := os.Getenv("YANDEX_FOLDER_ID")
+	if folderID == "" {
+		return nil, fmt.Errorf("YANDEX_FOLDER_ID environment variable is required")
+	}
+
+	tokenProvider, err := newYandexTokenProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimSpace(os.Getenv("YANDEX_OPENAI_BASE_URL"))
+	if baseURL == "" {
+		baseURL = defaultYandexOpenAIBaseURL
+	}
+
+	return newYandexProviderWith(&http.Client{Timeout: llmRequestTimeout}, tokenProvider, withTrailingSlash(baseURL), folderID), nil
+}
+
+func newYandexProviderWith(httpClient *http.Client, tokenProvider tokenProvider, baseURL, folderID string) *yandexProvider {
+	return &yandexProvider{
+		openAICompatibleProvider: openAICompatibleProvider{
+			name:          config.LLMProviderYandex,
+			httpClient:    httpClient,
+			tokenProvider: tokenProvider,
+			baseURL:       baseURL,
+			headers:       map[string]string{"x-folder-id": folderID},
+			// Yandex эмбеддинги принимают по одному тексту на запрос — эмбеддим поштучно.
+			batchEmbeddings:  false,
+			structuredOutput: config.LLMStructuredOutput(config.LLMProviderYandex),
+		},
+		folderID: folderID,
+	}
+}
+
+func (p *yandexProvider) resolveModel(stage, model string) string {
+	embedding := stage == config.LLMStageEmbedDoc || stage == config.LLMStageEmbedQuery
+	switch {
+	case model == "" && stage == config.LLMStageEmbedDoc:
+		return config.EmbedDocURI(p.folderID)
+	case model == "" && stage == config.LLMStageEmbedQuery:
+		return config.EmbedQueryURI(p.folderID)
+	case model == "":
+		if uri := os.Getenv("YANDEX_MODEL_URI"); uri != "" {
+			return uri
+		}
+		return fmt.Sprintf("gpt://%s/%s", p.folderID, defaultYandexModelName)
+	case strings.Contains(model, "://"):
+		return model
+	case embedding:
+		return fmt.Sprintf("emb://%s/%s", p.folderID, model)
+	default:
+		return fmt.Sprintf("gpt://%s/%s", p.folderID, model)
+	}
+}
+
+type tokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+type staticTokenProvider struct {
+	token string
+}
+
+func (p staticTokenProvider) Token(context.Context) (string, error) {
+	return p.token, nil
+}
+
+type iamTokenProvider struct {
+	sdk *ycsdk.SDK
+}
+
+func (p *iamTokenProvider) Token(ctx context.Context) (string, error) {
+	token, err := p.sdk.CreateIAMToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get IAM token: %w", err)
+	}
+	return token.IamToken, nil
+}
+
+func newYandexTokenProvider(ctx context.Context) (tokenProvider, error) {
+	apiKey := os.Getenv("YANDEX_API_KEY")
+	if apiKey != "" {
+		return staticTokenProvider{token: apiKey}, nil
+	}
+
+	serviceAccountKeyPath := os.Getenv("YANDEX_SERVICE_ACCOUNT_KEY_PATH")
+	if serviceAccountKeyPath == "" {
+		return nil, fmt.Errorf("YANDEX_API_KEY or YANDEX_SERVICE_ACCOUNT_KEY_PATH environment variable is required")
+	}
+
+	keyData, err := os.ReadFile(serviceAccountKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+
+	key, err := iamkey.ReadFromJSONBytes(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+
+	creds, err := ycsdk.ServiceAccountKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credentials: %w", err)
+	}
+
+	sdk, err := ycsdk.Build(ctx, ycsdk.Config{
+		Credentials: creds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Yandex Cloud SDK: %w", err)
+	}
+
+	return &iamTokenProvider{sdk: sdk}, nil
+}
+
+func float64sToFloat32s(in []float64) []float32 {
+	out := make([]float32, len(in))
+	for i, v := range in {
+		out[i] = float32(v)
+	}
+	return out
+}
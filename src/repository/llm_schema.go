@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const maxExtractedTopics = 8 // подставляется в промпт стадии A ({{.MaxTopics}})
+
+// JSONSchema - схема ответа стадии для structured output (если провайдер его поддерживает).
+type JSONSchema struct {
+	Name   string
+	Schema map[string]any
+}
+
+// FieldError - ошибка валидации одного поля ответа, Field - путь вида topics[0].importance.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError - ответ модели разобран, но не прошёл проверку схемы.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "invalid response: " + strings.Join(parts, "; ")
+}
+
+// validator копит ошибки полей; err() возвращает nil, если ошибок нет.
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) addf(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// completeJSON запрашивает у стадии JSON-ответ и разбирает его через parse.
+// Если ответ не парсится или не проходит валидацию, модель переспрашивается не более
+// config.LLMRepairAttempts раз с текстом ошибок. Возвращает ошибку последней попытки:
+// *ValidationError, если JSON разобран, но невалиден, - вызывающий может принять его нестрого.
+func (r *MLRepository) completeJSON(ctx context.Context, stage, systemPrompt, userPrompt string, schema *JSONSchema, parse func(content string) error) error {
+	prompt := userPrompt
+	for attempt := 0; ; attempt++ {
+		raw, err := r.createChatCompletion(ctx, stage, systemPrompt, prompt, schema)
+		if err != nil {
+			return err
+		}
+		err = parse(cleanResponse(raw))
+		if err == nil {
+			return nil
+		}
+		if attempt >= config.LLMRepairAttempts {
+			return fmt.Errorf("invalid %s response after %d attempts: %w", stage, attempt+1, err)
+		}
+		log.Warnf("Stage %s returned invalid JSON (attempt %d/%d): %v", stage, attempt+1, config.LLMRepairAttempts+1, err)
+		prompt = buildRepairPrompt(userPrompt, raw, err)
+	}
+}
+
+func buildRepairPrompt(userPrompt, raw string, err error) string {
+	var b strings.Builder
+	b.WriteString(userPrompt)
+	b.WriteString("\n\nТвой предыдущий ответ:\n")
+	b.WriteString(strings.TrimSpace(raw))
+	b.WriteString("\n\nОшибки проверки:\n")
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		for _, fe := range verr.Errors {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", fe.Field, fe.Message))
+		}
+	} else {
+		b.WriteString(fmt.Sprintf("- ответ не является валидным JSON: %v\n", err))
+	}
+	b.WriteString("\nИсправь ответ и верни только валидный JSON без markdown.")
+	return b.String()
+}
+
+// --- стадия A ---
+
+func (p candidateTopicsPlan) validate(messageCount int) error {
+	var v validator
+	if len(p.Topics) > maxExtractedTopics {
+		v.addf("topics", "must contain at most %d topics, got %d", maxExtractedTopics, len(p.Topics))
+	}
+	for i, t := range p.Topics {
+		field := fmt.Sprintf("topics[%d]", i)
+		if strings.TrimSpace(t.Title) == "" {
+			v.addf(field+".title", "must not be empty")
+		}
+		if strings.TrimSpace(t.Summary) == "" {
+			v.addf(field+".summary", "must not be empty")
+		}
+		if t.Importance < 1 || t.Importance > 5 {
+			v.addf(field+".importance", "must be between 1 and 5, got %d", t.Importance)
+		}
+		if !config.IsCategory(strings.TrimSpace(t.Category)) {
+			v.addf(field+".category", "must be one of %s, got %q", strings.Join(config.Categories, ", "), t.Category)
+		}
+		if len(t.SourceMessageNumbers) == 0 {
+			v.addf(field+".source_message_numbers", "must not be empty")
+		}
+		for j, n := range t.SourceMessageNumbers {
+			if n < 1 || n > messageCount {
+				v.addf(fmt.Sprintf("%s.source_message_numbers[%d]", field, j), "must be between 1 and %d, got %d", messageCount, n)
+			}
+		}
+	}
+	return v.err()
+}
+
+func topicsSchema(messageCount int) *JSONSchema {
+	return &JSONSchema{
+		Name: "topics",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []string{"topics"},
+			"properties": map[string]any{
+				"topics": map[string]any{
+					"type":     "array",
+					"maxItems": maxExtractedTopics,
+					"items": map[string]any{
+						"type":     "object",
+						"required": []string{"title", "summary", "category", "importance", "source_message_numbers"},
+						"properties": map[string]any{
+							"title":      map[string]any{"type": "string", "minLength": 1},
+							"summary":    map[string]any{"type": "string", "minLength": 1},
+							"category":   map[string]any{"type": "string", "enum": config.Categories},
+							"importance": map[string]any{"type": "integer", "minimum": 1, "maximum": 5},
+							"source_message_numbers": map[string]any{
+								"type":     "array",
+								"minItems": 1,
+								"items":    map[string]any{"type": "integer", "minimum": 1, "maximum": messageCount},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// --- стадия C ---
+
+func (resp confirmMatchResponse) validate(options []StorylineBrief) error {
+	var v validator
+	if resp.IsNew {
+		if resp.MatchedID != nil {
+			v.addf("matched_id", "must be null when is_new is true")
+		}
+		return v.err()
+	}
+	if resp.MatchedID == nil {
+		v.addf("matched_id", "must be set when is_new is false")
+		return v.err()
+	}
+	ids := make([]string, len(options))
+	for i, o := range options {
+		if o.ID == *resp.MatchedID {
+			return nil
+		}
+		ids[i] = fmt.Sprint(o.ID)
+	}
+	v.addf("matched_id", "must be one of [%s], got %d", strings.Join(ids, ", "), *resp.MatchedID)
+	return v.err()
+}
+
+func matchConfirmSchema(options []StorylineBrief) *JSONSchema {
+	ids := make([]any, 0, len(options)+1)
+	for _, o := range options {
+		ids = append(ids, o.ID)
+	}
+	ids = append(ids, nil)
+	return &JSONSchema{
+		Name: "match_confirmation",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []string{"matched_id", "is_new", "reason"},
+			"properties": map[string]any{
+				"matched_id": map[string]any{"type": []string{"integer", "null"}, "enum": ids},
+				"is_new":     map[string]any{"type": "boolean"},
+				"reason":     map[string]any{"type": "string"},
+			},
+		},
+	}
+}
+
+// --- стадия D ---
+
+func (resp writeDeltaResponse) validate() error {
+	var v validator
+	state := strings.TrimSpace(resp.State)
+	if state == "" {
+		v.addf("state", "must not be empty")
+	}
+	if n := len([]rune(state)); n > config.StorylineStateMaxRunes {
+		v.addf("state", "must be at most %d characters, got %d", config.StorylineStateMaxRunes, n)
+	}
+	return v.err()
+}
+
+func deltaSchema() *JSONSchema {
+	return &JSONSchema{
+		Name: "storyline_delta",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []string{"delta_summary", "state"},
+			"properties": map[string]any{
+				"delta_summary": map[string]any{"type": "string"},
+				"state":         map[string]any{"type": "string", "minLength": 1, "maxLength": config.StorylineStateMaxRunes},
+			},
+		},
+	}
+}
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Message struct {
@@ -11,12 +15,57 @@ type Message struct {
 	MessageID   int
 	MessageText string
 	MessageDate time.Time
+	EditDate    time.Time // нулевое - пост не редактировался
+	GroupedID   int64     // альбом; 0 - одиночный пост
+	Meta        MessageMeta
 	CreatedAt   time.Time
 }
 
+// MessageMeta - messages.meta (миграция 0014): медиа, пересылка и метрики поста.
+type MessageMeta struct {
+	Media           string          `json:"media,omitempty"`             // photo, video, audio, document, poll, ...
+	MediaCount      int             `json:"media_count,omitempty"`       // элементов в альбоме
+	AlbumMessageIDs []int           `json:"album_message_ids,omitempty"` // остальные элементы альбома
+	ForwardFrom     *MessageForward `json:"fwd_from,omitempty"`
+	ReplyToMsgID    int             `json:"reply_to_msg_id,omitempty"`
+	URL             string          `json:"url,omitempty"`  // адрес материала у постов RSS/Atom-лент
+	GUID            string          `json:"guid,omitempty"` // ключ записи ленты (guid, иначе ссылка), уникален в канале
+
+	Views     int `json:"views,omitempty"`
+	Forwards  int `json:"forwards,omitempty"`
+	Reactions int `json:"reactions,omitempty"` // сумма всех реакций
+	Replies   int `json:"replies,omitempty"`
+}
+
+// MessageForward - происхождение пересланного поста.
+type MessageForward struct {
+	ChannelID int64     `json:"channel_id,omitempty"`
+	MessageID int       `json:"message_id,omitempty"`
+	FromName  string    `json:"from_name,omitempty"`
+	Date      time.Time `json:"date"`
+}
+
 type MessageRepositoryInterface interface {
-	SaveMessage(message *Message) error
-	GetLastMessageTime(channelID int64) (time.Time, error)
+	// inserted == false - сообщение уже было сохранено раньше
+	SaveMessage(message *Message) (inserted bool, err error)
+	// курсор загрузки (миграция 0015), смысл значения задаёт источник; без
+	// строки в channel_cursors - значение из сохранённых постов по fallback,
+	// 0 - постов ещё нет
+	GetChannelCursor(channelID int64, fallback CursorFallback) (int, error)
+	// курсор только растёт: меньший lastMessageID не сдвигает его назад
+	SaveChannelCursor(channelID int64, lastMessageID int) error
+	// сообщения канала по message_id (например, source_message_ids наблюдений сюжета), без удалённых
+	GetMessagesByIDs(channelID int64, messageIDs []int64) ([]Message, error)
+
+	// синхронизация правок и удалений (миграция 0013); обе операции помечают
+	// needs_regeneration у наблюдений сюжетов, ссылающихся на пост
+	// updated == false - пост не сохранён, удалён или эта правка уже записана
+	UpdateEditedMessage(message *Message) (updated bool, err error)
+	// message_id неудалённых сообщений канала с message_date >= since
+	GetMessageIDsSince(channelID int64, since time.Time) ([]int64, error)
+	MarkMessagesDeleted(channelID int64, messageIDs []int64, deletedAt time.Time) error
+	// обновляет views/forwards/reactions/replies в meta сохранённых постов
+	UpdateMessageMetrics(channelID int64, messages []*Message) error
 }
 
 type MessageRepository struct {
@@ -27,33 +76,220 @@ func NewMessageRepository(db *sql.DB) MessageRepositoryInterface {
 	return &MessageRepository{db: db}
 }
 
-func (r *MessageRepository) SaveMessage(message *Message) error {
+func (r *MessageRepository) SaveMessage(message *Message) (bool, error) {
+	meta, err := json.Marshal(message.Meta)
+	if err != nil {
+		return false, err
+	}
 	query := `
-		INSERT INTO messages (channel_id, message_id, message_text, message_date)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (channel_id, message_id) DO NOTHING
+		INSERT INTO messages (channel_id, message_id, message_text, message_date, edit_date, grouped_id, meta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
 	`
-	_, err := r.db.Exec(query,
+	// Без цели конфликта: запись ленты с уже сохранённым meta.guid (уникальный
+	// индекс из 0018) пропускается так же, как повтор (channel_id, message_id).
+	res, err := r.db.Exec(query,
 		message.ChannelID,
 		message.MessageID,
 		message.MessageText,
 		message.MessageDate,
+		nullTime(message.EditDate),
+		nullInt64(message.GroupedID),
+		meta,
 	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CursorFallback - чем GetChannelCursor заменяет курсор канала, которого ещё
+// нет в channel_cursors.
+type CursorFallback int
+
+const (
+	CursorByMessageID   CursorFallback = iota // максимальный сохранённый message_id (Telegram)
+	CursorByMessageDate                       // Unix-время самого свежего поста (RSS/Atom-ленты)
+)
+
+func (r *MessageRepository) GetChannelCursor(channelID int64, fallback CursorFallback) (int, error) {
+	var stored string
+	switch fallback {
+	case CursorByMessageID:
+		stored = `SELECT MAX(message_id) FROM messages WHERE channel_id = $1`
+	case CursorByMessageDate:
+		stored = `SELECT EXTRACT(EPOCH FROM MAX(message_date))::BIGINT FROM messages WHERE channel_id = $1`
+	default:
+		return 0, fmt.Errorf("unknown cursor fallback %d", fallback)
+	}
+
+	var cursor int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(
+			(SELECT last_message_id FROM channel_cursors WHERE channel_id = $1),
+			(`+stored+`),
+			0)
+	`, channelID).Scan(&cursor)
+	return cursor, err
+}
+
+func (r *MessageRepository) SaveChannelCursor(channelID int64, lastMessageID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO channel_cursors (channel_id, last_message_id, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (channel_id) DO UPDATE
+		SET last_message_id = GREATEST(channel_cursors.last_message_id, EXCLUDED.last_message_id),
+			updated_at = NOW()
+	`, channelID, lastMessageID)
 	return err
 }
 
-func (r *MessageRepository) GetLastMessageTime(channelID int64) (time.Time, error) {
-	var messageDate time.Time
-	query := `
-		SELECT message_date 
-		FROM messages 
-		WHERE channel_id = $1 
-		ORDER BY message_date DESC 
-		LIMIT 1
+func (r *MessageRepository) GetMessagesByIDs(channelID int64, messageIDs []int64) ([]Message, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+	q := `
+		SELECT id, channel_id, message_id, COALESCE(message_text, ''), message_date, created_at, meta
+		FROM messages
+		WHERE channel_id = $1 AND message_id = ANY($2) AND deleted_at IS NULL
+		ORDER BY message_date, message_id
 	`
-	err := r.db.QueryRow(query, channelID).Scan(&messageDate)
-	if err == sql.ErrNoRows {
-		return time.Time{}, nil
+	rows, err := r.db.Query(q, channelID, pq.Array(messageIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Message
+	for rows.Next() {
+		var m Message
+		var meta []byte
+		if err := rows.Scan(&m.ID, &m.ChannelID, &m.MessageID, &m.MessageText, &m.MessageDate, &m.CreatedAt, &meta); err != nil {
+			return nil, err
+		}
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &m.Meta); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+func (r *MessageRepository) UpdateEditedMessage(message *Message) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
 	}
-	return messageDate.UTC(), err
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE messages
+		SET message_text = $3, edit_date = $4
+		WHERE channel_id = $1 AND message_id = $2 AND deleted_at IS NULL
+			AND (edit_date IS NULL OR edit_date < $4)
+	`, message.ChannelID, message.MessageID, message.MessageText, message.EditDate)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := flagObservations(tx, message.ChannelID, []int64{int64(message.MessageID)}); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *MessageRepository) GetMessageIDsSince(channelID int64, since time.Time) ([]int64, error) {
+	rows, err := r.db.Query(`
+		SELECT message_id
+		FROM messages
+		WHERE channel_id = $1 AND message_date >= $2 AND deleted_at IS NULL
+	`, channelID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *MessageRepository) MarkMessagesDeleted(channelID int64, messageIDs []int64, deletedAt time.Time) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE messages
+		SET deleted_at = $3
+		WHERE channel_id = $1 AND message_id = ANY($2) AND deleted_at IS NULL
+	`, channelID, pq.Array(messageIDs), deletedAt)
+	if err != nil {
+		return err
+	}
+	if err := flagObservations(tx, channelID, messageIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MessageRepository) UpdateMessageMetrics(channelID int64, messages []*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(messages))
+	views := make([]int64, len(messages))
+	forwards := make([]int64, len(messages))
+	reactions := make([]int64, len(messages))
+	replies := make([]int64, len(messages))
+	for i, m := range messages {
+		ids[i] = int64(m.MessageID)
+		views[i] = int64(m.Meta.Views)
+		forwards[i] = int64(m.Meta.Forwards)
+		reactions[i] = int64(m.Meta.Reactions)
+		replies[i] = int64(m.Meta.Replies)
+	}
+	_, err := r.db.Exec(`
+		UPDATE messages AS m
+		SET meta = m.meta || jsonb_build_object(
+			'views', u.views, 'forwards', u.forwards, 'reactions', u.reactions, 'replies', u.replies)
+		FROM unnest($2::bigint[], $3::int[], $4::int[], $5::int[], $6::int[])
+			AS u(message_id, views, forwards, reactions, replies)
+		WHERE m.channel_id = $1 AND m.message_id = u.message_id AND m.deleted_at IS NULL
+	`, channelID, pq.Array(ids), pq.Array(views), pq.Array(forwards), pq.Array(reactions), pq.Array(replies))
+	return err
+}
+
+// flagObservations помечает для перегенерации наблюдения, собранные по этим постам.
+func flagObservations(tx *sql.Tx, channelID int64, messageIDs []int64) error {
+	_, err := tx.Exec(`
+		UPDATE storyline_observations
+		SET needs_regeneration = TRUE
+		WHERE channel_id = $1 AND source_message_ids && $2::bigint[]
+	`, channelID, pq.Array(messageIDs))
+	return err
 }
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRepository_SaveMessageStoresMeta(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+	date := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	msg := &Message{
+		ChannelID:   1754252633,
+		MessageID:   820,
+		MessageDate: date,
+		GroupedID:   13700000001,
+		Meta:        MessageMeta{Media: "photo", MediaCount: 3, AlbumMessageIDs: []int{821, 822}, Views: 1500},
+	}
+
+	mock.ExpectExec("INSERT INTO messages \\(channel_id, message_id, message_text, message_date, edit_date, grouped_id, meta\\)").
+		WithArgs(int64(1754252633), 820, "", date, nil, int64(13700000001),
+			[]byte(`{"media":"photo","media_count":3,"album_message_ids":[821,822],"views":1500}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	inserted, err := repo.SaveMessage(msg)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_UpdateMessageMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+
+	mock.ExpectExec("UPDATE messages AS m\\s+SET meta = m.meta \\|\\| jsonb_build_object.*FROM unnest").
+		WithArgs(int64(1754252633), pq.Array([]int64{805, 812}), pq.Array([]int64{1200, 300}),
+			pq.Array([]int64{4, 0}), pq.Array([]int64{57, 2}), pq.Array([]int64{0, 1})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.UpdateMessageMetrics(1754252633, []*Message{
+		{MessageID: 805, Meta: MessageMeta{Views: 1200, Forwards: 4, Reactions: 57}},
+		{MessageID: 812, Meta: MessageMeta{Views: 300, Reactions: 2, Replies: 1}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateMessageMetrics(1754252633, nil), "пустой список - без запросов")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_ChannelCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+
+	mock.ExpectQuery("SELECT COALESCE\\(\\s+\\(SELECT last_message_id FROM channel_cursors WHERE channel_id = \\$1\\),\\s+\\(SELECT MAX\\(message_id\\) FROM messages WHERE channel_id = \\$1\\)").
+		WithArgs(int64(1754252633)).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(812))
+	mock.ExpectExec("INSERT INTO channel_cursors.*ON CONFLICT \\(channel_id\\) DO UPDATE\\s+SET last_message_id = GREATEST").
+		WithArgs(int64(1754252633), 830).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	cursor, err := repo.GetChannelCursor(1754252633, CursorByMessageID)
+	require.NoError(t, err)
+	assert.Equal(t, 812, cursor)
+	require.NoError(t, repo.SaveChannelCursor(1754252633, 830))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_ChannelCursorByMessageDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+
+	// у ленты message_id - хэш, запасной курсор - время самой свежей записи
+	mock.ExpectQuery("SELECT COALESCE\\(\\s+\\(SELECT last_message_id FROM channel_cursors WHERE channel_id = \\$1\\),\\s+\\(SELECT EXTRACT\\(EPOCH FROM MAX\\(message_date\\)\\)::BIGINT FROM messages WHERE channel_id = \\$1\\)").
+		WithArgs(int64(-1)).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(1760860800))
+
+	cursor, err := repo.GetChannelCursor(-1, CursorByMessageDate)
+	require.NoError(t, err)
+	assert.Equal(t, 1760860800, cursor)
+
+	_, err = repo.GetChannelCursor(-1, CursorFallback(42))
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetMessagesByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+	date := time.Date(2026, 10, 17, 13, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM messages\\s+WHERE channel_id = \\$1 AND message_id = ANY\\(\\$2\\)").
+		WithArgs(int64(1754252633), pq.Array([]int64{805, 812})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel_id", "message_id", "message_text", "message_date", "created_at", "meta"}).
+			AddRow(int64(1), int64(1754252633), 805, "ЦБ сохранил ставку", date, date, []byte(`{"url":"https://news.example.ru/805"}`)))
+
+	messages, err := repo.GetMessagesByIDs(1754252633, []int64{805, 812})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, 805, messages[0].MessageID)
+	assert.Equal(t, "ЦБ сохранил ставку", messages[0].MessageText)
+	assert.Equal(t, "https://news.example.ru/805", messages[0].Meta.URL)
+
+	none, err := repo.GetMessagesByIDs(1754252633, nil)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_UpdateEditedMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+	edited := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	msg := &Message{ChannelID: 1754252633, MessageID: 805, MessageText: "ЦБ снизил ставку", EditDate: edited}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE messages\\s+SET message_text = \\$3, edit_date = \\$4.*deleted_at IS NULL\\s+AND \\(edit_date IS NULL OR edit_date < \\$4\\)").
+		WithArgs(int64(1754252633), 805, "ЦБ снизил ставку", edited).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE storyline_observations\\s+SET needs_regeneration = TRUE\\s+WHERE channel_id = \\$1 AND source_message_ids && \\$2::bigint\\[\\]").
+		WithArgs(int64(1754252633), pq.Array([]int64{805})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// повторная правка с той же edit_date ничего не меняет и наблюдения не трогает
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE messages").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	updated, err := repo.UpdateEditedMessage(msg)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	updated, err = repo.UpdateEditedMessage(msg)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_MarkMessagesDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMessageRepository(db)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-48 * time.Hour)
+
+	mock.ExpectQuery("SELECT message_id\\s+FROM messages\\s+WHERE channel_id = \\$1 AND message_date >= \\$2 AND deleted_at IS NULL").
+		WithArgs(int64(1754252633), since).
+		WillReturnRows(sqlmock.NewRows([]string{"message_id"}).AddRow(int64(805)).AddRow(int64(812)))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE messages\\s+SET deleted_at = \\$3").
+		WithArgs(int64(1754252633), pq.Array([]int64{812}), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE storyline_observations\\s+SET needs_regeneration = TRUE").
+		WithArgs(int64(1754252633), pq.Array([]int64{812})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ids, err := repo.GetMessageIDsSince(1754252633, since)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{805, 812}, ids)
+
+	require.NoError(t, repo.MarkMessagesDeleted(1754252633, []int64{812}, now))
+	require.NoError(t, repo.MarkMessagesDeleted(1754252633, nil, now), "пустой список - без запросов")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
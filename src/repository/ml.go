@@ -5,22 +5,15 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/Ra1ze505/goNewsBot/src/config"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
-	"github.com/openai/openai-go/packages/param"
-	"github.com/openai/openai-go/shared"
 	log "github.com/sirupsen/logrus"
-	ycsdk "github.com/yandex-cloud/go-sdk"
-	"github.com/yandex-cloud/go-sdk/iamkey"
 )
 
 const (
@@ -30,93 +23,39 @@ const (
 	defaultExtractTemperature  = 0.2
 	defaultRenderMaxTokens     = 8000
 	defaultRenderTemperature   = 1.0
-	yandexRequestTimeout       = 300 * time.Second
+	llmRequestTimeout          = 300 * time.Second
 )
 
-const topicExtractionSystemPrompt = `Ты аналитик новостной редакции.
-
-Твоя задача — превратить поток сообщений Telegram-канала в структурированный план дайджеста.
-
-Правила:
-- Объединяй связанные сообщения в один топик, даже если они написаны разными словами.
-- Удаляй рекламу, повторы, эмоциональные комментарии без фактов, анонсы без сути и малозначимые локальные детали.
-- Выбирай не больше 5 топиков.
-- Оцени важность от 1 до 5.
-- Сохраняй номера исходных сообщений, из которых собран топик.
-- Не выдумывай факты, которых нет в сообщениях.
-- Верни только валидный JSON без markdown.`
-
-const topicsExtractionSystemPrompt = `Ты аналитик новостной редакции.
-
-Преврати поток сообщений Telegram-канала в список топиков.
-
-Правила:
-- Объединяй связанные сообщения в один топик, даже если они написаны разными словами.
-- Удаляй рекламу, повторы, эмоциональные комментарии без фактов.
-- Не больше 8 топиков.
-- Оцени важность от 1 до 5.
-- Укажи рубрику (одно из: военное, происшествия, экономика, политика, общество, другое).
-- Сохраняй номера исходных сообщений, из которых собран топик.
-- Не выдумывай факты, которых нет в сообщениях.
-- Верни только валидный JSON без markdown.
-Схема: {"topics":[{"title","summary","category","importance","source_message_numbers":[..]}]}`
-
-const matchConfirmSystemPrompt = `Ты аналитик, который отслеживает развитие сюжетов в новостном канале.
-
-Тебе дан сегодняшний топик и несколько похожих существующих сюжетов канала.
-Реши: топик — это продолжение одного из сюжетов или это НОВЫЙ сюжет?
-Не объединяй разные по сути сюжеты.
-Верни только валидный JSON без markdown: {"matched_id": <id или null>, "is_new": <true|false>, "reason": "коротко"}.`
-
-const deltaSystemPrompt = `Ты аналитик, который ведёт хронику сюжета в новостном канале.
-
-Дан сюжет (его текущее состояние), сегодняшние сообщения по нему и статистика-факты.
-Статистику не оспаривай и не выдумывай иное.
-Задача:
-1) "delta_summary" — кратко что нового именно сегодня (1-2 предложения; может быть пустым, если новизны нет);
-2) "state" — обновлённое состояние сюжета, до 600 символов, без воды и без номеров сообщений.
-Верни только валидный JSON без markdown: {"delta_summary":"...","state":"..."}.`
-
-const groupedDigestSystemPrompt = `Ты выпускающий редактор Telegram-дайджеста.
-
-Собери дайджест по сгруппированным сюжетам. Группы и порядок (заголовок группы выводи только если в ней есть сюжеты):
-🆕 Новое — сюжеты из группы new;
-🔺 Эскалация — сюжеты из группы escalation;
-▶️ Развитие — сюжеты из группы ongoing.
-Для каждого сюжета: жирный заголовок и 1-2 предложения сути из его delta_summary/state.
-Группу recurring_noise НЕ перечисляй по одному — сверни в одну строку в конце:
-"Фон без изменений: <рубрики/темы через запятую>".
-Пиши по-русски, только факты из входных данных, без номеров сообщений, итог < 3500 символов.
-Верни только готовый текст без markdown-ограждений.`
-
-const digestRenderSystemPrompt = `Ты выпускающий редактор Telegram-дайджеста.
-
-Твоя задача — написать финальную сводку по готовому JSON-плану топиков.
-
-Правила:
-- Пиши на русском языке.
-- Не добавляй факты, которых нет в JSON.
-- Сохраняй только 3-5 самых важных топиков.
-- Формат каждого топика: жирный номер и заголовок, затем 1-2 предложения сути.
-- Добавляй "Почему важно:" только если это реально помогает понять значение новости.
-- Не показывай номера исходных сообщений.
-- Итог должен быть короче 3500 символов.`
-
 type MLRepositoryInterface interface {
 	// стадия A: извлечение топиков с рубриками и номерами исходных сообщений
 	ExtractTopics(messages []MessageInput) ([]CandidateTopic, error)
 	// стадия B: эмбеддинги (text-search-doc / text-search-query, 256d)
 	EmbedDocuments(texts []string) ([][]float32, error)
 	EmbedQueries(texts []string) ([][]float32, error)
-	// стадия C: подтверждение матчинга в "серой зоне"
+	// стадия C: подтверждение матчинга в "серой зоне"; ErrMLBudgetExceeded, если
+	// месячный бюджет исчерпан и у стадии нет LLM_MATCH_BUDGET_MODEL (модель не вызывается)
 	ConfirmMatch(cand CandidateTopic, options []StorylineBrief) (matchedID int64, isNew bool, err error)
 	// стадия D: дельта + обновлённое состояние сюжета
 	WriteDelta(in DeltaInput) (newState string, deltaSummary string, err error)
 	// стадия F: рендер сгруппированного дайджеста
 	RenderDigest(groups DigestGroups) (string, error)
+	// дайджест за неделю/месяц по дугам сюжетов
+	RenderPeriodDigest(in PeriodDigestInput) (string, error)
+	// офлайн-оценка дневного дайджеста LLM-судьёй (scripts/digest_eval)
+	JudgeDigest(in DigestJudgeInput) (DigestJudgement, error)
+	// ответ на вопрос читателя строго по найденным материалам архива (/ask);
+	// ErrNoGroundedAnswer, если в материалах ответа нет
+	AnswerQuestion(in QuestionInput) (string, error)
 
 	// обратная совместимость на время миграции (использует scripts/historical_summary)
 	SummarizeMessages(messages []string) (string, error)
+
+	// ForDay - тот же репозиторий, вызовы которого учитываются в ml_calls за канал и день.
+	ForDay(channelID int64, date time.Time) MLRepositoryInterface
+	// версии промптов дневного конвейера (пишутся в summaries и storyline_observations)
+	PromptVersion() string
+	// копия с другими версиями промптов (имя -> версия) для сравнения
+	WithPromptVersions(versions map[string]string) (MLRepositoryInterface, error)
 }
 
 // CandidateTopic - топик дня, извлечённый из сообщений (стадия A).
@@ -149,6 +88,7 @@ type DeltaInput struct {
 	MedianCount      float64
 	MedianImportance float64
 	ChangeType       string
+	GapDays          int // для revived: сколько дней сюжет не появлялся
 	TodayMessages    []string
 }
 
@@ -159,82 +99,136 @@ type DigestItem struct {
 	State        string
 	Category     string
 	Importance   int
+	// Ref - метка ссылки на источник ("S1"): модель ставит [[S1]] после сюжета,
+	// сервис заменяет её ссылкой на SourceMessageID. Пусто - ссылки не будет.
+	Ref             string `json:",omitempty"`
+	SourceMessageID int64  `json:"-"`
+	SourceURL       string `json:"-"` // Meta.URL исходного поста: у записей лент ссылка не строится по id
 }
 
 // DigestGroups - сгруппированные сюжеты для финального рендера (стадия F).
 type DigestGroups struct {
 	New            []DigestItem
 	Escalation     []DigestItem
+	Revived        []DigestItem // dormant/closed сюжеты, вернувшиеся после паузы
 	Ongoing        []DigestItem // ongoing/deescalation с непустым delta_summary
 	RecurringNoise []string     // рубрики/темы фона
 }
 
+// PeriodDigestInput - сюжеты периода с их дугами для недельного/месячного дайджеста.
+type PeriodDigestInput struct {
+	Period     string // week | month
+	From       string // YYYY-MM-DD, включительно
+	To         string // YYYY-MM-DD, включительно
+	Storylines []PeriodStoryline
+}
+
+// PeriodStoryline - сюжет за период: итоговое состояние и дуга по дням.
+type PeriodStoryline struct {
+	Title         string
+	State         string
+	Category      string
+	Status        string
+	TotalMessages int
+	MaxImportance int
+	Arc           []ArcPoint
+}
+
+// ArcPoint - один день дуги сюжета.
+type ArcPoint struct {
+	Date         string // YYYY-MM-DD
+	ChangeType   string
+	DeltaSummary string
+}
+
 type chatCompletionParams struct {
 	MaxTokens   int
 	Temperature float64
 }
 
+// llmStage - провайдер, модель и параметры генерации одной стадии конвейера.
+type llmStage struct {
+	provider LLMProvider
+	model    string
+	// budgetModel - модель после исчерпания месячного бюджета (пусто - та же model)
+	budgetModel string
+	params      chatCompletionParams
+}
+
 type MLRepository struct {
-	httpClient    *http.Client
-	tokenProvider tokenProvider
-	baseURL       string
-	folderID      string
-	modelURI      string
-	embedDocURI   string
-	embedQueryURI string
-	extractPrompt string
-	renderPrompt  string
-	extractParams chatCompletionParams
-	renderParams  chatCompletionParams
+	stages map[string]llmStage
+	// системные промпты по имени (см. prompt.go, ml_prompts.go)
+	prompts    map[string]Prompt
+	promptRepo PromptRepositoryInterface
+
+	// кэш эмбеддингов (см. ml_embedding_cache.go); nil - без кэша
+	embedCache EmbeddingCacheRepositoryInterface
+
+	// учёт вызовов (см. ml_budget.go); usageRepo == nil - вызовы не записываются
+	usageRepo MLUsageRepositoryInterface
+	budget    *mlBudget
+	channelID int64
+	callDate  time.Time
 }
 
+// NewMLRepository собирает провайдеров для стадий конвейера по конфигурации
+// (config.LLMStageProvider / config.LLMStageModel). Провайдер одного типа
+// создаётся один раз и переиспользуется между стадиями.
 func NewMLRepository() (*MLRepository, error) {
 	ctx := context.Background()
 
-	folderID := os.Getenv("YANDEX_FOLDER_ID")
-	if folderID == "" {
-		return nil, fmt.Errorf("YANDEX_FOLDER_ID environment variable is required")
-	}
+	providers := make(map[string]LLMProvider)
+	stages := make(map[string]llmStage, len(config.LLMStages))
+	for _, stage := range config.LLMStages {
+		name := config.LLMStageProvider(stage)
+		provider, ok := providers[name]
+		if !ok {
+			var err error
+			provider, err = newLLMProvider(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create LLM provider for stage %s: %w", stage, err)
+			}
+			providers[name] = provider
+		}
 
-	tokenProvider, err := newYandexTokenProvider(ctx)
-	if err != nil {
-		return nil, err
-	}
+		model := config.LLMStageModel(stage)
+		if resolver, ok := provider.(modelResolver); ok {
+			model = resolver.resolveModel(stage, model)
+		}
+		if model == "" {
+			return nil, fmt.Errorf("LLM_%s_MODEL environment variable is required for provider %s", strings.ToUpper(stage), name)
+		}
 
-	baseURL := strings.TrimSpace(os.Getenv("YANDEX_OPENAI_BASE_URL"))
-	if baseURL == "" {
-		baseURL = defaultYandexOpenAIBaseURL
-	}
-	if !strings.HasSuffix(baseURL, "/") {
-		baseURL += "/"
+		budgetModel := config.LLMStageBudgetModel(stage)
+		if resolver, ok := provider.(modelResolver); ok && budgetModel != "" {
+			budgetModel = resolver.resolveModel(stage, budgetModel)
+		}
+
+		stages[stage] = llmStage{provider: provider, model: model, budgetModel: budgetModel, params: defaultStageParams(stage)}
 	}
 
-	modelURI := os.Getenv("YANDEX_MODEL_URI")
-	if modelURI == "" {
-		modelURI = fmt.Sprintf("gpt://%s/%s", folderID, defaultYandexModelName)
+	prompts, err := defaultPrompts()
+	if err != nil {
+		return nil, err
 	}
 
 	return &MLRepository{
-		httpClient:    &http.Client{Timeout: yandexRequestTimeout},
-		tokenProvider: tokenProvider,
-		baseURL:       baseURL,
-		folderID:      folderID,
-		modelURI:      modelURI,
-		embedDocURI:   config.EmbedDocURI(folderID),
-		embedQueryURI: config.EmbedQueryURI(folderID),
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{
-			MaxTokens:   defaultExtractMaxTokens,
-			Temperature: defaultExtractTemperature,
-		},
-		renderParams: chatCompletionParams{
-			MaxTokens:   defaultRenderMaxTokens,
-			Temperature: defaultRenderTemperature,
-		},
+		stages:  stages,
+		prompts: prompts,
 	}, nil
 }
 
+// defaultStageParams - аналитические стадии (извлечение, матчинг) работают с низкой
+// температурой, текстовые (дельта, рендер) - с высокой и меньшим лимитом токенов.
+func defaultStageParams(stage string) chatCompletionParams {
+	switch stage {
+	case config.LLMStageDelta, config.LLMStageRender:
+		return chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature}
+	default:
+		return chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature}
+	}
+}
+
 func cleanResponse(content string) string {
 	content = regexp.MustCompile("```[a-zA-Z]*\n").ReplaceAllString(content, "")
 	content = regexp.MustCompile("```").ReplaceAllString(content, "")
@@ -244,10 +238,10 @@ func cleanResponse(content string) string {
 }
 
 func (r *MLRepository) SummarizeMessages(messages []string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
 	defer cancel()
 
-	log.Infof("Creating topic extraction request for %d messages using Yandex AI Studio OpenAI-compatible API", len(messages))
+	log.Infof("Creating topic extraction request for %d messages using %s", len(messages), r.stages[config.LLMStageExtract].provider.Name())
 
 	topicPlan, err := r.extractTopicPlan(ctx, messages)
 	if err != nil {
@@ -265,71 +259,11 @@ func (r *MLRepository) SummarizeMessages(messages []string) (string, error) {
 		return "", err
 	}
 
-	log.Infof("Successfully received digest from Yandex AI Studio")
+	log.Infof("Successfully received digest from %s", r.stages[config.LLMStageRender].provider.Name())
 
 	return cleanResponse(result), nil
 }
 
-type tokenProvider interface {
-	Token(ctx context.Context) (string, error)
-}
-
-type staticTokenProvider struct {
-	token string
-}
-
-func (p staticTokenProvider) Token(context.Context) (string, error) {
-	return p.token, nil
-}
-
-type iamTokenProvider struct {
-	sdk *ycsdk.SDK
-}
-
-func (p *iamTokenProvider) Token(ctx context.Context) (string, error) {
-	token, err := p.sdk.CreateIAMToken(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get IAM token: %w", err)
-	}
-	return token.IamToken, nil
-}
-
-func newYandexTokenProvider(ctx context.Context) (tokenProvider, error) {
-	apiKey := os.Getenv("YANDEX_API_KEY")
-	if apiKey != "" {
-		return staticTokenProvider{token: apiKey}, nil
-	}
-
-	serviceAccountKeyPath := os.Getenv("YANDEX_SERVICE_ACCOUNT_KEY_PATH")
-	if serviceAccountKeyPath == "" {
-		return nil, fmt.Errorf("YANDEX_API_KEY or YANDEX_SERVICE_ACCOUNT_KEY_PATH environment variable is required")
-	}
-
-	keyData, err := os.ReadFile(serviceAccountKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read service account key: %w", err)
-	}
-
-	key, err := iamkey.ReadFromJSONBytes(keyData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse service account key: %w", err)
-	}
-
-	creds, err := ycsdk.ServiceAccountKey(key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create credentials: %w", err)
-	}
-
-	sdk, err := ycsdk.Build(ctx, ycsdk.Config{
-		Credentials: creds,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Yandex Cloud SDK: %w", err)
-	}
-
-	return &iamTokenProvider{sdk: sdk}, nil
-}
-
 type summaryTopicPlan struct {
 	Topics              []summaryTopic `json:"topics"`
 	NoiseMessageNumbers []int          `json:"noise_message_numbers,omitempty"`
@@ -343,45 +277,33 @@ type summaryTopic struct {
 	SourceMessageNumbers []int  `json:"source_message_numbers"`
 }
 
-func (r *MLRepository) newClient(token string) openai.Client {
-	return openai.NewClient(
-		option.WithAPIKey(token),
-		option.WithBaseURL(r.baseURL),
-		option.WithHeader("x-folder-id", r.folderID),
-		option.WithHTTPClient(r.httpClient),
-		option.WithRequestTimeout(yandexRequestTimeout),
-	)
-}
-
-func (r *MLRepository) createChatCompletion(ctx context.Context, params chatCompletionParams, systemPrompt, userPrompt string) (string, error) {
-	token, err := r.tokenProvider.Token(ctx)
-	if err != nil {
-		return "", err
-	}
-
-	client := r.newClient(token)
-
-	completion, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model: shared.ChatModel(r.modelURI),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
-		MaxTokens:   param.NewOpt(int64(params.MaxTokens)),
-		Temperature: param.NewOpt(params.Temperature),
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to call Yandex AI Studio chat completions: %w", err)
+// createChatCompletion вызывает провайдера стадии; schema (может быть nil) включает
+// structured output у провайдеров, которые его поддерживают.
+func (r *MLRepository) createChatCompletion(ctx context.Context, stage, systemPrompt, userPrompt string, schema *JSONSchema) (string, error) {
+	s, ok := r.stages[stage]
+	if !ok {
+		return "", fmt.Errorf("LLM stage %s is not configured", stage)
 	}
-	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
-		return "", fmt.Errorf("received empty chat completion response")
+	model := s.model
+	if s.budgetModel != "" && r.budget.exceeded() {
+		model = s.budgetModel
 	}
 
-	return completion.Choices[0].Message.Content, nil
+	start := time.Now()
+	resp, err := s.provider.ChatCompletion(ctx, ChatRequest{
+		Model:        model,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		MaxTokens:    s.params.MaxTokens,
+		Temperature:  s.params.Temperature,
+		Schema:       schema,
+	})
+	r.recordCall(stage, s.provider.Name(), model, resp.Usage, time.Since(start), err)
+	return resp.Content, err
 }
 
 func (r *MLRepository) extractTopicPlan(ctx context.Context, messages []string) (*summaryTopicPlan, error) {
-	rawPlan, err := r.createChatCompletion(ctx, r.extractParams, r.extractPrompt, buildTopicExtractionPrompt(messages))
+	rawPlan, err := r.createChatCompletion(ctx, config.LLMStageExtract, r.prompt(PromptLegacyExtract), buildTopicExtractionPrompt(messages), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract news topics: %w", err)
 	}
@@ -400,7 +322,7 @@ func (r *MLRepository) renderDigest(ctx context.Context, topicPlan *summaryTopic
 		return "", err
 	}
 
-	return r.createChatCompletion(ctx, r.renderParams, r.renderPrompt, prompt)
+	return r.createChatCompletion(ctx, config.LLMStageRender, r.prompt(PromptLegacyRender), prompt, nil)
 }
 
 func buildTopicExtractionPrompt(messages []string) string {
@@ -500,22 +422,26 @@ func (r *MLRepository) ExtractTopics(messages []MessageInput) ([]CandidateTopic,
 		return nil, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
 	defer cancel()
 
-	texts := make([]string, len(messages))
-	for i, m := range messages {
-		texts[i] = m.Text
-	}
-
-	raw, err := r.createChatCompletion(ctx, r.extractParams, topicsExtractionSystemPrompt, buildTopicsExtractionPrompt(texts))
-	if err != nil {
+	var plan candidateTopicsPlan
+	err := r.completeJSON(ctx, config.LLMStageExtract, r.prompt(PromptExtract), buildTopicsExtractionPrompt(messages), topicsSchema(len(messages)), func(content string) error {
+		plan = candidateTopicsPlan{}
+		if err := json.Unmarshal([]byte(content), &plan); err != nil {
+			return err
+		}
+		return plan.validate(len(messages))
+	})
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		// Модель так и не исправила ответ: берём разобранные топики, нормализуя нестрого.
+		log.Warnf("Accepting topics plan with validation errors: %v", err)
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to extract topics: %w", err)
 	}
-
-	var plan candidateTopicsPlan
-	if err := json.Unmarshal([]byte(cleanResponse(raw)), &plan); err != nil {
-		return nil, fmt.Errorf("failed to parse topics plan: %w", err)
+	if len(plan.Topics) > maxExtractedTopics {
+		plan.Topics = plan.Topics[:maxExtractedTopics]
 	}
 
 	candidates := make([]CandidateTopic, 0, len(plan.Topics))
@@ -532,10 +458,14 @@ func (r *MLRepository) ExtractTopics(messages []MessageInput) ([]CandidateTopic,
 		if importance > 5 {
 			importance = 5
 		}
+		category := strings.TrimSpace(t.Category)
+		if !config.IsCategory(category) {
+			category = "другое"
+		}
 		candidates = append(candidates, CandidateTopic{
 			Title:                title,
 			Summary:              summary,
-			Category:             strings.TrimSpace(t.Category),
+			Category:             category,
 			Importance:           importance,
 			SourceMessageNumbers: validMessageNumbers(t.SourceMessageNumbers, len(messages)),
 		})
@@ -543,68 +473,140 @@ func (r *MLRepository) ExtractTopics(messages []MessageInput) ([]CandidateTopic,
 	return candidates, nil
 }
 
-func buildTopicsExtractionPrompt(messages []string) string {
+func buildTopicsExtractionPrompt(messages []MessageInput) string {
+	medianViews := medianMessageViews(messages)
+
 	var b strings.Builder
 	b.WriteString("Проанализируй сообщения канала и верни JSON строго по схеме:\n")
 	b.WriteString(`{"topics":[{"title":"короткий заголовок","summary":"фактическая суть","category":"экономика","importance":5,"source_message_numbers":[1,2]}]}`)
 	b.WriteString("\n\nЕсли значимых топиков нет, верни {\"topics\":[]}.\n")
 	b.WriteString("Сообщения:\n")
 	for i, msg := range messages {
-		b.WriteString(fmt.Sprintf("\n[#%d]\n%s\n", i+1, strings.TrimSpace(msg)))
+		b.WriteString(fmt.Sprintf("\n[#%d]\n", i+1))
+		if signals := messageSignals(msg.Meta, medianViews); signals != "" {
+			b.WriteString("(" + signals + ")\n")
+		}
+		b.WriteString(strings.TrimSpace(msg.Text) + "\n")
 	}
 	return b.String()
 }
 
-// EmbedDocuments - эмбеддинги документов (состояний сюжетов) моделью text-search-doc.
+// medianMessageViews - медиана просмотров постов дня; 0, если метрик нет.
+func medianMessageViews(messages []MessageInput) int {
+	var views []int
+	for _, m := range messages {
+		if m.Meta.Views > 0 {
+			views = append(views, m.Meta.Views)
+		}
+	}
+	if len(views) == 0 {
+		return 0
+	}
+	sort.Ints(views)
+	return views[len(views)/2]
+}
+
+// messageSignals - строка метрик и пометок поста для стадии A. Охват даётся
+// относительно медианы дня: абсолютные просмотры у каналов несопоставимы.
+func messageSignals(meta MessageMeta, medianViews int) string {
+	var parts []string
+	switch {
+	case meta.MediaCount > 1:
+		parts = append(parts, fmt.Sprintf("альбом: %d медиа", meta.MediaCount))
+	case meta.Media != "":
+		parts = append(parts, "медиа: "+meta.Media)
+	}
+	if meta.ForwardFrom != nil {
+		if meta.ForwardFrom.FromName != "" {
+			parts = append(parts, "переслано от «"+meta.ForwardFrom.FromName+"»")
+		} else {
+			parts = append(parts, "переслано")
+		}
+	}
+	if meta.Views > 0 && medianViews > 0 {
+		parts = append(parts, fmt.Sprintf("охват ×%.1f к медиане дня", float64(meta.Views)/float64(medianViews)))
+	}
+	if meta.Reactions > 0 {
+		parts = append(parts, fmt.Sprintf("реакций: %d", meta.Reactions))
+	}
+	if meta.Forwards > 0 {
+		parts = append(parts, fmt.Sprintf("репостов: %d", meta.Forwards))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// EmbedDocuments - эмбеддинги документов (состояний сюжетов), стадия embed_doc.
 func (r *MLRepository) EmbedDocuments(texts []string) ([][]float32, error) {
-	return r.embed(r.embedDocURI, texts)
+	return r.embed(config.LLMStageEmbedDoc, texts)
 }
 
-// EmbedQueries - эмбеддинги запросов (топиков-кандидатов) моделью text-search-query.
+// EmbedQueries - эмбеддинги запросов (топиков-кандидатов), стадия embed_query.
 func (r *MLRepository) EmbedQueries(texts []string) ([][]float32, error) {
-	return r.embed(r.embedQueryURI, texts)
+	return r.embed(config.LLMStageEmbedQuery, texts)
 }
 
-func (r *MLRepository) embed(modelURI string, texts []string) ([][]float32, error) {
+// embed возвращает эмбеддинги текстов стадии. Если подключён кэш (WithEmbeddingCache),
+// провайдеру уходят только тексты, которых нет в кэше, без повторов внутри пачки.
+func (r *MLRepository) embed(stage string, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
+	s, ok := r.stages[stage]
+	if !ok {
+		return nil, fmt.Errorf("LLM stage %s is not configured", stage)
+	}
+
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		hashes[i] = EmbeddingTextHash(text)
+	}
+	cached := r.cachedEmbeddings(s.model, hashes)
+
+	result := make([][]float32, len(texts))
+	var missTexts, missHashes []string
+	seen := make(map[string]bool)
+	for i, h := range hashes {
+		if vec, ok := cached[h]; ok {
+			result[i] = vec
+			continue
+		}
+		if !seen[h] {
+			seen[h] = true
+			missTexts = append(missTexts, texts[i])
+			missHashes = append(missHashes, h)
+		}
+	}
+	if len(missTexts) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
 	defer cancel()
 
-	token, err := r.tokenProvider.Token(ctx)
+	start := time.Now()
+	embeddings, usage, err := s.provider.Embed(ctx, s.model, missTexts)
+	r.recordCall(stage, s.provider.Name(), s.model, usage, time.Since(start), err)
 	if err != nil {
 		return nil, err
 	}
-	client := r.newClient(token)
+	if len(embeddings) != len(missTexts) {
+		return nil, fmt.Errorf("received %d embeddings for %d texts", len(embeddings), len(missTexts))
+	}
 
-	// Yandex эмбеддинги принимают по одному тексту на запрос — эмбеддим поштучно.
-	result := make([][]float32, 0, len(texts))
-	for _, text := range texts {
-		resp, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-			Model: openai.EmbeddingModel(modelURI),
-			Input: openai.EmbeddingNewParamsInputUnion{OfString: param.NewOpt(text)},
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create embedding: %w", err)
-		}
-		if len(resp.Data) == 0 {
-			return nil, fmt.Errorf("received empty embedding response")
+	fresh := make(map[string][]float32, len(missHashes))
+	for i, h := range missHashes {
+		fresh[h] = embeddings[i]
+	}
+	r.cacheEmbeddings(s.model, fresh)
+	for i, h := range hashes {
+		if result[i] == nil {
+			result[i] = fresh[h]
 		}
-		result = append(result, float64sToFloat32s(resp.Data[0].Embedding))
 	}
 	return result, nil
 }
 
-func float64sToFloat32s(in []float64) []float32 {
-	out := make([]float32, len(in))
-	for i, v := range in {
-		out[i] = float32(v)
-	}
-	return out
-}
-
 type confirmMatchResponse struct {
 	MatchedID *int64 `json:"matched_id"`
 	IsNew     bool   `json:"is_new"`
@@ -613,17 +615,26 @@ type confirmMatchResponse struct {
 
 // ConfirmMatch - стадия C: LLM решает, продолжение это одного из сюжетов или новый сюжет.
 func (r *MLRepository) ConfirmMatch(cand CandidateTopic, options []StorylineBrief) (int64, bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
-	defer cancel()
-
-	raw, err := r.createChatCompletion(ctx, r.extractParams, matchConfirmSystemPrompt, buildMatchConfirmPrompt(cand, options))
-	if err != nil {
-		return 0, false, fmt.Errorf("failed to confirm match: %w", err)
+	if r.stages[config.LLMStageMatch].budgetModel == "" && r.budget.exceeded() {
+		return 0, false, ErrMLBudgetExceeded
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
+	defer cancel()
+
 	var resp confirmMatchResponse
-	if err := json.Unmarshal([]byte(cleanResponse(raw)), &resp); err != nil {
-		return 0, false, fmt.Errorf("failed to parse match confirmation: %w", err)
+	err := r.completeJSON(ctx, config.LLMStageMatch, r.prompt(PromptMatch), buildMatchConfirmPrompt(cand, options), matchConfirmSchema(options), func(content string) error {
+		resp = confirmMatchResponse{}
+		if err := json.Unmarshal([]byte(content), &resp); err != nil {
+			return err
+		}
+		return resp.validate(options)
+	})
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		log.Warnf("Accepting match confirmation with validation errors: %v", err)
+	} else if err != nil {
+		return 0, false, fmt.Errorf("failed to confirm match: %w", err)
 	}
 
 	if resp.IsNew || resp.MatchedID == nil {
@@ -673,21 +684,28 @@ type writeDeltaResponse struct {
 
 // WriteDelta - стадия D: дельта дня и обновлённое состояние сюжета.
 func (r *MLRepository) WriteDelta(in DeltaInput) (string, string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
 	defer cancel()
 
-	raw, err := r.createChatCompletion(ctx, r.renderParams, deltaSystemPrompt, buildDeltaPrompt(in))
-	if err != nil {
-		return "", "", fmt.Errorf("failed to write delta: %w", err)
-	}
-
 	var resp writeDeltaResponse
-	if err := json.Unmarshal([]byte(cleanResponse(raw)), &resp); err != nil {
-		return "", "", fmt.Errorf("failed to parse delta response: %w", err)
+	err := r.completeJSON(ctx, config.LLMStageDelta, r.prompt(PromptDelta), buildDeltaPrompt(in), deltaSchema(), func(content string) error {
+		resp = writeDeltaResponse{}
+		if err := json.Unmarshal([]byte(content), &resp); err != nil {
+			return err
+		}
+		return resp.validate()
+	})
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		log.Warnf("Accepting storyline delta with validation errors: %v", err)
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to write delta: %w", err)
 	}
 
+	// Невалидное состояние (пустое или длиннее config.StorylineStateMaxRunes)
+	// не сохраняется: сюжет остаётся с прежним, дельта дня принимается.
 	state := strings.TrimSpace(resp.State)
-	if state == "" {
+	if state == "" || len([]rune(state)) > config.StorylineStateMaxRunes {
 		state = strings.TrimSpace(in.CurrentState)
 	}
 	return state, strings.TrimSpace(resp.DeltaSummary), nil
@@ -701,6 +719,9 @@ func buildDeltaPrompt(in DeltaInput) string {
 	b.WriteString(fmt.Sprintf("- встречался %d из %d дней окна\n", in.DaysSeen, in.WindowDays))
 	b.WriteString(fmt.Sprintf("- медиана %.1f сообщений/день, сегодня %d\n", in.MedianCount, in.TodayCount))
 	b.WriteString(fmt.Sprintf("- медианная важность %.1f\n", in.MedianImportance))
+	if in.GapDays > 0 {
+		b.WriteString(fmt.Sprintf("- сюжет возвращается после паузы в %d дн.\n", in.GapDays))
+	}
 	b.WriteString(fmt.Sprintf("- предварительная метка изменения: %s\n\n", in.ChangeType))
 	b.WriteString("Сегодняшние сообщения по сюжету:\n")
 	for i, msg := range in.TodayMessages {
@@ -711,11 +732,12 @@ func buildDeltaPrompt(in DeltaInput) string {
 
 // RenderDigest - стадия F: финальный сгруппированный дайджест.
 func (r *MLRepository) RenderDigest(groups DigestGroups) (string, error) {
-	if len(groups.New) == 0 && len(groups.Escalation) == 0 && len(groups.Ongoing) == 0 && len(groups.RecurringNoise) == 0 {
+	if len(groups.New) == 0 && len(groups.Escalation) == 0 && len(groups.Revived) == 0 &&
+		len(groups.Ongoing) == 0 && len(groups.RecurringNoise) == 0 {
 		return "За последние сутки значимых новостей не найдено.", nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), yandexRequestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
 	defer cancel()
 
 	prompt, err := buildGroupedDigestPrompt(groups)
@@ -723,13 +745,34 @@ func (r *MLRepository) RenderDigest(groups DigestGroups) (string, error) {
 		return "", err
 	}
 
-	result, err := r.createChatCompletion(ctx, r.renderParams, groupedDigestSystemPrompt, prompt)
+	result, err := r.createChatCompletion(ctx, config.LLMStageRender, r.prompt(PromptRender), prompt, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to render digest: %w", err)
 	}
 	return cleanResponse(result), nil
 }
 
+func (r *MLRepository) RenderPeriodDigest(in PeriodDigestInput) (string, error) {
+	if len(in.Storylines) == 0 {
+		return "За период значимых сюжетов не найдено.", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
+	defer cancel()
+
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal period digest input: %w", err)
+	}
+	prompt := "Собери дайджест за период по этим сюжетам:\n\n" + string(data)
+
+	result, err := r.createChatCompletion(ctx, config.LLMStageRender, r.prompt(PromptPeriodRender), prompt, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to render period digest: %w", err)
+	}
+	return cleanResponse(result), nil
+}
+
 func buildGroupedDigestPrompt(groups DigestGroups) (string, error) {
 	data, err := json.MarshalIndent(groups, "", "  ")
 	if err != nil {
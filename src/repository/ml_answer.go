@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+)
+
+// noAnswerMarker - ответ модели, когда в материалах нет ответа (см. prompts/answer.*.tmpl).
+const noAnswerMarker = "НЕТ_ОТВЕТА"
+
+// ErrNoGroundedAnswer - модель не нашла ответа в переданных материалах.
+var ErrNoGroundedAnswer = errors.New("no grounded answer in retrieved news")
+
+// QuestionInput - вопрос читателя и найденные по нему материалы архива.
+type QuestionInput struct {
+	Question   string
+	From       string // YYYY-MM-DD, включительно
+	To         string // YYYY-MM-DD, включительно
+	Storylines []PeriodStoryline
+	Messages   []QuestionMessage
+}
+
+// QuestionMessage - сообщение канала с меткой для цитирования ([[S1]]).
+type QuestionMessage struct {
+	Ref     string
+	Channel string
+	Date    string // YYYY-MM-DD HH:MM UTC
+	Text    string
+}
+
+// AnswerQuestion отвечает на вопрос по сюжетам и сообщениям; метки [[S1]] в ответе
+// сервис заменяет ссылками. Ответ пишет модель стадии render.
+func (r *MLRepository) AnswerQuestion(in QuestionInput) (string, error) {
+	if len(in.Storylines) == 0 && len(in.Messages) == 0 {
+		return "", ErrNoGroundedAnswer
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
+	defer cancel()
+
+	result, err := r.createChatCompletion(ctx, config.LLMStageRender, r.prompt(PromptAnswer), buildQuestionPrompt(in), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to answer question: %w", err)
+	}
+	answer := cleanResponse(result)
+	if answer == "" || strings.Contains(answer, noAnswerMarker) {
+		return "", ErrNoGroundedAnswer
+	}
+	return answer, nil
+}
+
+func buildQuestionPrompt(in QuestionInput) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Вопрос: %s\nПериод: %s — %s\n", strings.TrimSpace(in.Question), in.From, in.To))
+
+	if len(in.Storylines) > 0 {
+		b.WriteString("\nСюжеты:\n")
+		for i, s := range in.Storylines {
+			b.WriteString(fmt.Sprintf("\n%d. %s (%s, %s)\n", i+1, s.Title, s.Category, s.Status))
+			if s.State != "" {
+				b.WriteString("Состояние: " + s.State + "\n")
+			}
+			for _, p := range s.Arc {
+				b.WriteString(fmt.Sprintf("- %s %s", p.Date, p.ChangeType))
+				if p.DeltaSummary != "" {
+					b.WriteString(": " + p.DeltaSummary)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if len(in.Messages) > 0 {
+		b.WriteString("\nСообщения:\n")
+		for _, m := range in.Messages {
+			b.WriteString(fmt.Sprintf("\n[%s] %s, %s\n%s\n", m.Ref, m.Channel, m.Date, strings.TrimSpace(m.Text)))
+		}
+	}
+	return b.String()
+}
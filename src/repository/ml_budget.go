@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrMLBudgetExceeded - месячный бюджет на ML исчерпан, вызов стадии пропущен.
+var ErrMLBudgetExceeded = errors.New("monthly ML budget exceeded")
+
+// WithUsage включает запись вызовов в ml_calls и месячный бюджет config.MLMonthlyBudget.
+// Без него (скрипты без базы) вызовы не учитываются и бюджет не ограничен.
+func (r *MLRepository) WithUsage(usageRepo MLUsageRepositoryInterface) *MLRepository {
+	r.usageRepo = usageRepo
+	r.budget = newMLBudget(config.MLMonthlyBudget(), usageRepo)
+	return r
+}
+
+// ForDay возвращает копию репозитория, вызовы которой записываются за канал и день.
+// Провайдеры, usageRepo и бюджет общие с исходным репозиторием.
+func (r *MLRepository) ForDay(channelID int64, date time.Time) MLRepositoryInterface {
+	scoped := *r
+	scoped.channelID = channelID
+	scoped.callDate = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return &scoped
+}
+
+// recordCall пишет вызов в ml_calls. Ошибка записи только логируется: учёт
+// не должен ронять построение дайджеста.
+func (r *MLRepository) recordCall(stage, provider, model string, usage Usage, latency time.Duration, callErr error) {
+	if r.usageRepo == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	callDate := r.callDate
+	if callDate.IsZero() {
+		callDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	cost := float64(usage.PromptTokens+usage.CompletionTokens) / 1000 * config.ModelPricePer1K(model)
+	r.budget.add(now, cost)
+
+	err := r.usageRepo.SaveCall(&MLCall{
+		Stage:            stage,
+		ChannelID:        r.channelID,
+		CallDate:         callDate,
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             cost,
+		Latency:          latency,
+		Success:          callErr == nil,
+		CreatedAt:        now,
+	})
+	if err != nil {
+		log.Warnf("Failed to record ML call for stage %s: %v", stage, err)
+	}
+}
+
+// mlBudget - потраченное за текущий месяц. Сумма перечитывается из ml_calls раз в
+// config.MLBudgetRefreshPeriod секунд (её пишут и другие процессы, например бэкфилл),
+// между перечитываниями к ней прибавляются собственные вызовы.
+type mlBudget struct {
+	limit     float64
+	usageRepo MLUsageRepositoryInterface
+	now       func() time.Time
+
+	mu          sync.Mutex
+	month       time.Time // начало месяца, к которому относится spent
+	spent       float64
+	refreshedAt time.Time
+}
+
+func newMLBudget(limit float64, usageRepo MLUsageRepositoryInterface) *mlBudget {
+	return &mlBudget{limit: limit, usageRepo: usageRepo, now: time.Now}
+}
+
+// exceeded сообщает, исчерпан ли бюджет. nil-бюджет и нулевой лимит - без ограничения.
+func (b *mlBudget) exceeded() bool {
+	if b == nil || b.limit <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !month.Equal(b.month) || now.Sub(b.refreshedAt) >= config.MLBudgetRefreshPeriod*time.Second {
+		spent, err := b.usageRepo.TotalCost(month, month.AddDate(0, 1, 0))
+		if err != nil {
+			log.Warnf("Failed to load ML spending for %s: %v", month.Format("2006-01"), err)
+			if !month.Equal(b.month) {
+				b.spent = 0
+			}
+		} else {
+			b.spent = spent
+		}
+		b.month = month
+		b.refreshedAt = now
+		if b.spent >= b.limit {
+			log.Warnf("Monthly ML budget exceeded: spent %.2f of %.2f", b.spent, b.limit)
+		}
+	}
+	return b.spent >= b.limit
+}
+
+// add учитывает только что записанный вызов до следующего перечитывания.
+func (b *mlBudget) add(at time.Time, cost float64) {
+	if b == nil || b.limit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.month.Year() == at.Year() && b.month.Month() == at.Month() {
+		b.spent += cost
+	}
+}
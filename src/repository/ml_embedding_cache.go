@@ -0,0 +1,36 @@
+package repository
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// WithEmbeddingCache подключает общий кэш эмбеддингов к MLRepository.embed.
+// Ошибки кэша только логируются: без него векторы просто запрашиваются у провайдера.
+func (r *MLRepository) WithEmbeddingCache(cache EmbeddingCacheRepositoryInterface) *MLRepository {
+	r.embedCache = cache
+	return r
+}
+
+func (r *MLRepository) cachedEmbeddings(model string, hashes []string) map[string][]float32 {
+	if r.embedCache == nil {
+		return nil
+	}
+	cached, err := r.embedCache.GetEmbeddings(model, hashes)
+	if err != nil {
+		log.Warnf("Failed to read embedding cache for %s: %v", model, err)
+		return nil
+	}
+	if len(cached) > 0 {
+		log.Debugf("Embedding cache: %d of %d texts found for %s", len(cached), len(hashes), model)
+	}
+	return cached
+}
+
+func (r *MLRepository) cacheEmbeddings(model string, embeddings map[string][]float32) {
+	if r.embedCache == nil {
+		return
+	}
+	if err := r.embedCache.SaveEmbeddings(model, embeddings); err != nil {
+		log.Warnf("Failed to save %d embeddings to cache for %s: %v", len(embeddings), model, err)
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DigestJudgeInput - дневной дайджест и то, по чему он собран, для офлайн-оценки.
+type DigestJudgeInput struct {
+	Digest   string
+	Sources  []string // тексты сообщений из source_message_ids наблюдений дня
+	KeyItems []string // главные сюжеты дня ("название: что нового"), которые дайджест должен покрыть
+}
+
+// DigestJudgement - вердикт судьи по дайджесту.
+type DigestJudgement struct {
+	Claims       int      // фактических утверждений в дайджесте
+	Unsupported  []string // утверждения, которых нет в источниках
+	CoveredItems []int    // номера KeyItems (1-based), отражённые в дайджесте
+}
+
+type digestJudgeResponse struct {
+	Claims       int      `json:"claims"`
+	Unsupported  []string `json:"unsupported"`
+	CoveredItems []int    `json:"covered_items"`
+}
+
+// JudgeDigest сверяет дайджест с исходными сообщениями и ключевыми сюжетами дня.
+// Судья работает на модели стадии extract: она и так читает все сообщения дня.
+func (r *MLRepository) JudgeDigest(in DigestJudgeInput) (DigestJudgement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), llmRequestTimeout)
+	defer cancel()
+
+	var resp digestJudgeResponse
+	err := r.completeJSON(ctx, config.LLMStageExtract, r.prompt(PromptDigestJudge), buildDigestJudgePrompt(in), digestJudgeSchema(len(in.KeyItems)), func(content string) error {
+		resp = digestJudgeResponse{}
+		if err := json.Unmarshal([]byte(content), &resp); err != nil {
+			return err
+		}
+		return resp.validate(len(in.KeyItems))
+	})
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		log.Warnf("Accepting digest judgement with validation errors: %v", err)
+	} else if err != nil {
+		return DigestJudgement{}, fmt.Errorf("failed to judge digest: %w", err)
+	}
+
+	judgement := DigestJudgement{Claims: max(resp.Claims, len(resp.Unsupported))}
+	for _, claim := range resp.Unsupported {
+		if claim = strings.TrimSpace(claim); claim != "" {
+			judgement.Unsupported = append(judgement.Unsupported, claim)
+		}
+	}
+	seen := make(map[int]bool, len(resp.CoveredItems))
+	for _, n := range resp.CoveredItems {
+		if n >= 1 && n <= len(in.KeyItems) && !seen[n] {
+			seen[n] = true
+			judgement.CoveredItems = append(judgement.CoveredItems, n)
+		}
+	}
+	return judgement, nil
+}
+
+func buildDigestJudgePrompt(in DigestJudgeInput) string {
+	var b strings.Builder
+	b.WriteString("Исходные сообщения:\n")
+	for i, msg := range in.Sources {
+		b.WriteString(fmt.Sprintf("\n[#%d]\n%s\n", i+1, strings.TrimSpace(msg)))
+	}
+	b.WriteString("\nКлючевые сюжеты дня:\n")
+	for i, item := range in.KeyItems {
+		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, strings.TrimSpace(item)))
+	}
+	b.WriteString("\nДайджест:\n")
+	b.WriteString(strings.TrimSpace(in.Digest))
+	return b.String()
+}
+
+func (resp digestJudgeResponse) validate(itemCount int) error {
+	var v validator
+	if resp.Claims < 0 {
+		v.addf("claims", "must be non-negative, got %d", resp.Claims)
+	}
+	if len(resp.Unsupported) > resp.Claims {
+		v.addf("unsupported", "has %d items but claims is %d", len(resp.Unsupported), resp.Claims)
+	}
+	for i, n := range resp.CoveredItems {
+		if n < 1 || n > itemCount {
+			v.addf(fmt.Sprintf("covered_items[%d]", i), "must be between 1 and %d, got %d", itemCount, n)
+		}
+	}
+	return v.err()
+}
+
+func digestJudgeSchema(itemCount int) *JSONSchema {
+	return &JSONSchema{
+		Name: "digest_judgement",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []string{"claims", "unsupported", "covered_items"},
+			"properties": map[string]any{
+				"claims":      map[string]any{"type": "integer", "minimum": 0},
+				"unsupported": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"covered_items": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "integer", "minimum": 1, "maximum": max(itemCount, 1)},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultPrompts - последние встроенные версии всех промптов реестра.
+func defaultPrompts() (map[string]Prompt, error) {
+	prompts := make(map[string]Prompt, len(PromptNames))
+	for _, name := range PromptNames {
+		versions := BuiltinPromptVersions(name)
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("builtin prompt %s is missing", name)
+		}
+		p, _, err := builtinPrompt(name, versions[len(versions)-1])
+		if err != nil {
+			return nil, err
+		}
+		prompts[name] = p
+	}
+	return prompts, nil
+}
+
+// LoadPrompts подключает версии промптов из базы: активные заменяют встроенные.
+// Вызывается при старте, после смены активной версии бота нужно перезапустить.
+func (r *MLRepository) LoadPrompts(store PromptRepositoryInterface) error {
+	versions, err := store.ListPromptVersions()
+	if err != nil {
+		return fmt.Errorf("failed to load prompt versions: %w", err)
+	}
+	for _, v := range versions {
+		if !v.Active {
+			continue
+		}
+		if !slices.Contains(PromptNames, v.Name) {
+			log.Warnf("Skipping unknown prompt %s@%s from database", v.Name, v.Version)
+			continue
+		}
+		p, err := RenderPrompt(v.Name, v.Version, v.Template)
+		if err != nil {
+			return err
+		}
+		r.prompts[v.Name] = p
+		log.Infof("Using prompt %s@%s from database", v.Name, v.Version)
+	}
+	r.promptRepo = store
+	return nil
+}
+
+// WithPromptVersions возвращает копию репозитория, в которой промпты из versions
+// (имя -> версия) заменены указанными версиями: сначала ищутся встроенные, затем в базе.
+func (r *MLRepository) WithPromptVersions(versions map[string]string) (MLRepositoryInterface, error) {
+	scoped := *r
+	scoped.prompts = maps.Clone(r.prompts)
+	for name, version := range versions {
+		p, err := r.resolvePrompt(name, version)
+		if err != nil {
+			return nil, err
+		}
+		scoped.prompts[name] = p
+	}
+	return &scoped, nil
+}
+
+func (r *MLRepository) resolvePrompt(name, version string) (Prompt, error) {
+	if !slices.Contains(PromptNames, name) {
+		return Prompt{}, fmt.Errorf("unknown prompt %q", name)
+	}
+	p, ok, err := builtinPrompt(name, version)
+	if ok || err != nil {
+		return p, err
+	}
+	if r.promptRepo != nil {
+		v, err := r.promptRepo.GetPromptVersion(name, version)
+		if err != nil {
+			return Prompt{}, fmt.Errorf("failed to get prompt %s@%s: %w", name, version, err)
+		}
+		if v != nil {
+			return RenderPrompt(v.Name, v.Version, v.Template)
+		}
+	}
+	return Prompt{}, fmt.Errorf("prompt %s@%s not found", name, version)
+}
+
+// PromptVersion - версии промптов дневного конвейера, например "extract@v1,match@v1,delta@v2,render@v1".
+func (r *MLRepository) PromptVersion() string {
+	return FormatPromptVersions(r.prompts, DailyPrompts)
+}
+
+func (r *MLRepository) prompt(name string) string {
+	return r.prompts[name].Text
+}
@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Ra1ze505/goNewsBot/src/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -29,15 +32,68 @@ func TestNewMLRepositoryUsesOpenAICompatibleDefaults(t *testing.T) {
 	t.Setenv("YANDEX_ASSISTANT_ID", "")
 	t.Setenv("YANDEX_OPENAI_BASE_URL", "")
 	t.Setenv("YANDEX_MODEL_URI", "")
+	t.Setenv("YANDEX_EMBED_DOC_URI", "")
+	t.Setenv("YANDEX_EMBED_QUERY_URI", "")
+	t.Setenv("LLM_PROVIDER", "")
+	for _, stage := range config.LLMStages {
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_PROVIDER", "")
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_MODEL", "")
+	}
+
+	repo, err := NewMLRepository()
+
+	require.NoError(t, err)
+	provider, ok := repo.stages[config.LLMStageExtract].provider.(*yandexProvider)
+	require.True(t, ok)
+	assert.Equal(t, defaultYandexOpenAIBaseURL, provider.baseURL)
+	assert.Equal(t, "test-folder", provider.folderID)
+	assert.Equal(t, "gpt://test-folder/yandexgpt/latest", repo.stages[config.LLMStageExtract].model)
+	assert.Equal(t, "gpt://test-folder/yandexgpt/latest", repo.stages[config.LLMStageRender].model)
+	assert.Equal(t, "emb://test-folder/text-search-doc/latest", repo.stages[config.LLMStageEmbedDoc].model)
+	assert.Equal(t, "emb://test-folder/text-search-query/latest", repo.stages[config.LLMStageEmbedQuery].model)
+	assert.Same(t, repo.stages[config.LLMStageExtract].provider, repo.stages[config.LLMStageRender].provider)
+	assert.NotEmpty(t, repo.prompt(PromptLegacyExtract))
+	assert.NotEmpty(t, repo.prompt(PromptLegacyRender))
+	assert.Equal(t, "extract@v2,match@v1,delta@v1,render@v2", repo.PromptVersion())
+}
+
+func TestNewMLRepositoryPerStageProviders(t *testing.T) {
+	t.Setenv("YANDEX_FOLDER_ID", "test-folder")
+	t.Setenv("YANDEX_API_KEY", "test-api-key")
+	t.Setenv("YANDEX_MODEL_URI", "")
+	t.Setenv("LLM_PROVIDER", "")
+	for _, stage := range config.LLMStages {
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_PROVIDER", "")
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_MODEL", "")
+	}
+	t.Setenv("LLM_EXTRACT_MODEL", "yandexgpt-lite/latest")
+	t.Setenv("LLM_RENDER_PROVIDER", "ollama")
+	t.Setenv("LLM_RENDER_MODEL", "qwen2.5:14b")
+	t.Setenv("OLLAMA_BASE_URL", "http://ollama:11434")
 
 	repo, err := NewMLRepository()
 
 	require.NoError(t, err)
-	assert.Equal(t, defaultYandexOpenAIBaseURL, repo.baseURL)
-	assert.Equal(t, "test-folder", repo.folderID)
-	assert.Equal(t, "gpt://test-folder/yandexgpt/latest", repo.modelURI)
-	assert.NotEmpty(t, repo.extractPrompt)
-	assert.NotEmpty(t, repo.renderPrompt)
+	assert.Equal(t, config.LLMProviderYandex, repo.stages[config.LLMStageExtract].provider.Name())
+	assert.Equal(t, "gpt://test-folder/yandexgpt-lite/latest", repo.stages[config.LLMStageExtract].model)
+	assert.Equal(t, config.LLMProviderOllama, repo.stages[config.LLMStageRender].provider.Name())
+	assert.Equal(t, "qwen2.5:14b", repo.stages[config.LLMStageRender].model)
+	assert.Equal(t, "http://ollama:11434/", repo.stages[config.LLMStageRender].provider.(*ollamaProvider).baseURL)
+	assert.Equal(t, config.LLMProviderYandex, repo.stages[config.LLMStageDelta].provider.Name())
+}
+
+func TestNewMLRepositoryRequiresModelForNonYandexProvider(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("OPENAI_BASE_URL", "https://api.example.com/v1")
+	for _, stage := range config.LLMStages {
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_PROVIDER", "")
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_MODEL", "")
+	}
+
+	_, err := NewMLRepository()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LLM_EXTRACT_MODEL")
 }
 
 func TestCreateChatCompletionSendsOpenAICompatibleRequest(t *testing.T) {
@@ -57,19 +113,9 @@ func TestCreateChatCompletionSendsOpenAICompatibleRequest(t *testing.T) {
 	}))
 	defer server.Close()
 
-	repo := &MLRepository{
-		httpClient:    server.Client(),
-		tokenProvider: staticTokenProvider{token: "test-token"},
-		baseURL:       server.URL,
-		folderID:      "test-folder",
-		modelURI:      "gpt://test-folder/yandexgpt/latest",
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature},
-		renderParams:  chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature},
-	}
+	repo := newTestMLRepo(server)
 
-	result, err := repo.createChatCompletion(context.Background(), repo.extractParams, topicExtractionSystemPrompt, "Пользовательский промпт")
+	result, err := repo.createChatCompletion(context.Background(), config.LLMStageExtract, repo.prompt(PromptLegacyExtract), "Пользовательский промпт", nil)
 
 	require.NoError(t, err)
 	assert.Equal(t, "Готовая сводка", result)
@@ -91,19 +137,9 @@ func TestCreateChatCompletionReturnsAPIErrorMessage(t *testing.T) {
 	}))
 	defer server.Close()
 
-	repo := &MLRepository{
-		httpClient:    server.Client(),
-		tokenProvider: staticTokenProvider{token: "test-token"},
-		baseURL:       server.URL,
-		folderID:      "test-folder",
-		modelURI:      "gpt://test-folder/yandexgpt/latest",
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature},
-		renderParams:  chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature},
-	}
+	repo := newTestMLRepo(server)
 
-	result, err := repo.createChatCompletion(context.Background(), repo.extractParams, topicExtractionSystemPrompt, "prompt")
+	result, err := repo.createChatCompletion(context.Background(), config.LLMStageExtract, repo.prompt(PromptLegacyExtract), "prompt", nil)
 
 	assert.Empty(t, result)
 	require.Error(t, err)
@@ -141,17 +177,7 @@ func TestSummarizeMessagesExtractsTopicsThenRendersDigest(t *testing.T) {
 	}))
 	defer server.Close()
 
-	repo := &MLRepository{
-		httpClient:    server.Client(),
-		tokenProvider: staticTokenProvider{token: "test-token"},
-		baseURL:       server.URL,
-		folderID:      "test-folder",
-		modelURI:      "gpt://test-folder/yandexgpt/latest",
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature},
-		renderParams:  chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature},
-	}
+	repo := newTestMLRepo(server)
 
 	result, err := repo.SummarizeMessages([]string{
 		"Рубль снизился к доллару.",
@@ -186,17 +212,7 @@ func TestSummarizeMessagesReturnsEmptyDigestWhenNoTopics(t *testing.T) {
 	}))
 	defer server.Close()
 
-	repo := &MLRepository{
-		httpClient:    server.Client(),
-		tokenProvider: staticTokenProvider{token: "test-token"},
-		baseURL:       server.URL,
-		folderID:      "test-folder",
-		modelURI:      "gpt://test-folder/yandexgpt/latest",
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature},
-		renderParams:  chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature},
-	}
+	repo := newTestMLRepo(server)
 
 	result, err := repo.SummarizeMessages([]string{"Рекламный пост"})
 
@@ -213,19 +229,38 @@ func TestBuildTopicExtractionPromptNumbersAndTrimsMessages(t *testing.T) {
 	assert.True(t, strings.HasPrefix(prompt, "Проанализируй сообщения"))
 }
 
+func TestBuildTopicsExtractionPromptAddsEngagementSignals(t *testing.T) {
+	prompt := buildTopicsExtractionPrompt([]MessageInput{
+		{MessageID: 801, Text: "ЦБ сохранил ставку", Meta: MessageMeta{Views: 10000}},
+		{MessageID: 802, Text: "Взрыв на заводе", Meta: MessageMeta{Media: "video", Views: 30000, Reactions: 450, Forwards: 120}},
+		{MessageID: 803, Text: "Фото с места", Meta: MessageMeta{Media: "photo", MediaCount: 4, Views: 8000, ForwardFrom: &MessageForward{FromName: "РИА Новости"}}},
+		{MessageID: 804, Text: "Без метрик"},
+	})
+
+	// медиана просмотров дня - 10000
+	assert.Contains(t, prompt, "[#1]\n(охват ×1.0 к медиане дня)\nЦБ сохранил ставку")
+	assert.Contains(t, prompt, "[#2]\n(медиа: video; охват ×3.0 к медиане дня; реакций: 450; репостов: 120)\nВзрыв на заводе")
+	assert.Contains(t, prompt, "[#3]\n(альбом: 4 медиа; переслано от «РИА Новости»; охват ×0.8 к медиане дня)\nФото с места")
+	assert.Contains(t, prompt, "[#4]\nБез метрик")
+}
+
 func newTestMLRepo(server *httptest.Server) *MLRepository {
+	provider := newYandexProviderWith(server.Client(), staticTokenProvider{token: "test-token"}, server.URL+"/", "test-folder")
+	return newTestMLRepoWith(provider, func(stage string) string { return provider.resolveModel(stage, "") })
+}
+
+func newTestMLRepoWith(provider LLMProvider, model func(stage string) string) *MLRepository {
+	stages := make(map[string]llmStage, len(config.LLMStages))
+	for _, stage := range config.LLMStages {
+		stages[stage] = llmStage{provider: provider, model: model(stage), params: defaultStageParams(stage)}
+	}
+	prompts, err := defaultPrompts()
+	if err != nil {
+		panic(err)
+	}
 	return &MLRepository{
-		httpClient:    server.Client(),
-		tokenProvider: staticTokenProvider{token: "test-token"},
-		baseURL:       server.URL + "/",
-		folderID:      "test-folder",
-		modelURI:      "gpt://test-folder/yandexgpt/latest",
-		embedDocURI:   "emb://test-folder/text-search-doc/latest",
-		embedQueryURI: "emb://test-folder/text-search-query/latest",
-		extractPrompt: topicExtractionSystemPrompt,
-		renderPrompt:  digestRenderSystemPrompt,
-		extractParams: chatCompletionParams{MaxTokens: defaultExtractMaxTokens, Temperature: defaultExtractTemperature},
-		renderParams:  chatCompletionParams{MaxTokens: defaultRenderMaxTokens, Temperature: defaultRenderTemperature},
+		stages:  stages,
+		prompts: prompts,
 	}
 }
 
@@ -316,6 +351,25 @@ func TestWriteDeltaFallsBackToCurrentState(t *testing.T) {
 	assert.Equal(t, "новое", delta)
 }
 
+func TestWriteDeltaKeepsCurrentStateWhenTooLong(t *testing.T) {
+	long := strings.Repeat("я", config.StorylineStateMaxRunes+1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := json.Marshal(writeDeltaResponse{DeltaSummary: "новое", State: long})
+		body, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": string(content)}}}})
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(body)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	state, delta, err := repo.WriteDelta(DeltaInput{Title: "T", CurrentState: "старое состояние", ChangeType: "ongoing"})
+	require.NoError(t, err)
+	assert.Equal(t, "старое состояние", state)
+	assert.Equal(t, "новое", delta)
+}
+
 func TestRenderDigestEmptyGroups(t *testing.T) {
 	repo := &MLRepository{}
 	result, err := repo.RenderDigest(DigestGroups{})
@@ -342,3 +396,620 @@ func TestParseSummaryTopicPlanNormalizesTopics(t *testing.T) {
 	assert.Equal(t, []int{2}, topicPlan.Topics[1].SourceMessageNumbers)
 	assert.Equal(t, []int{3}, topicPlan.NoiseMessageNumbers)
 }
+
+func TestRenderPeriodDigestSendsStorylineArcs(t *testing.T) {
+	var capturedRequest testChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedRequest))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Итоги недели"}}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	result, err := repo.RenderPeriodDigest(PeriodDigestInput{
+		Period: "week",
+		From:   "2026-10-12",
+		To:     "2026-10-18",
+		Storylines: []PeriodStoryline{{
+			Title: "Переговоры",
+			State: "стороны договорились",
+			Arc:   []ArcPoint{{Date: "2026-10-12", ChangeType: "new"}, {Date: "2026-10-15", ChangeType: "escalation", DeltaSummary: "срыв встречи"}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Итоги недели", result)
+	require.Len(t, capturedRequest.Messages, 2)
+	assert.Contains(t, capturedRequest.Messages[0].Content, "дайджест канала за период")
+	assert.Contains(t, capturedRequest.Messages[1].Content, "срыв встречи")
+}
+
+func TestRenderPeriodDigestEmpty(t *testing.T) {
+	repo := &MLRepository{}
+	result, err := repo.RenderPeriodDigest(PeriodDigestInput{Period: "week"})
+	require.NoError(t, err)
+	assert.Equal(t, "За период значимых сюжетов не найдено.", result)
+}
+
+func TestAnswerQuestionSendsStorylinesAndMessages(t *testing.T) {
+	var capturedRequest testChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedRequest))
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ЦБ сохранил ставку 16%. [[S1]]"}}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	answer, err := repo.AnswerQuestion(QuestionInput{
+		Question: "что решил ЦБ по ставке?",
+		From:     "2026-09-19",
+		To:       "2026-10-19",
+		Storylines: []PeriodStoryline{{
+			Title: "Ключевая ставка", State: "ставка сохранена", Category: "экономика", Status: "active",
+			Arc: []ArcPoint{{Date: "2026-10-17", ChangeType: "escalation", DeltaSummary: "решение совета директоров"}},
+		}},
+		Messages: []QuestionMessage{{Ref: "S1", Channel: "@topor_live", Date: "2026-10-17 13:30 UTC", Text: "ЦБ сохранил ключевую ставку на уровне 16%"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ЦБ сохранил ставку 16%. [[S1]]", answer)
+	require.Len(t, capturedRequest.Messages, 2)
+	assert.Contains(t, capturedRequest.Messages[0].Content, "Отвечай только по этим материалам")
+	assert.Contains(t, capturedRequest.Messages[1].Content, "Вопрос: что решил ЦБ по ставке?")
+	assert.Contains(t, capturedRequest.Messages[1].Content, "- 2026-10-17 escalation: решение совета директоров")
+	assert.Contains(t, capturedRequest.Messages[1].Content, "[S1] @topor_live, 2026-10-17 13:30 UTC\nЦБ сохранил ключевую ставку")
+}
+
+func TestAnswerQuestionRefusals(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"НЕТ_ОТВЕТА"}}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	_, err := repo.AnswerQuestion(QuestionInput{Question: "кто выиграл матч?"})
+	assert.ErrorIs(t, err, ErrNoGroundedAnswer)
+	assert.Zero(t, calls, "без материалов модель не вызывается")
+
+	_, err = repo.AnswerQuestion(QuestionInput{
+		Question: "кто выиграл матч?",
+		Messages: []QuestionMessage{{Ref: "S1", Text: "Погода на выходные"}},
+	})
+	assert.ErrorIs(t, err, ErrNoGroundedAnswer)
+	assert.Equal(t, 1, calls)
+}
+
+// recordingProvider запоминает, какие модели запрашивались, и отвечает заготовкой.
+type recordingProvider struct {
+	models   []string
+	response string
+	usage    Usage
+	err      error
+}
+
+func (p *recordingProvider) Name() string { return "fake" }
+
+func (p *recordingProvider) ChatCompletion(_ context.Context, req ChatRequest) (ChatResponse, error) {
+	p.models = append(p.models, req.Model)
+	return ChatResponse{Content: p.response, Usage: p.usage}, p.err
+}
+
+func (p *recordingProvider) Embed(_ context.Context, model string, texts []string) ([][]float32, Usage, error) {
+	p.models = append(p.models, model)
+	return make([][]float32, len(texts)), p.usage, p.err
+}
+
+func TestStagesRouteToConfiguredModels(t *testing.T) {
+	provider := &recordingProvider{response: `{"matched_id":null,"is_new":true,"delta_summary":"d","state":"s"}`}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage })
+
+	_, _, err := repo.ConfirmMatch(CandidateTopic{Title: "T"}, nil)
+	require.NoError(t, err)
+	_, _, err = repo.WriteDelta(DeltaInput{Title: "T"})
+	require.NoError(t, err)
+	_, err = repo.RenderDigest(DigestGroups{RecurringNoise: []string{"погода"}})
+	require.NoError(t, err)
+	_, err = repo.EmbedDocuments([]string{"doc"})
+	require.NoError(t, err)
+	_, err = repo.EmbedQueries([]string{"query"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"model-match", "model-delta", "model-render", "model-embed_doc", "model-embed_query"}, provider.models)
+}
+
+func TestOpenAICompatibleProviderBatchesEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("x-folder-id"))
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "text-embedding-3-small", req.Model)
+		assert.Equal(t, []string{"a", "b"}, req.Input)
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"data":[{"embedding":[2],"index":1},{"embedding":[1],"index":0}],"model":"m","object":"list","usage":{"prompt_tokens":4,"total_tokens":4}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	provider := &openAICompatibleProvider{
+		name:            config.LLMProviderOpenAI,
+		httpClient:      server.Client(),
+		tokenProvider:   staticTokenProvider{token: "sk-test"},
+		baseURL:         server.URL + "/",
+		batchEmbeddings: true,
+	}
+
+	vecs, usage, err := provider.Embed(context.Background(), "text-embedding-3-small", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vecs)
+	assert.Equal(t, Usage{PromptTokens: 4}, usage)
+}
+
+func TestOllamaProviderChatAndEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/chat":
+			var req ollamaChatRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "qwen2.5:14b", req.Model)
+			assert.False(t, req.Stream)
+			require.Len(t, req.Messages, 2)
+			assert.Equal(t, "system", req.Messages[0].Role)
+			assert.Equal(t, "промпт", req.Messages[1].Content)
+			assert.Equal(t, float64(100), req.Options["num_predict"])
+			assert.Equal(t, 0.2, req.Options["temperature"])
+			_, err := w.Write([]byte(`{"message":{"role":"assistant","content":"ответ"},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+			require.NoError(t, err)
+		case "/api/embed":
+			_, err := w.Write([]byte(`{"embeddings":[[0.5,0.25],[1,0]]}`))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := &ollamaProvider{httpClient: server.Client(), baseURL: server.URL + "/"}
+
+	result, err := provider.ChatCompletion(context.Background(), ChatRequest{
+		Model: "qwen2.5:14b", SystemPrompt: "система", UserPrompt: "промпт", MaxTokens: 100, Temperature: 0.2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ответ", result.Content)
+	assert.Equal(t, Usage{PromptTokens: 12, CompletionTokens: 3}, result.Usage)
+
+	vecs, _, err := provider.Embed(context.Background(), "nomic-embed-text", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.5, 0.25}, {1, 0}}, vecs)
+}
+
+func TestYandexProviderResolvesShortModelNames(t *testing.T) {
+	t.Setenv("YANDEX_MODEL_URI", "")
+	t.Setenv("YANDEX_EMBED_DOC_URI", "")
+	provider := newYandexProviderWith(http.DefaultClient, staticTokenProvider{}, defaultYandexOpenAIBaseURL, "f")
+
+	assert.Equal(t, "gpt://f/yandexgpt-lite/latest", provider.resolveModel(config.LLMStageExtract, "yandexgpt-lite/latest"))
+	assert.Equal(t, "emb://f/text-search-doc/latest", provider.resolveModel(config.LLMStageEmbedDoc, ""))
+	assert.Equal(t, "emb://f/custom/latest", provider.resolveModel(config.LLMStageEmbedQuery, "custom/latest"))
+	assert.Equal(t, "gpt://other/yandexgpt/rc", provider.resolveModel(config.LLMStageRender, "gpt://other/yandexgpt/rc"))
+}
+
+func chatResponse(t *testing.T, w http.ResponseWriter, content string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+	require.NoError(t, err)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	require.NoError(t, err)
+}
+
+func TestExtractTopicsRepairsInvalidResponse(t *testing.T) {
+	var requests []testChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request testChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			chatResponse(t, w, `{"topics":[{"title":"Тема","summary":"Суть","category":"спорт","importance":9,"source_message_numbers":[1,5]}]}`)
+			return
+		}
+		chatResponse(t, w, `{"topics":[{"title":"Тема","summary":"Суть","category":"общество","importance":5,"source_message_numbers":[1]}]}`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	candidates, err := repo.ExtractTopics([]MessageInput{{MessageID: 10, Text: "a"}, {MessageID: 11, Text: "b"}})
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "общество", candidates[0].Category)
+
+	require.Len(t, requests, 2)
+	repair := requests[1].Messages[1].Content
+	assert.Contains(t, repair, "[#1]\na")
+	assert.Contains(t, repair, "Ошибки проверки")
+	assert.Contains(t, repair, "topics[0].importance: must be between 1 and 5, got 9")
+	assert.Contains(t, repair, `topics[0].category: must be one of`)
+	assert.Contains(t, repair, "topics[0].source_message_numbers[1]: must be between 1 and 2, got 5")
+}
+
+func TestExtractTopicsAcceptsInvalidPlanLenientlyAfterRepairs(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		chatResponse(t, w, `{"topics":[{"title":"Тема","summary":"Суть","category":"спорт","importance":0,"source_message_numbers":[]}]}`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	candidates, err := repo.ExtractTopics([]MessageInput{{MessageID: 10, Text: "a"}})
+	require.NoError(t, err)
+	assert.Equal(t, config.LLMRepairAttempts+1, requestCount)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "другое", candidates[0].Category)
+	assert.Equal(t, 1, candidates[0].Importance)
+}
+
+func TestWriteDeltaFailsWhenResponseNeverParses(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		chatResponse(t, w, `Состояние: без изменений`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	_, _, err := repo.WriteDelta(DeltaInput{Title: "T", CurrentState: "старое"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid delta response after 3 attempts")
+	assert.Equal(t, config.LLMRepairAttempts+1, requestCount)
+}
+
+func TestConfirmMatchRepairsUnknownID(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request testChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		prompts = append(prompts, request.Messages[1].Content)
+		if len(prompts) == 1 {
+			chatResponse(t, w, `{"matched_id":"42","is_new":false}`)
+			return
+		}
+		chatResponse(t, w, `{"matched_id":42,"is_new":false,"reason":"продолжение"}`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	matchedID, isNew, err := repo.ConfirmMatch(CandidateTopic{Title: "T"}, []StorylineBrief{{ID: 42}})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, int64(42), matchedID)
+	require.Len(t, prompts, 2)
+	assert.Contains(t, prompts[1], "ответ не является валидным JSON")
+}
+
+func TestConfirmMatchValidation(t *testing.T) {
+	id := int64(7)
+	options := []StorylineBrief{{ID: 1}, {ID: 2}}
+
+	assert.NoError(t, confirmMatchResponse{IsNew: true}.validate(options))
+	assert.EqualError(t, confirmMatchResponse{MatchedID: &id}.validate(options), "invalid response: matched_id: must be one of [1, 2], got 7")
+	assert.EqualError(t, confirmMatchResponse{}.validate(options), "invalid response: matched_id: must be set when is_new is false")
+	assert.EqualError(t, confirmMatchResponse{IsNew: true, MatchedID: &id}.validate(options), "invalid response: matched_id: must be null when is_new is true")
+}
+
+func TestOpenAICompatibleProviderSendsJSONSchemaWhenSupported(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		chatResponse(t, w, `{"delta_summary":"","state":"s"}`)
+	}))
+	defer server.Close()
+
+	provider := &openAICompatibleProvider{
+		name:             config.LLMProviderOpenAI,
+		httpClient:       server.Client(),
+		tokenProvider:    staticTokenProvider{token: "k"},
+		baseURL:          server.URL + "/",
+		structuredOutput: true,
+	}
+	_, err := provider.ChatCompletion(context.Background(), ChatRequest{Model: "m", UserPrompt: "u", Schema: deltaSchema()})
+	require.NoError(t, err)
+
+	format, ok := body["response_format"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "json_schema", format["type"])
+	assert.Equal(t, "storyline_delta", format["json_schema"].(map[string]any)["name"])
+
+	provider.structuredOutput = false
+	body = nil
+	_, err = provider.ChatCompletion(context.Background(), ChatRequest{Model: "m", UserPrompt: "u", Schema: deltaSchema()})
+	require.NoError(t, err)
+	assert.NotContains(t, body, "response_format")
+}
+
+func TestOllamaProviderSendsFormatSchema(t *testing.T) {
+	var req ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, err := w.Write([]byte(`{"message":{"role":"assistant","content":"{}"}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	provider := &ollamaProvider{httpClient: server.Client(), baseURL: server.URL + "/", structuredOutput: true}
+	_, err := provider.ChatCompletion(context.Background(), ChatRequest{Model: "m", Schema: topicsSchema(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "object", req.Format["type"])
+}
+
+// fakeUsageRepo копит записанные вызовы; TotalCost возвращает spent.
+type fakeUsageRepo struct {
+	calls []MLCall
+	spent float64
+}
+
+func (f *fakeUsageRepo) SaveCall(call *MLCall) error {
+	f.calls = append(f.calls, *call)
+	return nil
+}
+
+func (f *fakeUsageRepo) TotalCost(from, to time.Time) (float64, error) { return f.spent, nil }
+
+func (f *fakeUsageRepo) TotalsByChannel(from, to time.Time) ([]MLUsageTotal, error) { return nil, nil }
+
+func (f *fakeUsageRepo) TotalsByStage(from, to time.Time) ([]MLUsageTotal, error) { return nil, nil }
+
+func TestCallsAreRecordedForScopedDay(t *testing.T) {
+	t.Setenv("ML_MONTHLY_BUDGET", "")
+	t.Setenv("ML_MODEL_PRICES", "model-delta=2; broken")
+	usage := &fakeUsageRepo{}
+	provider := &recordingProvider{
+		response: `{"delta_summary":"d","state":"s"}`,
+		usage:    Usage{PromptTokens: 100, CompletionTokens: 20},
+	}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage }).WithUsage(usage)
+	day := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+
+	_, _, err := repo.ForDay(123, day).WriteDelta(DeltaInput{Title: "T"})
+	require.NoError(t, err)
+	provider.err = errors.New("boom")
+	_, err = repo.EmbedQueries([]string{"query"})
+	require.Error(t, err)
+
+	require.Len(t, usage.calls, 2)
+	delta := usage.calls[0]
+	assert.Equal(t, config.LLMStageDelta, delta.Stage)
+	assert.Equal(t, int64(123), delta.ChannelID)
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), delta.CallDate)
+	assert.Equal(t, "fake", delta.Provider)
+	assert.Equal(t, "model-delta", delta.Model)
+	assert.Equal(t, 100, delta.PromptTokens)
+	assert.Equal(t, 20, delta.CompletionTokens)
+	assert.InDelta(t, 0.24, delta.Cost, 1e-9)
+	assert.True(t, delta.Success)
+
+	embed := usage.calls[1]
+	assert.Equal(t, config.LLMStageEmbedQuery, embed.Stage)
+	assert.Equal(t, int64(0), embed.ChannelID) // вызов вне ForDay
+	assert.False(t, embed.Success)
+	assert.Zero(t, embed.Cost)
+}
+
+func TestExceededBudgetSkipsMatchAndSwitchesToBudgetModel(t *testing.T) {
+	t.Setenv("ML_MONTHLY_BUDGET", "100")
+	provider := &recordingProvider{response: `{"delta_summary":"d","state":"s"}`}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage })
+	s := repo.stages[config.LLMStageDelta]
+	s.budgetModel = "cheap-delta"
+	repo.stages[config.LLMStageDelta] = s
+	repo.WithUsage(&fakeUsageRepo{spent: 150})
+
+	_, _, err := repo.ConfirmMatch(CandidateTopic{Title: "T"}, []StorylineBrief{{ID: 1}})
+	assert.ErrorIs(t, err, ErrMLBudgetExceeded)
+	_, _, err = repo.WriteDelta(DeltaInput{Title: "T"})
+	require.NoError(t, err)
+	_, err = repo.EmbedDocuments([]string{"doc"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cheap-delta", "model-embed_doc"}, provider.models)
+}
+
+func TestBudgetBelowLimitKeepsStages(t *testing.T) {
+	t.Setenv("ML_MONTHLY_BUDGET", "100")
+	provider := &recordingProvider{response: `{"matched_id":1,"is_new":false,"reason":"r"}`}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage }).
+		WithUsage(&fakeUsageRepo{spent: 99.5})
+
+	matchedID, isNew, err := repo.ConfirmMatch(CandidateTopic{Title: "T"}, []StorylineBrief{{ID: 1}})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, int64(1), matchedID)
+	assert.Equal(t, []string{"model-match"}, provider.models)
+}
+
+// fakeEmbeddingCache - кэш в памяти, запоминает сохранённые хеши.
+type fakeEmbeddingCache struct {
+	vectors map[string][]float32 // model + "/" + hash
+	saved   []string
+}
+
+func (f *fakeEmbeddingCache) GetEmbeddings(model string, hashes []string) (map[string][]float32, error) {
+	result := map[string][]float32{}
+	for _, h := range hashes {
+		if v, ok := f.vectors[model+"/"+h]; ok {
+			result[h] = v
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeEmbeddingCache) SaveEmbeddings(model string, embeddings map[string][]float32) error {
+	for h, v := range embeddings {
+		f.vectors[model+"/"+h] = v
+		f.saved = append(f.saved, h)
+	}
+	return nil
+}
+
+// countingEmbedProvider отвечает на Embed вектором {длина текста} и запоминает запрошенные тексты.
+type countingEmbedProvider struct {
+	recordingProvider
+	texts [][]string
+}
+
+func (p *countingEmbedProvider) Embed(_ context.Context, model string, texts []string) ([][]float32, Usage, error) {
+	p.texts = append(p.texts, texts)
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		result[i] = []float32{float32(len([]rune(text)))}
+	}
+	return result, Usage{PromptTokens: len(texts)}, nil
+}
+
+func TestEmbedUsesCacheAndEmbedsOnlyMisses(t *testing.T) {
+	cache := &fakeEmbeddingCache{vectors: map[string][]float32{
+		"model-embed_query/" + EmbeddingTextHash("старый"): {42},
+	}}
+	provider := &countingEmbedProvider{}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage }).WithEmbeddingCache(cache)
+
+	vecs, err := repo.EmbedQueries([]string{"старый", "новый", "новый", "ещё"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{42}, {5}, {5}, {3}}, vecs)
+	assert.Equal(t, [][]string{{"новый", "ещё"}}, provider.texts)
+	assert.ElementsMatch(t, []string{EmbeddingTextHash("новый"), EmbeddingTextHash("ещё")}, cache.saved)
+
+	// повтор полностью из кэша, провайдер не вызывается
+	vecs, err = repo.EmbedQueries([]string{"ещё", "старый"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{3}, {42}}, vecs)
+	assert.Len(t, provider.texts, 1)
+
+	// кэш разделён по моделям: doc-стадия не берёт query-векторы
+	_, err = repo.EmbedDocuments([]string{"старый"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"старый"}, provider.texts[1])
+}
+
+// fakePromptRepo - версии промптов в памяти.
+type fakePromptRepo struct {
+	versions []PromptVersion
+}
+
+func (f *fakePromptRepo) ListPromptVersions() ([]PromptVersion, error) { return f.versions, nil }
+
+func (f *fakePromptRepo) GetPromptVersion(name, version string) (*PromptVersion, error) {
+	for _, v := range f.versions {
+		if v.Name == name && v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakePromptRepo) SavePromptVersion(p *PromptVersion) error { return nil }
+
+func (f *fakePromptRepo) SetActivePromptVersion(name, version string) error { return nil }
+
+func TestLoadPromptsAppliesActiveDatabaseVersions(t *testing.T) {
+	provider := &recordingProvider{response: "дайджест"}
+	repo := newTestMLRepoWith(provider, func(stage string) string { return "model-" + stage })
+	store := &fakePromptRepo{versions: []PromptVersion{
+		{Name: PromptRender, Version: "v3", Template: "Короткий рендер, рубрики: {{.Categories}}", Active: true},
+		{Name: PromptDelta, Version: "v2", Template: "Неактивная дельта"},
+	}}
+
+	require.NoError(t, repo.LoadPrompts(store))
+
+	assert.Equal(t, "extract@v2,match@v1,delta@v1,render@v3", repo.PromptVersion())
+	assert.Equal(t, "Короткий рендер, рубрики: военное, происшествия, экономика, политика, общество, другое", repo.prompt(PromptRender))
+}
+
+func TestWithPromptVersionsSwapsPromptsInCopy(t *testing.T) {
+	var systems []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req testChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		systems = append(systems, req.Messages[0].Content)
+		chatResponse(t, w, `{"delta_summary":"d","state":"s"}`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+	require.NoError(t, repo.LoadPrompts(&fakePromptRepo{versions: []PromptVersion{
+		{Name: PromptDelta, Version: "v2", Template: "Дельта v2, до {{.StateMaxRunes}} символов"},
+	}}))
+
+	variant, err := repo.WithPromptVersions(map[string]string{PromptDelta: "v2"})
+	require.NoError(t, err)
+	assert.Equal(t, "extract@v2,match@v1,delta@v2,render@v2", variant.PromptVersion())
+	assert.Equal(t, "extract@v2,match@v1,delta@v1,render@v2", repo.PromptVersion())
+
+	_, _, err = variant.WriteDelta(DeltaInput{Title: "T"})
+	require.NoError(t, err)
+	_, _, err = repo.WriteDelta(DeltaInput{Title: "T"})
+	require.NoError(t, err)
+	require.Len(t, systems, 2)
+	assert.Equal(t, "Дельта v2, до 600 символов", systems[0])
+	assert.Contains(t, systems[1], "хронику сюжета")
+
+	_, err = repo.WithPromptVersions(map[string]string{PromptDelta: "v3"})
+	assert.EqualError(t, err, "prompt delta@v3 not found")
+	_, err = repo.WithPromptVersions(map[string]string{"summary": "v1"})
+	assert.EqualError(t, err, `unknown prompt "summary"`)
+}
+
+func TestJudgeDigestSendsSourcesAndNormalizesVerdict(t *testing.T) {
+	var request testChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		chatResponse(t, w, `{"claims":3,"unsupported":["выдуманная цифра"," "],"covered_items":[2,2,1]}`)
+	}))
+	defer server.Close()
+
+	repo := newTestMLRepo(server)
+
+	judgement, err := repo.JudgeDigest(DigestJudgeInput{
+		Digest:   "Дайджест дня",
+		Sources:  []string{"Первое сообщение", "Второе сообщение"},
+		KeyItems: []string{"Выборы: итоги", "Погода: шторм"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, judgement.Claims)
+	assert.Equal(t, []string{"выдуманная цифра"}, judgement.Unsupported)
+	assert.Equal(t, []int{2, 1}, judgement.CoveredItems)
+
+	require.Len(t, request.Messages, 2)
+	assert.Contains(t, request.Messages[0].Content, "covered_items")
+	assert.Contains(t, request.Messages[1].Content, "[#2]\nВторое сообщение")
+	assert.Contains(t, request.Messages[1].Content, "2. Погода: шторм")
+	assert.True(t, strings.HasSuffix(request.Messages[1].Content, "Дайджест:\nДайджест дня"))
+}
+
+func TestDigestJudgeValidation(t *testing.T) {
+	assert.NoError(t, digestJudgeResponse{Claims: 2, Unsupported: []string{"a"}, CoveredItems: []int{1}}.validate(1))
+	assert.EqualError(t, digestJudgeResponse{Claims: 0, Unsupported: []string{"a"}}.validate(1),
+		"invalid response: unsupported: has 1 items but claims is 0")
+	assert.EqualError(t, digestJudgeResponse{Claims: 1, CoveredItems: []int{3}}.validate(2),
+		"invalid response: covered_items[0]: must be between 1 and 2, got 3")
+}
@@ -0,0 +1,112 @@
+package repository
+
+//go:generate mockgen -source=ml_usage.go -destination=../mocks/repository/ml_usage_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MLCall - один вызов провайдера LLM/эмбеддингов.
+type MLCall struct {
+	Stage            string
+	ChannelID        int64     // 0, если вызов не привязан к каналу
+	CallDate         time.Time // день, для которого строился дайджест
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64 // ₽, по config.ModelPricePer1K на момент вызова
+	Latency          time.Duration
+	Success          bool
+	CreatedAt        time.Time
+}
+
+// MLUsageTotal - агрегат вызовов по ключу (канал или стадия).
+type MLUsageTotal struct {
+	Key              string
+	Calls            int
+	Failures         int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// MLUsageRepositoryInterface - учёт расходов на ML. Периоды полуоткрытые по created_at: from <= t < to.
+type MLUsageRepositoryInterface interface {
+	SaveCall(call *MLCall) error
+	TotalCost(from, to time.Time) (float64, error)
+	// по каналам (Key - channel_id), по убыванию стоимости
+	TotalsByChannel(from, to time.Time) ([]MLUsageTotal, error)
+	// по стадиям (Key - stage), по убыванию стоимости
+	TotalsByStage(from, to time.Time) ([]MLUsageTotal, error)
+}
+
+type MLUsageRepository struct {
+	db *sql.DB
+}
+
+func NewMLUsageRepository(db *sql.DB) MLUsageRepositoryInterface {
+	return &MLUsageRepository{db: db}
+}
+
+func (r *MLUsageRepository) SaveCall(call *MLCall) error {
+	q := `
+		INSERT INTO ml_calls (stage, channel_id, call_date, provider, model,
+			prompt_tokens, completion_tokens, cost, latency_ms, success, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.Exec(q,
+		call.Stage, call.ChannelID, call.CallDate, call.Provider, call.Model,
+		call.PromptTokens, call.CompletionTokens, call.Cost, call.Latency.Milliseconds(), call.Success, call.CreatedAt,
+	)
+	return err
+}
+
+func (r *MLUsageRepository) TotalCost(from, to time.Time) (float64, error) {
+	q := `SELECT COALESCE(SUM(cost), 0) FROM ml_calls WHERE created_at >= $1 AND created_at < $2`
+	var cost float64
+	if err := r.db.QueryRow(q, from, to).Scan(&cost); err != nil {
+		return 0, err
+	}
+	return cost, nil
+}
+
+func (r *MLUsageRepository) TotalsByChannel(from, to time.Time) ([]MLUsageTotal, error) {
+	return r.totals("channel_id::text", from, to)
+}
+
+func (r *MLUsageRepository) TotalsByStage(from, to time.Time) ([]MLUsageTotal, error) {
+	return r.totals("stage", from, to)
+}
+
+// totals группирует вызовы по выражению key (только константы из этого файла).
+func (r *MLUsageRepository) totals(key string, from, to time.Time) ([]MLUsageTotal, error) {
+	q := `
+		SELECT ` + key + ` AS key,
+			COUNT(*) AS calls,
+			COUNT(*) FILTER (WHERE NOT success) AS failures,
+			COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0),
+			COALESCE(SUM(cost), 0) AS total_cost
+		FROM ml_calls
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY key
+		ORDER BY total_cost DESC, key
+	`
+	rows, err := r.db.Query(q, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []MLUsageTotal
+	for rows.Next() {
+		var t MLUsageTotal
+		if err := rows.Scan(&t.Key, &t.Calls, &t.Failures, &t.PromptTokens, &t.CompletionTokens, &t.Cost); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
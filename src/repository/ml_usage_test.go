@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMLUsageRepository_SaveCall(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMLUsageRepository(db)
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO ml_calls").
+		WithArgs("delta", int64(123), day, "yandex", "gpt://f/yandexgpt/latest", 1000, 200, 1.44, int64(1500), true, createdAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SaveCall(&MLCall{
+		Stage:            "delta",
+		ChannelID:        123,
+		CallDate:         day,
+		Provider:         "yandex",
+		Model:            "gpt://f/yandexgpt/latest",
+		PromptTokens:     1000,
+		CompletionTokens: 200,
+		Cost:             1.44,
+		Latency:          1500 * time.Millisecond,
+		Success:          true,
+		CreatedAt:        createdAt,
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMLUsageRepository_TotalCost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMLUsageRepository(db)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(cost\\), 0\\) FROM ml_calls").
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(123.5))
+
+	cost, err := repo.TotalCost(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 123.5, cost)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMLUsageRepository_TotalsByStage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMLUsageRepository(db)
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery("SELECT stage AS key").
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "calls", "failures", "prompt", "completion", "total_cost"}).
+			AddRow("extract", 4, 1, 12000, 3000, 18.0).
+			AddRow("embed_query", 10, 0, 800, 0, 0.008))
+
+	totals, err := repo.TotalsByStage(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []MLUsageTotal{
+		{Key: "extract", Calls: 4, Failures: 1, PromptTokens: 12000, CompletionTokens: 3000, Cost: 18.0},
+		{Key: "embed_query", Calls: 10, PromptTokens: 800, Cost: 0.008},
+	}, totals)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package repository
+
+//go:generate mockgen -source=period_summary.go -destination=../mocks/repository/period_summary_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PeriodSummary - дайджест канала за неделю или месяц.
+type PeriodSummary struct {
+	ID                int64
+	ChannelID         int64
+	Period            string    // week | month
+	PeriodStart       time.Time // включительно
+	PeriodEnd         time.Time // не включительно
+	Summary           string
+	ObservationsCount int
+	InputHash         string // hex SHA-256 входа RenderPeriodDigest, см. PeriodDigestService.Get
+	CreatedAt         time.Time
+}
+
+func (s *PeriodSummary) GetFormattedSummary() string {
+	label := "неделю"
+	if s.Period == "month" {
+		label = "месяц"
+	}
+	return fmt.Sprintf("Главное за %s (%s–%s):\n%s", label,
+		s.PeriodStart.Format("02.01"), s.PeriodEnd.AddDate(0, 0, -1).Format("02.01"), s.Summary)
+}
+
+// PeriodObservation - наблюдение сюжета за период вместе с текущей карточкой сюжета.
+type PeriodObservation struct {
+	Storyline   Storyline // без эмбеддинга
+	Observation Observation
+}
+
+type PeriodSummaryRepositoryInterface interface {
+	GetPeriodSummary(channelID int64, period string, start, end time.Time) (*PeriodSummary, error) // nil, если нет
+	SavePeriodSummary(s *PeriodSummary) error                                                      // upsert по (channel_id, period, period_start, period_end)
+	// наблюдения канала с from <= obs_date < to, упорядочены по storyline_id, obs_date
+	GetPeriodObservations(channelID int64, from, to time.Time) ([]PeriodObservation, error)
+}
+
+type PeriodSummaryRepository struct {
+	db *sql.DB
+}
+
+func NewPeriodSummaryRepository(db *sql.DB) PeriodSummaryRepositoryInterface {
+	return &PeriodSummaryRepository{db: db}
+}
+
+func (r *PeriodSummaryRepository) GetPeriodSummary(channelID int64, period string, start, end time.Time) (*PeriodSummary, error) {
+	q := `
+		SELECT id, channel_id, period, period_start, period_end, summary, observations_count, input_hash, created_at
+		FROM period_summaries
+		WHERE channel_id = $1 AND period = $2 AND period_start = $3 AND period_end = $4
+	`
+	s := &PeriodSummary{}
+	err := r.db.QueryRow(q, channelID, period, start, end).Scan(
+		&s.ID, &s.ChannelID, &s.Period, &s.PeriodStart, &s.PeriodEnd, &s.Summary, &s.ObservationsCount, &s.InputHash, &s.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *PeriodSummaryRepository) SavePeriodSummary(s *PeriodSummary) error {
+	q := `
+		INSERT INTO period_summaries (channel_id, period, period_start, period_end, summary, observations_count, input_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (channel_id, period, period_start, period_end) DO UPDATE SET
+			summary = EXCLUDED.summary,
+			observations_count = EXCLUDED.observations_count,
+			input_hash = EXCLUDED.input_hash,
+			created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.Exec(q, s.ChannelID, s.Period, s.PeriodStart, s.PeriodEnd, s.Summary, s.ObservationsCount, s.InputHash, s.CreatedAt)
+	return err
+}
+
+func (r *PeriodSummaryRepository) GetPeriodObservations(channelID int64, from, to time.Time) ([]PeriodObservation, error) {
+	q := `
+		SELECT s.id, s.channel_id, s.title, s.state, COALESCE(s.category, ''), s.status, s.importance, s.first_seen, s.last_seen,
+			o.obs_date, o.message_count, o.importance, o.change_type, COALESCE(o.delta_summary, '')
+		FROM storyline_observations o
+		JOIN storylines s ON s.id = o.storyline_id
+		WHERE o.channel_id = $1 AND o.obs_date >= $2 AND o.obs_date < $3
+		ORDER BY s.id, o.obs_date
+	`
+	rows, err := r.db.Query(q, channelID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PeriodObservation
+	for rows.Next() {
+		var p PeriodObservation
+		if err := rows.Scan(
+			&p.Storyline.ID, &p.Storyline.ChannelID, &p.Storyline.Title, &p.Storyline.State, &p.Storyline.Category,
+			&p.Storyline.Status, &p.Storyline.Importance, &p.Storyline.FirstSeen, &p.Storyline.LastSeen,
+			&p.Observation.ObsDate, &p.Observation.MessageCount, &p.Observation.Importance,
+			&p.Observation.ChangeType, &p.Observation.DeltaSummary,
+		); err != nil {
+			return nil, err
+		}
+		p.Observation.StorylineID = p.Storyline.ID
+		p.Observation.ChannelID = p.Storyline.ChannelID
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodSummaryRepository_GetPeriodSummaryNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPeriodSummaryRepository(db)
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	mock.ExpectQuery("FROM period_summaries").
+		WithArgs(int64(123), "week", start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel_id", "period", "period_start", "period_end", "summary", "observations_count", "input_hash", "created_at"}))
+
+	summary, err := repo.GetPeriodSummary(123, "week", start, end)
+	require.NoError(t, err)
+	assert.Nil(t, summary)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPeriodSummaryRepository_SavePeriodSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPeriodSummaryRepository(db)
+	start := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO period_summaries").
+		WithArgs(int64(123), "week", start, start.AddDate(0, 0, 7), "итоги", 12, "abc", createdAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.SavePeriodSummary(&PeriodSummary{
+		ChannelID:         123,
+		Period:            "week",
+		PeriodStart:       start,
+		PeriodEnd:         start.AddDate(0, 0, 7),
+		Summary:           "итоги",
+		ObservationsCount: 12,
+		InputHash:         "abc",
+		CreatedAt:         createdAt,
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPeriodSummaryRepository_GetPeriodObservations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPeriodSummaryRepository(db)
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "channel_id", "title", "state", "category", "status", "importance", "first_seen", "last_seen",
+		"obs_date", "message_count", "importance", "change_type", "delta_summary",
+	}).AddRow(int64(42), int64(123), "Сюжет", "состояние", "политика", "active", 3, from, from,
+		from, 4, 3, "new", "началось")
+
+	mock.ExpectQuery("FROM storyline_observations o").
+		WithArgs(int64(123), from, to).
+		WillReturnRows(rows)
+
+	result, err := repo.GetPeriodObservations(123, from, to)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, int64(42), result[0].Observation.StorylineID)
+	assert.Equal(t, "new", result[0].Observation.ChangeType)
+	assert.Equal(t, "Сюжет", result[0].Storyline.Title)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPeriodSummary_GetFormattedSummary(t *testing.T) {
+	s := &PeriodSummary{
+		Period:      "month",
+		PeriodStart: time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Summary:     "текст",
+	}
+	assert.Equal(t, "Главное за месяц (19.09–18.10):\nтекст", s.GetFormattedSummary())
+}
@@ -0,0 +1,50 @@
+package repository
+
+//go:generate mockgen -source=personalized_summary.go -destination=../mocks/repository/personalized_summary_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PersonalizedSummaryRepositoryInterface - кэш дайджестов, перерендеренных под
+// настройки рубрик: один вариант на дайджест и CategoryPreferences.Key().
+type PersonalizedSummaryRepositoryInterface interface {
+	// ok == false - варианта ещё нет
+	GetVariant(summaryID int64, preferenceKey string) (summary string, ok bool, err error)
+	SaveVariant(summaryID int64, preferenceKey, summary string) error
+}
+
+type PersonalizedSummaryRepository struct {
+	db *sql.DB
+}
+
+func NewPersonalizedSummaryRepository(db *sql.DB) PersonalizedSummaryRepositoryInterface {
+	return &PersonalizedSummaryRepository{db: db}
+}
+
+func (r *PersonalizedSummaryRepository) GetVariant(summaryID int64, preferenceKey string) (string, bool, error) {
+	var summary string
+	err := r.db.QueryRow(`
+		SELECT summary
+		FROM personalized_summaries
+		WHERE summary_id = $1 AND preference_key = $2
+	`, summaryID, preferenceKey).Scan(&summary)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return summary, true, nil
+}
+
+// SaveVariant не перезаписывает вариант, если его параллельно уже сохранил другой читатель.
+func (r *PersonalizedSummaryRepository) SaveVariant(summaryID int64, preferenceKey, summary string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO personalized_summaries (summary_id, preference_key, summary, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (summary_id, preference_key) DO NOTHING
+	`, summaryID, preferenceKey, summary, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalizedSummaryRepository_GetVariant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPersonalizedSummaryRepository(db)
+
+	mock.ExpectQuery("FROM personalized_summaries\\s+WHERE summary_id = \\$1 AND preference_key = \\$2").
+		WithArgs(int64(7), "mute=военное;boost=").
+		WillReturnRows(sqlmock.NewRows([]string{"summary"}))
+	mock.ExpectQuery("FROM personalized_summaries").
+		WithArgs(int64(7), "mute=;boost=экономика").
+		WillReturnRows(sqlmock.NewRows([]string{"summary"}).AddRow("Экономика первой"))
+
+	_, ok, err := repo.GetVariant(7, "mute=военное;boost=")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	summary, ok, err := repo.GetVariant(7, "mute=;boost=экономика")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Экономика первой", summary)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPersonalizedSummaryRepository_SaveVariantKeepsExisting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPersonalizedSummaryRepository(db)
+
+	mock.ExpectExec("INSERT INTO personalized_summaries.*ON CONFLICT \\(summary_id, preference_key\\) DO NOTHING").
+		WithArgs(int64(7), "mute=военное;boost=", "Без военных новостей", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SaveVariant(7, "mute=военное;boost=", "Без военных новостей"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,231 @@
+package repository
+
+//go:generate mockgen -source=prompt.go -destination=../mocks/repository/prompt_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+)
+
+// Имена системных промптов. Встроенные версии лежат в prompts/<имя>.<версия>.tmpl,
+// дополнительные - в prompt_versions (миграция 0008).
+const (
+	PromptLegacyExtract = "legacy_extract" // план топиков для SummarizeMessages
+	PromptLegacyRender  = "legacy_render"  // рендер плана для SummarizeMessages
+	PromptExtract       = "extract"        // стадия A
+	PromptMatch         = "match"          // стадия C
+	PromptDelta         = "delta"          // стадия D
+	PromptRender        = "render"         // стадия F
+	PromptPeriodRender  = "period_render"  // дайджест за неделю/месяц
+	PromptDigestJudge   = "digest_judge"   // офлайн-оценка дайджеста (scripts/digest_eval)
+	PromptAnswer        = "answer"         // ответ на вопрос читателя по архиву (/ask)
+)
+
+// PromptNames - все промпты реестра.
+var PromptNames = []string{PromptLegacyExtract, PromptLegacyRender, PromptExtract, PromptMatch, PromptDelta, PromptRender, PromptPeriodRender, PromptDigestJudge, PromptAnswer}
+
+// DailyPrompts - промпты дневного конвейера; их версии пишутся в summaries и storyline_observations.
+var DailyPrompts = []string{PromptExtract, PromptMatch, PromptDelta, PromptRender}
+
+//go:embed prompts/*.tmpl
+var builtinPromptFiles embed.FS
+
+// Prompt - отрендеренный системный промпт определённой версии.
+type Prompt struct {
+	Name    string
+	Version string
+	Text    string
+}
+
+// PromptVersion - версия промпта из базы (шаблон text/template, см. promptData).
+type PromptVersion struct {
+	Name      string
+	Version   string
+	Template  string
+	Active    bool
+	CreatedAt time.Time
+}
+
+// promptData - значения, доступные в шаблонах промптов.
+type promptData struct {
+	MaxTopics     int
+	StateMaxRunes int
+	Categories    string
+}
+
+// RenderPrompt проверяет и рендерит шаблон промпта.
+func RenderPrompt(name, version, tmpl string) (Prompt, error) {
+	t, err := template.New(name + "." + version).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("failed to parse prompt %s@%s: %w", name, version, err)
+	}
+	var b strings.Builder
+	err = t.Execute(&b, promptData{
+		MaxTopics:     maxExtractedTopics,
+		StateMaxRunes: config.StorylineStateMaxRunes,
+		Categories:    strings.Join(config.Categories, ", "),
+	})
+	if err != nil {
+		return Prompt{}, fmt.Errorf("failed to render prompt %s@%s: %w", name, version, err)
+	}
+	return Prompt{Name: name, Version: version, Text: strings.TrimSpace(b.String())}, nil
+}
+
+// BuiltinPromptVersions возвращает встроенные версии промпта по возрастанию.
+func BuiltinPromptVersions(name string) []string {
+	var versions []string
+	for key := range builtinPromptTemplates() {
+		if n, v, _ := strings.Cut(key, "@"); n == name {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return promptVersionLess(versions[i], versions[j]) })
+	return versions
+}
+
+// builtinPrompt рендерит встроенную версию промпта; ok = false, если такой версии нет.
+func builtinPrompt(name, version string) (Prompt, bool, error) {
+	tmpl, ok := builtinPromptTemplates()[name+"@"+version]
+	if !ok {
+		return Prompt{}, false, nil
+	}
+	p, err := RenderPrompt(name, version, tmpl)
+	return p, true, err
+}
+
+// builtinPromptTemplates - шаблоны из prompts/ по ключу "имя@версия".
+func builtinPromptTemplates() map[string]string {
+	entries, err := builtinPromptFiles.ReadDir("prompts")
+	if err != nil {
+		return nil
+	}
+	result := make(map[string]string, len(entries))
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".tmpl")
+		name, version, ok := strings.Cut(base, ".")
+		if !ok {
+			continue
+		}
+		data, err := builtinPromptFiles.ReadFile(path.Join("prompts", e.Name()))
+		if err != nil {
+			continue
+		}
+		result[name+"@"+version] = string(data)
+	}
+	return result
+}
+
+// promptVersionLess сравнивает версии вида v<N> численно, остальные - как строки.
+func promptVersionLess(a, b string) bool {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// FormatPromptVersions - подпись набора промптов: "extract@v1,match@v2".
+func FormatPromptVersions(prompts map[string]Prompt, names []string) string {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if p, ok := prompts[name]; ok {
+			parts = append(parts, p.Name+"@"+p.Version)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// PromptRepositoryInterface - версии промптов в базе поверх встроенных.
+type PromptRepositoryInterface interface {
+	ListPromptVersions() ([]PromptVersion, error)
+	GetPromptVersion(name, version string) (*PromptVersion, error) // nil, если нет
+	SavePromptVersion(p *PromptVersion) error                      // upsert шаблона по (name, version)
+	// делает активной версию version промпта name; если её нет в базе,
+	// активной не остаётся ни одна и используется последняя встроенная
+	SetActivePromptVersion(name, version string) error
+}
+
+type PromptRepository struct {
+	db *sql.DB
+}
+
+func NewPromptRepository(db *sql.DB) PromptRepositoryInterface {
+	return &PromptRepository{db: db}
+}
+
+func (r *PromptRepository) ListPromptVersions() ([]PromptVersion, error) {
+	q := `
+		SELECT name, version, template, active, created_at
+		FROM prompt_versions
+		ORDER BY name, created_at
+	`
+	rows, err := r.db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []PromptVersion
+	for rows.Next() {
+		var p PromptVersion
+		if err := rows.Scan(&p.Name, &p.Version, &p.Template, &p.Active, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+func (r *PromptRepository) GetPromptVersion(name, version string) (*PromptVersion, error) {
+	q := `
+		SELECT name, version, template, active, created_at
+		FROM prompt_versions
+		WHERE name = $1 AND version = $2
+	`
+	p := &PromptVersion{}
+	err := r.db.QueryRow(q, name, version).Scan(&p.Name, &p.Version, &p.Template, &p.Active, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PromptRepository) SavePromptVersion(p *PromptVersion) error {
+	q := `
+		INSERT INTO prompt_versions (name, version, template)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name, version) DO UPDATE SET template = EXCLUDED.template
+	`
+	_, err := r.db.Exec(q, p.Name, p.Version, p.Template)
+	return err
+}
+
+func (r *PromptRepository) SetActivePromptVersion(name, version string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Сначала снимаем активность, иначе частичный уникальный индекс по name сработает посреди UPDATE.
+	if _, err := tx.Exec(`UPDATE prompt_versions SET active = FALSE WHERE name = $1 AND active`, name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE prompt_versions SET active = TRUE WHERE name = $1 AND version = $2`, name, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
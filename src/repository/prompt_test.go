@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinPromptsRender(t *testing.T) {
+	for _, name := range PromptNames {
+		versions := BuiltinPromptVersions(name)
+		require.NotEmpty(t, versions, name)
+		for _, v := range versions {
+			p, ok, err := builtinPrompt(name, v)
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.NotContains(t, p.Text, "{{", "%s@%s", name, v)
+			assert.Equal(t, strings.TrimSpace(p.Text), p.Text)
+		}
+	}
+
+	extract, _, err := builtinPrompt(PromptExtract, "v1")
+	require.NoError(t, err)
+	assert.Contains(t, extract.Text, "Не больше 8 топиков")
+	assert.Contains(t, extract.Text, "(одно из: военное, происшествия, экономика, политика, общество, другое)")
+	delta, _, err := builtinPrompt(PromptDelta, "v1")
+	require.NoError(t, err)
+	assert.Contains(t, delta.Text, "до 600 символов")
+}
+
+func TestRenderPromptRejectsUnknownField(t *testing.T) {
+	_, err := RenderPrompt(PromptDelta, "v9", "до {{.StateMaxChars}} символов")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "delta@v9")
+}
+
+func TestPromptVersionLess(t *testing.T) {
+	assert.True(t, promptVersionLess("v2", "v10"))
+	assert.False(t, promptVersionLess("v10", "v2"))
+	assert.True(t, promptVersionLess("v1", "v1-short"))
+}
+
+func TestPromptRepository_GetPromptVersionNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPromptRepository(db)
+
+	mock.ExpectQuery("FROM prompt_versions").
+		WithArgs("delta", "v2").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "version", "template", "active", "created_at"}))
+
+	p, err := repo.GetPromptVersion("delta", "v2")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPromptRepository_ListPromptVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPromptRepository(db)
+	createdAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM prompt_versions").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "version", "template", "active", "created_at"}).
+			AddRow("delta", "v2", "текст", true, createdAt))
+
+	versions, err := repo.ListPromptVersions()
+	require.NoError(t, err)
+	assert.Equal(t, []PromptVersion{{Name: "delta", Version: "v2", Template: "текст", Active: true, CreatedAt: createdAt}}, versions)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPromptRepository_SetActivePromptVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPromptRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE prompt_versions SET active = FALSE").
+		WithArgs("delta").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE prompt_versions SET active = TRUE").
+		WithArgs("delta", "v2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetActivePromptVersion("delta", "v2"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
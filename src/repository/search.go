@@ -0,0 +1,82 @@
+package repository
+
+//go:generate mockgen -source=search.go -destination=../mocks/repository/search_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SearchQuery - запрос /search по архиву сообщений.
+type SearchQuery struct {
+	Text      string    // синтаксис websearch_to_tsquery: "точная фраза", OR, -исключить
+	ChannelID int64     // 0 - все каналы
+	From      time.Time // включительно; нулевое - без ограничения
+	To        time.Time // не включительно; нулевое - без ограничения
+	Limit     int
+	Offset    int
+}
+
+// SearchResult - найденное сообщение с фрагментом, где совпадения выделены «».
+type SearchResult struct {
+	ChannelID   int64
+	MessageID   int64
+	MessageDate time.Time
+	Headline    string
+	URL         string // meta.url: адрес материала у постов RSS/Atom-лент
+}
+
+type SearchRepositoryInterface interface {
+	// найденные сообщения от новых к старым и общее число совпадений
+	SearchMessages(q SearchQuery) ([]SearchResult, int, error)
+}
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepositoryInterface {
+	return &SearchRepository{db: db}
+}
+
+func (r *SearchRepository) SearchMessages(q SearchQuery) ([]SearchResult, int, error) {
+	query := `
+		WITH query AS (SELECT websearch_to_tsquery('russian', $1) AS tsq)
+		SELECT m.channel_id, m.message_id, m.message_date,
+			ts_headline('russian', COALESCE(m.message_text, ''), query.tsq,
+				'StartSel=«, StopSel=», MaxWords=35, MinWords=15, MaxFragments=1'),
+			COALESCE(m.meta->>'url', ''), COUNT(*) OVER ()
+		FROM messages m, query
+		WHERE m.search_vector @@ query.tsq AND m.deleted_at IS NULL
+			AND ($2::bigint = 0 OR m.channel_id = $2)
+			AND ($3::timestamp IS NULL OR m.message_date >= $3)
+			AND ($4::timestamp IS NULL OR m.message_date < $4)
+		ORDER BY m.message_date DESC, m.id DESC
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Query(query, q.Text, q.ChannelID, nullTime(q.From), nullTime(q.To), q.Limit, q.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		results []SearchResult
+		total   int
+	)
+	for rows.Next() {
+		var res SearchResult
+		if err := rows.Scan(&res.ChannelID, &res.MessageID, &res.MessageDate, &res.Headline, &res.URL, &total); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, res)
+	}
+	return results, total, rows.Err()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRepository_SearchMessages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSearchRepository(db)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	msgDate := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery("websearch_to_tsquery\\('russian', \\$1\\).*ts_headline.*COUNT\\(\\*\\) OVER \\(\\).*search_vector @@ query.tsq.*ORDER BY m.message_date DESC.*LIMIT \\$5 OFFSET \\$6").
+		WithArgs("ключевая ставка", int64(1754252633), sql.NullTime{Time: from, Valid: true}, sql.NullTime{}, 5, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"channel_id", "message_id", "message_date", "headline", "url", "total"}).
+			AddRow(int64(1754252633), int64(9001), msgDate, "ЦБ сохранил «ключевую» «ставку»", "", 11))
+
+	results, total, err := repo.SearchMessages(SearchQuery{
+		Text:      "ключевая ставка",
+		ChannelID: 1754252633,
+		From:      from,
+		Limit:     5,
+		Offset:    10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 11, total)
+	require.Len(t, results, 1)
+	assert.Equal(t, SearchResult{
+		ChannelID:   1754252633,
+		MessageID:   9001,
+		MessageDate: msgDate,
+		Headline:    "ЦБ сохранил «ключевую» «ставку»",
+	}, results[0])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_NoResults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSearchRepository(db)
+
+	mock.ExpectQuery("websearch_to_tsquery").
+		WithArgs("газпром", int64(0), sql.NullTime{}, sql.NullTime{}, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"channel_id", "message_id", "message_date", "headline", "url", "total"}))
+
+	results, total, err := repo.SearchMessages(SearchQuery{Text: "газпром", Limit: 5})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, results)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package repository
+
+//go:generate mockgen -source=session.go -destination=../mocks/repository/session_mock.go -package=mock_repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gotd/td/session"
+)
+
+// SessionName - ключ сессии аккаунта, читающего каналы, в telegram_sessions.
+const SessionName = "reader"
+
+// SessionRepositoryInterface - telegram.SessionStorage в базе (миграция 0017).
+// Без сохранённой сессии LoadSession возвращает session.ErrNotFound.
+type SessionRepositoryInterface interface {
+	LoadSession(ctx context.Context) ([]byte, error)
+	StoreSession(ctx context.Context, data []byte) error
+}
+
+type SessionRepository struct {
+	db   *sql.DB
+	aead cipher.AEAD
+	name string
+}
+
+// NewSessionRepository - хранилище сессии name, зашифрованной ключом key (32 байта, AES-256-GCM).
+func NewSessionRepository(db *sql.DB, key []byte, name string) (SessionRepositoryInterface, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("session encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionRepository{db: db, aead: aead, name: name}, nil
+}
+
+// ParseSessionKey разбирает SESSION_ENCRYPTION_KEY: 32 байта в base64
+// (например, `openssl rand -base64 32`).
+func ParseSessionKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("session encryption key is not base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("session encryption key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+func (r *SessionRepository) LoadSession(ctx context.Context) ([]byte, error) {
+	var sealed []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM telegram_sessions WHERE name = $1`, r.name).Scan(&sealed)
+	if err == sql.ErrNoRows {
+		return nil, session.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := r.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("stored session is too short")
+	}
+	// name - дополнительные данные: сессию нельзя подложить под другим именем.
+	data, err := r.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(r.name))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt session (wrong SESSION_ENCRYPTION_KEY?): %w", err)
+	}
+	return data, nil
+}
+
+func (r *SessionRepository) StoreSession(ctx context.Context, data []byte) error {
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := r.aead.Seal(nonce, nonce, data, []byte(r.name))
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO telegram_sessions (name, data, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW()
+	`, r.name, sealed)
+	return err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gotd/td/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedBytes запоминает аргумент запроса, чтобы вернуть его из следующего SELECT.
+type capturedBytes struct {
+	value []byte
+}
+
+func (c *capturedBytes) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	c.value = b
+	return ok
+}
+
+func TestSessionRepository_StoreAndLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	key := bytes.Repeat([]byte{7}, 32)
+	repo, err := NewSessionRepository(db, key, SessionName)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	data := []byte(`{"Version":1,"Data":{"AuthKey":"secret"}}`)
+	sealed := &capturedBytes{}
+	mock.ExpectExec("INSERT INTO telegram_sessions.*ON CONFLICT \\(name\\) DO UPDATE").
+		WithArgs(SessionName, sealed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.StoreSession(ctx, data))
+
+	// в базу попадает только шифртекст
+	assert.NotContains(t, string(sealed.value), "secret")
+
+	mock.ExpectQuery("SELECT data FROM telegram_sessions WHERE name = \\$1").
+		WithArgs(SessionName).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(sealed.value))
+	loaded, err := repo.LoadSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, data, loaded)
+
+	// другой ключ или другое имя сессии не расшифровывают данные
+	other, err := NewSessionRepository(db, bytes.Repeat([]byte{8}, 32), SessionName)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT data FROM telegram_sessions").
+		WithArgs(SessionName).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(sealed.value))
+	_, err = other.LoadSession(ctx)
+	assert.Error(t, err)
+
+	renamed, err := NewSessionRepository(db, key, "backup")
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT data FROM telegram_sessions").
+		WithArgs("backup").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(sealed.value))
+	_, err = renamed.LoadSession(ctx)
+	assert.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepository_LoadNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo, err := NewSessionRepository(db, bytes.Repeat([]byte{7}, 32), SessionName)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT data FROM telegram_sessions").
+		WithArgs(SessionName).
+		WillReturnRows(sqlmock.NewRows([]string{"data"}))
+
+	_, err = repo.LoadSession(context.Background())
+	assert.True(t, errors.Is(err, session.ErrNotFound))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseSessionKey(t *testing.T) {
+	key, err := ParseSessionKey(" " + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)) + "\n")
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = ParseSessionKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+
+	_, err = ParseSessionKey("not base64!")
+	assert.Error(t, err)
+}
@@ -4,8 +4,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/Ra1ze505/goNewsBot/src/config"
 	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
@@ -32,6 +35,7 @@ type Observation struct {
 	ChangeType       string
 	DeltaSummary     string
 	SourceMessageIDs []int64
+	PromptVersion    string // подпись промптов конвейера, см. MLRepositoryInterface.PromptVersion
 }
 
 // StorylineStats - агрегаты по observations со obs_date < date (окно BaselineWindowDays).
@@ -49,8 +53,13 @@ type ScoredStoryline struct {
 
 type StorylineRepositoryInterface interface {
 	// матчинг
-	SearchNearest(channelID int64, query []float32, k int) ([]ScoredStoryline, error) // active only
+	// active + dormant; closed - только с last_seen >= closedSince (нулевое время - без closed)
+	SearchNearest(channelID int64, query []float32, k int, closedSince time.Time) ([]ScoredStoryline, error)
+	// поиск сюжета читателем: все каналы и статусы
+	SearchNearestAll(query []float32, k int) ([]ScoredStoryline, error)
 	GetActive(channelID int64) ([]Storyline, error)
+	// самопроверка HNSW: средняя доля точных top-k, найденных ANN-поиском, на случайной выборке сюжетов
+	CheckANNRecall(sampleSize, k int) (float64, error)
 
 	// статистика для классификации (строго obs_date < before)
 	GetStats(storylineID int64, before time.Time, windowDays int) (StorylineStats, error)
@@ -66,27 +75,101 @@ type StorylineRepositoryInterface interface {
 
 	// идемпотентность перегенерации/бэкфилла
 	DeleteObservationsForDate(channelID int64, date time.Time) error
+	// наблюдения, чьи исходные посты отредактированы или удалены (миграция 0013), по дням
+	CountObservationsNeedingRegeneration(channelID int64) (map[time.Time]int, error)
 	ResetChannel(channelID int64) error
+
+	// ручная правка из админки
+	GetStoryline(id int64) (*Storyline, error) // nil, если не найден
+	ListStorylines(channelID int64, limit int) ([]Storyline, error)
+	GetObservations(storylineID int64) ([]Observation, error)
+	MergeStorylines(target *Storyline, sourceID int64) error
+	SplitStoryline(source *Storyline, dates []time.Time, s *Storyline) (int64, error)
+	RenameStoryline(id int64, title string, embedding []float32) error
+	UpdateCategory(id int64, category string) error
 }
 
 type StorylineRepository struct {
-	db *sql.DB
+	db         *sql.DB
+	searchMode string // config.SearchMode*
+
+	// кэш числа сюжетов с эмбеддингом для режима auto
+	mu         sync.Mutex
+	rowCount   int64
+	rowCountAt time.Time
 }
 
 func NewStorylineRepository(db *sql.DB) StorylineRepositoryInterface {
-	return &StorylineRepository{db: db}
+	return &StorylineRepository{db: db, searchMode: config.StorylineSearchMode()}
 }
 
-func (r *StorylineRepository) SearchNearest(channelID int64, query []float32, k int) ([]ScoredStoryline, error) {
+func (r *StorylineRepository) SearchNearest(channelID int64, query []float32, k int, closedSince time.Time) ([]ScoredStoryline, error) {
+	ann, err := r.useANN()
+	if err != nil {
+		return nil, err
+	}
+	results, err := r.searchNearest(channelID, query, k, closedSince, ann)
+	if err != nil || !ann || len(results) >= k {
+		return results, err
+	}
+	// HNSW берёт hnsw.ef_search ближайших по всей таблице и только потом фильтрует
+	// по каналу и статусу: если сюжетов меньше k, поиск повторяется точным
+	// перебором, иначе матчинг не находит сюжет и плодит дубли.
+	return r.searchNearest(channelID, query, k, closedSince, false)
+}
+
+// searchNearest выполняет поиск в транзакции, чтобы настройки планировщика
+// (SET LOCAL) действовали только на этот запрос, а не на соединение из пула.
+func (r *StorylineRepository) searchNearest(channelID int64, query []float32, k int, closedSince time.Time, ann bool) ([]ScoredStoryline, error) {
 	q := `
 		SELECT id, channel_id, title, state, COALESCE(category, ''), status, importance, first_seen, last_seen,
 			1 - (embedding <=> $2) AS similarity
 		FROM storylines
-		WHERE channel_id = $1 AND status = 'active' AND embedding IS NOT NULL
+		WHERE channel_id = $1 AND embedding IS NOT NULL
+			AND (status IN ('active', 'dormant')
+				OR (status = 'closed' AND $4::date IS NOT NULL AND last_seen >= $4::date))
 		ORDER BY embedding <=> $2
 		LIMIT $3
 	`
-	rows, err := r.db.Query(q, channelID, pgvector.NewVector(query), k)
+	closed := sql.NullTime{Time: closedSince, Valid: !closedSince.IsZero()}
+	return r.queryNearest(ann, q, channelID, pgvector.NewVector(query), k, closed)
+}
+
+// SearchNearestAll ищет по всем каналам и статусам, включая давно закрытые сюжеты.
+func (r *StorylineRepository) SearchNearestAll(query []float32, k int) ([]ScoredStoryline, error) {
+	ann, err := r.useANN()
+	if err != nil {
+		return nil, err
+	}
+	q := `
+		SELECT id, channel_id, title, state, COALESCE(category, ''), status, importance, first_seen, last_seen,
+			1 - (embedding <=> $1) AS similarity
+		FROM storylines
+		WHERE embedding IS NOT NULL
+		ORDER BY embedding <=> $1
+		LIMIT $2
+	`
+	return r.queryNearest(ann, q, pgvector.NewVector(query), k)
+}
+
+func (r *StorylineRepository) queryNearest(ann bool, q string, args ...interface{}) ([]ScoredStoryline, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if ann {
+		if _, err := tx.Exec(fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", config.HNSWEfSearch)); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := tx.Exec("SET LOCAL enable_indexscan = off"); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +187,11 @@ func (r *StorylineRepository) SearchNearest(channelID int64, query []float32, k
 		}
 		results = append(results, ScoredStoryline{Storyline: s, Similarity: sim})
 	}
-	return results, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return results, tx.Commit()
 }
 
 func (r *StorylineRepository) GetActive(channelID int64) ([]Storyline, error) {
@@ -193,18 +280,20 @@ func (r *StorylineRepository) UpdateStoryline(s *Storyline) error {
 func (r *StorylineRepository) SaveObservation(o *Observation) error {
 	q := `
 		INSERT INTO storyline_observations
-			(storyline_id, channel_id, obs_date, message_count, importance, change_type, delta_summary, source_message_ids)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			(storyline_id, channel_id, obs_date, message_count, importance, change_type, delta_summary, source_message_ids, prompt_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (storyline_id, obs_date) DO UPDATE SET
 			message_count = EXCLUDED.message_count,
 			importance = EXCLUDED.importance,
 			change_type = EXCLUDED.change_type,
 			delta_summary = EXCLUDED.delta_summary,
-			source_message_ids = EXCLUDED.source_message_ids
+			source_message_ids = EXCLUDED.source_message_ids,
+			prompt_version = EXCLUDED.prompt_version,
+			needs_regeneration = FALSE
 	`
 	_, err := r.db.Exec(q,
 		o.StorylineID, o.ChannelID, o.ObsDate, o.MessageCount, o.Importance,
-		o.ChangeType, nullString(o.DeltaSummary), pq.Array(o.SourceMessageIDs),
+		o.ChangeType, nullString(o.DeltaSummary), pq.Array(o.SourceMessageIDs), nullString(o.PromptVersion),
 	)
 	return err
 }
@@ -236,6 +325,31 @@ func (r *StorylineRepository) DeleteObservationsForDate(channelID int64, date ti
 	return err
 }
 
+func (r *StorylineRepository) CountObservationsNeedingRegeneration(channelID int64) (map[time.Time]int, error) {
+	q := `
+		SELECT obs_date, COUNT(*)
+		FROM storyline_observations
+		WHERE channel_id = $1 AND needs_regeneration
+		GROUP BY obs_date
+	`
+	rows, err := r.db.Query(q, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[time.Time]int)
+	for rows.Next() {
+		var day time.Time
+		var count int
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		result[day.UTC()] = count
+	}
+	return result, rows.Err()
+}
+
 func (r *StorylineRepository) ResetChannel(channelID int64) error {
 	if _, err := r.db.Exec(`DELETE FROM storyline_observations WHERE channel_id = $1`, channelID); err != nil {
 		return err
@@ -244,6 +358,202 @@ func (r *StorylineRepository) ResetChannel(channelID int64) error {
 	return err
 }
 
+func (r *StorylineRepository) GetStoryline(id int64) (*Storyline, error) {
+	q := `
+		SELECT id, channel_id, title, state, COALESCE(category, ''), status, importance, first_seen, last_seen
+		FROM storylines
+		WHERE id = $1
+	`
+	var s Storyline
+	err := r.db.QueryRow(q, id).Scan(
+		&s.ID, &s.ChannelID, &s.Title, &s.State, &s.Category, &s.Status, &s.Importance,
+		&s.FirstSeen, &s.LastSeen,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListStorylines возвращает незакрытые сюжеты канала, свежие первыми.
+func (r *StorylineRepository) ListStorylines(channelID int64, limit int) ([]Storyline, error) {
+	q := `
+		SELECT id, channel_id, title, state, COALESCE(category, ''), status, importance, first_seen, last_seen
+		FROM storylines
+		WHERE channel_id = $1 AND status <> 'closed'
+		ORDER BY last_seen DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(q, channelID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Storyline
+	for rows.Next() {
+		var s Storyline
+		if err := rows.Scan(
+			&s.ID, &s.ChannelID, &s.Title, &s.State, &s.Category, &s.Status, &s.Importance,
+			&s.FirstSeen, &s.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
+
+func (r *StorylineRepository) GetObservations(storylineID int64) ([]Observation, error) {
+	q := `
+		SELECT storyline_id, channel_id, obs_date, message_count, importance, change_type,
+			COALESCE(delta_summary, ''), COALESCE(source_message_ids, '{}'), COALESCE(prompt_version, '')
+		FROM storyline_observations
+		WHERE storyline_id = $1
+		ORDER BY obs_date ASC
+	`
+	rows, err := r.db.Query(q, storylineID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Observation
+	for rows.Next() {
+		var o Observation
+		if err := rows.Scan(
+			&o.StorylineID, &o.ChannelID, &o.ObsDate, &o.MessageCount, &o.Importance, &o.ChangeType,
+			&o.DeltaSummary, pq.Array(&o.SourceMessageIDs), &o.PromptVersion,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, o)
+	}
+	return results, rows.Err()
+}
+
+// MergeStorylines сливает сюжет sourceID в target: переносит наблюдения (совпавшие
+// дни складываются), записывает итоговое состояние target и удаляет source.
+func (r *StorylineRepository) MergeStorylines(target *Storyline, sourceID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	moveQ := `
+		INSERT INTO storyline_observations
+			(storyline_id, channel_id, obs_date, message_count, importance, change_type, delta_summary, source_message_ids, prompt_version)
+		SELECT $1, channel_id, obs_date, message_count, importance, change_type, delta_summary, source_message_ids, prompt_version
+		FROM storyline_observations
+		WHERE storyline_id = $2
+		ON CONFLICT (storyline_id, obs_date) DO UPDATE SET
+			message_count = storyline_observations.message_count + EXCLUDED.message_count,
+			importance = GREATEST(storyline_observations.importance, EXCLUDED.importance),
+			delta_summary = NULLIF(CONCAT_WS(' ', storyline_observations.delta_summary, EXCLUDED.delta_summary), ''),
+			source_message_ids = ARRAY(
+				SELECT DISTINCT unnest(storyline_observations.source_message_ids || EXCLUDED.source_message_ids)
+			)
+	`
+	if _, err := tx.Exec(moveQ, target.ID, sourceID); err != nil {
+		return err
+	}
+
+	updateQ := `
+		UPDATE storylines
+		SET title = $2, state = $3, category = $4, status = $5, importance = $6,
+			embedding = $7, first_seen = $8, last_seen = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(updateQ,
+		target.ID, target.Title, target.State, nullString(target.Category), statusOrActive(target.Status), target.Importance,
+		pgvector.NewVector(target.Embedding), target.FirstSeen, target.LastSeen,
+	); err != nil {
+		return err
+	}
+
+	// Наблюдения source уже скопированы, остаток удалит ON DELETE CASCADE.
+	if _, err := tx.Exec(`DELETE FROM storylines WHERE id = $1`, sourceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SplitStoryline создаёт сюжет s и переносит в него наблюдения source.ID за даты
+// dates. Состояние, важность, статус, эмбеддинг и границы исходного сюжета
+// перезаписываются значениями из source, пересчитанными по оставшимся наблюдениям.
+func (r *StorylineRepository) SplitStoryline(source *Storyline, dates []time.Time, s *Storyline) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var newID int64
+	insertQ := `
+		INSERT INTO storylines (channel_id, title, state, category, status, importance, embedding, first_seen, last_seen)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	if err := tx.QueryRow(insertQ,
+		s.ChannelID, s.Title, s.State, nullString(s.Category), statusOrActive(s.Status), s.Importance,
+		pgvector.NewVector(s.Embedding), s.FirstSeen, s.LastSeen,
+	).Scan(&newID); err != nil {
+		return 0, err
+	}
+
+	days := make([]string, len(dates))
+	for i, d := range dates {
+		days[i] = d.Format("2006-01-02")
+	}
+	moveQ := `
+		UPDATE storyline_observations
+		SET storyline_id = $1
+		WHERE storyline_id = $2 AND obs_date = ANY($3::date[])
+	`
+	if _, err := tx.Exec(moveQ, newID, source.ID, pq.Array(days)); err != nil {
+		return 0, err
+	}
+
+	sourceQ := `
+		UPDATE storylines
+		SET state = $2, importance = $3, status = $4, embedding = $5, first_seen = $6, last_seen = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(sourceQ,
+		source.ID, source.State, source.Importance, statusOrActive(source.Status),
+		pgvector.NewVector(source.Embedding), source.FirstSeen, source.LastSeen,
+	); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+func (r *StorylineRepository) RenameStoryline(id int64, title string, embedding []float32) error {
+	q := `
+		UPDATE storylines
+		SET title = $2, embedding = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(q, id, title, pgvector.NewVector(embedding))
+	return err
+}
+
+func (r *StorylineRepository) UpdateCategory(id int64, category string) error {
+	q := `UPDATE storylines SET category = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.db.Exec(q, id, nullString(category))
+	return err
+}
+
 func nullString(s string) sql.NullString {
 	if s == "" {
 		return sql.NullString{}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/pgvector/pgvector-go"
+)
+
+// rowCountTTL - как долго режим auto доверяет закэшированному числу сюжетов.
+const rowCountTTL = 10 * time.Minute
+
+// useANN решает, искать ли через HNSW-индекс или точным перебором.
+func (r *StorylineRepository) useANN() (bool, error) {
+	switch r.searchMode {
+	case config.SearchModeANN:
+		return true, nil
+	case config.SearchModeExact:
+		return false, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rowCountAt.IsZero() || time.Since(r.rowCountAt) > rowCountTTL {
+		var count int64
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM storylines WHERE embedding IS NOT NULL`).Scan(&count); err != nil {
+			return false, err
+		}
+		r.rowCount = count
+		r.rowCountAt = time.Now()
+	}
+	return r.rowCount >= config.ANNMinRows, nil
+}
+
+// CheckANNRecall берёт случайные сюжеты, ищет их соседей по собственному эмбеддингу
+// точным перебором и через HNSW и возвращает среднюю долю совпавших top-k.
+// Если сравнивать не с чем (нет сюжетов с эмбеддингом), recall считается равным 1.
+func (r *StorylineRepository) CheckANNRecall(sampleSize, k int) (float64, error) {
+	q := `
+		SELECT channel_id, embedding
+		FROM storylines
+		WHERE embedding IS NOT NULL AND status IN ('active', 'dormant')
+		ORDER BY random()
+		LIMIT $1
+	`
+	rows, err := r.db.Query(q, sampleSize)
+	if err != nil {
+		return 0, err
+	}
+	type sample struct {
+		channelID int64
+		embedding []float32
+	}
+	var samples []sample
+	for rows.Next() {
+		var s sample
+		var vec pgvector.Vector
+		if err := rows.Scan(&s.channelID, &vec); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		s.embedding = vec.Slice()
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	var total float64
+	var checked int
+	for _, s := range samples {
+		exact, err := r.searchNearest(s.channelID, s.embedding, k, time.Time{}, false)
+		if err != nil {
+			return 0, err
+		}
+		if len(exact) == 0 {
+			continue
+		}
+		approx, err := r.searchNearest(s.channelID, s.embedding, k, time.Time{}, true)
+		if err != nil {
+			return 0, err
+		}
+		found := make(map[int64]struct{}, len(approx))
+		for _, a := range approx {
+			found[a.Storyline.ID] = struct{}{}
+		}
+		hits := 0
+		for _, e := range exact {
+			if _, ok := found[e.Storyline.ID]; ok {
+				hits++
+			}
+		}
+		total += float64(hits) / float64(len(exact))
+		checked++
+	}
+	if checked == 0 {
+		return 1, nil
+	}
+	return total / float64(checked), nil
+}
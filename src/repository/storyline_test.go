@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1ze505/goNewsBot/src/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,18 +15,23 @@ func TestStorylineRepository_SearchNearest(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	repo := NewStorylineRepository(db)
+	repo := &StorylineRepository{db: db, searchMode: config.SearchModeAuto}
 
 	firstSeen := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
 	lastSeen := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
 	rows := sqlmock.NewRows([]string{"id", "channel_id", "title", "state", "category", "status", "importance", "first_seen", "last_seen", "similarity"}).
 		AddRow(int64(42), int64(123), "Сюжет", "состояние", "политика", "active", 3, firstSeen, lastSeen, 0.87)
 
+	// auto на малой таблице -> точный перебор
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(300)))
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL enable_indexscan = off").WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectQuery("SELECT id, channel_id, title, state").
-		WithArgs(int64(123), sqlmock.AnyArg(), 5).
+		WithArgs(int64(123), sqlmock.AnyArg(), 5, sqlmock.AnyArg()).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	results, err := repo.SearchNearest(123, []float32{0.1, 0.2, 0.3}, 5)
+	results, err := repo.SearchNearest(123, []float32{0.1, 0.2, 0.3}, 5, time.Time{})
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 	assert.Equal(t, int64(42), results[0].Storyline.ID)
@@ -35,6 +41,126 @@ func TestStorylineRepository_SearchNearest(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestStorylineRepository_SearchNearestANN(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &StorylineRepository{db: db, searchMode: config.SearchModeAuto}
+
+	columns := []string{"id", "channel_id", "title", "state", "category", "status", "importance", "first_seen", "last_seen", "similarity"}
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(config.ANNMinRows)))
+	// HNSW отфильтровал всех кандидатов - поиск повторяется точным перебором
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL hnsw.ef_search = 100").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, channel_id, title, state").WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL enable_indexscan = off").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, channel_id, title, state").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(42), int64(123), "Сюжет", "состояние", "", "active", 3, day, day, 0.8))
+	mock.ExpectCommit()
+	// второй поиск берёт число строк из кэша; ANN вернул k сюжетов - без повтора
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL hnsw.ef_search = 100").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, channel_id, title, state").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(int64(42), int64(123), "Сюжет", "состояние", "", "active", 3, day, day, 0.8))
+	mock.ExpectCommit()
+
+	for i := 0; i < 2; i++ {
+		results, err := repo.SearchNearest(123, []float32{0.1, 0.2, 0.3}, 1, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, int64(42), results[0].Storyline.ID)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorylineRepository_SearchNearestAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &StorylineRepository{db: db, searchMode: config.SearchModeExact}
+
+	firstSeen := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	lastSeen := time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "channel_id", "title", "state", "category", "status", "importance", "first_seen", "last_seen", "similarity"}).
+		AddRow(int64(7), int64(456), "Закрытый сюжет", "итог", "экономика", "closed", 2, firstSeen, lastSeen, 0.41)
+
+	// без фильтра по каналу и статусу
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL enable_indexscan = off").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM storylines\\s+WHERE embedding IS NOT NULL\\s+ORDER BY embedding <=> \\$1\\s+LIMIT \\$2").
+		WithArgs(sqlmock.AnyArg(), 5).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	results, err := repo.SearchNearestAll([]float32{0.1, 0.2, 0.3}, 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "closed", results[0].Storyline.Status)
+	assert.Equal(t, int64(456), results[0].Storyline.ChannelID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorylineRepository_CountObservationsNeedingRegeneration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &StorylineRepository{db: db}
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM storyline_observations\\s+WHERE channel_id = \\$1 AND needs_regeneration\\s+GROUP BY obs_date").
+		WithArgs(int64(123)).
+		WillReturnRows(sqlmock.NewRows([]string{"obs_date", "count"}).AddRow(day, 2))
+
+	counts, err := repo.CountObservationsNeedingRegeneration(123)
+	require.NoError(t, err)
+	assert.Equal(t, map[time.Time]int{day: 2}, counts)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorylineRepository_CheckANNRecall(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &StorylineRepository{db: db, searchMode: config.SearchModeAuto}
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "channel_id", "title", "state", "category", "status", "importance", "first_seen", "last_seen", "similarity"}
+	result := func(ids ...int64) *sqlmock.Rows {
+		rows := sqlmock.NewRows(columns)
+		for _, id := range ids {
+			rows.AddRow(id, int64(123), "Сюжет", "состояние", "", "active", 1, day, day, 0.9)
+		}
+		return rows
+	}
+
+	mock.ExpectQuery("ORDER BY random").WithArgs(50).
+		WillReturnRows(sqlmock.NewRows([]string{"channel_id", "embedding"}).AddRow(int64(123), "[0.1,0.2,0.3]"))
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL enable_indexscan = off").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, channel_id, title, state").WillReturnRows(result(1, 2, 3, 4))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL hnsw.ef_search").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, channel_id, title, state").WillReturnRows(result(1, 2, 3, 9))
+	mock.ExpectCommit()
+
+	recall, err := repo.CheckANNRecall(50, 5)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, recall, 1e-9)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestStorylineRepository_GetStats(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -95,10 +221,12 @@ func TestStorylineRepository_SaveObservationUpsert(t *testing.T) {
 	o := &Observation{
 		StorylineID: 7, ChannelID: 123, ObsDate: day, MessageCount: 2, Importance: 3,
 		ChangeType: "escalation", DeltaSummary: "новое", SourceMessageIDs: []int64{100, 101},
+		PromptVersion: "extract@v1,match@v1,delta@v1,render@v1",
 	}
 
-	mock.ExpectExec("INSERT INTO storyline_observations").
-		WithArgs(int64(7), int64(123), day, 2, 3, "escalation", sqlmock.AnyArg(), sqlmock.AnyArg()).
+	// пересчитанное наблюдение больше не ждёт перегенерации
+	mock.ExpectExec("INSERT INTO storyline_observations.*needs_regeneration = FALSE").
+		WithArgs(int64(7), int64(123), day, 2, 3, "escalation", sqlmock.AnyArg(), sqlmock.AnyArg(), "extract@v1,match@v1,delta@v1,render@v1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = repo.SaveObservation(o)
@@ -142,3 +270,91 @@ func TestStorylineRepository_MarkDormant(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestStorylineRepository_MergeStorylines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStorylineRepository(db)
+
+	firstSeen := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	lastSeen := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	target := &Storyline{
+		ID: 42, ChannelID: 123, Title: "Сюжет", State: "состояние", Category: "политика",
+		Status: "active", Importance: 4, Embedding: []float32{0.1}, FirstSeen: firstSeen, LastSeen: lastSeen,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO storyline_observations").
+		WithArgs(int64(42), int64(57)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE storylines").
+		WithArgs(int64(42), "Сюжет", "состояние", sqlmock.AnyArg(), "active", 4, sqlmock.AnyArg(), firstSeen, lastSeen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM storylines").
+		WithArgs(int64(57)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.MergeStorylines(target, 57)
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorylineRepository_MergeStorylinesRollsBackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStorylineRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO storyline_observations").
+		WithArgs(int64(42), int64(57)).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = repo.MergeStorylines(&Storyline{ID: 42}, 57)
+	require.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorylineRepository_SplitStoryline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewStorylineRepository(db)
+
+	day := time.Date(2026, 6, 18, 0, 0, 0, 0, time.UTC)
+	s := &Storyline{
+		ChannelID: 123, Title: "Отдельный", State: "дельта", Category: "экономика",
+		Status: "active", Importance: 3, Embedding: []float32{0.2}, FirstSeen: day, LastSeen: day,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO storylines").
+		WithArgs(int64(123), "Отдельный", "дельта", sqlmock.AnyArg(), "active", 3, sqlmock.AnyArg(), day, day).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(99)))
+	mock.ExpectExec("UPDATE storyline_observations").
+		WithArgs(int64(99), int64(42), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// исходный сюжет пересчитан по оставшимся наблюдениям
+	mock.ExpectExec("UPDATE storylines\\s+SET state = \\$2, importance = \\$3, status = \\$4, embedding = \\$5").
+		WithArgs(int64(42), "остаток", 2, "dormant", sqlmock.AnyArg(), day.AddDate(0, 0, -20), day.AddDate(0, 0, -10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	source := &Storyline{
+		ID: 42, State: "остаток", Status: "dormant", Importance: 2, Embedding: []float32{0.3},
+		FirstSeen: day.AddDate(0, 0, -20), LastSeen: day.AddDate(0, 0, -10),
+	}
+	id, err := repo.SplitStoryline(source, []time.Time{day}, s)
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), id)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,21 +4,28 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
 
 type Summary struct {
-	ID        int64
-	ChannelID int64
-	Summary   string
+	ID            int64
+	ChannelID     int64
+	Summary       string
+	PromptVersion string // подпись промптов конвейера, см. MLRepositoryInterface.PromptVersion
+	// Groups - группы сюжетов, из которых отрендерен дайджест; сохраняются для
+	// персонального перерендера. nil - не сохранять.
+	Groups    *DigestGroups
 	CreatedAt time.Time
 }
 
 // MessageInput - сообщение канала с реальным message_id для резолва источников в TDT.
+// Посты без текста (медиа без подписи) в конвейер не попадают.
 type MessageInput struct {
 	MessageID int64
 	Text      string
+	Meta      MessageMeta // медиа и метрики - сигнал важности для стадии A
 }
 
 func (s *Summary) GetFormattedSummary() string {
@@ -34,6 +41,8 @@ type SummaryRepositoryInterface interface {
 	GetMessagesForDateWithIDs(channelID int64, date time.Time) ([]MessageInput, error)
 	GetMessagesForLastDayWithIDs(channelID int64) ([]MessageInput, error)
 	DeleteLastSummary(channelID int64) error
+	// GetDigestGroups - группы сюжетов дайджеста; nil, если дайджест собран до их сохранения.
+	GetDigestGroups(summaryID int64) (*DigestGroups, error)
 }
 
 type SummaryRepository struct {
@@ -45,18 +54,106 @@ func NewSummaryRepository(db *sql.DB) SummaryRepositoryInterface {
 }
 
 func (r *SummaryRepository) SaveSummary(summary *Summary) error {
+	groups, err := marshalDigestGroups(summary.Groups)
+	if err != nil {
+		return err
+	}
 	query := `
-		INSERT INTO summaries (channel_id, summary, created_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO summaries (channel_id, summary, prompt_version, digest_groups, created_at)
+		VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err := r.db.Exec(query,
+	_, err = r.db.Exec(query,
 		summary.ChannelID,
 		summary.Summary,
+		nullString(summary.PromptVersion),
+		groups,
 		summary.CreatedAt,
 	)
 	return err
 }
 
+func (r *SummaryRepository) GetDigestGroups(summaryID int64) (*DigestGroups, error) {
+	var data []byte
+	err := r.db.QueryRow(`SELECT digest_groups FROM summaries WHERE id = $1`, summaryID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		// дайджест сохранён до 0010 или без групп
+		return nil, nil
+	}
+	return unmarshalDigestGroups(data)
+}
+
+// storedDigestItem - DigestItem для summaries.digest_groups: в отличие от промпта рендера
+// хранит SourceMessageID и SourceURL, иначе перерендер потеряет ссылки на источники.
+type storedDigestItem struct {
+	DigestItem
+	SourceMessageID int64  `json:"source_message_id,omitempty"`
+	SourceURL       string `json:"source_url,omitempty"`
+}
+
+type storedDigestGroups struct {
+	New            []storedDigestItem
+	Escalation     []storedDigestItem
+	Revived        []storedDigestItem
+	Ongoing        []storedDigestItem
+	RecurringNoise []string
+}
+
+func marshalDigestGroups(groups *DigestGroups) (interface{}, error) {
+	if groups == nil {
+		return nil, nil
+	}
+	toStored := func(items []DigestItem) []storedDigestItem {
+		stored := make([]storedDigestItem, len(items))
+		for i, item := range items {
+			stored[i] = storedDigestItem{DigestItem: item, SourceMessageID: item.SourceMessageID, SourceURL: item.SourceURL}
+		}
+		return stored
+	}
+	data, err := json.Marshal(storedDigestGroups{
+		New:            toStored(groups.New),
+		Escalation:     toStored(groups.Escalation),
+		Revived:        toStored(groups.Revived),
+		Ongoing:        toStored(groups.Ongoing),
+		RecurringNoise: groups.RecurringNoise,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal digest groups: %w", err)
+	}
+	return data, nil
+}
+
+func unmarshalDigestGroups(data []byte) (*DigestGroups, error) {
+	var stored storedDigestGroups
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal digest groups: %w", err)
+	}
+	fromStored := func(stored []storedDigestItem) []DigestItem {
+		if len(stored) == 0 {
+			return nil
+		}
+		items := make([]DigestItem, len(stored))
+		for i, s := range stored {
+			items[i] = s.DigestItem
+			items[i].SourceMessageID = s.SourceMessageID
+			items[i].SourceURL = s.SourceURL
+		}
+		return items
+	}
+	return &DigestGroups{
+		New:            fromStored(stored.New),
+		Escalation:     fromStored(stored.Escalation),
+		Revived:        fromStored(stored.Revived),
+		Ongoing:        fromStored(stored.Ongoing),
+		RecurringNoise: stored.RecurringNoise,
+	}, nil
+}
+
 func (r *SummaryRepository) HasSummaryToday(channelID int64) (bool, error) {
 	now := time.Now().UTC()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
@@ -81,7 +178,7 @@ func (r *SummaryRepository) GetMessagesForLastDay(channelID int64) ([]string, er
 	query := `
 		SELECT message_text 
 		FROM messages 
-		WHERE channel_id = $1 
+		WHERE channel_id = $1 AND deleted_at IS NULL AND message_text <> ''
 		AND message_date >= NOW() - INTERVAL '1 day'
 		ORDER BY message_date ASC
 	`
@@ -135,7 +232,7 @@ func (r *SummaryRepository) GetMessagesForDate(channelID int64, date time.Time)
 	query := `
 		SELECT message_text 
 		FROM messages 
-		WHERE channel_id = $1 
+		WHERE channel_id = $1 AND deleted_at IS NULL AND message_text <> ''
 		AND message_date >= $2 
 		AND message_date < $3
 		ORDER BY message_date ASC
@@ -164,9 +261,9 @@ func (r *SummaryRepository) GetMessagesForDateWithIDs(channelID int64, date time
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
 	query := `
-		SELECT message_id, message_text 
+		SELECT message_id, message_text, meta
 		FROM messages 
-		WHERE channel_id = $1 
+		WHERE channel_id = $1 AND deleted_at IS NULL AND message_text <> ''
 		AND message_date >= $2 
 		AND message_date < $3
 		ORDER BY message_date ASC
@@ -183,9 +280,9 @@ func (r *SummaryRepository) GetMessagesForDateWithIDs(channelID int64, date time
 
 func (r *SummaryRepository) GetMessagesForLastDayWithIDs(channelID int64) ([]MessageInput, error) {
 	query := `
-		SELECT message_id, message_text 
+		SELECT message_id, message_text, meta
 		FROM messages 
-		WHERE channel_id = $1 
+		WHERE channel_id = $1 AND deleted_at IS NULL AND message_text <> ''
 		AND message_date >= NOW() - INTERVAL '1 day'
 		ORDER BY message_date ASC
 	`
@@ -203,10 +300,16 @@ func scanMessageInputs(rows *sql.Rows) ([]MessageInput, error) {
 	for rows.Next() {
 		var msg MessageInput
 		var text sql.NullString
-		if err := rows.Scan(&msg.MessageID, &text); err != nil {
+		var meta []byte
+		if err := rows.Scan(&msg.MessageID, &text, &meta); err != nil {
 			return nil, err
 		}
 		msg.Text = text.String
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &msg.Meta); err != nil {
+				return nil, err
+			}
+		}
 		messages = append(messages, msg)
 	}
 	return messages, rows.Err()
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryRepository_DigestGroupsKeepSourceMessageIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSummaryRepository(db)
+	now := time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)
+	groups := &DigestGroups{
+		New:            []DigestItem{{Title: "Паводок", Category: "происшествия", Importance: 4, Ref: "S1", SourceMessageID: 101}},
+		Ongoing:        []DigestItem{{Title: "Бюджет", DeltaSummary: "Второе чтение", Category: "экономика", Ref: "S2", SourceMessageID: 205, SourceURL: "https://news.example.ru/205"}},
+		RecurringNoise: []string{"погода"},
+	}
+
+	var stored []byte
+	mock.ExpectExec("INSERT INTO summaries \\(channel_id, summary, prompt_version, digest_groups, created_at\\)").
+		WithArgs(int64(123), "digest", "extract@v1", captureArg(&stored), now).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	require.NoError(t, repo.SaveSummary(&Summary{
+		ChannelID:     123,
+		Summary:       "digest",
+		PromptVersion: "extract@v1",
+		Groups:        groups,
+		CreatedAt:     now,
+	}))
+	assert.Contains(t, string(stored), `"source_message_id":101`)
+	assert.Contains(t, string(stored), `"source_url":"https://news.example.ru/205"`)
+
+	mock.ExpectQuery("SELECT digest_groups FROM summaries WHERE id = \\$1").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"digest_groups"}).AddRow(stored))
+	mock.ExpectQuery("SELECT digest_groups FROM summaries").
+		WithArgs(int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"digest_groups"}).AddRow(nil))
+
+	loaded, err := repo.GetDigestGroups(7)
+	require.NoError(t, err)
+	assert.Equal(t, groups, loaded)
+
+	loaded, err = repo.GetDigestGroups(8)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSummaryRepository_GetDigestGroupsErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSummaryRepository(db)
+
+	mock.ExpectQuery("SELECT digest_groups FROM summaries").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"digest_groups"}))
+	mock.ExpectQuery("SELECT digest_groups FROM summaries").
+		WithArgs(int64(8)).
+		WillReturnError(errors.New("connection reset"))
+
+	// нет дайджеста - не ошибка
+	loaded, err := repo.GetDigestGroups(7)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// ошибка базы не выдаётся за дайджест без групп
+	_, err = repo.GetDigestGroups(8)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// captureArg - sqlmock.Argument, запоминающий переданное значение.
+type capturedArg struct{ dst *[]byte }
+
+func captureArg(dst *[]byte) sqlmock.Argument { return capturedArg{dst: dst} }
+
+func (a capturedArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	*a.dst = b
+	return ok
+}
@@ -0,0 +1,159 @@
+package repository
+
+//go:generate mockgen -source=trend.go -destination=../mocks/repository/trend_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// StorylineTrend - сюжет с агрегатами наблюдений за период.
+type StorylineTrend struct {
+	StorylineID    int64
+	Title          string
+	Category       string
+	Status         string
+	TotalMessages  int
+	MaxImportance  int
+	DaysSeen       int
+	EscalationDays int
+	FirstSeen      time.Time // первое наблюдение в периоде (для ClosedStorylines - storylines.first_seen)
+	LastSeen       time.Time
+}
+
+// CategoryShare - суммарный объём сообщений рубрики за период.
+type CategoryShare struct {
+	Category      string
+	TotalMessages int
+}
+
+// TrendRepositoryInterface - аналитика канала по временному ряду storyline_observations.
+// Все периоды полуоткрытые: from <= obs_date < to.
+type TrendRepositoryInterface interface {
+	// сюжеты по суммарному объёму и важности
+	TopStorylines(channelID int64, from, to time.Time, limit int) ([]StorylineTrend, error)
+	// сюжеты с наибольшим числом дней escalation
+	TopEscalations(channelID int64, from, to time.Time, limit int) ([]StorylineTrend, error)
+	// объём сообщений по рубрикам
+	CategoryShares(channelID int64, from, to time.Time) ([]CategoryShare, error)
+	// closed-сюжеты с last_seen в [lastSeenFrom, lastSeenTo)
+	ClosedStorylines(channelID int64, lastSeenFrom, lastSeenTo time.Time) ([]StorylineTrend, error)
+}
+
+type TrendRepository struct {
+	db *sql.DB
+}
+
+func NewTrendRepository(db *sql.DB) TrendRepositoryInterface {
+	return &TrendRepository{db: db}
+}
+
+func (r *TrendRepository) TopStorylines(channelID int64, from, to time.Time, limit int) ([]StorylineTrend, error) {
+	q := `
+		SELECT s.id, s.title, COALESCE(s.category, ''), s.status,
+			SUM(o.message_count) AS total_messages,
+			MAX(o.importance) AS max_importance,
+			COUNT(*) AS days_seen,
+			COUNT(*) FILTER (WHERE o.change_type = 'escalation') AS escalation_days,
+			MIN(o.obs_date) AS first_obs,
+			MAX(o.obs_date) AS last_obs
+		FROM storyline_observations o
+		JOIN storylines s ON s.id = o.storyline_id
+		WHERE o.channel_id = $1 AND o.obs_date >= $2 AND o.obs_date < $3
+		GROUP BY s.id, s.title, s.category, s.status
+		ORDER BY total_messages DESC, max_importance DESC, s.id
+		LIMIT $4
+	`
+	return r.queryTrends(q, channelID, from, to, limit)
+}
+
+func (r *TrendRepository) TopEscalations(channelID int64, from, to time.Time, limit int) ([]StorylineTrend, error) {
+	q := `
+		SELECT s.id, s.title, COALESCE(s.category, ''), s.status,
+			SUM(o.message_count) AS total_messages,
+			MAX(o.importance) AS max_importance,
+			COUNT(*) AS days_seen,
+			COUNT(*) FILTER (WHERE o.change_type = 'escalation') AS escalation_days,
+			MIN(o.obs_date) AS first_obs,
+			MAX(o.obs_date) AS last_obs
+		FROM storyline_observations o
+		JOIN storylines s ON s.id = o.storyline_id
+		WHERE o.channel_id = $1 AND o.obs_date >= $2 AND o.obs_date < $3
+		GROUP BY s.id, s.title, s.category, s.status
+		HAVING COUNT(*) FILTER (WHERE o.change_type = 'escalation') > 0
+		ORDER BY escalation_days DESC, max_importance DESC, total_messages DESC, s.id
+		LIMIT $4
+	`
+	return r.queryTrends(q, channelID, from, to, limit)
+}
+
+func (r *TrendRepository) CategoryShares(channelID int64, from, to time.Time) ([]CategoryShare, error) {
+	q := `
+		SELECT COALESCE(s.category, ''), SUM(o.message_count) AS total_messages
+		FROM storyline_observations o
+		JOIN storylines s ON s.id = o.storyline_id
+		WHERE o.channel_id = $1 AND o.obs_date >= $2 AND o.obs_date < $3
+		GROUP BY COALESCE(s.category, '')
+		ORDER BY total_messages DESC
+	`
+	rows, err := r.db.Query(q, channelID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []CategoryShare
+	for rows.Next() {
+		var c CategoryShare
+		if err := rows.Scan(&c.Category, &c.TotalMessages); err != nil {
+			return nil, err
+		}
+		shares = append(shares, c)
+	}
+	return shares, rows.Err()
+}
+
+func (r *TrendRepository) ClosedStorylines(channelID int64, lastSeenFrom, lastSeenTo time.Time) ([]StorylineTrend, error) {
+	q := `
+		SELECT id, title, COALESCE(category, ''), status, importance, first_seen, last_seen
+		FROM storylines
+		WHERE channel_id = $1 AND status = 'closed' AND last_seen >= $2 AND last_seen < $3
+		ORDER BY importance DESC, last_seen DESC
+	`
+	rows, err := r.db.Query(q, channelID, lastSeenFrom, lastSeenTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trends []StorylineTrend
+	for rows.Next() {
+		var t StorylineTrend
+		if err := rows.Scan(&t.StorylineID, &t.Title, &t.Category, &t.Status, &t.MaxImportance, &t.FirstSeen, &t.LastSeen); err != nil {
+			return nil, err
+		}
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
+
+func (r *TrendRepository) queryTrends(q string, args ...interface{}) ([]StorylineTrend, error) {
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trends []StorylineTrend
+	for rows.Next() {
+		var t StorylineTrend
+		if err := rows.Scan(
+			&t.StorylineID, &t.Title, &t.Category, &t.Status,
+			&t.TotalMessages, &t.MaxImportance, &t.DaysSeen, &t.EscalationDays, &t.FirstSeen, &t.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendRepository_TopStorylines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTrendRepository(db)
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "status", "total_messages", "max_importance", "days_seen", "escalation_days", "first_obs", "last_obs"}).
+		AddRow(int64(42), "Переговоры", "политика", "active", 31, 4, 5, 2, from, to.AddDate(0, 0, -1))
+
+	mock.ExpectQuery("FROM storyline_observations o").
+		WithArgs(int64(123), from, to, 5).
+		WillReturnRows(rows)
+
+	trends, err := repo.TopStorylines(123, from, to, 5)
+	require.NoError(t, err)
+	require.Len(t, trends, 1)
+	assert.Equal(t, int64(42), trends[0].StorylineID)
+	assert.Equal(t, 31, trends[0].TotalMessages)
+	assert.Equal(t, 2, trends[0].EscalationDays)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrendRepository_ClosedStorylines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTrendRepository(db)
+	from := time.Date(2026, 9, 12, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	firstSeen := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "status", "importance", "first_seen", "last_seen"}).
+		AddRow(int64(7), "Паводок", "", "closed", 3, firstSeen, from)
+
+	mock.ExpectQuery("status = 'closed'").
+		WithArgs(int64(123), from, to).
+		WillReturnRows(rows)
+
+	trends, err := repo.ClosedStorylines(123, from, to)
+	require.NoError(t, err)
+	require.Len(t, trends, 1)
+	assert.Equal(t, "Паводок", trends[0].Title)
+	assert.Equal(t, firstSeen, trends[0].FirstSeen)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
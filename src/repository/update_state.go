@@ -0,0 +1,186 @@
+package repository
+
+//go:generate mockgen -source=update_state.go -destination=../mocks/repository/update_state_mock.go -package=mock_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gotd/td/telegram/updates"
+)
+
+// ErrUpdateStateNotFound - у аккаунта ещё нет сохранённого состояния: менеджер
+// updates в этом случае сначала вызывает SetState.
+var ErrUpdateStateNotFound = errors.New("update state not found")
+
+// UpdateStateRepositoryInterface - хранилище состояния потока обновлений MTProto
+// (миграция 0016) для менеджера updates из gotd: updates.StateStorage и
+// updates.ChannelAccessHasher. Set* без сохранённого состояния возвращают
+// ErrUpdateStateNotFound.
+type UpdateStateRepositoryInterface interface {
+	GetState(ctx context.Context, userID int64) (state updates.State, found bool, err error)
+	SetState(ctx context.Context, userID int64, state updates.State) error
+	SetPts(ctx context.Context, userID int64, pts int) error
+	SetQts(ctx context.Context, userID int64, qts int) error
+	SetDate(ctx context.Context, userID int64, date int) error
+	SetSeq(ctx context.Context, userID int64, seq int) error
+	SetDateSeq(ctx context.Context, userID int64, date, seq int) error
+	GetChannelPts(ctx context.Context, userID, channelID int64) (pts int, found bool, err error)
+	SetChannelPts(ctx context.Context, userID, channelID int64, pts int) error
+	ForEachChannels(ctx context.Context, userID int64, f func(ctx context.Context, channelID int64, pts int) error) error
+
+	SetChannelAccessHash(ctx context.Context, userID, channelID, accessHash int64) error
+	GetChannelAccessHash(ctx context.Context, userID, channelID int64) (accessHash int64, found bool, err error)
+}
+
+var (
+	_ updates.StateStorage        = UpdateStateRepositoryInterface(nil)
+	_ updates.ChannelAccessHasher = UpdateStateRepositoryInterface(nil)
+)
+
+type UpdateStateRepository struct {
+	db *sql.DB
+}
+
+func NewUpdateStateRepository(db *sql.DB) UpdateStateRepositoryInterface {
+	return &UpdateStateRepository{db: db}
+}
+
+func (r *UpdateStateRepository) GetState(ctx context.Context, userID int64) (updates.State, bool, error) {
+	var state updates.State
+	err := r.db.QueryRowContext(ctx, `
+		SELECT pts, qts, date, seq FROM mtproto_state WHERE user_id = $1
+	`, userID).Scan(&state.Pts, &state.Qts, &state.Date, &state.Seq)
+	if err == sql.ErrNoRows {
+		return updates.State{}, false, nil
+	}
+	if err != nil {
+		return updates.State{}, false, err
+	}
+	return state, true, nil
+}
+
+func (r *UpdateStateRepository) SetState(ctx context.Context, userID int64, state updates.State) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO mtproto_state (user_id, pts, qts, date, seq)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET pts = EXCLUDED.pts, qts = EXCLUDED.qts, date = EXCLUDED.date, seq = EXCLUDED.seq
+	`, userID, state.Pts, state.Qts, state.Date, state.Seq)
+	return err
+}
+
+func (r *UpdateStateRepository) SetPts(ctx context.Context, userID int64, pts int) error {
+	return r.updateState(ctx, userID, `UPDATE mtproto_state SET pts = $2 WHERE user_id = $1`, pts)
+}
+
+func (r *UpdateStateRepository) SetQts(ctx context.Context, userID int64, qts int) error {
+	return r.updateState(ctx, userID, `UPDATE mtproto_state SET qts = $2 WHERE user_id = $1`, qts)
+}
+
+func (r *UpdateStateRepository) SetDate(ctx context.Context, userID int64, date int) error {
+	return r.updateState(ctx, userID, `UPDATE mtproto_state SET date = $2 WHERE user_id = $1`, date)
+}
+
+func (r *UpdateStateRepository) SetSeq(ctx context.Context, userID int64, seq int) error {
+	return r.updateState(ctx, userID, `UPDATE mtproto_state SET seq = $2 WHERE user_id = $1`, seq)
+}
+
+func (r *UpdateStateRepository) SetDateSeq(ctx context.Context, userID int64, date, seq int) error {
+	return r.updateState(ctx, userID, `UPDATE mtproto_state SET date = $2, seq = $3 WHERE user_id = $1`, date, seq)
+}
+
+// updateState меняет поля существующего состояния; без состояния - ErrUpdateStateNotFound.
+func (r *UpdateStateRepository) updateState(ctx context.Context, userID int64, query string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, query, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("user %d: %w", userID, ErrUpdateStateNotFound)
+	}
+	return nil
+}
+
+func (r *UpdateStateRepository) GetChannelPts(ctx context.Context, userID, channelID int64) (int, bool, error) {
+	var pts sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT pts FROM mtproto_channels WHERE user_id = $1 AND channel_id = $2
+	`, userID, channelID).Scan(&pts)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return int(pts.Int64), pts.Valid, nil
+}
+
+func (r *UpdateStateRepository) SetChannelPts(ctx context.Context, userID, channelID int64, pts int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO mtproto_channels (user_id, channel_id, pts)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, channel_id) DO UPDATE SET pts = EXCLUDED.pts
+	`, userID, channelID, pts)
+	return err
+}
+
+func (r *UpdateStateRepository) ForEachChannels(ctx context.Context, userID int64, f func(ctx context.Context, channelID int64, pts int) error) error {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT channel_id, pts FROM mtproto_channels WHERE user_id = $1 AND pts IS NOT NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+	// f обращается к базе (GetChannelAccessHash), поэтому строки читаются целиком заранее.
+	channels := make(map[int64]int)
+	for rows.Next() {
+		var channelID int64
+		var pts int
+		if err := rows.Scan(&channelID, &pts); err != nil {
+			rows.Close()
+			return err
+		}
+		channels[channelID] = pts
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for channelID, pts := range channels {
+		if err := f(ctx, channelID, pts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *UpdateStateRepository) SetChannelAccessHash(ctx context.Context, userID, channelID, accessHash int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO mtproto_channels (user_id, channel_id, access_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, channel_id) DO UPDATE SET access_hash = EXCLUDED.access_hash
+	`, userID, channelID, accessHash)
+	return err
+}
+
+func (r *UpdateStateRepository) GetChannelAccessHash(ctx context.Context, userID, channelID int64) (int64, bool, error) {
+	var hash sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT access_hash FROM mtproto_channels WHERE user_id = $1 AND channel_id = $2
+	`, userID, channelID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return hash.Int64, hash.Valid, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gotd/td/telegram/updates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateStateRepository_State(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUpdateStateRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT pts, qts, date, seq FROM mtproto_state WHERE user_id = \\$1").
+		WithArgs(int64(5001)).
+		WillReturnRows(sqlmock.NewRows([]string{"pts", "qts", "date", "seq"}))
+	mock.ExpectExec("INSERT INTO mtproto_state.*ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs(int64(5001), 120, 0, 1760864400, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT pts, qts, date, seq FROM mtproto_state").
+		WithArgs(int64(5001)).
+		WillReturnRows(sqlmock.NewRows([]string{"pts", "qts", "date", "seq"}).AddRow(120, 0, 1760864400, 7))
+	mock.ExpectExec("UPDATE mtproto_state SET date = \\$2, seq = \\$3 WHERE user_id = \\$1").
+		WithArgs(int64(5001), 1760864460, 8).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE mtproto_state SET pts = \\$2 WHERE user_id = \\$1").
+		WithArgs(int64(6002), 10).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, found, err := repo.GetState(ctx, 5001)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.SetState(ctx, 5001, updates.State{Pts: 120, Date: 1760864400, Seq: 7}))
+
+	state, found, err := repo.GetState(ctx, 5001)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, updates.State{Pts: 120, Date: 1760864400, Seq: 7}, state)
+
+	require.NoError(t, repo.SetDateSeq(ctx, 5001, 1760864460, 8))
+
+	// менеджер updates ждёт ошибку, если состояния аккаунта ещё нет
+	err = repo.SetPts(ctx, 6002, 10)
+	assert.True(t, errors.Is(err, ErrUpdateStateNotFound))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStateRepository_Channels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUpdateStateRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO mtproto_channels \\(user_id, channel_id, access_hash\\).*DO UPDATE SET access_hash = EXCLUDED.access_hash").
+		WithArgs(int64(5001), int64(1754252633), int64(-8812)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT pts FROM mtproto_channels WHERE user_id = \\$1 AND channel_id = \\$2").
+		WithArgs(int64(5001), int64(1754252633)).
+		WillReturnRows(sqlmock.NewRows([]string{"pts"}).AddRow(nil))
+	mock.ExpectExec("INSERT INTO mtproto_channels \\(user_id, channel_id, pts\\).*DO UPDATE SET pts = EXCLUDED.pts").
+		WithArgs(int64(5001), int64(1754252633), 4410).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT channel_id, pts FROM mtproto_channels WHERE user_id = \\$1 AND pts IS NOT NULL").
+		WithArgs(int64(5001)).
+		WillReturnRows(sqlmock.NewRows([]string{"channel_id", "pts"}).AddRow(int64(1754252633), 4410))
+	mock.ExpectQuery("SELECT access_hash FROM mtproto_channels").
+		WithArgs(int64(5001), int64(1754252633)).
+		WillReturnRows(sqlmock.NewRows([]string{"access_hash"}).AddRow(int64(-8812)))
+
+	require.NoError(t, repo.SetChannelAccessHash(ctx, 5001, 1754252633, -8812))
+
+	// строка есть, но pts ещё не записан
+	_, found, err := repo.GetChannelPts(ctx, 5001, 1754252633)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.SetChannelPts(ctx, 5001, 1754252633, 4410))
+
+	channels := map[int64]int{}
+	err = repo.ForEachChannels(ctx, 5001, func(ctx context.Context, channelID int64, pts int) error {
+		channels[channelID] = pts
+		hash, found, err := repo.GetChannelAccessHash(ctx, 5001, channelID)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, int64(-8812), hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int{1754252633: 4410}, channels)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
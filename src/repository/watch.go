@@ -0,0 +1,109 @@
+package repository
+
+//go:generate mockgen -source=watch.go -destination=../mocks/repository/watch_mock.go -package=mock_repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// WatchSubscription - подписка читателя на слово/фразу или регулярное выражение.
+type WatchSubscription struct {
+	ID        int64
+	ChatID    int64
+	Pattern   string
+	IsRegex   bool
+	CreatedAt time.Time
+}
+
+type WatchRepositoryInterface interface {
+	// добавляет подписку; ok == false - такая подписка у читателя уже есть
+	AddSubscription(s *WatchSubscription) (ok bool, err error)
+	ListSubscriptions(chatID int64) ([]WatchSubscription, error)
+	CountSubscriptions(chatID int64) (int, error)
+	// удаляет подписку читателя; ok == false - подписки с таким id у него нет
+	DeleteSubscription(chatID, id int64) (ok bool, err error)
+	// все подписки - для проверки новых сообщений
+	GetAllSubscriptions() ([]WatchSubscription, error)
+}
+
+type WatchRepository struct {
+	db *sql.DB
+}
+
+func NewWatchRepository(db *sql.DB) WatchRepositoryInterface {
+	return &WatchRepository{db: db}
+}
+
+func (r *WatchRepository) AddSubscription(s *WatchSubscription) (bool, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO watch_subscriptions (chat_id, pattern, is_regex, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat_id, pattern, is_regex) DO NOTHING
+		RETURNING id
+	`, s.ChatID, s.Pattern, s.IsRegex, s.CreatedAt).Scan(&s.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *WatchRepository) ListSubscriptions(chatID int64) ([]WatchSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, chat_id, pattern, is_regex, created_at
+		FROM watch_subscriptions
+		WHERE chat_id = $1
+		ORDER BY id
+	`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWatchSubscriptions(rows)
+}
+
+func (r *WatchRepository) CountSubscriptions(chatID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM watch_subscriptions WHERE chat_id = $1`, chatID).Scan(&count)
+	return count, err
+}
+
+func (r *WatchRepository) DeleteSubscription(chatID, id int64) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM watch_subscriptions WHERE chat_id = $1 AND id = $2`, chatID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *WatchRepository) GetAllSubscriptions() ([]WatchSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, chat_id, pattern, is_regex, created_at
+		FROM watch_subscriptions
+		ORDER BY chat_id, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWatchSubscriptions(rows)
+}
+
+func scanWatchSubscriptions(rows *sql.Rows) ([]WatchSubscription, error) {
+	var result []WatchSubscription
+	for rows.Next() {
+		var s WatchSubscription
+		if err := rows.Scan(&s.ID, &s.ChatID, &s.Pattern, &s.IsRegex, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
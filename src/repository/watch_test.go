@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchRepository_AddSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWatchRepository(db)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO watch_subscriptions.*ON CONFLICT \\(chat_id, pattern, is_regex\\) DO NOTHING\\s+RETURNING id").
+		WithArgs(int64(555), "газпром", false, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(3)))
+	mock.ExpectQuery("INSERT INTO watch_subscriptions").
+		WithArgs(int64(555), "газпром", false, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	sub := &WatchSubscription{ChatID: 555, Pattern: "газпром", CreatedAt: now}
+	ok, err := repo.AddSubscription(sub)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), sub.ID)
+
+	ok, err = repo.AddSubscription(&WatchSubscription{ChatID: 555, Pattern: "газпром", CreatedAt: now})
+	require.NoError(t, err)
+	assert.False(t, ok, "повторная подписка не добавляется")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchRepository_DeleteOnlyOwnSubscription(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWatchRepository(db)
+
+	mock.ExpectExec("DELETE FROM watch_subscriptions WHERE chat_id = \\$1 AND id = \\$2").
+		WithArgs(int64(555), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.DeleteSubscription(555, 3)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchRepository_GetAllSubscriptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWatchRepository(db)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM watch_subscriptions\\s+ORDER BY chat_id, id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "pattern", "is_regex", "created_at"}).
+			AddRow(int64(1), int64(555), "газпром", false, now).
+			AddRow(int64(2), int64(777), "сбер(банк)?", true, now))
+
+	subs, err := repo.GetAllSubscriptions()
+	require.NoError(t, err)
+	assert.Equal(t, []WatchSubscription{
+		{ID: 1, ChatID: 555, Pattern: "газпром", CreatedAt: now},
+		{ID: 2, ChatID: 777, Pattern: "сбер(банк)?", IsRegex: true, CreatedAt: now},
+	}, subs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
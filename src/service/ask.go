@@ -0,0 +1,213 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// ErrNoRelevantNews - в архиве не нашлось материалов, отвечающих на вопрос.
+var ErrNoRelevantNews = errors.New("no relevant news in archive")
+
+// AskService отвечает на вопросы читателя по архиву: находит сюжеты по эмбеддингу
+// вопроса и сообщения (исходные сообщения сюжетов и полнотекстовый поиск), а ответ
+// пишет LLM строго по ним, со ссылками на сообщения.
+type AskService struct {
+	mlRepo        repository.MLRepositoryInterface
+	storylineRepo repository.StorylineRepositoryInterface
+	messageRepo   repository.MessageRepositoryInterface
+	searchRepo    repository.SearchRepositoryInterface
+}
+
+func NewAskService(
+	mlRepo repository.MLRepositoryInterface,
+	storylineRepo repository.StorylineRepositoryInterface,
+	messageRepo repository.MessageRepositoryInterface,
+	searchRepo repository.SearchRepositoryInterface,
+) *AskService {
+	return &AskService{
+		mlRepo:        mlRepo,
+		storylineRepo: storylineRepo,
+		messageRepo:   messageRepo,
+		searchRepo:    searchRepo,
+	}
+}
+
+// messageKey - сообщение канала в материалах ответа.
+type messageKey struct {
+	channelID int64
+	messageID int64
+}
+
+// Ask отвечает на вопрос q (разобранный ParseSearchQuery: фильтры как у /search)
+// в Markdown. Без с: берутся последние config.AskWindowDays дней. ErrNoRelevantNews -
+// если ничего относящегося к вопросу не нашлось или модель не нашла ответа в найденном.
+func (s *AskService) Ask(q repository.SearchQuery, now time.Time) (string, error) {
+	if q.From.IsZero() {
+		q.From = now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -config.AskWindowDays)
+	}
+	to := q.To
+	if to.IsZero() {
+		to = now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+
+	storylines, keys, err := s.retrieveStorylines(q, to)
+	if err != nil {
+		return "", err
+	}
+
+	hits, _, err := s.searchRepo.SearchMessages(repository.SearchQuery{
+		Text: q.Text, ChannelID: q.ChannelID, From: q.From, To: q.To, Limit: config.AskSearchMessages,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search messages: %w", err)
+	}
+	for _, h := range hits {
+		keys = append(keys, messageKey{channelID: h.ChannelID, messageID: h.MessageID})
+	}
+
+	if len(storylines) == 0 && len(hits) == 0 {
+		return "", ErrNoRelevantNews
+	}
+
+	messages, urls, err := s.loadMessages(keys)
+	if err != nil {
+		return "", err
+	}
+
+	in := repository.QuestionInput{
+		Question:   q.Text,
+		From:       q.From.Format("2006-01-02"),
+		To:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Storylines: storylines,
+		Messages:   messages,
+	}
+	answer, err := s.mlRepo.AnswerQuestion(in)
+	if errors.Is(err, repository.ErrNoGroundedAnswer) {
+		return "", ErrNoRelevantNews
+	}
+	if err != nil {
+		return "", err
+	}
+
+	header := fmt.Sprintf("**Ответ по архиву за %s — %s**\n\n",
+		q.From.Format("02.01.2006"), to.AddDate(0, 0, -1).Format("02.01.2006"))
+	return header + replaceSourceMarkers(answer, urls), nil
+}
+
+// retrieveStorylines возвращает ближайшие к вопросу сюжеты, живые в периоде [q.From, to),
+// с дугой за период и id их последних исходных сообщений.
+func (s *AskService) retrieveStorylines(q repository.SearchQuery, to time.Time) ([]repository.PeriodStoryline, []messageKey, error) {
+	vecs, err := s.mlRepo.EmbedQueries([]string{q.Text})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+	if len(vecs) != 1 {
+		return nil, nil, fmt.Errorf("embedding count mismatch: got %d for 1 question", len(vecs))
+	}
+	scored, err := s.storylineRepo.SearchNearestAll(vecs[0], config.AskCandidateStorylines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search storylines: %w", err)
+	}
+
+	var (
+		storylines []repository.PeriodStoryline
+		keys       []messageKey
+	)
+	for _, sc := range scored {
+		st := sc.Storyline
+		if len(storylines) >= config.AskMaxStorylines {
+			break
+		}
+		if sc.Similarity < config.AskMinSim ||
+			(q.ChannelID != 0 && st.ChannelID != q.ChannelID) ||
+			st.LastSeen.Before(q.From) || !st.FirstSeen.Before(to) {
+			continue
+		}
+
+		observations, err := s.storylineRepo.GetObservations(st.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get observations of storyline %d: %w", st.ID, err)
+		}
+		ps := repository.PeriodStoryline{Title: st.Title, State: st.State, Category: st.Category, Status: st.Status}
+		var sourceIDs []int64
+		for _, o := range observations {
+			if o.ObsDate.Before(q.From) || !o.ObsDate.Before(to) {
+				continue
+			}
+			ps.TotalMessages += o.MessageCount
+			ps.MaxImportance = max(ps.MaxImportance, o.Importance)
+			ps.Arc = append(ps.Arc, repository.ArcPoint{
+				Date:         o.ObsDate.Format("2006-01-02"),
+				ChangeType:   o.ChangeType,
+				DeltaSummary: o.DeltaSummary,
+			})
+			sourceIDs = append(sourceIDs, o.SourceMessageIDs...)
+		}
+		if len(ps.Arc) == 0 {
+			continue
+		}
+		storylines = append(storylines, ps)
+
+		// наблюдения идут по возрастанию даты: берём самые свежие источники
+		if len(sourceIDs) > config.AskMessagesPerStoryline {
+			sourceIDs = sourceIDs[len(sourceIDs)-config.AskMessagesPerStoryline:]
+		}
+		for _, id := range sourceIDs {
+			keys = append(keys, messageKey{channelID: st.ChannelID, messageID: id})
+		}
+	}
+	return storylines, keys, nil
+}
+
+// loadMessages загружает тексты сообщений, нумерует их метками S1, S2, ... по дате
+// и возвращает адреса постов по меткам.
+func (s *AskService) loadMessages(keys []messageKey) ([]repository.QuestionMessage, map[string]string, error) {
+	byChannel := make(map[int64][]int64)
+	seen := make(map[messageKey]bool, len(keys))
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			byChannel[k.channelID] = append(byChannel[k.channelID], k.messageID)
+		}
+	}
+
+	var loaded []repository.Message
+	for channelID, ids := range byChannel {
+		msgs, err := s.messageRepo.GetMessagesByIDs(channelID, ids)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load messages of channel %d: %w", channelID, err)
+		}
+		loaded = append(loaded, msgs...)
+	}
+	sort.Slice(loaded, func(i, j int) bool {
+		if !loaded[i].MessageDate.Equal(loaded[j].MessageDate) {
+			return loaded[i].MessageDate.Before(loaded[j].MessageDate)
+		}
+		return loaded[i].ChannelID < loaded[j].ChannelID
+	})
+
+	messages := make([]repository.QuestionMessage, 0, len(loaded))
+	urls := make(map[string]string, len(loaded))
+	for i, m := range loaded {
+		ref := fmt.Sprintf("S%d", i+1)
+		if url := PostURL(m.ChannelID, int64(m.MessageID), m.Meta.URL); url != "" {
+			urls[ref] = url
+		}
+		text := []rune(m.MessageText)
+		if len(text) > config.AskMessageMaxRunes {
+			text = append(text[:config.AskMessageMaxRunes], '…')
+		}
+		messages = append(messages, repository.QuestionMessage{
+			Ref:     ref,
+			Channel: ChannelLabel(m.ChannelID),
+			Date:    m.MessageDate.UTC().Format("2006-01-02 15:04") + " UTC",
+			Text:    string(text),
+		})
+	}
+	return messages, urls, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+type askMocks struct {
+	ml         *mock_repository.MockMLRepositoryInterface
+	storylines *mock_repository.MockStorylineRepositoryInterface
+	messages   *mock_repository.MockMessageRepositoryInterface
+	search     *mock_repository.MockSearchRepositoryInterface
+}
+
+func newTestAskService(ctrl *gomock.Controller) (*AskService, askMocks) {
+	m := askMocks{
+		ml:         mock_repository.NewMockMLRepositoryInterface(ctrl),
+		storylines: mock_repository.NewMockStorylineRepositoryInterface(ctrl),
+		messages:   mock_repository.NewMockMessageRepositoryInterface(ctrl),
+		search:     mock_repository.NewMockSearchRepositoryInterface(ctrl),
+	}
+	return NewAskService(m.ml, m.storylines, m.messages, m.search), m
+}
+
+func TestAskService_AnswersWithSourceLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, m := newTestAskService(ctrl)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	vec := []float32{0.3}
+	m.ml.EXPECT().EmbedQueries([]string{"ключевая ставка"}).Return([][]float32{vec}, nil)
+	m.storylines.EXPECT().SearchNearestAll(vec, config.AskCandidateStorylines).Return([]repository.ScoredStoryline{
+		{Storyline: repository.Storyline{ID: 1, ChannelID: 1754252633, Title: "Ключевая ставка", Status: "active", FirstSeen: day, LastSeen: day}, Similarity: 0.6},
+		{Storyline: repository.Storyline{ID: 2, ChannelID: 1754252633, Title: "Старый сюжет", FirstSeen: from.AddDate(0, -3, 0), LastSeen: from.AddDate(0, -2, 0)}, Similarity: 0.6},
+		{Storyline: repository.Storyline{ID: 3, ChannelID: 1754252633, Title: "Не о том", FirstSeen: day, LastSeen: day}, Similarity: 0.2},
+	}, nil)
+	m.storylines.EXPECT().GetObservations(int64(1)).Return([]repository.Observation{
+		{StorylineID: 1, ObsDate: from.AddDate(0, 0, -1), ChangeType: "new", SourceMessageIDs: []int64{700}},
+		{StorylineID: 1, ObsDate: day, ChangeType: "escalation", MessageCount: 3, DeltaSummary: "ставка сохранена", SourceMessageIDs: []int64{805}},
+	}, nil)
+	m.search.EXPECT().SearchMessages(repository.SearchQuery{Text: "ключевая ставка", From: from, Limit: config.AskSearchMessages}).
+		Return([]repository.SearchResult{{ChannelID: 1754252633, MessageID: 805}, {ChannelID: 1754252633, MessageID: 790}}, 2, nil)
+	m.messages.EXPECT().GetMessagesByIDs(int64(1754252633), []int64{805, 790}).Return([]repository.Message{
+		{ChannelID: 1754252633, MessageID: 805, MessageText: "ЦБ сохранил ставку", MessageDate: day.Add(13 * time.Hour)},
+		{ChannelID: 1754252633, MessageID: 790, MessageText: "Аналитики ждут паузы", MessageDate: day.Add(-24 * time.Hour)},
+	}, nil)
+	m.ml.EXPECT().AnswerQuestion(gomock.Any()).DoAndReturn(func(in repository.QuestionInput) (string, error) {
+		assert.Equal(t, "2026-09-19", in.From)
+		assert.Equal(t, "2026-10-19", in.To)
+		require.Len(t, in.Storylines, 1, "сюжеты вне периода и с низкой близостью отброшены")
+		assert.Equal(t, []repository.ArcPoint{{Date: "2026-10-17", ChangeType: "escalation", DeltaSummary: "ставка сохранена"}}, in.Storylines[0].Arc)
+		require.Len(t, in.Messages, 2)
+		assert.Equal(t, repository.QuestionMessage{Ref: "S1", Channel: "@topor_live", Date: "2026-10-16 00:00 UTC", Text: "Аналитики ждут паузы"}, in.Messages[0])
+		assert.Equal(t, "S2", in.Messages[1].Ref)
+		return "Ставку сохранили. [[S2]] Паузу ждали заранее. [[S1]][[S9]]", nil
+	})
+
+	answer, err := svc.Ask(repository.SearchQuery{Text: "ключевая ставка"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "**Ответ по архиву за 19.09.2026 — 19.10.2026**\n\n"+
+		"Ставку сохранили. [источник](https://t.me/topor_live/805) Паузу ждали заранее. [источник](https://t.me/topor_live/790)", answer)
+}
+
+func TestAskService_RefusesWithoutRelevantNews(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, m := newTestAskService(ctrl)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	m.ml.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{{0.1}}, nil)
+	m.storylines.EXPECT().SearchNearestAll(gomock.Any(), config.AskCandidateStorylines).Return([]repository.ScoredStoryline{
+		{Storyline: repository.Storyline{ID: 3, LastSeen: now}, Similarity: 0.1},
+	}, nil)
+	m.search.EXPECT().SearchMessages(gomock.Any()).Return(nil, 0, nil)
+
+	_, err := svc.Ask(repository.SearchQuery{Text: "кто выиграл матч"}, now)
+	assert.ErrorIs(t, err, ErrNoRelevantNews)
+}
+
+func TestAskService_ModelFindsNoAnswer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, m := newTestAskService(ctrl)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	m.ml.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{{0.1}}, nil)
+	m.storylines.EXPECT().SearchNearestAll(gomock.Any(), gomock.Any()).Return(nil, nil)
+	m.search.EXPECT().SearchMessages(gomock.Any()).Return([]repository.SearchResult{{ChannelID: 1754252633, MessageID: 5}}, 1, nil)
+	m.messages.EXPECT().GetMessagesByIDs(int64(1754252633), []int64{5}).Return([]repository.Message{{ChannelID: 1754252633, MessageID: 5, MessageText: "матч"}}, nil)
+	m.ml.EXPECT().AnswerQuestion(gomock.Any()).Return("", repository.ErrNoGroundedAnswer)
+
+	_, err := svc.Ask(repository.SearchQuery{Text: "кто выиграл матч"}, now)
+	assert.ErrorIs(t, err, ErrNoRelevantNews)
+}
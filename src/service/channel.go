@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/telegramutil"
+)
+
+// ChannelLabel - источник так, как его видит читатель: @username канала
+// Telegram, имя ленты или «канал <id>», если источника уже нет в config.
+func ChannelLabel(channelID int64) string {
+	if feed, ok := config.Feeds[channelID]; ok {
+		return feed.Name
+	}
+	if username := config.ChannelNames()[channelID]; username != "" {
+		return "@" + username
+	}
+	return fmt.Sprintf("канал %d", channelID)
+}
+
+// PostURL - ссылка на пост: t.me по id для канала Telegram, для записи ленты
+// (и канала, которого уже нет в config) - сохранённый адрес из Meta.URL.
+// Пусто - ссылки нет.
+func PostURL(channelID, messageID int64, storedURL string) string {
+	if _, feed := config.Feeds[channelID]; !feed {
+		if username := config.ChannelNames()[channelID]; username != "" {
+			return telegramutil.PostURL(username, messageID)
+		}
+	}
+	return storedURL
+}
+
+// channelByName - id канала или ленты по имени из фильтра канал: (можно с @).
+func channelByName(value string) (int64, error) {
+	name := strings.TrimPrefix(strings.ToLower(value), "@")
+	var known []string
+	for id, channelName := range config.ChannelNames() {
+		if strings.ToLower(channelName) == name {
+			return id, nil
+		}
+		known = append(known, channelName)
+	}
+	sort.Strings(known)
+	return 0, fmt.Errorf("неизвестный канал %q, доступны: %s", value, strings.Join(known, ", "))
+}
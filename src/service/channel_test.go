@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestChannels подменяет каналы и ленты config на время теста, чтобы имена
+// и ссылки не зависели от боевого списка.
+func useTestChannels(t *testing.T, channels map[int64]string, feeds map[int64]config.Feed) {
+	savedChannels, savedFeeds := config.Channels, config.Feeds
+	config.Channels, config.Feeds = channels, feeds
+	t.Cleanup(func() { config.Channels, config.Feeds = savedChannels, savedFeeds })
+}
+
+func TestChannelLabelAndPostURL(t *testing.T) {
+	useTestChannels(t, map[int64]string{100: "test_channel"}, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	assert.Equal(t, "@test_channel", ChannelLabel(100))
+	assert.Equal(t, "city_news", ChannelLabel(-1))
+	assert.Equal(t, "канал 42", ChannelLabel(42))
+
+	assert.Equal(t, "https://t.me/test_channel/7", PostURL(100, 7, ""))
+	// у ленты id записи - хэш, ссылка только из meta
+	assert.Equal(t, "https://news.example.ru/7", PostURL(-1, 7, "https://news.example.ru/7"))
+	assert.Equal(t, "", PostURL(-1, 7, ""))
+	assert.Equal(t, "https://news.example.ru/old", PostURL(42, 7, "https://news.example.ru/old"))
+}
+
+func TestChannelByName(t *testing.T) {
+	useTestChannels(t, map[int64]string{100: "test_channel"}, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	id, err := channelByName("@Test_Channel")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), id)
+
+	id, err = channelByName("city_news")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), id)
+
+	_, err = channelByName("unknown")
+	assert.ErrorContains(t, err, "доступны: city_news, test_channel")
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"regexp"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// sourceMarkerRe - метки [[S1]], которые стадия F ставит в конце абзаца сюжета.
+var sourceMarkerRe = regexp.MustCompile(`[ \t]*\[\[(S\d+)\]\]`)
+
+// linkSources заменяет метки сюжетов Markdown-ссылками на исходные посты канала.
+// Адрес строит PostURL из DigestItem.SourceMessageID и SourceURL, модель его не пишет;
+// метки без сюжета или без адреса просто удаляются.
+func linkSources(digest string, channelID int64, groups repository.DigestGroups) string {
+	urls := make(map[string]string)
+	for _, items := range digestItemGroups(groups) {
+		for _, item := range items {
+			if item.Ref == "" {
+				continue
+			}
+			if url := PostURL(channelID, item.SourceMessageID, item.SourceURL); url != "" {
+				urls[item.Ref] = url
+			}
+		}
+	}
+
+	return replaceSourceMarkers(digest, urls)
+}
+
+// withSourceURLs проставляет DigestItem.SourceURL - сохранённый адрес исходного
+// поста (Meta.URL записи ленты) из сообщений дня.
+func withSourceURLs(groups repository.DigestGroups, msgs []repository.MessageInput) repository.DigestGroups {
+	urls := make(map[int64]string)
+	for _, m := range msgs {
+		if m.Meta.URL != "" {
+			urls[m.MessageID] = m.Meta.URL
+		}
+	}
+	for _, items := range digestItemGroups(groups) {
+		for i := range items {
+			items[i].SourceURL = urls[items[i].SourceMessageID]
+		}
+	}
+	return groups
+}
+
+func digestItemGroups(groups repository.DigestGroups) [][]repository.DigestItem {
+	return [][]repository.DigestItem{groups.New, groups.Escalation, groups.Revived, groups.Ongoing}
+}
+
+// replaceSourceMarkers заменяет метки [[S1]] Markdown-ссылками из urls (метка -> адрес).
+func replaceSourceMarkers(text string, urls map[string]string) string {
+	return sourceMarkerRe.ReplaceAllStringFunc(text, func(marker string) string {
+		url, ok := urls[sourceMarkerRe.FindStringSubmatch(marker)[1]]
+		if !ok {
+			return ""
+		}
+		return " [источник](" + url + ")"
+	})
+}
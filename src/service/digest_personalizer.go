@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// allMutedDigest - ответ, когда все сюжеты дня попали в скрытые рубрики (без вызова LLM).
+const allMutedDigest = "Все сюжеты дня - в скрытых рубриках. Изменить настройки можно кнопкой «Рубрики»."
+
+// DigestPersonalizer перерендеривает дневной дайджест под настройки рубрик читателя:
+// убирает скрытые рубрики и поднимает избранные из сохранённых DigestGroups. Рендер
+// кэшируется на (дайджест, набор настроек), так что LLM вызывается не на каждого читателя.
+type DigestPersonalizer struct {
+	summaryRepo repository.SummaryRepositoryInterface
+	prefRepo    repository.CategoryPreferenceRepositoryInterface
+	variantRepo repository.PersonalizedSummaryRepositoryInterface
+	mlRepo      repository.MLRepositoryInterface
+}
+
+func NewDigestPersonalizer(
+	summaryRepo repository.SummaryRepositoryInterface,
+	prefRepo repository.CategoryPreferenceRepositoryInterface,
+	variantRepo repository.PersonalizedSummaryRepositoryInterface,
+	mlRepo repository.MLRepositoryInterface,
+) *DigestPersonalizer {
+	return &DigestPersonalizer{
+		summaryRepo: summaryRepo,
+		prefRepo:    prefRepo,
+		variantRepo: variantRepo,
+		mlRepo:      mlRepo,
+	}
+}
+
+// Personalize возвращает дайджест для читателя chatID. Без настроек, для дайджестов
+// без сохранённых групп и при ошибках отдаётся общий summary.
+func (p *DigestPersonalizer) Personalize(chatID int64, summary *repository.Summary) *repository.Summary {
+	text, err := p.personalize(chatID, summary)
+	if err != nil {
+		log.Errorf("Error personalizing summary %d for chat %d: %v", summary.ID, chatID, err)
+		return summary
+	}
+	if text == summary.Summary {
+		return summary
+	}
+	personalized := *summary
+	personalized.Summary = text
+	return &personalized
+}
+
+func (p *DigestPersonalizer) personalize(chatID int64, summary *repository.Summary) (string, error) {
+	prefs, err := p.prefRepo.GetPreferences(chatID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get category preferences: %w", err)
+	}
+	if prefs.IsEmpty() {
+		return summary.Summary, nil
+	}
+
+	groups, err := p.summaryRepo.GetDigestGroups(summary.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get digest groups: %w", err)
+	}
+	if groups == nil {
+		return summary.Summary, nil
+	}
+	filtered, changed := applyCategoryPreferences(*groups, prefs)
+	if !changed {
+		return summary.Summary, nil
+	}
+
+	key := prefs.Key()
+	cached, ok, err := p.variantRepo.GetVariant(summary.ID, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get personalized variant: %w", err)
+	}
+	if ok {
+		return cached, nil
+	}
+
+	text := allMutedDigest
+	if !isEmptyDigestGroups(filtered) {
+		digest, err := p.mlRepo.ForDay(summary.ChannelID, summaryDay(summary)).RenderDigest(filtered)
+		if err != nil {
+			return "", fmt.Errorf("failed to render personalized digest: %w", err)
+		}
+		text = linkSources(digest, summary.ChannelID, filtered)
+	}
+
+	if err := p.variantRepo.SaveVariant(summary.ID, key, text); err != nil {
+		log.Errorf("Error saving personalized variant of summary %d: %v", summary.ID, err)
+	}
+	return text, nil
+}
+
+// applyCategoryPreferences убирает сюжеты скрытых рубрик и ставит избранные рубрики
+// первыми внутри каждой группы, сохраняя порядок остальных. changed == false -
+// настройки дайджест не меняют и перерендер не нужен.
+func applyCategoryPreferences(groups repository.DigestGroups, prefs *repository.CategoryPreferences) (repository.DigestGroups, bool) {
+	changed := false
+	apply := func(items []repository.DigestItem) []repository.DigestItem {
+		var boosted, rest []repository.DigestItem
+		for _, item := range items {
+			switch prefs.Preference(item.Category) {
+			case repository.PreferenceMute:
+				changed = true
+			case repository.PreferenceBoost:
+				boosted = append(boosted, item)
+			default:
+				rest = append(rest, item)
+			}
+		}
+		result := append(boosted, rest...)
+		if !changed && len(boosted) > 0 && !slices.EqualFunc(result, items, func(a, b repository.DigestItem) bool {
+			return a.Ref == b.Ref && a.Title == b.Title
+		}) {
+			changed = true
+		}
+		return result
+	}
+
+	result := repository.DigestGroups{
+		New:        apply(groups.New),
+		Escalation: apply(groups.Escalation),
+		Revived:    apply(groups.Revived),
+		Ongoing:    apply(groups.Ongoing),
+	}
+	// фон подписан рубрикой (или названием сюжета без рубрики)
+	for _, label := range groups.RecurringNoise {
+		if prefs.Preference(label) == repository.PreferenceMute {
+			changed = true
+			continue
+		}
+		result.RecurringNoise = append(result.RecurringNoise, label)
+	}
+	return result, changed
+}
+
+func isEmptyDigestGroups(groups repository.DigestGroups) bool {
+	return len(groups.New) == 0 && len(groups.Escalation) == 0 && len(groups.Revived) == 0 &&
+		len(groups.Ongoing) == 0 && len(groups.RecurringNoise) == 0
+}
+
+// summaryDay - день, за который построен дайджест: бот сохраняет дайджест дня D в D+1,
+// storyline_backfill с -write-summaries - ровно в полночь D.
+func summaryDay(summary *repository.Summary) time.Time {
+	day := truncateToDay(summary.CreatedAt)
+	if day.Equal(summary.CreatedAt.UTC()) {
+		return day
+	}
+	return day.AddDate(0, 0, -1)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func personalizerGroups() *repository.DigestGroups {
+	return &repository.DigestGroups{
+		New: []repository.DigestItem{
+			{Title: "Пожар на складе", Category: "происшествия", Ref: "S1", SourceMessageID: 11},
+			{Title: "Новый налог", Category: "экономика", Ref: "S2", SourceMessageID: 12},
+		},
+		Ongoing: []repository.DigestItem{
+			{Title: "Выборы", Category: "политика", DeltaSummary: "Дебаты", Ref: "S3", SourceMessageID: 13},
+			{Title: "Курс рубля", Category: "экономика", DeltaSummary: "Снижение", Ref: "S4", SourceMessageID: 14},
+		},
+		RecurringNoise: []string{"происшествия", "погода"},
+	}
+}
+
+func TestApplyCategoryPreferences(t *testing.T) {
+	prefs := &repository.CategoryPreferences{Muted: []string{"происшествия"}, Boosted: []string{"экономика"}}
+
+	result, changed := applyCategoryPreferences(*personalizerGroups(), prefs)
+
+	assert.True(t, changed)
+	assert.Len(t, result.New, 1)
+	assert.Equal(t, "Новый налог", result.New[0].Title)
+	assert.Equal(t, []string{"Курс рубля", "Выборы"}, []string{result.Ongoing[0].Title, result.Ongoing[1].Title})
+	assert.Equal(t, []string{"погода"}, result.RecurringNoise)
+}
+
+func TestApplyCategoryPreferences_BoostAlreadyFirst(t *testing.T) {
+	groups := repository.DigestGroups{
+		New: []repository.DigestItem{{Title: "Новый налог", Category: "экономика"}, {Title: "Выборы", Category: "политика"}},
+	}
+	_, changed := applyCategoryPreferences(groups, &repository.CategoryPreferences{Boosted: []string{"экономика"}})
+	assert.False(t, changed)
+}
+
+func TestDigestPersonalizer_RendersOnceAndCaches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, map[int64]string{100: "test_channel"}, nil)
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	prefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	variantRepo := mock_repository.NewMockPersonalizedSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+
+	summary := &repository.Summary{ID: 7, ChannelID: 100, Summary: "общий", CreatedAt: time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)}
+	prefs := &repository.CategoryPreferences{Muted: []string{"происшествия"}}
+
+	prefRepo.EXPECT().GetPreferences(int64(555)).Return(prefs, nil)
+	summaryRepo.EXPECT().GetDigestGroups(int64(7)).Return(personalizerGroups(), nil)
+	variantRepo.EXPECT().GetVariant(int64(7), prefs.Key()).Return("", false, nil)
+	// расход считается за день дайджеста, а не за день его сохранения
+	mlRepo.EXPECT().ForDay(int64(100), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)).Return(mlRepo)
+	mlRepo.EXPECT().RenderDigest(gomock.Any()).DoAndReturn(func(groups repository.DigestGroups) (string, error) {
+		assert.Len(t, groups.New, 1)
+		return "Налог [[S2]]", nil
+	})
+	variantRepo.EXPECT().SaveVariant(int64(7), prefs.Key(), "Налог [источник](https://t.me/test_channel/12)").Return(nil)
+
+	p := NewDigestPersonalizer(summaryRepo, prefRepo, variantRepo, mlRepo)
+	result := p.Personalize(555, summary)
+
+	assert.Equal(t, "Налог [источник](https://t.me/test_channel/12)", result.Summary)
+	assert.Equal(t, "общий", summary.Summary, "исходный дайджест не меняется")
+}
+
+func TestDigestPersonalizer_UsesCachedVariant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, map[int64]string{100: "test_channel"}, nil)
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	prefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	variantRepo := mock_repository.NewMockPersonalizedSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+
+	summary := &repository.Summary{ID: 7, ChannelID: 100, Summary: "общий"}
+	prefs := &repository.CategoryPreferences{Boosted: []string{"экономика"}}
+
+	prefRepo.EXPECT().GetPreferences(int64(555)).Return(prefs, nil)
+	summaryRepo.EXPECT().GetDigestGroups(int64(7)).Return(personalizerGroups(), nil)
+	variantRepo.EXPECT().GetVariant(int64(7), prefs.Key()).Return("экономика первой", true, nil)
+
+	p := NewDigestPersonalizer(summaryRepo, prefRepo, variantRepo, mlRepo)
+	assert.Equal(t, "экономика первой", p.Personalize(555, summary).Summary)
+}
+
+func TestDigestPersonalizer_FallsBackToCommonDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, map[int64]string{100: "test_channel"}, nil)
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	prefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	variantRepo := mock_repository.NewMockPersonalizedSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	p := NewDigestPersonalizer(summaryRepo, prefRepo, variantRepo, mlRepo)
+
+	summary := &repository.Summary{ID: 7, ChannelID: 100, Summary: "общий"}
+
+	// без настроек группы не читаются
+	prefRepo.EXPECT().GetPreferences(int64(1)).Return(&repository.CategoryPreferences{}, nil)
+	assert.Same(t, summary, p.Personalize(1, summary))
+
+	// дайджест собран до сохранения групп
+	prefRepo.EXPECT().GetPreferences(int64(2)).Return(&repository.CategoryPreferences{Muted: []string{"военное"}}, nil)
+	summaryRepo.EXPECT().GetDigestGroups(int64(7)).Return(nil, nil)
+	assert.Same(t, summary, p.Personalize(2, summary))
+
+	// ошибка рендера
+	prefRepo.EXPECT().GetPreferences(int64(3)).Return(&repository.CategoryPreferences{Muted: []string{"политика"}}, nil)
+	summaryRepo.EXPECT().GetDigestGroups(int64(7)).Return(personalizerGroups(), nil)
+	variantRepo.EXPECT().GetVariant(int64(7), gomock.Any()).Return("", false, nil)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo)
+	mlRepo.EXPECT().RenderDigest(gomock.Any()).Return("", errors.New("llm down"))
+	assert.Same(t, summary, p.Personalize(3, summary))
+}
+
+func TestDigestPersonalizer_AllMutedSkipsLLM(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, map[int64]string{100: "test_channel"}, nil)
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	prefRepo := mock_repository.NewMockCategoryPreferenceRepositoryInterface(ctrl)
+	variantRepo := mock_repository.NewMockPersonalizedSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+
+	summary := &repository.Summary{ID: 7, ChannelID: 100, Summary: "общий"}
+	prefs := &repository.CategoryPreferences{Muted: []string{"происшествия"}}
+
+	prefRepo.EXPECT().GetPreferences(int64(555)).Return(prefs, nil)
+	summaryRepo.EXPECT().GetDigestGroups(int64(7)).Return(&repository.DigestGroups{
+		New:            []repository.DigestItem{{Title: "Пожар", Category: "происшествия"}},
+		RecurringNoise: []string{"происшествия"},
+	}, nil)
+	variantRepo.EXPECT().GetVariant(int64(7), prefs.Key()).Return("", false, nil)
+	variantRepo.EXPECT().SaveVariant(int64(7), prefs.Key(), allMutedDigest).Return(nil)
+
+	p := NewDigestPersonalizer(summaryRepo, prefRepo, variantRepo, mlRepo)
+	assert.Equal(t, allMutedDigest, p.Personalize(555, summary).Summary)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+
+	"golang.org/x/net/html/charset"
+)
+
+// feedSource - RSS- и Atom-ленты из config.Feeds. У записей ленты нет
+// числовых id, поэтому message_id - хэш guid (см. feedItemID), а сам guid
+// хранится в meta.guid под уникальным индексом; курсор - Unix-время самой
+// свежей записи. Лента отдаёт только последние записи, так
+// что удаления не синхронизируются.
+type feedSource struct {
+	client *http.Client
+	feeds  map[int64]config.Feed
+}
+
+func newFeedSource(client *http.Client, feeds map[int64]config.Feed) *feedSource {
+	return &feedSource{client: client, feeds: feeds}
+}
+
+func (f *feedSource) Name() string { return "rss" }
+
+func (f *feedSource) Channels() []int64 {
+	return slices.Sorted(maps.Keys(f.feeds))
+}
+
+func (f *feedSource) CursorFallback() repository.CursorFallback {
+	return repository.CursorByMessageDate
+}
+
+func (f *feedSource) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *feedSource) Fetch(ctx context.Context, req SourceRequest) (*SourceBatch, error) {
+	feed, ok := f.feeds[req.ChannelID]
+	if !ok {
+		return nil, fmt.Errorf("feed %d is not configured", req.ChannelID)
+	}
+	items, err := f.load(ctx, feed.URL)
+	if err != nil {
+		return nil, fmt.Errorf("load feed %s: %w", feed.Name, err)
+	}
+
+	since := feedSince(req.Cursor, req.Now)
+	batch := &SourceBatch{Cursor: req.Cursor}
+	for _, item := range items {
+		post := item.post(req.ChannelID, req.Now)
+		if post == nil || post.MessageDate.Before(since) {
+			continue
+		}
+		batch.Posts = append(batch.Posts, post)
+		if !item.Published.IsZero() {
+			batch.Cursor = max(batch.Cursor, int(item.Published.Unix()))
+		}
+	}
+	return batch, nil
+}
+
+// feedSince - с какого времени брать записи. Лента перечитывается с запасом
+// config.MessageSyncLookbackHours до курсора: запись могла появиться в ленте
+// позже своей даты, а уже сохранённые записи вставка просто пропустит.
+func feedSince(cursor int, now time.Time) time.Time {
+	since := time.Unix(int64(cursor), 0).UTC().Add(-config.MessageSyncLookbackHours * time.Hour)
+	if floor := now.Add(-config.MessageBackfillMaxHours * time.Hour); since.Before(floor) {
+		since = floor
+	}
+	return since
+}
+
+func (f *feedSource) load(ctx context.Context, url string) ([]feedItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "goNewsBot")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, config.FeedMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > config.FeedMaxBytes {
+		return nil, fmt.Errorf("feed is larger than %d bytes", config.FeedMaxBytes)
+	}
+	return parseFeed(body)
+}
+
+// feedItem - запись RSS или Atom, приведённая к общему виду.
+type feedItem struct {
+	GUID      string
+	Title     string
+	Link      string
+	Body      string // HTML описания или содержимого
+	Media     string
+	Published time.Time // нулевое - в ленте нет даты
+	Updated   time.Time
+}
+
+type feedDocument struct {
+	XMLName xml.Name
+	// RSS 2.0; в RSS 1.0 (RDF) item лежат прямо в корне
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+	// Atom
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string `xml:"pubDate"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Enclosures  []struct {
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Summary   atomText `xml:"summary"`
+	Content   atomText `xml:"content"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+}
+
+// atomText - text, html или xhtml: у xhtml текст лежит во вложенной разметке.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+// parseFeed разбирает RSS 2.0, RSS 1.0 и Atom; кодировка берётся из
+// XML-декларации (ленты бывают в windows-1251).
+func parseFeed(data []byte) ([]feedItem, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+
+	var items []feedItem
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		for _, it := range append(doc.Channel.Items, doc.Items...) {
+			item := feedItem{
+				GUID:  strings.TrimSpace(it.GUID),
+				Title: it.Title,
+				Link:  strings.TrimSpace(it.Link),
+				Body:  it.Content,
+			}
+			if strings.TrimSpace(item.Body) == "" {
+				item.Body = it.Description
+			}
+			item.Published = parseFeedDate(it.PubDate)
+			if item.Published.IsZero() {
+				item.Published = parseFeedDate(it.DCDate)
+			}
+			for _, enclosure := range it.Enclosures {
+				if item.Media = enclosureMedia(enclosure.Type); item.Media != "" {
+					break
+				}
+			}
+			items = append(items, item)
+		}
+	case "feed":
+		for _, e := range doc.Entries {
+			item := feedItem{
+				GUID:      strings.TrimSpace(e.ID),
+				Title:     e.Title,
+				Body:      e.Content.String(),
+				Published: parseFeedDate(e.Published),
+				Updated:   parseFeedDate(e.Updated),
+			}
+			if strings.TrimSpace(item.Body) == "" {
+				item.Body = e.Summary.String()
+			}
+			if item.Published.IsZero() {
+				item.Published, item.Updated = item.Updated, time.Time{}
+			}
+			for _, link := range e.Links {
+				switch link.Rel {
+				case "", "alternate":
+					if item.Link == "" {
+						item.Link = strings.TrimSpace(link.Href)
+					}
+				case "enclosure":
+					if item.Media == "" {
+						item.Media = enclosureMedia(link.Type)
+					}
+				}
+			}
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("unknown feed format <%s>", doc.XMLName.Local)
+	}
+	return items, nil
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+}
+
+// parseFeedDate - дата записи; нулевое время, если формат не распознан.
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// enclosureMedia - тип вложения в терминах MessageMeta.Media.
+func enclosureMedia(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "photo"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	default:
+		return ""
+	}
+}
+
+// post - запись как пост канала; nil, если в ней нет текста. Запись без даты
+// датируется моментом загрузки.
+func (item feedItem) post(channelID int64, now time.Time) *repository.Message {
+	title := feedText(item.Title)
+	body := feedText(item.Body)
+	if strings.HasPrefix(body, title) {
+		// в описании часто повторяется заголовок
+		title = ""
+	}
+	text := strings.TrimSpace(title + "\n\n" + body)
+	if text == "" {
+		return nil
+	}
+
+	key := item.key()
+	post := &repository.Message{
+		ChannelID:   channelID,
+		MessageID:   feedItemID(key),
+		MessageText: text,
+		MessageDate: item.Published,
+	}
+	if post.MessageDate.IsZero() {
+		post.MessageDate = now
+	}
+	if item.Updated.After(post.MessageDate) {
+		post.EditDate = item.Updated
+	}
+	post.Meta.URL = item.Link
+	post.Meta.GUID = key
+	post.Meta.Media = item.Media
+	return post
+}
+
+// key - постоянный ключ записи: guid, ссылка, если guid нет, иначе заголовок с датой.
+func (item feedItem) key() string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Title + "\n" + item.Published.String()
+}
+
+// feedItemID - message_id записи: 63-битный FNV-64a хэш ключа, чтобы запись
+// получала тот же id при каждом чтении ленты и помещалась в BIGINT. 31 бита
+// мало: на десятках тысяч записей совпадения становятся вероятными.
+func feedItemID(key string) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return max(int(h.Sum64()&math.MaxInt64), 1)
+}
+
+var (
+	feedBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6]|blockquote)>`)
+	feedTagRe   = regexp.MustCompile(`<[^>]*>`)
+	feedSpaceRe = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+	feedLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// feedText - HTML записи как простой текст: абзацы разделены пустой строкой.
+func feedText(value string) string {
+	value = feedBreakRe.ReplaceAllString(value, "\n\n")
+	value = feedTagRe.ReplaceAllString(value, " ")
+	value = html.UnescapeString(value)
+	value = feedSpaceRe.ReplaceAllString(value, " ")
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	value = strings.Join(lines, "\n")
+	return strings.TrimSpace(feedLinesRe.ReplaceAllString(value, "\n\n"))
+}
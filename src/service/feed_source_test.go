@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// serveFeeds отдаёт файлы из testdata по путям /<имя файла>.
+func serveFeeds(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testFeedSource(server *httptest.Server, file string) *feedSource {
+	return newFeedSource(server.Client(), map[int64]config.Feed{
+		-1: {Name: "city_news", URL: server.URL + "/" + file},
+	})
+}
+
+func TestFeedSource_FetchRSS(t *testing.T) {
+	server := serveFeeds(t)
+	source := testFeedSource(server, "feed_rss.xml")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	batch, err := source.Fetch(context.Background(), SourceRequest{ChannelID: -1, Now: now})
+	require.NoError(t, err)
+
+	// запись старше config.MessageBackfillMaxHours и запись без текста пропущены
+	require.Len(t, batch.Posts, 2)
+	first := batch.Posts[0]
+	assert.Equal(t, int64(-1), first.ChannelID)
+	assert.Equal(t, "В центре перекрыли набережную\n\nДвижение закрыто до 22:00 из-за ремонта.\n\nОбъезд по Садовой.", first.MessageText)
+	assert.Equal(t, time.Date(2026, 10, 19, 6, 30, 0, 0, time.UTC), first.MessageDate)
+	assert.Equal(t, "https://news.example.ru/2026/10/19/embankment", first.Meta.URL)
+	assert.Equal(t, "photo", first.Meta.Media)
+	assert.Equal(t, feedItemID("news-1002"), first.MessageID)
+	assert.Equal(t, "news-1002", first.Meta.GUID)
+
+	// заголовок, повторённый в начале content:encoded, не дублируется
+	assert.Equal(t, "Мэрия утвердила бюджет на 2027 год.\n\nРасходы на транспорт выросли на 12%.", batch.Posts[1].MessageText)
+
+	assert.Nil(t, batch.SeenIDs)
+	assert.Equal(t, int(first.MessageDate.Unix()), batch.Cursor)
+
+	// повторное чтение даёт те же message_id
+	again, err := source.Fetch(context.Background(), SourceRequest{ChannelID: -1, Cursor: batch.Cursor, Now: now})
+	require.NoError(t, err)
+	require.Len(t, again.Posts, 2)
+	assert.Equal(t, first.MessageID, again.Posts[0].MessageID)
+
+	// записи раньше курсора минус config.MessageSyncLookbackHours не перечитываются
+	cursor := int(time.Date(2026, 10, 20, 20, 0, 0, 0, time.UTC).Unix())
+	again, err = source.Fetch(context.Background(), SourceRequest{ChannelID: -1, Cursor: cursor, Now: now.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	assert.Len(t, again.Posts, 1)
+}
+
+func TestFeedSource_FetchAtom(t *testing.T) {
+	server := serveFeeds(t)
+	source := testFeedSource(server, "feed_atom.xml")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	batch, err := source.Fetch(context.Background(), SourceRequest{ChannelID: -1, Now: now})
+	require.NoError(t, err)
+	require.Len(t, batch.Posts, 2)
+
+	post := batch.Posts[0]
+	assert.Equal(t, "Как мы считаем охваты\n\nОхват считается по уникальным читателям.", post.MessageText)
+	assert.Equal(t, time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC), post.MessageDate)
+	assert.Equal(t, time.Date(2026, 10, 19, 9, 15, 0, 0, time.UTC), post.EditDate)
+	assert.Equal(t, "https://blog.example.ru/42", post.Meta.URL)
+	assert.Equal(t, "video", post.Meta.Media)
+
+	// без published датой записи становится updated
+	post = batch.Posts[1]
+	assert.Equal(t, "Итоги «недели»\n\nГлавное за неделю.", post.MessageText)
+	assert.Equal(t, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), post.MessageDate)
+	assert.True(t, post.EditDate.IsZero())
+}
+
+func TestFeedSource_FetchWindows1251(t *testing.T) {
+	server := serveFeeds(t)
+	source := testFeedSource(server, "feed_cp1251.xml")
+
+	batch, err := source.Fetch(context.Background(), SourceRequest{
+		ChannelID: -1,
+		Now:       time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Len(t, batch.Posts, 1)
+	assert.Equal(t, "Открылся новый мост\n\nМост соединил два района.", batch.Posts[0].MessageText)
+	// без guid id строится по ссылке
+	assert.Equal(t, feedItemID("https://region.example.ru/bridge"), batch.Posts[0].MessageID)
+	assert.Equal(t, "https://region.example.ru/bridge", batch.Posts[0].Meta.GUID)
+}
+
+func TestFeedSource_FetchErrors(t *testing.T) {
+	server := serveFeeds(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	_, err := testFeedSource(server, "missing.xml").Fetch(context.Background(), SourceRequest{ChannelID: -1, Now: now})
+	assert.Error(t, err)
+
+	// не лента
+	_, err = testFeedSource(server, "storyline_e2e.json").Fetch(context.Background(), SourceRequest{ChannelID: -1, Now: now})
+	assert.Error(t, err)
+
+	_, err = testFeedSource(server, "feed_rss.xml").Fetch(context.Background(), SourceRequest{ChannelID: -2, Now: now})
+	assert.Error(t, err)
+}
+
+func TestFeedItemID_NoCollisions(t *testing.T) {
+	// при 31-битном хэше на 100 тысячах ключей ожидается пара совпадений
+	seen := make(map[int]string, 100000)
+	for i := 0; i < 100000; i++ {
+		key := fmt.Sprintf("https://news.example.ru/2026/10/%d", i)
+		id := feedItemID(key)
+		if id <= 0 {
+			t.Fatalf("feedItemID(%q) = %d", key, id)
+		}
+		if other, ok := seen[id]; ok {
+			t.Fatalf("%s collides with %s", key, other)
+		}
+		seen[id] = key
+	}
+}
+
+func TestFeedSince(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	cursor := int(now.Add(-time.Hour).Unix())
+
+	assert.Equal(t, now.Add(-time.Hour-config.MessageSyncLookbackHours*time.Hour), feedSince(cursor, now))
+	assert.Equal(t, now.Add(-config.MessageBackfillMaxHours*time.Hour), feedSince(0, now))
+}
+
+// stubSource - источник с заранее заданными ответами по каналам.
+type stubSource struct {
+	batches map[int64]*SourceBatch
+	errs    map[int64]error
+}
+
+func (s *stubSource) Name() string { return "stub" }
+
+func (s *stubSource) Channels() []int64 { return []int64{-1, -2} }
+
+func (s *stubSource) CursorFallback() repository.CursorFallback {
+	return repository.CursorByMessageDate
+}
+
+func (s *stubSource) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (s *stubSource) Fetch(ctx context.Context, req SourceRequest) (*SourceBatch, error) {
+	if err := s.errs[req.ChannelID]; err != nil {
+		return nil, err
+	}
+	return s.batches[req.ChannelID], nil
+}
+
+func TestMessageService_SyncSourcesSavesPostsAndCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	watcher := &recordingWatcher{}
+	svc := NewMessageService(repo, nil, nil, watcher)
+
+	post := &repository.Message{ChannelID: -1, MessageID: 77, MessageText: "Открылся новый мост"}
+	source := &stubSource{
+		batches: map[int64]*SourceBatch{-1: {Posts: []*repository.Message{post}, Cursor: 1760860800}},
+		errs:    map[int64]error{-2: errors.New("feed is down")},
+	}
+	svc.sources = []Source{source}
+
+	repo.EXPECT().GetChannelCursor(int64(-1), repository.CursorByMessageDate).Return(1760857200, nil)
+	repo.EXPECT().GetMessageIDsSince(int64(-1), gomock.Any()).Return([]int64{70}, nil)
+	repo.EXPECT().SaveMessage(post).Return(true, nil)
+	repo.EXPECT().UpdateMessageMetrics(int64(-1), []*repository.Message{post}).Return(nil)
+	repo.EXPECT().SaveChannelCursor(int64(-1), 1760860800).Return(nil)
+	repo.EXPECT().GetChannelCursor(int64(-2), repository.CursorByMessageDate).Return(0, nil)
+	repo.EXPECT().GetMessageIDsSince(int64(-2), gomock.Any()).Return(nil, nil)
+	// без SeenIDs удаления не синхронизируются: MarkMessagesDeleted не вызывается
+
+	// упавшая лента не делает неудачным весь проход
+	require.NoError(t, svc.syncSources(context.Background()))
+	assert.Equal(t, int64(-1), watcher.channelID)
+	assert.Equal(t, []*repository.Message{post}, watcher.messages)
+}
+
+func TestMessageService_SyncSourcesFailsWhenNothingSynced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewMessageService(repo, nil, nil, nil)
+	svc.sources = []Source{&stubSource{errs: map[int64]error{
+		-1: errors.New("feed is down"),
+		-2: errors.New("feed is down"),
+	}}}
+
+	repo.EXPECT().GetChannelCursor(gomock.Any(), repository.CursorByMessageDate).Return(0, nil).Times(2)
+	repo.EXPECT().GetMessageIDsSince(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+
+	assert.Error(t, svc.syncSources(context.Background()))
+}
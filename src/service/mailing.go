@@ -23,8 +23,12 @@ type MailingService struct {
 	rateRepo    repository.RateRepositoryInterface
 	summaryRepo repository.SummaryRepositoryInterface
 	weatherRepo repository.WeatherRepositoryInterface
-	bot         BotSender
-	mailingChan chan *repository.User
+	// trendService добавляет недельный отчёт в воскресную рассылку; nil - без отчёта.
+	trendService *TrendService
+	// personalizer подстраивает дайджест под рубрики читателя; nil - общий дайджест.
+	personalizer *DigestPersonalizer
+	bot          BotSender
+	mailingChan  chan *repository.User
 }
 
 func NewMailingService(
@@ -32,15 +36,19 @@ func NewMailingService(
 	rateRepo repository.RateRepositoryInterface,
 	summaryRepo repository.SummaryRepositoryInterface,
 	weatherRepo repository.WeatherRepositoryInterface,
+	trendService *TrendService,
+	personalizer *DigestPersonalizer,
 	bot BotSender,
 ) *MailingService {
 	return &MailingService{
-		userRepo:    userRepo,
-		rateRepo:    rateRepo,
-		summaryRepo: summaryRepo,
-		weatherRepo: weatherRepo,
-		bot:         bot,
-		mailingChan: make(chan *repository.User, 100),
+		userRepo:     userRepo,
+		rateRepo:     rateRepo,
+		summaryRepo:  summaryRepo,
+		weatherRepo:  weatherRepo,
+		trendService: trendService,
+		personalizer: personalizer,
+		bot:          bot,
+		mailingChan:  make(chan *repository.User, 100),
 	}
 }
 
@@ -73,12 +81,11 @@ func (s *MailingService) scheduleMailings(ctx context.Context) {
 
 			now := time.Now()
 			for _, user := range users {
-				timezone, err := strconv.Atoi(user.Timezone)
+				userLoc, err := userLocation(user)
 				if err != nil {
 					log.Errorf("Error converting timezone to int: %v", err)
 					continue
 				}
-				userLoc := time.FixedZone(user.Timezone, timezone*60*60)
 				nowInUserZone := now.In(userLoc)
 
 				if nowInUserZone.Hour() == user.MailingTime.Hour() &&
@@ -97,42 +104,71 @@ func (s *MailingService) sendMailings(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case user := <-s.mailingChan:
-			// Get weather
-			weatherMsg, err := s.getWeatherMessage(user.City)
-			if err != nil {
-				log.Errorf("Error getting weather: %v", err)
-				continue
-			}
+			s.sendMailing(user, time.Now())
+		}
+	}
+}
 
-			ratesMsg, err := s.getRatesMessage()
-			if err != nil {
-				log.Errorf("Error getting rates: %v", err)
-				continue
-			}
+// sendMailing отправляет ежедневную рассылку, а по воскресеньям после неё -
+// отдельным сообщением недельные тренды, чтобы кнопки оценки остались под
+// дайджестом.
+func (s *MailingService) sendMailing(user *repository.User, now time.Time) {
+	// Get weather
+	weatherMsg, err := s.getWeatherMessage(user.City)
+	if err != nil {
+		log.Errorf("Error getting weather: %v", err)
+		return
+	}
 
-			newsMsg, err := s.getNewsMessage(user.PreferredChannelID)
-			if err != nil {
-				log.Errorf("Error getting news: %v", err)
-				continue
-			}
+	ratesMsg, err := s.getRatesMessage()
+	if err != nil {
+		log.Errorf("Error getting rates: %v", err)
+		return
+	}
 
-			fullMessage := fmt.Sprintf("Ежедневная рассылка:\n\n%s\n\n%s\n\n%s",
-				weatherMsg, ratesMsg, newsMsg)
+	newsMsg, summaryID, err := s.getNewsMessage(user)
+	if err != nil {
+		log.Errorf("Error getting news: %v", err)
+		return
+	}
 
-			parts := telegramutil.SplitMessage(fullMessage)
-			for i, part := range parts {
-				opts := makeMailingSendOptions(i == len(parts)-1)
+	fullMessage := fmt.Sprintf("Ежедневная рассылка:\n\n%s\n\n%s\n\n%s",
+		weatherMsg, ratesMsg, newsMsg)
 
-				_, err = s.bot.Send(&tele.User{ID: user.ChatID}, part, opts...)
-				if err != nil {
-					log.Errorf("Error sending mailing part %d/%d to user %d: %v", i+1, len(parts), user.ChatID, err)
-					log.Info("Try send plain text message")
-					_, err = s.bot.Send(&tele.User{ID: user.ChatID}, part, makeMailingPlainSendOptions(i == len(parts)-1)...)
-					if err != nil {
-						log.Errorf("Error sending plain text mailing part %d/%d to user %d: %v", i+1, len(parts), user.ChatID, err)
-						break
-					}
-				}
+	// Под последней частью - кнопки оценки дайджеста, если он есть.
+	lastMarkup := keyboard.GetStartKeyboard()
+	if summaryID != 0 {
+		lastMarkup = keyboard.GetFeedbackKeyboard(summaryID)
+	}
+	s.sendMailingParts(user.ChatID, fullMessage, lastMarkup)
+
+	if trendsMsg := s.getTrendsMessage(user, now); trendsMsg != "" {
+		s.sendMailingParts(user.ChatID, trendsMsg, keyboard.GetStartKeyboard())
+	}
+}
+
+// sendMailingParts отправляет сообщение частями по лимиту Telegram, markup -
+// под последней частью. Если часть не уходит и без Markdown, остальные не
+// отправляются.
+func (s *MailingService) sendMailingParts(chatID int64, message string, lastMarkup *tele.ReplyMarkup) {
+	parts := telegramutil.SplitMessage(message)
+	for i, part := range parts {
+		var markup *tele.ReplyMarkup
+		if i == len(parts)-1 {
+			markup = lastMarkup
+		}
+		opts := makeMailingSendOptions(markup)
+
+		_, err := s.bot.Send(&tele.User{ID: chatID}, part, opts...)
+		if err != nil {
+			log.Errorf("Error sending mailing part %d/%d to user %d: %v", i+1, len(parts), chatID, err)
+			log.Info("Try send plain text message")
+			// Без Markdown ссылки на источники передаём сущностями text_link.
+			text, entities := telegramutil.LinkEntities(part)
+			_, err = s.bot.Send(&tele.User{ID: chatID}, text, append(makeMailingPlainSendOptions(markup), entities)...)
+			if err != nil {
+				log.Errorf("Error sending plain text mailing part %d/%d to user %d: %v", i+1, len(parts), chatID, err)
+				return
 			}
 		}
 	}
@@ -161,30 +197,64 @@ func (s *MailingService) getRatesMessage() (string, error) {
 		((rates.EUR.Value-rates.EUR.Previous)/rates.EUR.Previous)*100), nil
 }
 
-func makeMailingSendOptions(withKeyboard bool) []interface{} {
+func makeMailingSendOptions(markup *tele.ReplyMarkup) []interface{} {
 	opts := []interface{}{
 		&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 	}
-	if withKeyboard {
-		opts = append([]interface{}{keyboard.GetStartKeyboard()}, opts...)
+	if markup != nil {
+		opts = append([]interface{}{markup}, opts...)
 	}
 	return opts
 }
 
-func makeMailingPlainSendOptions(withKeyboard bool) []interface{} {
-	if withKeyboard {
-		return []interface{}{keyboard.GetStartKeyboard()}
+func makeMailingPlainSendOptions(markup *tele.ReplyMarkup) []interface{} {
+	if markup != nil {
+		return []interface{}{markup}
 	}
 	return nil
 }
 
-func (s *MailingService) getNewsMessage(channelID int64) (string, error) {
-	summary, err := s.summaryRepo.GetLatestSummary(channelID)
+// getTrendsMessage возвращает недельный отчёт по каналу пользователя,
+// если у него в часовом поясе воскресенье; иначе (и при ошибке) - пустую строку.
+func (s *MailingService) getTrendsMessage(user *repository.User, now time.Time) string {
+	if s.trendService == nil {
+		return ""
+	}
+	userLoc, err := userLocation(user)
+	if err != nil || now.In(userLoc).Weekday() != time.Sunday {
+		return ""
+	}
+	report, err := s.trendService.WeeklyReport(user.PreferredChannelID, now)
 	if err != nil {
-		return "", err
+		log.Errorf("Error getting weekly trends: %v", err)
+		return ""
+	}
+	if report.IsEmpty() {
+		return ""
+	}
+	return report.Format()
+}
+
+// userLocation - фиксированный UTC-сдвиг пользователя (users.timezone хранит часы строкой).
+func userLocation(user *repository.User) (*time.Location, error) {
+	timezone, err := strconv.Atoi(user.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return time.FixedZone(user.Timezone, timezone*60*60), nil
+}
+
+// getNewsMessage возвращает текст новостей и id дайджеста (0, если дайджеста нет).
+func (s *MailingService) getNewsMessage(user *repository.User) (string, int64, error) {
+	summary, err := s.summaryRepo.GetLatestSummary(user.PreferredChannelID)
+	if err != nil {
+		return "", 0, err
 	}
 	if summary == nil {
-		return "Новостей пока нет. Проверьте позже.", nil
+		return "Новостей пока нет. Проверьте позже.", 0, nil
+	}
+	if s.personalizer != nil {
+		summary = s.personalizer.Personalize(user.ChatID, summary)
 	}
-	return summary.GetFormattedSummary(), nil
+	return summary.GetFormattedSummary(), summary.ID, nil
 }
@@ -71,6 +71,8 @@ func TestMailingService_SendMailings(t *testing.T) {
 		mockRateRepo,
 		mockSummaryRepo,
 		mockWeatherRepo,
+		nil,
+		nil,
 		mockBot,
 	)
 
@@ -118,6 +120,7 @@ func TestMailingService_SendMailingsLongSummary(t *testing.T) {
 	}
 
 	testSummary := &repository.Summary{
+		ID:        7,
 		Summary:   strings.Repeat("a", 4097),
 		CreatedAt: time.Date(2026, 6, 3, 10, 0, 0, 0, time.UTC),
 	}
@@ -147,7 +150,7 @@ func TestMailingService_SendMailingsLongSummary(t *testing.T) {
 			mockBot.EXPECT().Send(
 				gomock.Any(),
 				part,
-				keyboard.GetStartKeyboard(),
+				keyboard.GetFeedbackKeyboard(testSummary.ID),
 				&tele.SendOptions{ParseMode: tele.ModeMarkdown},
 			).DoAndReturn(func(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error) {
 				sentParts = append(sentParts, what.(string))
@@ -171,6 +174,8 @@ func TestMailingService_SendMailingsLongSummary(t *testing.T) {
 		mockRateRepo,
 		mockSummaryRepo,
 		mockWeatherRepo,
+		nil,
+		nil,
 		mockBot,
 	)
 
@@ -243,6 +248,8 @@ func TestMailingService_ErrorHandling(t *testing.T) {
 		mockRateRepo,
 		mockSummaryRepo,
 		mockWeatherRepo,
+		nil,
+		nil,
 		mockBot,
 	)
 
@@ -271,6 +278,8 @@ func TestMailingService_EmptyUsers(t *testing.T) {
 		mockRateRepo,
 		mockSummaryRepo,
 		mockWeatherRepo,
+		nil,
+		nil,
 		mockBot,
 	)
 
@@ -281,3 +290,90 @@ func TestMailingService_EmptyUsers(t *testing.T) {
 
 	<-ctx.Done()
 }
+
+func TestMailingService_TrendsOnlyOnSunday(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTrendRepo := mock_repository.NewMockTrendRepositoryInterface(ctrl)
+	service := NewMailingService(nil, nil, nil, nil, NewTrendService(mockTrendRepo), nil, nil)
+
+	testUser := &repository.User{ChatID: 123, Timezone: "3", PreferredChannelID: 1429590454}
+
+	// суббота 22:00 UTC - у пользователя (UTC+3) уже воскресенье
+	sunday := time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC)
+	mockTrendRepo.EXPECT().TopStorylines(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]repository.StorylineTrend{
+		{Title: "Переговоры", TotalMessages: 5, DaysSeen: 2, MaxImportance: 3},
+	}, nil)
+	mockTrendRepo.EXPECT().TopEscalations(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockTrendRepo.EXPECT().CategoryShares(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	mockTrendRepo.EXPECT().ClosedStorylines(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	if msg := service.getTrendsMessage(testUser, sunday); !strings.Contains(msg, "Переговоры") {
+		t.Fatalf("expected weekly trends on Sunday, got %q", msg)
+	}
+
+	monday := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	if msg := service.getTrendsMessage(testUser, monday); msg != "" {
+		t.Fatalf("expected no weekly trends on Monday, got %q", msg)
+	}
+}
+
+func TestMailingService_SendMailingTrendsSeparately(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRateRepo := mock_repository.NewMockRateRepositoryInterface(ctrl)
+	mockSummaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	mockWeatherRepo := mock_repository.NewMockWeatherRepositoryInterface(ctrl)
+	mockTrendRepo := mock_repository.NewMockTrendRepositoryInterface(ctrl)
+	mockBot := mock_telebot.NewMockBot(ctrl)
+
+	testUser := &repository.User{ChatID: 123, City: "Москва", Timezone: "3", PreferredChannelID: 1429590454}
+
+	mockRateRepo.EXPECT().GetRates().Return(&repository.Rates{
+		USD: repository.CurrencyRate{Value: 90.0, Previous: 89.0},
+		EUR: repository.CurrencyRate{Value: 100.0, Previous: 99.0},
+	}, nil)
+	mockWeatherRepo.EXPECT().GetWeatherByCity(testUser.City).Return(&repository.WeatherResponse{
+		Main:    repository.MainResponse{Temp: 20.0},
+		City:    "Москва",
+		Weather: []repository.WResponse{{Desc: "ясно"}},
+	}, nil)
+	mockSummaryRepo.EXPECT().GetLatestSummary(testUser.PreferredChannelID).Return(&repository.Summary{
+		ID:      7,
+		Summary: "Тестовая новость",
+	}, nil)
+	mockTrendRepo.EXPECT().TopStorylines(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]repository.StorylineTrend{
+		{Title: "Переговоры", TotalMessages: 5, DaysSeen: 2, MaxImportance: 3},
+	}, nil)
+	mockTrendRepo.EXPECT().TopEscalations(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockTrendRepo.EXPECT().CategoryShares(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	mockTrendRepo.EXPECT().ClosedStorylines(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	var sent []string
+	record := func(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error) {
+		sent = append(sent, what.(string))
+		return &tele.Message{}, nil
+	}
+	// кнопки оценки - под дайджестом, тренды - отдельным сообщением со стартовой клавиатурой
+	gomock.InOrder(
+		mockBot.EXPECT().Send(gomock.Any(), gomock.Any(), keyboard.GetFeedbackKeyboard(7), &tele.SendOptions{ParseMode: tele.ModeMarkdown}).DoAndReturn(record),
+		mockBot.EXPECT().Send(gomock.Any(), gomock.Any(), keyboard.GetStartKeyboard(), &tele.SendOptions{ParseMode: tele.ModeMarkdown}).DoAndReturn(record),
+	)
+
+	service := NewMailingService(nil, mockRateRepo, mockSummaryRepo, mockWeatherRepo, NewTrendService(mockTrendRepo), nil, mockBot)
+
+	// суббота 22:00 UTC - у пользователя (UTC+3) уже воскресенье
+	service.sendMailing(testUser, time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC))
+
+	if len(sent) != 2 {
+		t.Fatalf("expected digest and trends as two messages, got %d", len(sent))
+	}
+	if !strings.Contains(sent[0], "Тестовая новость") || strings.Contains(sent[0], "Переговоры") {
+		t.Fatalf("first message should be the digest without trends, got %q", sent[0])
+	}
+	if !strings.Contains(sent[1], "Переговоры") {
+		t.Fatalf("second message should be weekly trends, got %q", sent[1])
+	}
+}
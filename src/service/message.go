@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"maps"
+	"net/http"
 	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Ra1ze505/goNewsBot/src/config"
@@ -20,27 +25,82 @@ import (
 )
 
 const (
-	MessageBatchLimit = 20
+	MessageBatchLimit = 100 // максимум messages.getHistory
 )
 
+// MessageWatcher получает сообщения, впервые сохранённые за проход по каналу.
+type MessageWatcher interface {
+	Notify(channelID int64, messages []*repository.Message)
+}
+
 type MessageService struct {
+	// mu не даёт проходам загрузки и /backfill работать с клиентом одновременно
+	mu     sync.Mutex
 	client *telegram.Client
 	waiter *floodwait.Waiter
 	api    *tg.Client
-	repo   repository.MessageRepositoryInterface
+	// live - API постоянного подключения потока обновлений, nil - не подключено
+	live *tg.Client
+	repo repository.MessageRepositoryInterface
+	// stateRepo хранит состояние потока обновлений; nil - только опрос раз в 15 минут.
+	stateRepo repository.UpdateStateRepositoryInterface
+	// sessions - сессия MTProto-аккаунта (см. NewSessionStorage)
+	sessions telegram.SessionStorage
+	// watcher проверяет новые сообщения на подписки читателей; nil - без проверки.
+	watcher MessageWatcher
+	// pending - посты из потока обновлений, ждущие сохранения (см. queueMessage)
+	pending pendingMessages
+	// syncRequests - внеочередной проход, когда поток обновлений не смог закрыть пропуск
+	syncRequests chan struct{}
+	// reconnect прерывает паузу перед переподключением потока обновлений
+	reconnect chan struct{}
+	// sources - откуда берутся посты: каналы Telegram и RSS/Atom-ленты
+	sources []Source
 	// Channel to signal when messages are fetched
 	MessagesFetched chan struct{}
 }
 
-func NewMessageService(repo repository.MessageRepositoryInterface) *MessageService {
-	return &MessageService{
+func NewMessageService(repo repository.MessageRepositoryInterface, stateRepo repository.UpdateStateRepositoryInterface, sessions telegram.SessionStorage, watcher MessageWatcher) *MessageService {
+	s := &MessageService{
 		repo:            repo,
+		stateRepo:       stateRepo,
+		sessions:        sessions,
+		watcher:         watcher,
+		syncRequests:    make(chan struct{}, 1),
+		reconnect:       make(chan struct{}, 1),
 		MessagesFetched: make(chan struct{}),
 	}
+	s.sources = []Source{
+		&telegramSource{s: s},
+		newFeedSource(&http.Client{Timeout: config.FeedFetchTimeoutSeconds * time.Second}, config.Feeds),
+	}
+	return s
+}
+
+// withAPI выполняет fn через постоянное подключение потока обновлений, а если
+// его нет - через отдельного клиента на время вызова.
+func (s *MessageService) withAPI(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	if s.live != nil {
+		defer s.mu.Unlock()
+		s.api = s.live
+		return fn(ctx)
+	}
+	s.mu.Unlock()
+	return s.withRunClient(ctx, fn)
+}
+
+func (s *MessageService) setLive(api *tg.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live = api
 }
 
 func (s *MessageService) withRunClient(ctx context.Context, fn func(ctx context.Context) error) error {
-	client, waiter, err := createTgClient()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, waiter, err := createTgClient(s.sessions, nil)
 	if err != nil {
 		return errors.Wrap(err, "create tg client")
 	}
@@ -64,7 +124,7 @@ func (s *MessageService) StartMessageFetcher(ctx context.Context) {
 	ticker := time.NewTicker(15 * time.Minute)
 	defer ticker.Stop()
 
-	if err := s.withRunClient(ctx, s.fetchMessages); err != nil {
+	if err := s.syncSources(ctx); err != nil {
 		log.Errorf("Error fetching messages on startup: %v", err)
 	} else {
 		s.MessagesFetched <- struct{}{}
@@ -75,56 +135,383 @@ func (s *MessageService) StartMessageFetcher(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := s.withRunClient(ctx, s.fetchMessages); err != nil {
-				log.Errorf("Error fetching messages: %v", err)
-			} else {
-				// Signal that messages were fetched successfully
-				s.MessagesFetched <- struct{}{}
-			}
+		case <-s.syncRequests:
+		}
+		if err := s.syncSources(ctx); err != nil {
+			log.Errorf("Error fetching messages: %v", err)
+		} else {
+			// Signal that messages were fetched successfully
+			s.MessagesFetched <- struct{}{}
 		}
 	}
 }
 
-func (s *MessageService) fetchMessages(ctx context.Context) error {
-	log.Info("Fetching messages")
+// SessionRestored - сессия аккаунта пересоздана входом из бота: поток
+// обновлений переподключается сразу, не дожидаясь паузы, и догоняет пропущенное.
+func (s *MessageService) SessionRestored() {
+	select {
+	case s.reconnect <- struct{}{}:
+	default:
+	}
+	s.requestSync()
+}
 
-	for peerID, channelName := range config.Channels {
-		log.Infof("Fetching messages for channel: %s", channelName)
-		channel, err := s.getChannel(ctx, peerID)
+// requestSync просит внеочередной проход загрузки; повторные запросы до его
+// начала схлопываются в один.
+func (s *MessageService) requestSync() {
+	select {
+	case s.syncRequests <- struct{}{}:
+	default:
+	}
+}
+
+// savePosts сохраняет ещё не сохранённые посты (в том числе пропущенные потоком
+// обновлений), для уже сохранённых записывает правки. failed - хотя бы один
+// пост не удалось сохранить.
+func (s *MessageService) savePosts(posts []*repository.Message) (saved []*repository.Message, edited int, failed bool) {
+	for _, message := range posts {
+		inserted, err := s.repo.SaveMessage(message)
 		if err != nil {
-			log.Errorf("Error resolving channel: %v", err)
-			return err
+			log.Errorf("Error saving message: %v", err)
+			failed = true
+			continue
+		}
+		if inserted {
+			saved = append(saved, message)
 		}
 
-		lastMessageTime, err := s.repo.GetLastMessageTime(channel.ID)
+		if !inserted && !message.EditDate.IsZero() {
+			updated, err := s.repo.UpdateEditedMessage(message)
+			if err != nil {
+				log.Errorf("Error updating edited message %d: %v", message.MessageID, err)
+				continue
+			}
+			if updated {
+				edited++
+			}
+		}
+	}
+	return saved, edited, failed
+}
+
+// historyMinID - нижняя граница message_id для чтения истории: после курсора
+// и так, чтобы в выдачу попали все сохранённые посты окна синхронизации.
+func historyMinID(cursor int, stored []int64) int {
+	minID := cursor
+	for _, id := range stored {
+		if int(id)-1 < minID {
+			minID = int(id) - 1
+		}
+	}
+	return max(minID, 0)
+}
+
+func maxMessageID(ids map[int64]bool) int {
+	var result int64
+	for id := range ids {
+		result = max(result, id)
+	}
+	return int(result)
+}
+
+// BackfillResult - итог /backfill.
+type BackfillResult struct {
+	Fetched int // постов в истории за период
+	Saved   int // из них сохранено впервые
+}
+
+// Backfill загружает посты канала с message_date в [from, to): догружает
+// пропуски, которые старше окна config.MessageBackfillMaxHours. Курсор не
+// меняется, подписки /watch по старым постам не срабатывают.
+func (s *MessageService) Backfill(ctx context.Context, channelID int64, from, to time.Time) (BackfillResult, error) {
+	var result BackfillResult
+	err := s.withAPI(ctx, func(ctx context.Context) error {
+		channel, err := s.getChannel(ctx, channelID)
 		if err != nil {
-			log.Errorf("Error getting last message time: %v", err)
 			return err
 		}
-
-		messages, err := s.getChannelMessages(ctx, channel, lastMessageTime)
+		messages, _, err := s.getChannelMessages(ctx, channel, historyRange{Until: to, Since: from})
 		if err != nil {
-			log.Errorf("Error getting channel messages: %v", err)
 			return err
 		}
 
-		log.Infof("Fetched %d messages for channel with peer_id: %d", len(messages), peerID)
+		posts := buildPosts(channel.ID, messages)
+		saved, _, failed := s.savePosts(posts)
+		if err := s.repo.UpdateMessageMetrics(channel.ID, posts); err != nil {
+			log.Errorf("Error updating message metrics: %v", err)
+		}
+		if failed {
+			return errors.New("some messages were not saved")
+		}
+		result = BackfillResult{Fetched: len(posts), Saved: len(saved)}
+		return nil
+	})
+	return result, err
+}
+
+// ParseBackfillRequest разбирает аргументы /backfill: "<канал> <с> [по]", даты
+// включительно в формате ДД.ММ.ГГГГ, по умолчанию "по" совпадает с "с".
+// to - начало дня после последнего дня периода.
+func ParseBackfillRequest(payload string) (channelID int64, from, to time.Time, err error) {
+	fields := strings.Fields(payload)
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, from, to, errors.New("нужно: /backfill <канал> <с> [по], даты в формате ДД.ММ.ГГГГ")
+	}
+	if channelID, err = channelByName(fields[0]); err != nil {
+		return 0, from, to, err
+	}
+	if _, feed := config.Feeds[channelID]; feed {
+		return 0, from, to, fmt.Errorf("%s - лента, она отдаёт только последние записи; /backfill работает с каналами Telegram", fields[0])
+	}
+	if from, err = parseSearchDate(fields[1]); err != nil {
+		return 0, from, to, err
+	}
+	last := from
+	if len(fields) == 3 {
+		if last, err = parseSearchDate(fields[2]); err != nil {
+			return 0, from, to, err
+		}
+	}
+	if last.Before(from) {
+		return 0, from, to, errors.New("дата «с» позже даты «по»")
+	}
+	to = last.AddDate(0, 0, 1)
+	if to.Sub(from) > config.MessageAdminBackfillMaxDays*24*time.Hour {
+		return 0, from, to, fmt.Errorf("не больше %d дней за раз", config.MessageAdminBackfillMaxDays)
+	}
+	return channelID, from, to, nil
+}
 
-		for _, msg := range messages {
-			message := &repository.Message{
-				ChannelID:   channel.ID,
-				MessageID:   msg.ID,
-				MessageText: msg.Message,
-				MessageDate: time.Unix(int64(msg.Date), 0).UTC(),
+// buildPosts превращает сообщения истории в посты для сохранения: альбом
+// (общий grouped_id) становится одним постом, сообщения без текста и медиа
+// отбрасываются. Порядок - как в истории.
+func buildPosts(channelID int64, messages []*tg.Message) []*repository.Message {
+	var posts []*repository.Message
+	albums := make(map[int64][]*tg.Message)
+	for _, msg := range messages {
+		groupedID, ok := msg.GetGroupedID()
+		if !ok {
+			if post := postFromMessage(channelID, msg); post != nil {
+				posts = append(posts, post)
 			}
+			continue
+		}
+		if _, seen := albums[groupedID]; !seen {
+			// место альбома в выдаче занимает заглушка, которую заменит собранный пост
+			posts = append(posts, &repository.Message{GroupedID: groupedID})
+		}
+		albums[groupedID] = append(albums[groupedID], msg)
+	}
 
-			if err := s.repo.SaveMessage(message); err != nil {
-				log.Errorf("Error saving message: %v", err)
+	result := posts[:0]
+	for _, post := range posts {
+		if post.GroupedID != 0 {
+			if post = albumPost(channelID, albums[post.GroupedID]); post == nil {
 				continue
 			}
 		}
+		result = append(result, post)
 	}
-	return nil
+	return result
+}
+
+// albumPost собирает альбом в один пост с message_id первого элемента:
+// подписи элементов склеиваются, метрики берутся по элементам альбома.
+func albumPost(channelID int64, items []*tg.Message) *repository.Message {
+	slices.SortFunc(items, func(a, b *tg.Message) int { return a.ID - b.ID })
+
+	post := postFromMessage(channelID, items[0])
+	if post == nil {
+		return nil
+	}
+	post.Meta.MediaCount = len(items)
+	var captions []string
+	for i, item := range items {
+		if text := strings.TrimSpace(item.Message); text != "" {
+			captions = append(captions, text)
+		}
+		if i == 0 {
+			continue
+		}
+		post.Meta.AlbumMessageIDs = append(post.Meta.AlbumMessageIDs, item.ID)
+
+		p := postFromMessage(channelID, item)
+		if p == nil {
+			continue
+		}
+		if p.MessageDate.Before(post.MessageDate) {
+			post.MessageDate = p.MessageDate
+		}
+		if p.EditDate.After(post.EditDate) {
+			post.EditDate = p.EditDate
+		}
+		if p.Meta.Media != post.Meta.Media {
+			post.Meta.Media = "mixed"
+		}
+		// просмотры и репосты считаются на каждый элемент, реакции и
+		// комментарии - обычно на один из них
+		post.Meta.Views = max(post.Meta.Views, p.Meta.Views)
+		post.Meta.Forwards = max(post.Meta.Forwards, p.Meta.Forwards)
+		post.Meta.Reactions += p.Meta.Reactions
+		post.Meta.Replies += p.Meta.Replies
+	}
+	post.MessageText = strings.Join(captions, "\n\n")
+	return post
+}
+
+// postFromMessage - пост из одного сообщения; nil, если нет ни текста, ни медиа.
+func postFromMessage(channelID int64, msg *tg.Message) *repository.Message {
+	post := &repository.Message{
+		ChannelID:   channelID,
+		MessageID:   msg.ID,
+		MessageText: msg.Message,
+		MessageDate: time.Unix(int64(msg.Date), 0).UTC(),
+	}
+	if editDate, ok := msg.GetEditDate(); ok {
+		post.EditDate = time.Unix(int64(editDate), 0).UTC()
+	}
+	post.GroupedID, _ = msg.GetGroupedID()
+
+	if media, ok := msg.GetMedia(); ok {
+		post.Meta.Media = mediaType(media)
+	}
+	if post.MessageText == "" && post.Meta.Media == "" {
+		return nil
+	}
+
+	if fwd, ok := msg.GetFwdFrom(); ok {
+		forward := &repository.MessageForward{
+			MessageID: fwd.ChannelPost,
+			FromName:  fwd.FromName,
+			Date:      time.Unix(int64(fwd.Date), 0).UTC(),
+		}
+		if peer, ok := fwd.FromID.(*tg.PeerChannel); ok {
+			forward.ChannelID = peer.ChannelID
+		}
+		post.Meta.ForwardFrom = forward
+	}
+	if replyTo, ok := msg.GetReplyTo(); ok {
+		if header, ok := replyTo.(*tg.MessageReplyHeader); ok {
+			post.Meta.ReplyToMsgID, _ = header.GetReplyToMsgID()
+		}
+	}
+
+	post.Meta.Views, _ = msg.GetViews()
+	post.Meta.Forwards, _ = msg.GetForwards()
+	if replies, ok := msg.GetReplies(); ok {
+		post.Meta.Replies = replies.Replies
+	}
+	if reactions, ok := msg.GetReactions(); ok {
+		for _, r := range reactions.Results {
+			post.Meta.Reactions += r.Count
+		}
+	}
+	return post
+}
+
+// mediaType - тип вложения поста; превью ссылок и неподдерживаемые вложения
+// медиа не считаются.
+func mediaType(media tg.MessageMediaClass) string {
+	switch m := media.(type) {
+	case *tg.MessageMediaPhoto:
+		return "photo"
+	case *tg.MessageMediaDocument:
+		doc, ok := m.Document.(*tg.Document)
+		if !ok {
+			return "document"
+		}
+		kind := "document"
+		for _, attr := range doc.Attributes {
+			switch a := attr.(type) {
+			case *tg.DocumentAttributeAnimated:
+				return "animation"
+			case *tg.DocumentAttributeSticker:
+				return "sticker"
+			case *tg.DocumentAttributeVideo:
+				kind = "video"
+			case *tg.DocumentAttributeAudio:
+				if a.Voice {
+					kind = "voice"
+				} else {
+					kind = "audio"
+				}
+			}
+		}
+		return kind
+	case *tg.MessageMediaPoll:
+		return "poll"
+	case *tg.MessageMediaGeo, *tg.MessageMediaGeoLive, *tg.MessageMediaVenue:
+		return "geo"
+	case *tg.MessageMediaContact:
+		return "contact"
+	default:
+		return ""
+	}
+}
+
+// syncDeletions помечает удалёнными сохранённые посты окна синхронизации
+// (stored), которых больше нет в истории канала.
+func (s *MessageService) syncDeletions(channelID int64, stored []int64, seenIDs map[int64]bool, now time.Time) (int, error) {
+	// Пустая история - скорее сбой API, чем удаление всех постов.
+	if len(seenIDs) == 0 {
+		return 0, nil
+	}
+	missing := missingMessageIDs(stored, seenIDs)
+	if err := s.repo.MarkMessagesDeleted(channelID, missing, now); err != nil {
+		return 0, err
+	}
+	return len(missing), nil
+}
+
+func missingMessageIDs(stored []int64, seenIDs map[int64]bool) []int64 {
+	var missing []int64
+	for _, id := range stored {
+		if !seenIDs[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// telegramSource - каналы config.Channels, которые читает MTProto-аккаунт MessageService.
+// Курсор - message_id последнего прочитанного поста.
+type telegramSource struct {
+	s *MessageService
+}
+
+func (t *telegramSource) Name() string { return "telegram" }
+
+func (t *telegramSource) Channels() []int64 {
+	return slices.Sorted(maps.Keys(config.Channels))
+}
+
+func (t *telegramSource) CursorFallback() repository.CursorFallback {
+	return repository.CursorByMessageID
+}
+
+// Run - один клиент на весь проход (или постоянное подключение потока обновлений).
+func (t *telegramSource) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.s.withAPI(ctx, fn)
+}
+
+func (t *telegramSource) Fetch(ctx context.Context, req SourceRequest) (*SourceBatch, error) {
+	channel, err := t.s.getChannel(ctx, req.ChannelID)
+	if err != nil {
+		log.Errorf("Error resolving channel: %v", err)
+		return nil, err
+	}
+	messages, seenIDs, err := t.s.getChannelMessages(ctx, channel, historyRange{
+		MinID: historyMinID(req.Cursor, req.Stored),
+		Since: req.Now.Add(-config.MessageBackfillMaxHours * time.Hour),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SourceBatch{
+		Posts:   buildPosts(channel.ID, messages),
+		SeenIDs: seenIDs,
+		Cursor:  maxMessageID(seenIDs),
+	}, nil
 }
 
 func (s *MessageService) getChannel(ctx context.Context, peerID int64) (*tg.Channel, error) {
@@ -159,17 +546,26 @@ func (s *MessageService) getChannel(ctx context.Context, peerID int64) (*tg.Chan
 	return channel, nil
 }
 
-func (s *MessageService) getChannelMessages(ctx context.Context, channel *tg.Channel, lastMessageTime time.Time) ([]*tg.Message, error) {
-	oneDayAgo := time.Now().Add(-24 * time.Hour)
-	oneDayAgoTimestamp := int(oneDayAgo.Unix())
+// historyRange - часть истории канала для getChannelMessages.
+type historyRange struct {
+	MinID int       // только посты с message_id больше MinID (фильтр на стороне Telegram)
+	Until time.Time // только посты до Until; нулевое - с самого нового
+	Since time.Time // чтение останавливается на первом посте старше Since
+}
+
+// getChannelMessages читает историю канала от новых постов к старым.
+// seenIDs - id всех прочитанных постов, включая служебные и без текста: по ним
+// определяется, какие сохранённые посты удалены.
+func (s *MessageService) getChannelMessages(ctx context.Context, channel *tg.Channel, r historyRange) ([]*tg.Message, map[int64]bool, error) {
+	sinceTimestamp := int(r.Since.Unix())
 
 	var allMessages []*tg.Message
-	processedMessageIDs := make(map[int]bool)
+	seenIDs := make(map[int64]bool)
 
 	offsetID := 0
-
-	if lastMessageTime.IsZero() {
-		lastMessageTime = oneDayAgo
+	offsetDate := 0
+	if !r.Until.IsZero() {
+		offsetDate = int(r.Until.Unix())
 	}
 
 	for {
@@ -178,73 +574,75 @@ func (s *MessageService) getChannelMessages(ctx context.Context, channel *tg.Cha
 				ChannelID:  channel.ID,
 				AccessHash: channel.AccessHash,
 			},
-			OffsetID: offsetID,
-			Limit:    MessageBatchLimit,
-			MaxID:    0,
-			MinID:    0,
-			Hash:     0,
+			OffsetID:   offsetID,
+			OffsetDate: offsetDate,
+			Limit:      MessageBatchLimit,
+			MaxID:      0,
+			MinID:      r.MinID,
+			Hash:       0,
 		})
 		if err != nil {
 			log.Errorf("Error getting messages: %v", err)
-			return nil, err
+			return nil, nil, err
 		}
 
 		channelMessages, ok := messages.(*tg.MessagesChannelMessages)
 		if !ok {
 			log.Errorf("Unexpected messages response type: %T", messages)
-			return nil, errors.New("unexpected messages response type")
+			return nil, nil, errors.New("unexpected messages response type")
 		}
 
 		if len(channelMessages.Messages) == 0 {
-			return allMessages, nil
+			return allMessages, seenIDs, nil
 		}
 
-		var batchMessages []*tg.Message
+		// Дальше страницы листаются по id последнего прочитанного поста.
+		offsetDate = 0
 		var shouldStop bool
 
 		for _, msg := range channelMessages.Messages {
-			if message, ok := msg.(*tg.Message); ok {
-				offsetID = message.ID
-
-				messageTime := time.Unix(int64(message.Date), 0).UTC()
-
-				if message.Date < oneDayAgoTimestamp {
-					shouldStop = true
-					break
-				}
-
-				if messageTime.Before(lastMessageTime) {
-					shouldStop = true
-					break
-				}
+			offsetID = msg.GetID()
+
+			var date int
+			switch m := msg.(type) {
+			case *tg.Message:
+				date = m.Date
+			case *tg.MessageService:
+				date = m.Date
+			default:
+				continue
+			}
 
-				if processedMessageIDs[message.ID] {
-					continue
-				}
+			if date < sinceTimestamp {
+				shouldStop = true
+				break
+			}
 
-				if message.Message == "" {
-					continue
-				}
+			id := int64(msg.GetID())
+			if seenIDs[id] {
+				continue
+			}
+			seenIDs[id] = true
 
-				batchMessages = append(batchMessages, message)
-				processedMessageIDs[message.ID] = true
+			if message, ok := msg.(*tg.Message); ok {
+				allMessages = append(allMessages, message)
 			}
 		}
 
-		allMessages = append(allMessages, batchMessages...)
-
 		if shouldStop {
-			return allMessages, nil
+			return allMessages, seenIDs, nil
 		}
 
 		if len(channelMessages.Messages) < MessageBatchLimit {
 			log.Infof("Received less than %d messages, reached the end", MessageBatchLimit)
-			return allMessages, nil
+			return allMessages, seenIDs, nil
 		}
 	}
 }
 
-func createTgClient() (*telegram.Client, *floodwait.Waiter, error) {
+// createTgClient - клиент MTProto с сессией из sessions; handler получает
+// обновления (nil - клиент только для запросов).
+func createTgClient(sessions telegram.SessionStorage, handler telegram.UpdateHandler) (*telegram.Client, *floodwait.Waiter, error) {
 	appID, err := strconv.Atoi(os.Getenv("API_ID"))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "parse app id")
@@ -254,31 +652,32 @@ func createTgClient() (*telegram.Client, *floodwait.Waiter, error) {
 		return nil, nil, errors.New("no app hash")
 	}
 
-	sessionDir := config.SessionDir
 	waiter := floodwait.NewWaiter().WithCallback(func(ctx context.Context, wait floodwait.FloodWait) {
 		log.Infof("Got FLOOD_WAIT. Will retry after %v", wait.Duration)
 	})
 
 	client := telegram.NewClient(appID, appHash, telegram.Options{
-		Logger: zap.NewNop(),
-		SessionStorage: &telegram.FileSessionStorage{
-			Path: filepath.Join(sessionDir, "session.json"),
-		},
+		Logger:         zap.NewNop(),
+		SessionStorage: sessions,
 		Middlewares: []telegram.Middleware{
 			waiter,
 		},
+		UpdateHandler: handler,
 	})
 
 	return client, waiter, nil
 }
 
-func InitAndStartMessageService(ctx context.Context, db *sql.DB) (*MessageService, error) {
+func InitAndStartMessageService(ctx context.Context, db *sql.DB, sessions telegram.SessionStorage, watcher MessageWatcher) (*MessageService, error) {
 	messageRepo := repository.NewMessageRepository(db)
-	messageService := NewMessageService(messageRepo)
+	messageService := NewMessageService(messageRepo, repository.NewUpdateStateRepository(db), sessions, watcher)
 
 	go func() {
 		messageService.StartMessageFetcher(ctx)
 	}()
+	go func() {
+		messageService.StartUpdatesListener(ctx)
+	}()
 
 	return messageService, nil
 }
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/gotd/td/tg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestMessageService_SyncDeletions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewMessageService(repo, nil, nil, nil)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	repo.EXPECT().MarkMessagesDeleted(int64(1754252633), []int64{805}, now).Return(nil)
+
+	deleted, err := svc.syncDeletions(1754252633, []int64{801, 805, 812}, map[int64]bool{801: true, 812: true, 813: true}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestMessageService_SyncDeletionsSkipsEmptyHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// пустая история канала не превращается в удаление всех постов
+	svc := NewMessageService(mock_repository.NewMockMessageRepositoryInterface(ctrl), nil, nil, nil)
+
+	deleted, err := svc.syncDeletions(1754252633, []int64{801, 805}, map[int64]bool{}, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+func TestBuildPostsGroupsAlbumsAndKeepsMediaPosts(t *testing.T) {
+	date := int(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC).Unix())
+	photo := &tg.MessageMediaPhoto{}
+	video := &tg.MessageMediaDocument{Document: &tg.Document{Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeVideo{}}}}
+
+	album := func(id int, caption string, media tg.MessageMediaClass, views int) *tg.Message {
+		m := &tg.Message{ID: id, Date: date, Message: caption}
+		m.SetMedia(media)
+		m.SetGroupedID(555)
+		m.SetViews(views)
+		return m
+	}
+	reactions := tg.MessageReactions{Results: []tg.ReactionCount{{Count: 30}, {Count: 12}}}
+	captioned := album(822, "Последствия удара по подстанции", photo, 1500)
+	captioned.SetReactions(reactions)
+	forwarded := &tg.Message{ID: 830, Date: date + 60, Message: "Заявление МИД"}
+	mediaOnly := &tg.Message{ID: 819, Date: date - 60}
+	mediaOnly.SetMedia(photo)
+	forwarded.SetFwdFrom(tg.MessageFwdHeader{FromID: &tg.PeerChannel{ChannelID: 1001}, FromName: "МИД России", ChannelPost: 77, Date: date})
+
+	// история приходит от новых постов к старым
+	posts := buildPosts(1754252633, []*tg.Message{
+		forwarded,
+		album(823, "", video, 1400),
+		captioned,
+		album(821, "", photo, 1600),
+		mediaOnly,                   // фото без подписи
+		{ID: 818, Date: date - 120}, // ни текста, ни медиа
+		{ID: 817, Date: date - 180, Message: "Сводка"},
+	})
+
+	require.Len(t, posts, 4)
+	assert.Equal(t, 830, posts[0].MessageID)
+	assert.Equal(t, &repository.MessageForward{ChannelID: 1001, MessageID: 77, FromName: "МИД России", Date: time.Unix(int64(date), 0).UTC()}, posts[0].Meta.ForwardFrom)
+
+	assert.Equal(t, 821, posts[1].MessageID, "альбом сохраняется под id первого элемента")
+	assert.Equal(t, int64(555), posts[1].GroupedID)
+	assert.Equal(t, "Последствия удара по подстанции", posts[1].MessageText)
+	assert.Equal(t, repository.MessageMeta{
+		Media:           "mixed",
+		MediaCount:      3,
+		AlbumMessageIDs: []int{822, 823},
+		Views:           1600,
+		Reactions:       42,
+	}, posts[1].Meta)
+
+	assert.Equal(t, 819, posts[2].MessageID)
+	assert.Empty(t, posts[2].MessageText)
+	assert.Equal(t, "photo", posts[2].Meta.Media)
+
+	assert.Equal(t, 817, posts[3].MessageID)
+	assert.Empty(t, posts[3].Meta.Media)
+}
+
+func TestHistoryMinIDCoversCursorAndSyncWindow(t *testing.T) {
+	// окно синхронизации начинается раньше курсора - читаем от первого сохранённого поста
+	assert.Equal(t, 800, historyMinID(812, []int64{805, 801, 812}))
+	// после простоя курсор старше окна синхронизации
+	assert.Equal(t, 640, historyMinID(640, []int64{701, 702}))
+	assert.Equal(t, 0, historyMinID(0, nil))
+}
+
+func TestMessageService_SavePosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewMessageService(repo, nil, nil, nil)
+
+	date := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	edited := &repository.Message{ChannelID: 1754252633, MessageID: 805, MessageText: "ЦБ снизил ставку", MessageDate: date, EditDate: date.Add(time.Hour)}
+	stored := &repository.Message{ChannelID: 1754252633, MessageID: 806, MessageText: "Сводка", MessageDate: date}
+	// пост, пропущенный потоком обновлений, сохраняет проход по истории
+	missed := &repository.Message{ChannelID: 1754252633, MessageID: 809, MessageText: "Взрыв на заводе", MessageDate: date}
+	broken := &repository.Message{ChannelID: 1754252633, MessageID: 813, MessageText: "Ошибка", MessageDate: date}
+
+	repo.EXPECT().SaveMessage(edited).Return(false, nil)
+	repo.EXPECT().UpdateEditedMessage(edited).Return(true, nil)
+	repo.EXPECT().SaveMessage(stored).Return(false, nil)
+	repo.EXPECT().SaveMessage(missed).Return(true, nil)
+	repo.EXPECT().SaveMessage(broken).Return(false, assert.AnError)
+
+	saved, editedCount, failed := svc.savePosts([]*repository.Message{edited, stored, missed, broken})
+	assert.Equal(t, []*repository.Message{missed}, saved)
+	assert.Equal(t, 1, editedCount)
+	assert.True(t, failed)
+}
+
+func TestParseBackfillRequest(t *testing.T) {
+	channelID, from, to, err := ParseBackfillRequest("topor_live 01.10.2026 05.10.2026")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1754252633), channelID)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2026, 10, 6, 0, 0, 0, 0, time.UTC), to)
+
+	_, from, to, err = ParseBackfillRequest("@topor_live 2026-10-03")
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, to.Sub(from), "без второй даты - один день")
+
+	for _, payload := range []string{
+		"",
+		"topor_live",
+		"unknown 01.10.2026",
+		"topor_live 05.10.2026 01.10.2026",
+		"topor_live 01.08.2026 01.10.2026",
+	} {
+		_, _, _, err := ParseBackfillRequest(payload)
+		assert.Error(t, err, payload)
+	}
+
+	// лента отдаёт только последние записи, догружать нечего
+	useTestChannels(t, config.Channels, map[int64]config.Feed{-1: {Name: "city_news"}})
+	_, _, _, err = ParseBackfillRequest("city_news 01.10.2026")
+	assert.ErrorContains(t, err, "лента")
+}
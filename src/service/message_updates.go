@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+
+	"github.com/gotd/td/telegram/updates"
+	"github.com/gotd/td/tg"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingMessages - новые посты из потока обновлений по каналам. Элементы
+// альбома приходят отдельными обновлениями, поэтому посты копятся
+// config.MessageUpdateFlushSeconds и сохраняются пачкой через buildPosts.
+type pendingMessages struct {
+	mu        sync.Mutex
+	byChannel map[int64][]*tg.Message
+	timer     *time.Timer
+}
+
+// StartUpdatesListener держит постоянное подключение MTProto и сохраняет посты
+// каналов по мере публикации. Состояние потока (pts) хранится в базе, поэтому
+// после перезапуска менеджер updates догоняет пропущенное через getDifference и
+// getChannelDifference. Проходы StartMessageFetcher продолжаются как запасной путь.
+func (s *MessageService) StartUpdatesListener(ctx context.Context) {
+	if s.stateRepo == nil {
+		return
+	}
+	for {
+		err := s.listenUpdates(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorf("Channel updates listener stopped: %v; polling continues, reconnecting in %d min", err, config.MessageUpdatesRetryMinutes)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(config.MessageUpdatesRetryMinutes * time.Minute):
+		case <-s.reconnect:
+		}
+	}
+}
+
+func (s *MessageService) listenUpdates(ctx context.Context) error {
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnNewChannelMessage(s.onNewChannelMessage)
+	dispatcher.OnEditChannelMessage(s.onEditChannelMessage)
+	dispatcher.OnDeleteChannelMessages(s.onDeleteChannelMessages)
+
+	manager := updates.New(updates.Config{
+		Handler:      dispatcher,
+		Storage:      s.stateRepo,
+		AccessHasher: s.stateRepo,
+		OnChannelTooLong: func(channelID int64) {
+			// getChannelDifference не отдаёт такой большой пропуск - его закроет проход по истории
+			log.Warnf("Update gap in channel %d is too long, requesting a sync pass", channelID)
+			s.requestSync()
+		},
+	})
+
+	client, waiter, err := createTgClient(s.sessions, manager)
+	if err != nil {
+		return errors.Wrap(err, "create tg client")
+	}
+	return waiter.Run(ctx, func(ctx context.Context) error {
+		return client.Run(ctx, func(ctx context.Context) error {
+			self, err := client.Self(ctx)
+			if err != nil {
+				return errors.Wrap(err, "get self")
+			}
+
+			s.setLive(client.API())
+			defer s.setLive(nil)
+
+			return manager.Run(ctx, client.API(), self.ID, updates.AuthOptions{
+				OnStart: func(ctx context.Context) {
+					log.Info("Listening for channel updates")
+				},
+			})
+		})
+	})
+}
+
+func (s *MessageService) onNewChannelMessage(ctx context.Context, e tg.Entities, u *tg.UpdateNewChannelMessage) error {
+	msg, ok := u.Message.(*tg.Message)
+	if !ok {
+		return nil
+	}
+	channelID, ok := trackedChannel(msg.PeerID)
+	if !ok {
+		return nil
+	}
+	s.queueMessage(channelID, msg)
+	return nil
+}
+
+func (s *MessageService) onEditChannelMessage(ctx context.Context, e tg.Entities, u *tg.UpdateEditChannelMessage) error {
+	msg, ok := u.Message.(*tg.Message)
+	if !ok {
+		return nil
+	}
+	channelID, ok := trackedChannel(msg.PeerID)
+	if !ok {
+		return nil
+	}
+	// Правки подписей альбома к посту не привязываются: их подхватит проход по истории.
+	post := postFromMessage(channelID, msg)
+	if post == nil || post.EditDate.IsZero() {
+		return nil
+	}
+	if _, err := s.repo.UpdateEditedMessage(post); err != nil {
+		log.Errorf("Error updating edited message %d: %v", post.MessageID, err)
+	}
+	return nil
+}
+
+func (s *MessageService) onDeleteChannelMessages(ctx context.Context, e tg.Entities, u *tg.UpdateDeleteChannelMessages) error {
+	if _, ok := config.Channels[u.ChannelID]; !ok {
+		return nil
+	}
+	ids := make([]int64, len(u.Messages))
+	for i, id := range u.Messages {
+		ids[i] = int64(id)
+	}
+	if err := s.repo.MarkMessagesDeleted(u.ChannelID, ids, time.Now().UTC()); err != nil {
+		log.Errorf("Error marking messages deleted in channel %d: %v", u.ChannelID, err)
+	}
+	return nil
+}
+
+// trackedChannel - id канала из config.Channels, которому принадлежит сообщение.
+func trackedChannel(peer tg.PeerClass) (int64, bool) {
+	channel, ok := peer.(*tg.PeerChannel)
+	if !ok {
+		return 0, false
+	}
+	_, tracked := config.Channels[channel.ChannelID]
+	return channel.ChannelID, tracked
+}
+
+func (s *MessageService) queueMessage(channelID int64, msg *tg.Message) {
+	s.pending.mu.Lock()
+	defer s.pending.mu.Unlock()
+
+	if s.pending.byChannel == nil {
+		s.pending.byChannel = make(map[int64][]*tg.Message)
+	}
+	s.pending.byChannel[channelID] = append(s.pending.byChannel[channelID], msg)
+	if s.pending.timer == nil {
+		s.pending.timer = time.AfterFunc(config.MessageUpdateFlushSeconds*time.Second, s.flushPending)
+	}
+}
+
+func (s *MessageService) flushPending() {
+	s.pending.mu.Lock()
+	batch := s.pending.byChannel
+	s.pending.byChannel = nil
+	s.pending.timer = nil
+	s.pending.mu.Unlock()
+
+	for channelID, messages := range batch {
+		s.saveUpdates(channelID, messages)
+	}
+}
+
+// saveUpdates сохраняет посты из потока обновлений, сдвигает курсор канала и
+// передаёт новые посты подпискам /watch.
+func (s *MessageService) saveUpdates(channelID int64, messages []*tg.Message) {
+	posts := buildPosts(channelID, messages)
+	saved, _, failed := s.savePosts(posts)
+
+	if !failed {
+		maxID := 0
+		for _, msg := range messages {
+			maxID = max(maxID, msg.ID)
+		}
+		if err := s.repo.SaveChannelCursor(channelID, maxID); err != nil {
+			log.Errorf("Error saving channel cursor: %v", err)
+		}
+	}
+
+	if len(saved) > 0 {
+		log.Infof("Saved %d messages from channel updates for channel %d", len(saved), channelID)
+	}
+	if s.watcher != nil && len(saved) > 0 {
+		s.watcher.Notify(channelID, saved)
+	}
+}
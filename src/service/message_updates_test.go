@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/gotd/td/tg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+type recordingWatcher struct {
+	channelID int64
+	messages  []*repository.Message
+}
+
+func (w *recordingWatcher) Notify(channelID int64, messages []*repository.Message) {
+	w.channelID = channelID
+	w.messages = append(w.messages, messages...)
+}
+
+func TestMessageService_SaveUpdatesGroupsAlbumAndMovesCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	watcher := &recordingWatcher{}
+	svc := NewMessageService(repo, nil, nil, watcher)
+
+	date := int(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC).Unix())
+	first := &tg.Message{ID: 901, Date: date, Message: "Кадры с места пожара"}
+	first.SetMedia(&tg.MessageMediaPhoto{})
+	first.SetGroupedID(777)
+	second := &tg.Message{ID: 902, Date: date}
+	second.SetMedia(&tg.MessageMediaPhoto{})
+	second.SetGroupedID(777)
+
+	repo.EXPECT().SaveMessage(gomock.Any()).DoAndReturn(func(m *repository.Message) (bool, error) {
+		assert.Equal(t, 901, m.MessageID)
+		assert.Equal(t, []int{902}, m.Meta.AlbumMessageIDs)
+		return true, nil
+	})
+	repo.EXPECT().SaveChannelCursor(int64(1754252633), 902).Return(nil)
+
+	// обновления приходят от старых постов к новым
+	svc.saveUpdates(1754252633, []*tg.Message{first, second})
+
+	assert.Equal(t, int64(1754252633), watcher.channelID)
+	require.Len(t, watcher.messages, 1)
+	assert.Equal(t, "Кадры с места пожара", watcher.messages[0].MessageText)
+}
+
+func TestMessageService_SaveUpdatesKeepsCursorOnFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewMessageService(repo, nil, nil, nil)
+
+	// курсор не сдвигается: пост сохранит следующий проход по истории
+	repo.EXPECT().SaveMessage(gomock.Any()).Return(false, assert.AnError)
+
+	svc.saveUpdates(1754252633, []*tg.Message{{ID: 903, Date: int(time.Now().Unix()), Message: "Сводка"}})
+}
+
+func TestMessageService_UpdateHandlersSkipUntrackedChannels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewMessageService(repo, nil, nil, nil)
+	ctx := context.Background()
+
+	edited := &tg.Message{ID: 805, Date: 1760864400, Message: "ЦБ снизил ставку", PeerID: &tg.PeerChannel{ChannelID: 1754252633}}
+	edited.SetEditDate(1760868000)
+	repo.EXPECT().UpdateEditedMessage(gomock.Any()).DoAndReturn(func(m *repository.Message) (bool, error) {
+		assert.Equal(t, 805, m.MessageID)
+		assert.Equal(t, time.Unix(1760868000, 0).UTC(), m.EditDate)
+		return true, nil
+	})
+	repo.EXPECT().MarkMessagesDeleted(int64(1754252633), []int64{806, 807}, gomock.Any()).Return(nil)
+
+	require.NoError(t, svc.onEditChannelMessage(ctx, tg.Entities{}, &tg.UpdateEditChannelMessage{Message: edited}))
+	require.NoError(t, svc.onDeleteChannelMessages(ctx, tg.Entities{}, &tg.UpdateDeleteChannelMessages{ChannelID: 1754252633, Messages: []int{806, 807}}))
+
+	// чужие каналы и группы не трогают базу
+	foreign := &tg.Message{ID: 1, Date: 1760864400, Message: "Чужой пост", PeerID: &tg.PeerChannel{ChannelID: 42}}
+	foreign.SetEditDate(1760868000)
+	require.NoError(t, svc.onEditChannelMessage(ctx, tg.Entities{}, &tg.UpdateEditChannelMessage{Message: foreign}))
+	require.NoError(t, svc.onNewChannelMessage(ctx, tg.Entities{}, &tg.UpdateNewChannelMessage{Message: foreign}))
+	require.NoError(t, svc.onDeleteChannelMessages(ctx, tg.Entities{}, &tg.UpdateDeleteChannelMessages{ChannelID: 42, Messages: []int{1}}))
+	assert.Nil(t, svc.pending.byChannel)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// Периоды дайджестов (period_summaries.period).
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+const periodArcMaxPoints = 8
+
+var (
+	periodDays           = map[string]int{PeriodWeek: 7, PeriodMonth: 30}
+	periodStorylineLimit = map[string]int{PeriodWeek: 10, PeriodMonth: 15}
+)
+
+// PeriodDigestService строит дайджест за неделю/месяц из дуг сюжетов, а не из сырых сообщений.
+type PeriodDigestService struct {
+	periodRepo repository.PeriodSummaryRepositoryInterface
+	mlRepo     repository.MLRepositoryInterface
+}
+
+func NewPeriodDigestService(
+	periodRepo repository.PeriodSummaryRepositoryInterface,
+	mlRepo repository.MLRepositoryInterface,
+) *PeriodDigestService {
+	return &PeriodDigestService{
+		periodRepo: periodRepo,
+		mlRepo:     mlRepo,
+	}
+}
+
+// Get возвращает дайджест канала за последние 7/30 полных UTC-дней до now.
+// Сохранённый дайджест переиспользуется, пока не изменился вход рендера: число
+// наблюдений не ловит пересчёт дня или правку сюжета, после которых наблюдений
+// столько же, а дельты и состояние уже другие.
+func (s *PeriodDigestService) Get(channelID int64, period string, now time.Time) (*repository.PeriodSummary, error) {
+	days, ok := periodDays[period]
+	if !ok {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+	end := truncateToDay(now)
+	start := end.AddDate(0, 0, -days)
+
+	observations, err := s.periodRepo.GetPeriodObservations(channelID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations for period: %w", err)
+	}
+
+	input := repository.PeriodDigestInput{
+		Period:     period,
+		From:       start.Format("2006-01-02"),
+		To:         end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Storylines: buildPeriodStorylines(observations, periodStorylineLimit[period]),
+	}
+	hash, err := periodInputHash(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := s.periodRepo.GetPeriodSummary(channelID, period, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get period summary: %w", err)
+	}
+	if cached != nil && cached.InputHash == hash {
+		return cached, nil
+	}
+
+	text, err := s.mlRepo.ForDay(channelID, end).RenderPeriodDigest(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render period digest: %w", err)
+	}
+
+	summary := &repository.PeriodSummary{
+		ChannelID:         channelID,
+		Period:            period,
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		Summary:           text,
+		ObservationsCount: len(observations),
+		InputHash:         hash,
+		CreatedAt:         time.Now().UTC(),
+	}
+	if err := s.periodRepo.SavePeriodSummary(summary); err != nil {
+		return nil, fmt.Errorf("failed to save period summary: %w", err)
+	}
+	return summary, nil
+}
+
+// periodInputHash - hex SHA-256 входа RenderPeriodDigest, ключ кэша period_summaries.
+func periodInputHash(in repository.PeriodDigestInput) (string, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash period digest input: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// buildPeriodStorylines группирует наблюдения по сюжетам, ранжирует сюжеты по
+// важности и объёму и оставляет limit самых значимых.
+func buildPeriodStorylines(observations []repository.PeriodObservation, limit int) []repository.PeriodStoryline {
+	var order []int64
+	byID := make(map[int64]*repository.PeriodStoryline)
+	arcs := make(map[int64][]repository.Observation)
+	for _, p := range observations {
+		ps, ok := byID[p.Storyline.ID]
+		if !ok {
+			ps = &repository.PeriodStoryline{
+				Title:    p.Storyline.Title,
+				State:    p.Storyline.State,
+				Category: p.Storyline.Category,
+				Status:   p.Storyline.Status,
+			}
+			byID[p.Storyline.ID] = ps
+			order = append(order, p.Storyline.ID)
+		}
+		ps.TotalMessages += p.Observation.MessageCount
+		if p.Observation.Importance > ps.MaxImportance {
+			ps.MaxImportance = p.Observation.Importance
+		}
+		arcs[p.Storyline.ID] = append(arcs[p.Storyline.ID], p.Observation)
+	}
+
+	result := make([]repository.PeriodStoryline, 0, len(order))
+	for _, id := range order {
+		ps := byID[id]
+		ps.Arc = compressArc(arcs[id], periodArcMaxPoints)
+		result = append(result, *ps)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].MaxImportance != result[j].MaxImportance {
+			return result[i].MaxImportance > result[j].MaxImportance
+		}
+		return result[i].TotalMessages > result[j].TotalMessages
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// compressArc оставляет в дуге первый и последний день и дни с содержательными
+// изменениями; если их всё равно больше maxPoints - начало и конец дуги.
+func compressArc(observations []repository.Observation, maxPoints int) []repository.ArcPoint {
+	var points []repository.ArcPoint
+	for i, o := range observations {
+		meaningful := o.DeltaSummary != "" || o.ChangeType == "new" || o.ChangeType == "escalation" || o.ChangeType == "revived"
+		if i != 0 && i != len(observations)-1 && !meaningful {
+			continue
+		}
+		points = append(points, repository.ArcPoint{
+			Date:         o.ObsDate.Format("2006-01-02"),
+			ChangeType:   o.ChangeType,
+			DeltaSummary: o.DeltaSummary,
+		})
+	}
+	if len(points) > maxPoints {
+		head := maxPoints / 2
+		points = append(points[:head:head], points[len(points)-(maxPoints-head):]...)
+	}
+	return points
+}
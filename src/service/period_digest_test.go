@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func periodObservation(id int64, title string, day time.Time, count, importance int, changeType, delta string) repository.PeriodObservation {
+	return repository.PeriodObservation{
+		Storyline: repository.Storyline{ID: id, Title: title, State: title + ": состояние", Status: "active"},
+		Observation: repository.Observation{
+			StorylineID: id, ObsDate: day, MessageCount: count, Importance: importance,
+			ChangeType: changeType, DeltaSummary: delta,
+		},
+	}
+}
+
+func TestPeriodDigestService_GetRendersAndSaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	periodRepo := mock_repository.NewMockPeriodSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	digestService := NewPeriodDigestService(periodRepo, mlRepo)
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -7)
+
+	observations := []repository.PeriodObservation{
+		periodObservation(1, "Паводок", start, 2, 2, "new", ""),
+		periodObservation(2, "Переговоры", start, 3, 3, "new", "начались"),
+		periodObservation(2, "Переговоры", start.AddDate(0, 0, 2), 9, 5, "escalation", "срыв встречи"),
+	}
+
+	periodRepo.EXPECT().GetPeriodObservations(int64(123), start, end).Return(observations, nil)
+	// сохранённый дайджест собран из другого входа - перегенерируем
+	periodRepo.EXPECT().GetPeriodSummary(int64(123), PeriodWeek, start, end).Return(&repository.PeriodSummary{ObservationsCount: 3, InputHash: "old"}, nil)
+	mlRepo.EXPECT().RenderPeriodDigest(gomock.Any()).DoAndReturn(func(in repository.PeriodDigestInput) (string, error) {
+		assert.Equal(t, "2026-10-12", in.From)
+		assert.Equal(t, "2026-10-18", in.To)
+		require.Len(t, in.Storylines, 2)
+		assert.Equal(t, "Переговоры", in.Storylines[0].Title) // важнее
+		assert.Equal(t, 12, in.Storylines[0].TotalMessages)
+		require.Len(t, in.Storylines[0].Arc, 2)
+		assert.Equal(t, "escalation", in.Storylines[0].Arc[1].ChangeType)
+		return "итоги недели", nil
+	})
+	periodRepo.EXPECT().SavePeriodSummary(gomock.Any()).DoAndReturn(func(s *repository.PeriodSummary) error {
+		assert.Equal(t, 3, s.ObservationsCount)
+		assert.Len(t, s.InputHash, 64)
+		assert.Equal(t, "итоги недели", s.Summary)
+		return nil
+	})
+
+	summary, err := digestService.Get(123, PeriodWeek, now)
+	require.NoError(t, err)
+	assert.Equal(t, "итоги недели", summary.Summary)
+}
+
+func TestPeriodDigestService_GetUsesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	periodRepo := mock_repository.NewMockPeriodSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	digestService := NewPeriodDigestService(periodRepo, mlRepo)
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	observations := []repository.PeriodObservation{
+		periodObservation(1, "Паводок", now, 2, 2, "new", ""),
+	}
+	var cached *repository.PeriodSummary
+
+	periodRepo.EXPECT().GetPeriodObservations(int64(123), gomock.Any(), gomock.Any()).Return(observations, nil).Times(2)
+	periodRepo.EXPECT().GetPeriodSummary(int64(123), PeriodMonth, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(int64, string, time.Time, time.Time) (*repository.PeriodSummary, error) { return cached, nil }).Times(2)
+	mlRepo.EXPECT().RenderPeriodDigest(gomock.Any()).Return("итоги месяца", nil)
+	periodRepo.EXPECT().SavePeriodSummary(gomock.Any()).DoAndReturn(func(s *repository.PeriodSummary) error {
+		cached = s
+		return nil
+	})
+
+	_, err := digestService.Get(123, PeriodMonth, now)
+	require.NoError(t, err)
+
+	// вход не изменился - дайджест берётся из кэша без LLM
+	summary, err := digestService.Get(123, PeriodMonth, now)
+	require.NoError(t, err)
+	assert.Same(t, cached, summary)
+}
+
+func TestPeriodDigestService_GetRerendersChangedDelta(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	periodRepo := mock_repository.NewMockPeriodSummaryRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	digestService := NewPeriodDigestService(periodRepo, mlRepo)
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	before := []repository.PeriodObservation{periodObservation(1, "Паводок", now, 2, 2, "new", "")}
+	hash, err := periodInputHash(repository.PeriodDigestInput{
+		Period: PeriodWeek, From: "2026-10-12", To: "2026-10-18",
+		Storylines: buildPeriodStorylines(before, periodStorylineLimit[PeriodWeek]),
+	})
+	require.NoError(t, err)
+
+	// день пересчитан: наблюдений столько же, но дельта другая
+	after := []repository.PeriodObservation{periodObservation(1, "Паводок", now, 2, 2, "new", "вода спала")}
+	periodRepo.EXPECT().GetPeriodObservations(int64(123), gomock.Any(), gomock.Any()).Return(after, nil)
+	periodRepo.EXPECT().GetPeriodSummary(int64(123), PeriodWeek, gomock.Any(), gomock.Any()).Return(
+		&repository.PeriodSummary{Summary: "устарело", ObservationsCount: 1, InputHash: hash}, nil)
+	mlRepo.EXPECT().RenderPeriodDigest(gomock.Any()).Return("вода спала", nil)
+	periodRepo.EXPECT().SavePeriodSummary(gomock.Any()).Return(nil)
+
+	summary, err := digestService.Get(123, PeriodWeek, now)
+	require.NoError(t, err)
+	assert.Equal(t, "вода спала", summary.Summary)
+}
+
+func TestPeriodDigestService_GetUnknownPeriod(t *testing.T) {
+	digestService := NewPeriodDigestService(nil, nil)
+	_, err := digestService.Get(123, "year", time.Now())
+	assert.Error(t, err)
+}
+
+func TestCompressArc(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var observations []repository.Observation
+	for i := 0; i < 12; i++ {
+		observations = append(observations, repository.Observation{ObsDate: day.AddDate(0, 0, i), ChangeType: "ongoing", DeltaSummary: "новое"})
+	}
+	observations[3].DeltaSummary = "" // без изменений, выбрасывается
+
+	points := compressArc(observations, 8)
+	require.Len(t, points, 8)
+	assert.Equal(t, "2026-10-01", points[0].Date)
+	assert.Equal(t, "2026-10-12", points[7].Date)
+	for _, p := range points {
+		assert.NotEqual(t, "2026-10-04", p.Date)
+	}
+}
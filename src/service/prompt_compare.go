@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// PromptVariant - дайджест дня, собранный одним набором промптов.
+type PromptVariant struct {
+	PromptVersion string
+	Digest        string
+}
+
+// PromptComparison - один и тот же день канала, пересобранный двумя наборами промптов.
+type PromptComparison struct {
+	ChannelID int64
+	Date      time.Time
+	A, B      PromptVariant
+}
+
+// PromptCompareService пересобирает прошедший день канала разными версиями промптов,
+// не меняя сюжеты: запись идёт в dryRunStorylineRepository.
+type PromptCompareService struct {
+	summaryRepo   repository.SummaryRepositoryInterface
+	storylineRepo repository.StorylineRepositoryInterface
+	mlRepo        repository.MLRepositoryInterface
+}
+
+func NewPromptCompareService(
+	summaryRepo repository.SummaryRepositoryInterface,
+	storylineRepo repository.StorylineRepositoryInterface,
+	mlRepo repository.MLRepositoryInterface,
+) *PromptCompareService {
+	return &PromptCompareService{
+		summaryRepo:   summaryRepo,
+		storylineRepo: storylineRepo,
+		mlRepo:        mlRepo,
+	}
+}
+
+// Compare строит дайджест дня date с версиями промптов a и b (имя -> версия;
+// не указанные промпты - текущие). Сюжеты берутся в нынешнем состоянии без
+// созданных в date и позже, поэтому это сравнение промптов, а не точный реплей дня.
+func (s *PromptCompareService) Compare(channelID int64, date time.Time, a, b map[string]string) (*PromptComparison, error) {
+	day := truncateToDay(date)
+	msgs, err := s.summaryRepo.GetMessagesForDateWithIDs(channelID, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages for %s: %w", day.Format("2006-01-02"), err)
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no messages for channel %d on %s", channelID, day.Format("2006-01-02"))
+	}
+
+	result := &PromptComparison{ChannelID: channelID, Date: day}
+	if result.A, err = s.runVariant(channelID, day, msgs, a); err != nil {
+		return nil, fmt.Errorf("variant A: %w", err)
+	}
+	if result.B, err = s.runVariant(channelID, day, msgs, b); err != nil {
+		return nil, fmt.Errorf("variant B: %w", err)
+	}
+	return result, nil
+}
+
+func (s *PromptCompareService) runVariant(channelID int64, day time.Time, msgs []repository.MessageInput, versions map[string]string) (PromptVariant, error) {
+	mlRepo, err := s.mlRepo.WithPromptVersions(versions)
+	if err != nil {
+		return PromptVariant{}, err
+	}
+	processor := NewStorylineProcessor(s.summaryRepo, newDryRunStorylineRepository(s.storylineRepo, day), mlRepo)
+	digest, err := processor.ProcessDay(channelID, day, msgs)
+	if err != nil {
+		return PromptVariant{}, err
+	}
+	return PromptVariant{PromptVersion: mlRepo.PromptVersion(), Digest: digest}, nil
+}
+
+// dryRunStorylineRepository читает сюжеты из настоящего репозитория и отбрасывает запись.
+// Сюжеты, впервые появившиеся в день пересборки или позже, в поиске не видны.
+type dryRunStorylineRepository struct {
+	repository.StorylineRepositoryInterface
+	day    time.Time
+	nextID int64
+}
+
+func newDryRunStorylineRepository(repo repository.StorylineRepositoryInterface, day time.Time) *dryRunStorylineRepository {
+	return &dryRunStorylineRepository{StorylineRepositoryInterface: repo, day: day}
+}
+
+func (r *dryRunStorylineRepository) SearchNearest(channelID int64, query []float32, k int, closedSince time.Time) ([]repository.ScoredStoryline, error) {
+	scored, err := r.StorylineRepositoryInterface.SearchNearest(channelID, query, k, closedSince)
+	if err != nil {
+		return nil, err
+	}
+	result := scored[:0]
+	for _, sc := range scored {
+		if sc.Storyline.FirstSeen.Before(r.day) {
+			result = append(result, sc)
+		}
+	}
+	return result, nil
+}
+
+func (r *dryRunStorylineRepository) CreateStoryline(s *repository.Storyline) (int64, error) {
+	r.nextID--
+	return r.nextID, nil
+}
+
+func (r *dryRunStorylineRepository) UpdateStoryline(s *repository.Storyline) error { return nil }
+
+func (r *dryRunStorylineRepository) SaveObservation(o *repository.Observation) error { return nil }
+
+func (r *dryRunStorylineRepository) MarkDormant(channelID int64, lastSeenBefore time.Time) error {
+	return nil
+}
+
+func (r *dryRunStorylineRepository) MarkClosed(channelID int64, lastSeenBefore time.Time) error {
+	return nil
+}
+
+func (r *dryRunStorylineRepository) DeleteObservationsForDate(channelID int64, date time.Time) error {
+	return nil
+}
+
+func (r *dryRunStorylineRepository) ResetChannel(channelID int64) error { return nil }
+
+func (r *dryRunStorylineRepository) MergeStorylines(target *repository.Storyline, sourceID int64) error {
+	return nil
+}
+
+func (r *dryRunStorylineRepository) SplitStoryline(source *repository.Storyline, dates []time.Time, s *repository.Storyline) (int64, error) {
+	r.nextID--
+	return r.nextID, nil
+}
+
+func (r *dryRunStorylineRepository) RenameStoryline(id int64, title string, embedding []float32) error {
+	return nil
+}
+
+func (r *dryRunStorylineRepository) UpdateCategory(id int64, category string) error { return nil }
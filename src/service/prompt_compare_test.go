@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPromptCompare_RunsBothVariantsWithoutWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	// записи в storylineRepo не ожидаются: любой вызов кроме SearchNearest уронит тест
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	compareService := NewPromptCompareService(summaryRepo, storylineRepo, mlRepo)
+
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	msgs := []repository.MessageInput{{MessageID: 1, Text: "Произошло событие"}}
+	embedding := make([]float32, 256)
+	// сюжет создан в пересобираемый день оригинальным прогоном - в сравнении его быть не должно
+	sameDay := repository.Storyline{ID: 7, ChannelID: 123, Title: "Событие", Status: "active", FirstSeen: day, LastSeen: day}
+
+	summaryRepo.EXPECT().GetMessagesForDateWithIDs(int64(123), day).Return(msgs, nil)
+
+	variants := map[string]*mock_repository.MockMLRepositoryInterface{
+		"v1": mock_repository.NewMockMLRepositoryInterface(ctrl),
+		"v2": mock_repository.NewMockMLRepositoryInterface(ctrl),
+	}
+	for version, variant := range variants {
+		mlRepo.EXPECT().WithPromptVersions(map[string]string{repository.PromptDelta: version}).Return(variant, nil)
+		variant.EXPECT().ForDay(int64(123), day).Return(variant)
+		variant.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@" + version + ",render@v1").AnyTimes()
+		variant.EXPECT().ExtractTopics(msgs).Return([]repository.CandidateTopic{
+			{Title: "Событие", Summary: "Описание", Importance: 3, SourceMessageNumbers: []int{1}},
+		}, nil)
+		variant.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{embedding}, nil)
+		variant.EXPECT().WriteDelta(gomock.Any()).Return("состояние "+version, "дельта "+version, nil)
+		variant.EXPECT().EmbedDocuments(gomock.Any()).Return([][]float32{embedding}, nil)
+		variant.EXPECT().RenderDigest(gomock.Any()).DoAndReturn(func(groups repository.DigestGroups) (string, error) {
+			require.Len(t, groups.New, 1)
+			assert.Equal(t, "Событие", groups.New[0].Title)
+			return "дайджест " + version, nil
+		})
+	}
+	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return([]repository.ScoredStoryline{
+		{Storyline: sameDay, Similarity: 0.95},
+	}, nil).Times(2)
+
+	result, err := compareService.Compare(123, day.Add(15*time.Hour),
+		map[string]string{repository.PromptDelta: "v1"},
+		map[string]string{repository.PromptDelta: "v2"})
+
+	require.NoError(t, err)
+	assert.Equal(t, day, result.Date)
+	assert.Equal(t, "extract@v1,match@v1,delta@v1,render@v1", result.A.PromptVersion)
+	assert.Equal(t, "дайджест v1", result.A.Digest)
+	assert.Equal(t, "extract@v1,match@v1,delta@v2,render@v1", result.B.PromptVersion)
+	assert.Equal(t, "дайджест v2", result.B.Digest)
+}
+
+func TestPromptCompare_NoMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	compareService := NewPromptCompareService(summaryRepo,
+		mock_repository.NewMockStorylineRepositoryInterface(ctrl),
+		mock_repository.NewMockMLRepositoryInterface(ctrl))
+
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	summaryRepo.EXPECT().GetMessagesForDateWithIDs(int64(123), day).Return(nil, nil)
+
+	_, err := compareService.Compare(123, day, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// ErrEmptySearchQuery - в /search нет слов для поиска, только фильтры или ничего.
+var ErrEmptySearchQuery = errors.New("empty search query")
+
+// searchDateLayouts - форматы дат в фильтрах с:/по:.
+var searchDateLayouts = []string{"02.01.2006", "2006-01-02"}
+
+// SearchPage - одна страница результатов /search.
+type SearchPage struct {
+	Query   repository.SearchQuery
+	Page    int // с нуля
+	Pages   int
+	Total   int
+	Results []repository.SearchResult
+}
+
+// SearchService ищет по архиву сообщений каналов (полнотекстовый индекс messages.search_vector).
+type SearchService struct {
+	searchRepo repository.SearchRepositoryInterface
+}
+
+func NewSearchService(searchRepo repository.SearchRepositoryInterface) *SearchService {
+	return &SearchService{searchRepo: searchRepo}
+}
+
+// Search возвращает страницу page (с нуля) результатов запроса q.
+func (s *SearchService) Search(q repository.SearchQuery, page int) (*SearchPage, error) {
+	q.Limit = config.SearchPageSize
+	q.Offset = page * config.SearchPageSize
+	results, total, err := s.searchRepo.SearchMessages(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	return &SearchPage{
+		Query:   q,
+		Page:    page,
+		Pages:   (total + config.SearchPageSize - 1) / config.SearchPageSize,
+		Total:   total,
+		Results: results,
+	}, nil
+}
+
+// ParseSearchQuery разбирает аргумент /search: слова запроса и фильтры
+// канал:<username>, с:<дата>, по:<дата> (включительно; ДД.ММ.ГГГГ или ГГГГ-ММ-ДД).
+func ParseSearchQuery(payload string) (repository.SearchQuery, error) {
+	var (
+		q     repository.SearchQuery
+		words []string
+	)
+	for _, field := range strings.Fields(payload) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			words = append(words, field)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "канал", "channel":
+			channelID, err := channelByName(value)
+			if err != nil {
+				return q, err
+			}
+			q.ChannelID = channelID
+		case "с", "from":
+			from, err := parseSearchDate(value)
+			if err != nil {
+				return q, err
+			}
+			q.From = from
+		case "по", "to":
+			to, err := parseSearchDate(value)
+			if err != nil {
+				return q, err
+			}
+			q.To = to.AddDate(0, 0, 1)
+		default:
+			words = append(words, field)
+		}
+	}
+
+	q.Text = strings.Join(words, " ")
+	if q.Text == "" {
+		return q, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(q.Text) > config.SearchMaxQueryLen {
+		return q, fmt.Errorf("запрос длиннее %d символов", config.SearchMaxQueryLen)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return q, errors.New("дата «с» позже даты «по»")
+	}
+	return q, nil
+}
+
+func parseSearchDate(value string) (time.Time, error) {
+	for _, layout := range searchDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("не удалось разобрать дату %q, нужен формат ДД.ММ.ГГГГ", value)
+}
+
+// Format - страница результатов простым текстом: фрагменты сообщений могут
+// содержать символы разметки, поэтому Markdown не используется.
+func (p *SearchPage) Format() string {
+	if p.Total == 0 {
+		return fmt.Sprintf("По запросу «%s» ничего не найдено.", p.Query.Text)
+	}
+	if len(p.Results) == 0 {
+		return fmt.Sprintf("По запросу «%s» больше результатов нет.", p.Query.Text)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Найдено: %d по запросу «%s», страница %d из %d\n", p.Total, p.Query.Text, p.Page+1, p.Pages))
+	for i, r := range p.Results {
+		b.WriteString(fmt.Sprintf("\n%d. %s UTC, %s", p.Page*config.SearchPageSize+i+1, r.MessageDate.UTC().Format("02.01.2006 15:04"), ChannelLabel(r.ChannelID)))
+		b.WriteString("\n" + strings.Join(strings.Fields(r.Headline), " ") + "\n")
+		if url := PostURL(r.ChannelID, r.MessageID, r.URL); url != "" {
+			b.WriteString(url + "\n")
+		}
+	}
+	return b.String()
+}
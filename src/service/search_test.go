@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`"ключевая ставка" канал:@topor_live с:01.10.2026 по:2026-10-15 -прогноз`)
+	require.NoError(t, err)
+	assert.Equal(t, `"ключевая ставка" -прогноз`, q.Text)
+	assert.Equal(t, int64(1754252633), q.ChannelID)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), q.From)
+	assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), q.To, "по: включает весь день")
+
+	q, err = ParseSearchQuery("время 12:30")
+	require.NoError(t, err)
+	assert.Equal(t, "время 12:30", q.Text, "двоеточие в обычном слове - не фильтр")
+}
+
+func TestParseSearchQuery_FeedFilter(t *testing.T) {
+	useTestChannels(t, config.Channels, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	q, err := ParseSearchQuery("набережная канал:city_news")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), q.ChannelID)
+}
+
+func TestParseSearchQuery_Errors(t *testing.T) {
+	_, err := ParseSearchQuery("")
+	assert.True(t, errors.Is(err, ErrEmptySearchQuery))
+
+	_, err = ParseSearchQuery("канал:topor_live")
+	assert.True(t, errors.Is(err, ErrEmptySearchQuery), "одни фильтры без слов")
+
+	_, err = ParseSearchQuery("нефть канал:unknown_channel")
+	assert.ErrorContains(t, err, "неизвестный канал")
+
+	_, err = ParseSearchQuery("нефть с:31.02.2026")
+	assert.ErrorContains(t, err, "не удалось разобрать дату")
+
+	_, err = ParseSearchQuery("нефть с:10.10.2026 по:01.10.2026")
+	assert.Error(t, err)
+}
+
+func TestSearchService_Search(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, map[int64]string{1754252633: "topor_live"}, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	mockSearchRepo := mock_repository.NewMockSearchRepositoryInterface(ctrl)
+	svc := NewSearchService(mockSearchRepo)
+
+	msgDate := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	mockSearchRepo.EXPECT().SearchMessages(gomock.Any()).DoAndReturn(func(q repository.SearchQuery) ([]repository.SearchResult, int, error) {
+		assert.Equal(t, config.SearchPageSize, q.Limit)
+		assert.Equal(t, config.SearchPageSize, q.Offset)
+		return []repository.SearchResult{
+			{ChannelID: 1754252633, MessageID: 9001, MessageDate: msgDate, Headline: "ЦБ сохранил\n«ставку»"},
+			{ChannelID: 42, MessageID: 7, MessageDate: msgDate, Headline: "«ставка» без канала"},
+			{ChannelID: -1, MessageID: 3001, MessageDate: msgDate, Headline: "«ставка» в ленте", URL: "https://news.example.ru/rate"},
+		}, config.SearchPageSize + 3, nil
+	})
+
+	page, err := svc.Search(repository.SearchQuery{Text: "ставка"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Pages)
+
+	text := page.Format()
+	assert.Contains(t, text, "Найдено: 8 по запросу «ставка», страница 2 из 2")
+	assert.Contains(t, text, "6. 18.10.2026 09:30 UTC, @topor_live\nЦБ сохранил «ставку»\nhttps://t.me/topor_live/9001")
+	assert.Contains(t, text, "7. 18.10.2026 09:30 UTC, канал 42\n«ставка» без канала\n")
+	assert.Contains(t, text, "8. 18.10.2026 09:30 UTC, city_news\n«ставка» в ленте\nhttps://news.example.ru/rate\n")
+}
+
+func TestSearchPage_FormatEmpty(t *testing.T) {
+	page := &SearchPage{Query: repository.SearchQuery{Text: "ничего"}}
+	assert.Equal(t, "По запросу «ничего» ничего не найдено.", page.Format())
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Source - источник постов для messages: каналы Telegram или RSS/Atom-ленты.
+// MessageService проходит по каналам каждого источника, сохраняет посты и
+// двигает курсор канала (channel_cursors), дальше посты попадают в
+// ProcessDay так же, как посты Telegram.
+type Source interface {
+	Name() string
+	// Channels - id каналов источника, ключи config.ChannelNames().
+	Channels() []int64
+	// CursorFallback - как восстановить курсор канала, которого ещё нет в
+	// channel_cursors, по уже сохранённым постам.
+	CursorFallback() repository.CursorFallback
+	// Run держит подключение к источнику на время прохода по его каналам.
+	Run(ctx context.Context, fn func(ctx context.Context) error) error
+	// Fetch - посты канала после курсора и новый курсор.
+	Fetch(ctx context.Context, req SourceRequest) (*SourceBatch, error)
+}
+
+// SourceRequest - что уже известно о канале перед загрузкой.
+type SourceRequest struct {
+	ChannelID int64
+	Cursor    int     // из channel_cursors, смысл значения задаёт источник
+	Stored    []int64 // message_id сохранённых постов окна config.MessageSyncLookbackHours
+	Now       time.Time
+}
+
+// SourceBatch - результат Source.Fetch.
+type SourceBatch struct {
+	Posts []*repository.Message
+	// SeenIDs - message_id всех постов, которые источник ещё отдаёт: сохранённые
+	// посты окна синхронизации не из этого списка помечаются удалёнными.
+	// nil - источник удалений не показывает.
+	SeenIDs map[int64]bool
+	Cursor  int // сохраняется, только если все посты сохранены
+}
+
+// syncSources - проход загрузки по всем каналам всех источников. Ошибка одного
+// канала не останавливает остальные: проход неудачен, только если не
+// синхронизировался ни один канал.
+func (s *MessageService) syncSources(ctx context.Context) error {
+	log.Info("Fetching messages")
+
+	var synced int
+	var lastErr error
+	for _, source := range s.sources {
+		channels := source.Channels()
+		if len(channels) == 0 {
+			continue
+		}
+		err := source.Run(ctx, func(ctx context.Context) error {
+			for _, channelID := range channels {
+				if err := s.syncChannel(ctx, source, channelID); err != nil {
+					log.Errorf("Error syncing %s channel %d: %v", source.Name(), channelID, err)
+					lastErr = err
+					continue
+				}
+				synced++
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("Error running %s source: %v", source.Name(), err)
+			lastErr = err
+		}
+	}
+
+	if synced == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// syncChannel сохраняет посты после курсора канала и синхронизирует правки,
+// удаления и метрики постов окна config.MessageSyncLookbackHours.
+func (s *MessageService) syncChannel(ctx context.Context, source Source, channelID int64) error {
+	channelName := config.ChannelNames()[channelID]
+	log.Infof("Fetching messages for channel: %s", channelName)
+
+	cursor, err := s.repo.GetChannelCursor(channelID, source.CursorFallback())
+	if err != nil {
+		log.Errorf("Error getting channel cursor: %v", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	stored, err := s.repo.GetMessageIDsSince(channelID, now.Add(-config.MessageSyncLookbackHours*time.Hour))
+	if err != nil {
+		log.Errorf("Error getting stored message ids: %v", err)
+		return err
+	}
+
+	batch, err := source.Fetch(ctx, SourceRequest{ChannelID: channelID, Cursor: cursor, Stored: stored, Now: now})
+	if err != nil {
+		log.Errorf("Error getting channel messages: %v", err)
+		return err
+	}
+
+	log.Infof("Fetched %d posts after cursor %d for channel %s", len(batch.Posts), cursor, channelName)
+
+	saved, edited, failed := s.savePosts(batch.Posts)
+
+	// Просмотры и реакции растут после публикации, поэтому метрики
+	// перезаписываются каждый проход, пока пост в окне синхронизации.
+	if err := s.repo.UpdateMessageMetrics(channelID, batch.Posts); err != nil {
+		log.Errorf("Error updating message metrics: %v", err)
+	}
+
+	deleted, err := s.syncDeletions(channelID, stored, batch.SeenIDs, now)
+	if err != nil {
+		log.Errorf("Error syncing deleted messages: %v", err)
+	}
+	if edited > 0 || deleted > 0 {
+		log.Infof("Channel %s: %d edited and %d deleted messages synced", channelName, edited, deleted)
+	}
+
+	// Курсор не сдвигается, если что-то не сохранилось: следующий проход
+	// повторит загрузку, повторная вставка ничего не испортит.
+	if !failed && batch.Cursor > cursor {
+		if err := s.repo.SaveChannelCursor(channelID, batch.Cursor); err != nil {
+			log.Errorf("Error saving channel cursor: %v", err)
+		}
+	}
+
+	// Подписки проверяются только на действительно новых сообщениях.
+	if s.watcher != nil && len(saved) > 0 {
+		s.watcher.Notify(channelID, saved)
+	}
+	return nil
+}
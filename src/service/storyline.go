@@ -1,7 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/Ra1ze505/goNewsBot/src/config"
@@ -43,21 +46,42 @@ type digestEntry struct {
 	category     string
 	importance   int
 	changeType   string
+	sourceIDs    []int64
 }
 
 // ProcessDay выполняет стадии A–F для одного (channelID, date) и возвращает текст дайджеста.
 // Запись в summaries остаётся вызывающему.
 func (p *StorylineProcessor) ProcessDay(channelID int64, date time.Time, msgs []repository.MessageInput) (string, error) {
+	digest, _, err := p.ProcessDayWithGroups(channelID, date, msgs)
+	return digest, err
+}
+
+// ProcessDayWithGroups - ProcessDay, который возвращает и группы сюжетов рендера,
+// чтобы сохранить их в summaries для персональных дайджестов.
+func (p *StorylineProcessor) ProcessDayWithGroups(channelID int64, date time.Time, msgs []repository.MessageInput) (string, repository.DigestGroups, error) {
 	day := truncateToDay(date)
 
+	// Вызовы ML этого прогона учитываются в ml_calls за (channelID, day).
+	scoped := *p
+	scoped.mlRepo = p.mlRepo.ForDay(channelID, day)
+	return scoped.processDay(channelID, day, msgs)
+}
+
+// PromptVersion - подпись промптов, которыми ProcessDay строит дайджест.
+func (p *StorylineProcessor) PromptVersion() string {
+	return p.mlRepo.PromptVersion()
+}
+
+func (p *StorylineProcessor) processDay(channelID int64, day time.Time, msgs []repository.MessageInput) (string, repository.DigestGroups, error) {
 	// A: извлечение топиков.
 	candidates, err := p.mlRepo.ExtractTopics(msgs)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract topics: %w", err)
+		return "", repository.DigestGroups{}, fmt.Errorf("failed to extract topics: %w", err)
 	}
 	if len(candidates) == 0 {
 		log.Infof("No topics extracted for channel %d on %s", channelID, day.Format("2006-01-02"))
-		return p.mlRepo.RenderDigest(repository.DigestGroups{})
+		digest, err := p.mlRepo.RenderDigest(repository.DigestGroups{})
+		return digest, repository.DigestGroups{}, err
 	}
 
 	// Резолв позиционных номеров в реальные message_id и индексы текста.
@@ -83,10 +107,10 @@ func (p *StorylineProcessor) ProcessDay(channelID int64, date time.Time, msgs []
 	// B: эмбеддинги кандидатов (query-модель).
 	qvecs, err := p.mlRepo.EmbedQueries(queryTexts)
 	if err != nil {
-		return "", fmt.Errorf("failed to embed candidate topics: %w", err)
+		return "", repository.DigestGroups{}, fmt.Errorf("failed to embed candidate topics: %w", err)
 	}
 	if len(qvecs) != len(candidates) {
-		return "", fmt.Errorf("embedding count mismatch: got %d for %d candidates", len(qvecs), len(candidates))
+		return "", repository.DigestGroups{}, fmt.Errorf("embedding count mismatch: got %d for %d candidates", len(qvecs), len(candidates))
 	}
 
 	// C: матчинг и агрегация по сюжетам.
@@ -95,7 +119,7 @@ func (p *StorylineProcessor) ProcessDay(channelID int64, date time.Time, msgs []
 	for i := range candidates {
 		matched, err := p.matchCandidate(channelID, day, candidates[i], qvecs[i])
 		if err != nil {
-			return "", err
+			return "", repository.DigestGroups{}, err
 		}
 		if matched == nil {
 			newAgg = append(newAgg, &aggregation{candidates: []repository.CandidateTopic{candidates[i]}})
@@ -114,39 +138,53 @@ func (p *StorylineProcessor) ProcessDay(channelID int64, date time.Time, msgs []
 	for _, agg := range existingAgg {
 		entry, err := p.processStoryline(channelID, day, agg, textByID)
 		if err != nil {
-			return "", err
+			return "", repository.DigestGroups{}, err
 		}
 		entries = append(entries, entry)
 	}
 	for _, agg := range newAgg {
 		entry, err := p.processStoryline(channelID, day, agg, textByID)
 		if err != nil {
-			return "", err
+			return "", repository.DigestGroups{}, err
 		}
 		entries = append(entries, entry)
 	}
 
 	// Жизненный цикл сюжетов по давности last_seen.
 	if err := p.storylineRepo.MarkDormant(channelID, day.AddDate(0, 0, -config.DormantAfterDays)); err != nil {
-		return "", fmt.Errorf("failed to mark dormant storylines: %w", err)
+		return "", repository.DigestGroups{}, fmt.Errorf("failed to mark dormant storylines: %w", err)
 	}
 	if err := p.storylineRepo.MarkClosed(channelID, day.AddDate(0, 0, -config.ClosedAfterDays)); err != nil {
-		return "", fmt.Errorf("failed to mark closed storylines: %w", err)
+		return "", repository.DigestGroups{}, fmt.Errorf("failed to mark closed storylines: %w", err)
 	}
 
-	// F: рендер сгруппированного дайджеста.
-	return p.mlRepo.RenderDigest(buildDigestGroups(entries))
+	// F: рендер сгруппированного дайджеста и ссылки на первоисточники.
+	groups := withSourceURLs(buildDigestGroups(entries), msgs)
+	digest, err := p.mlRepo.RenderDigest(groups)
+	if err != nil {
+		return "", repository.DigestGroups{}, err
+	}
+	return linkSources(digest, channelID, groups), groups, nil
 }
 
 // matchCandidate возвращает существующий сюжет для привязки или nil для нового.
 func (p *StorylineProcessor) matchCandidate(channelID int64, day time.Time, cand repository.CandidateTopic, qvec []float32) (*repository.Storyline, error) {
-	scored, err := p.storylineRepo.SearchNearest(channelID, qvec, config.MatchTopK)
+	var closedSince time.Time
+	if config.RevivalClosedWithinDays > 0 {
+		closedSince = day.AddDate(0, 0, -config.RevivalClosedWithinDays)
+	}
+	// Кандидаты берутся с запасом и обрезаются до MatchTopK уже после штрафа.
+	scored, err := p.storylineRepo.SearchNearest(channelID, qvec, config.MatchTopK+config.MatchRevivalMargin, closedSince)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search nearest storylines: %w", err)
 	}
 	if len(scored) == 0 {
 		return nil, nil
 	}
+	scored = applyRevivalPenalty(scored)
+	if len(scored) > config.MatchTopK {
+		scored = scored[:config.MatchTopK]
+	}
 
 	best := scored[0]
 	if best.Similarity >= config.MatchSimHigh {
@@ -177,6 +215,14 @@ func (p *StorylineProcessor) matchCandidate(channelID int64, day time.Time, cand
 	}
 
 	matchedID, isNew, err := p.mlRepo.ConfirmMatch(cand, briefs)
+	if errors.Is(err, repository.ErrMLBudgetExceeded) {
+		// Бюджет исчерпан: решаем по середине серой зоны без LLM.
+		if best.Similarity >= config.MatchSimBudget {
+			s := best.Storyline
+			return &s, nil
+		}
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to confirm match: %w", err)
 	}
@@ -235,6 +281,7 @@ func (p *StorylineProcessor) processStoryline(channelID int64, day time.Time, ag
 	currentState := ""
 	var stats repository.StorylineStats
 	changeType := "new"
+	gapDays := 0
 	if !isNew {
 		title = agg.existing.Title
 		currentState = agg.existing.State
@@ -244,6 +291,10 @@ func (p *StorylineProcessor) processStoryline(channelID int64, day time.Time, ag
 			return digestEntry{}, fmt.Errorf("failed to get stats for storyline %d: %w", agg.existing.ID, err)
 		}
 		changeType = classifyChangeType(stats, todayCount, todayImportance)
+		if isRevival(agg.existing.Status) {
+			changeType = "revived"
+			gapDays = int(day.Sub(truncateToDay(agg.existing.LastSeen)).Hours() / 24)
+		}
 		if category == "" {
 			category = agg.existing.Category
 		}
@@ -259,6 +310,7 @@ func (p *StorylineProcessor) processStoryline(channelID int64, day time.Time, ag
 		MedianCount:      stats.MedianCount,
 		MedianImportance: stats.MedianImportance,
 		ChangeType:       changeType,
+		GapDays:          gapDays,
 		TodayMessages:    todayMessages,
 	})
 	if err != nil {
@@ -317,6 +369,7 @@ func (p *StorylineProcessor) processStoryline(channelID int64, day time.Time, ag
 		ChangeType:       changeType,
 		DeltaSummary:     deltaSummary,
 		SourceMessageIDs: sourceIDs,
+		PromptVersion:    p.mlRepo.PromptVersion(),
 	}); err != nil {
 		return digestEntry{}, fmt.Errorf("failed to save observation for storyline %d: %w", storylineID, err)
 	}
@@ -328,6 +381,7 @@ func (p *StorylineProcessor) processStoryline(channelID int64, day time.Time, ag
 		category:     category,
 		importance:   todayImportance,
 		changeType:   changeType,
+		sourceIDs:    sourceIDs,
 	}, nil
 }
 
@@ -355,11 +409,34 @@ func classifyChangeType(stats repository.StorylineStats, todayCount, todayImport
 	return "ongoing"
 }
 
+// applyRevivalPenalty штрафует similarity dormant/closed сюжетов и пересортировывает
+// кандидатов, чтобы при близких оценках побеждал активный сюжет.
+func applyRevivalPenalty(scored []repository.ScoredStoryline) []repository.ScoredStoryline {
+	out := make([]repository.ScoredStoryline, len(scored))
+	copy(out, scored)
+	for i := range out {
+		switch out[i].Storyline.Status {
+		case "dormant":
+			out[i].Similarity -= config.RevivalDormantPenalty
+		case "closed":
+			out[i].Similarity -= config.RevivalClosedPenalty
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	return out
+}
+
+// isRevival - сюжет возвращается в новости из dormant/closed.
+func isRevival(status string) bool {
+	return status == "dormant" || status == "closed"
+}
+
 // buildDigestGroups раскладывает обработанные сюжеты по группам рендера.
 func buildDigestGroups(entries []digestEntry) repository.DigestGroups {
 	var groups repository.DigestGroups
 	noiseSeen := make(map[string]struct{})
-	for _, e := range entries {
+	refs := 0
+	withRef := func(e digestEntry) repository.DigestItem {
 		item := repository.DigestItem{
 			Title:        e.title,
 			DeltaSummary: e.deltaSummary,
@@ -367,14 +444,25 @@ func buildDigestGroups(entries []digestEntry) repository.DigestGroups {
 			Category:     e.category,
 			Importance:   e.importance,
 		}
+		// Ссылка ведёт на самое раннее сообщение сюжета за день.
+		if len(e.sourceIDs) > 0 {
+			refs++
+			item.Ref = fmt.Sprintf("S%d", refs)
+			item.SourceMessageID = slices.Min(e.sourceIDs)
+		}
+		return item
+	}
+	for _, e := range entries {
 		switch e.changeType {
 		case "new":
-			groups.New = append(groups.New, item)
+			groups.New = append(groups.New, withRef(e))
 		case "escalation":
-			groups.Escalation = append(groups.Escalation, item)
+			groups.Escalation = append(groups.Escalation, withRef(e))
+		case "revived":
+			groups.Revived = append(groups.Revived, withRef(e))
 		case "ongoing", "deescalation":
 			if e.deltaSummary != "" {
-				groups.Ongoing = append(groups.Ongoing, item)
+				groups.Ongoing = append(groups.Ongoing, withRef(e))
 			}
 		case "recurring_noise":
 			label := e.category
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// StorylineEditor - ручные правки сюжетов из админки: исправляет ошибки матчинга
+// без ResetChannel (слияние дублей, разделение склеенных сюжетов, переименование, рубрика).
+type StorylineEditor struct {
+	storylineRepo repository.StorylineRepositoryInterface
+	mlRepo        repository.MLRepositoryInterface
+}
+
+func NewStorylineEditor(
+	storylineRepo repository.StorylineRepositoryInterface,
+	mlRepo repository.MLRepositoryInterface,
+) *StorylineEditor {
+	return &StorylineEditor{
+		storylineRepo: storylineRepo,
+		mlRepo:        mlRepo,
+	}
+}
+
+// Merge сливает сюжет sourceID в targetID. Заголовок и рубрика остаются от target,
+// состояние берётся от сюжета, который встречался позже, эмбеддинг пересчитывается.
+func (e *StorylineEditor) Merge(targetID, sourceID int64) (*repository.Storyline, error) {
+	if targetID == sourceID {
+		return nil, fmt.Errorf("cannot merge storyline %d into itself", targetID)
+	}
+	target, err := e.getStoryline(targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := e.getStoryline(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ChannelID != source.ChannelID {
+		return nil, fmt.Errorf("storylines %d and %d belong to different channels", targetID, sourceID)
+	}
+
+	merged := *target
+	if source.LastSeen.After(target.LastSeen) {
+		merged.State = source.State
+		merged.LastSeen = source.LastSeen
+	}
+	if source.FirstSeen.Before(target.FirstSeen) {
+		merged.FirstSeen = source.FirstSeen
+	}
+	if source.Importance > merged.Importance {
+		merged.Importance = source.Importance
+	}
+	if merged.Category == "" {
+		merged.Category = source.Category
+	}
+	merged.Status = liveliestStatus(target.Status, source.Status)
+
+	embedding, err := e.embedStoryline(merged.Title, merged.State)
+	if err != nil {
+		return nil, err
+	}
+	merged.Embedding = embedding
+
+	if err := e.storylineRepo.MergeStorylines(&merged, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to merge storyline %d into %d: %w", sourceID, targetID, err)
+	}
+	return &merged, nil
+}
+
+// Split выносит наблюдения sourceID за даты dates в новый сюжет с заголовком title.
+// Состояние, важность, статус, границы и эмбеддинг обоих сюжетов пересчитываются
+// по их наблюдениям, иначе матчинг продолжал бы приписывать исходному сюжету
+// вынесенные дни.
+func (e *StorylineEditor) Split(sourceID int64, dates []time.Time, title string) (int64, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return 0, fmt.Errorf("new storyline title is empty")
+	}
+	if len(dates) == 0 {
+		return 0, fmt.Errorf("no observation dates to split")
+	}
+	source, err := e.getStoryline(sourceID)
+	if err != nil {
+		return 0, err
+	}
+	observations, err := e.storylineRepo.GetObservations(sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get observations for storyline %d: %w", sourceID, err)
+	}
+
+	wanted := make(map[string]struct{}, len(dates))
+	for _, d := range dates {
+		wanted[d.Format("2006-01-02")] = struct{}{}
+	}
+	var moved, kept []repository.Observation
+	for _, o := range observations {
+		if _, ok := wanted[o.ObsDate.Format("2006-01-02")]; ok {
+			moved = append(moved, o)
+		} else {
+			kept = append(kept, o)
+		}
+	}
+	if len(moved) != len(wanted) {
+		return 0, fmt.Errorf("storyline %d has observations for %d of %d requested dates", sourceID, len(moved), len(wanted))
+	}
+	if len(kept) == 0 {
+		return 0, fmt.Errorf("cannot move all observations out of storyline %d", sourceID)
+	}
+
+	now := time.Now().UTC()
+	split := *source
+	split.ID = 0
+	split.Title = title
+	if err := e.rebuildFromObservations(&split, moved, now); err != nil {
+		return 0, err
+	}
+	rest := *source
+	if err := e.rebuildFromObservations(&rest, kept, now); err != nil {
+		return 0, err
+	}
+
+	newID, err := e.storylineRepo.SplitStoryline(&rest, dates, &split)
+	if err != nil {
+		return 0, fmt.Errorf("failed to split storyline %d: %w", sourceID, err)
+	}
+	return newID, nil
+}
+
+// rebuildFromObservations собирает состояние сюжета из дельт его наблюдений
+// (последние, что умещаются в config.StorylineStateMaxRunes), берёт максимальную
+// важность, границы и статус по датам и пересчитывает эмбеддинг.
+func (e *StorylineEditor) rebuildFromObservations(s *repository.Storyline, observations []repository.Observation, now time.Time) error {
+	sort.Slice(observations, func(i, j int) bool { return observations[i].ObsDate.Before(observations[j].ObsDate) })
+
+	s.Importance = 1
+	var deltas []string
+	for _, o := range observations {
+		s.Importance = max(s.Importance, o.Importance)
+		if o.DeltaSummary != "" {
+			deltas = append(deltas, o.DeltaSummary)
+		}
+	}
+	s.State = ""
+	for i := len(deltas) - 1; i >= 0; i-- {
+		state := strings.TrimSpace(deltas[i] + " " + s.State)
+		if s.State != "" && len([]rune(state)) > config.StorylineStateMaxRunes {
+			break
+		}
+		s.State = state
+	}
+	if s.State == "" {
+		s.State = s.Title
+	}
+
+	s.FirstSeen = truncateToDay(observations[0].ObsDate)
+	s.LastSeen = truncateToDay(observations[len(observations)-1].ObsDate)
+	s.Status = statusForLastSeen(s.LastSeen, now)
+
+	embedding, err := e.embedStoryline(s.Title, s.State)
+	if err != nil {
+		return err
+	}
+	s.Embedding = embedding
+	return nil
+}
+
+// Rename меняет каноничный заголовок сюжета и пересчитывает его эмбеддинг.
+func (e *StorylineEditor) Rename(id int64, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fmt.Errorf("storyline title is empty")
+	}
+	s, err := e.getStoryline(id)
+	if err != nil {
+		return err
+	}
+	embedding, err := e.embedStoryline(title, s.State)
+	if err != nil {
+		return err
+	}
+	if err := e.storylineRepo.RenameStoryline(id, title, embedding); err != nil {
+		return fmt.Errorf("failed to rename storyline %d: %w", id, err)
+	}
+	return nil
+}
+
+// ChangeCategory меняет рубрику сюжета на одну из config.Categories.
+func (e *StorylineEditor) ChangeCategory(id int64, category string) error {
+	category = strings.TrimSpace(strings.ToLower(category))
+	if !config.IsCategory(category) {
+		return fmt.Errorf("unknown category %q", category)
+	}
+	if _, err := e.getStoryline(id); err != nil {
+		return err
+	}
+	if err := e.storylineRepo.UpdateCategory(id, category); err != nil {
+		return fmt.Errorf("failed to update category of storyline %d: %w", id, err)
+	}
+	return nil
+}
+
+func (e *StorylineEditor) getStoryline(id int64) (*repository.Storyline, error) {
+	s, err := e.storylineRepo.GetStoryline(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storyline %d: %w", id, err)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("storyline %d not found", id)
+	}
+	return s, nil
+}
+
+func (e *StorylineEditor) embedStoryline(title, state string) ([]float32, error) {
+	embeddings, err := e.mlRepo.EmbedDocuments([]string{title + "\n" + state})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed storyline state: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("received empty document embedding")
+	}
+	return embeddings[0], nil
+}
+
+// liveliestStatus выбирает «самый живой» статус из двух: active > dormant > closed.
+func liveliestStatus(a, b string) string {
+	rank := map[string]int{"active": 2, "dormant": 1, "closed": 0}
+	if rank[statusOrActive(b)] > rank[statusOrActive(a)] {
+		return statusOrActive(b)
+	}
+	return statusOrActive(a)
+}
+
+// statusForLastSeen применяет правила жизненного цикла к дате последнего появления.
+func statusForLastSeen(lastSeen, now time.Time) string {
+	today := truncateToDay(now)
+	switch {
+	case lastSeen.Before(today.AddDate(0, 0, -config.ClosedAfterDays)):
+		return "closed"
+	case lastSeen.Before(today.AddDate(0, 0, -config.DormantAfterDays)):
+		return "dormant"
+	default:
+		return "active"
+	}
+}
+
+func statusOrActive(s string) string {
+	if s == "" {
+		return "active"
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStorylineEditor_MergeKeepsTargetTitleAndLatestState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	editor := NewStorylineEditor(storylineRepo, mlRepo)
+
+	target := &repository.Storyline{
+		ID: 42, ChannelID: 123, Title: "Основной", State: "старое", Category: "политика", Status: "dormant",
+		Importance: 2, FirstSeen: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC), LastSeen: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC),
+	}
+	source := &repository.Storyline{
+		ID: 57, ChannelID: 123, Title: "Дубль", State: "свежее", Category: "политика", Status: "active",
+		Importance: 4, FirstSeen: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), LastSeen: time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC),
+	}
+	embedding := []float32{0.3}
+
+	storylineRepo.EXPECT().GetStoryline(int64(42)).Return(target, nil)
+	storylineRepo.EXPECT().GetStoryline(int64(57)).Return(source, nil)
+	mlRepo.EXPECT().EmbedDocuments([]string{"Основной\nсвежее"}).Return([][]float32{embedding}, nil)
+	storylineRepo.EXPECT().MergeStorylines(gomock.Any(), int64(57)).DoAndReturn(func(s *repository.Storyline, sourceID int64) error {
+		assert.Equal(t, int64(42), s.ID)
+		assert.Equal(t, "Основной", s.Title)
+		assert.Equal(t, "свежее", s.State)
+		assert.Equal(t, "active", s.Status)
+		assert.Equal(t, 4, s.Importance)
+		assert.Equal(t, source.FirstSeen, s.FirstSeen)
+		assert.Equal(t, source.LastSeen, s.LastSeen)
+		assert.Equal(t, embedding, s.Embedding)
+		return nil
+	})
+
+	merged, err := editor.Merge(42, 57)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), merged.ID)
+}
+
+func TestStorylineEditor_MergeRejectsDifferentChannels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	editor := NewStorylineEditor(storylineRepo, mlRepo)
+
+	storylineRepo.EXPECT().GetStoryline(int64(42)).Return(&repository.Storyline{ID: 42, ChannelID: 1}, nil)
+	storylineRepo.EXPECT().GetStoryline(int64(57)).Return(&repository.Storyline{ID: 57, ChannelID: 2}, nil)
+
+	_, err := editor.Merge(42, 57)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "different channels")
+}
+
+func TestStorylineEditor_SplitBuildsStateFromMovedDeltas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	editor := NewStorylineEditor(storylineRepo, mlRepo)
+
+	d1 := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	d3 := time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	storylineRepo.EXPECT().GetStoryline(int64(42)).Return(&repository.Storyline{
+		ID: 42, ChannelID: 123, Title: "Старый", State: "первое второе третье", Category: "экономика",
+		Status: "active", Importance: 4, FirstSeen: d1, LastSeen: d3,
+	}, nil)
+	storylineRepo.EXPECT().GetObservations(int64(42)).Return([]repository.Observation{
+		{StorylineID: 42, ObsDate: d1, Importance: 2, DeltaSummary: "первое"},
+		{StorylineID: 42, ObsDate: d2, Importance: 4, DeltaSummary: "второе"},
+		{StorylineID: 42, ObsDate: d3, Importance: 3, DeltaSummary: "третье"},
+	}, nil)
+	mlRepo.EXPECT().EmbedDocuments([]string{"Новый\nвторое третье"}).Return([][]float32{{0.1}}, nil)
+	mlRepo.EXPECT().EmbedDocuments([]string{"Старый\nпервое"}).Return([][]float32{{0.2}}, nil)
+	storylineRepo.EXPECT().SplitStoryline(gomock.Any(), []time.Time{d3, d2}, gomock.Any()).DoAndReturn(func(source *repository.Storyline, dates []time.Time, s *repository.Storyline) (int64, error) {
+		assert.Equal(t, "Новый", s.Title)
+		assert.Equal(t, "экономика", s.Category)
+		assert.Equal(t, 4, s.Importance)
+		assert.Equal(t, d2, s.FirstSeen)
+		assert.Equal(t, d3, s.LastSeen)
+		assert.Equal(t, []float32{0.1}, s.Embedding)
+
+		// из исходного сюжета ушли дни, дельты и важность вынесенных наблюдений
+		assert.Equal(t, int64(42), source.ID)
+		assert.Equal(t, "Старый", source.Title)
+		assert.Equal(t, "первое", source.State)
+		assert.Equal(t, 2, source.Importance)
+		assert.Equal(t, d1, source.FirstSeen)
+		assert.Equal(t, d1, source.LastSeen)
+		assert.Equal(t, "closed", source.Status)
+		assert.Equal(t, []float32{0.2}, source.Embedding)
+		return int64(99), nil
+	})
+
+	id, err := editor.Split(42, []time.Time{d3, d2}, " Новый ")
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), id)
+}
+
+func TestStorylineEditor_SplitRejectsMovingAllObservations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	editor := NewStorylineEditor(storylineRepo, mlRepo)
+
+	d1 := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	storylineRepo.EXPECT().GetStoryline(int64(42)).Return(&repository.Storyline{ID: 42, ChannelID: 123}, nil)
+	storylineRepo.EXPECT().GetObservations(int64(42)).Return([]repository.Observation{{StorylineID: 42, ObsDate: d1}}, nil)
+
+	_, err := editor.Split(42, []time.Time{d1}, "Новый")
+	require.Error(t, err)
+}
+
+func TestStorylineEditor_ChangeCategoryValidatesEnum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	editor := NewStorylineEditor(storylineRepo, mlRepo)
+
+	require.Error(t, editor.ChangeCategory(42, "спорт"))
+
+	storylineRepo.EXPECT().GetStoryline(int64(42)).Return(&repository.Storyline{ID: 42}, nil)
+	storylineRepo.EXPECT().UpdateCategory(int64(42), "экономика").Return(nil)
+	require.NoError(t, editor.ChangeCategory(42, "Экономика"))
+}
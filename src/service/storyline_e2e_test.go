@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/testutil/fakellm"
+	"github.com/Ra1ze505/goNewsBot/src/testutil/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сквозные тесты конвейера: настоящий MLRepository ходит в fakellm через
+// YANDEX_OPENAI_BASE_URL, StorylineRepository - в тестовый Postgres с pgvector.
+
+func newFakeLLM(t *testing.T) (*fakellm.Server, *repository.MLRepository) {
+	t.Helper()
+	fixtures, err := fakellm.LoadFixtures("testdata/storyline_e2e.json")
+	require.NoError(t, err)
+	server := fakellm.New(t, fixtures)
+	server.Setenv(t)
+	t.Setenv("STORYLINE_SEARCH_MODE", "exact")
+
+	mlRepo, err := repository.NewMLRepository()
+	require.NoError(t, err)
+	return server, mlRepo
+}
+
+func TestE2E_SummarizeMessagesParsesFixtureResponses(t *testing.T) {
+	server, mlRepo := newFakeLLM(t)
+
+	result, err := mlRepo.SummarizeMessages([]string{
+		"Рубль снизился к доллару.",
+		"ЦБ прокомментировал ситуацию на валютном рынке.",
+		"Реклама канала.",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "**1. Курс рубля**\nРубль ослаб после решения регулятора.", result)
+	render := server.RequestsFor("legacy-render")
+	require.Len(t, render, 1)
+	assert.Equal(t, "gpt://fake-folder/yandexgpt/latest", render[0].Model)
+	assert.Contains(t, render[0].User, `"importance": 5`) // 7 обрезано до 5
+	assert.NotContains(t, render[0].User, "9")            // номер вне пачки отброшен
+}
+
+func TestE2E_ProcessDayTracksStorylineAcrossDays(t *testing.T) {
+	db := testdb.Open(t)
+	server, mlRepo := newFakeLLM(t)
+
+	storylineRepo := repository.NewStorylineRepository(db)
+	processor := NewStorylineProcessor(repository.NewSummaryRepository(db), storylineRepo, mlRepo)
+
+	const channelID = int64(1429590454)
+	day1 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	days := []struct {
+		msgs   []repository.MessageInput
+		digest string
+	}{
+		{
+			msgs: []repository.MessageInput{
+				{MessageID: 101, Text: "Делегации начали переговоры в Женеве о прекращении огня."},
+				{MessageID: 102, Text: "В Якутии паводок: реки вышли из берегов, затоплены три посёлка."},
+			},
+			digest: "🆕 Новое\n**Переговоры в Женеве**\nДелегации начали переговоры.",
+		},
+		{
+			msgs: []repository.MessageInput{
+				{MessageID: 201, Text: "Переговоры в Женеве продолжились: стороны обменялись проектами соглашения о прекращении огня."},
+				{MessageID: 202, Text: "ЦБ повысил ключевую ставку до 18%."},
+			},
+			digest: "🆕 Новое\n**Ключевая ставка**\nЦБ повысил ставку.",
+		},
+		{
+			msgs: []repository.MessageInput{
+				{MessageID: 301, Text: "Стороны договорились об обмене пленными до подписания соглашения."},
+				{MessageID: 302, Text: "Обмен пленными пройдёт на следующей неделе."},
+			},
+			digest: "🔺 Эскалация\n**Переговоры в Женеве**\nДоговорились об обмене пленными.",
+		},
+	}
+
+	for i, d := range days {
+		digest, err := processor.ProcessDay(channelID, day1.AddDate(0, 0, i), d.msgs)
+		require.NoError(t, err, "day %d", i+1)
+		assert.Equal(t, d.digest, digest, "day %d", i+1)
+	}
+
+	// День 1: номер сообщения вне пачки в ответе стадии A исправлен повторным запросом.
+	assert.Len(t, server.RequestsFor("extract-day1"), 1)
+	assert.Len(t, server.RequestsFor("extract-day1-repair"), 1)
+	// День 2: сюжет про переговоры привязан без LLM (высокая близость), ставка - новый сюжет.
+	// День 3: "Обмен пленными" в серой зоне, LLM подтверждает сюжет #1 (первый созданный в чистой схеме).
+	assert.Len(t, server.RequestsFor("match-prisoners"), 1)
+
+	storylines, err := storylineRepo.ListStorylines(channelID, 10)
+	require.NoError(t, err)
+	titles := make([]string, len(storylines))
+	for i, s := range storylines {
+		titles[i] = s.Title
+	}
+	assert.ElementsMatch(t, []string{"Переговоры в Женеве", "Паводок в Якутии", "Ключевая ставка"}, titles)
+
+	geneva, err := storylineRepo.GetStoryline(1)
+	require.NoError(t, err)
+	require.NotNil(t, geneva)
+	assert.Equal(t, "Переговоры в Женеве", geneva.Title)
+	assert.Equal(t, "Стороны договорились об обмене пленными, соглашение о прекращении огня готовится к подписанию", geneva.State)
+	assert.Equal(t, 5, geneva.Importance)
+	assert.True(t, geneva.LastSeen.Equal(day1.AddDate(0, 0, 2)))
+
+	observations, err := storylineRepo.GetObservations(geneva.ID)
+	require.NoError(t, err)
+	require.Len(t, observations, 3)
+	assert.Equal(t, "new", observations[0].ChangeType)
+	assert.Equal(t, "ongoing", observations[1].ChangeType)
+	assert.Equal(t, "escalation", observations[2].ChangeType)
+	assert.Equal(t, []int64{301, 302}, observations[2].SourceMessageIDs)
+
+	delta := server.RequestsFor("delta-geneva-day3")
+	require.Len(t, delta, 1)
+	assert.Contains(t, delta[0].User, "предварительная метка изменения: escalation")
+	assert.Contains(t, delta[0].User, "Текущее состояние: Стороны обменялись проектами соглашения")
+}
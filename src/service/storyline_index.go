@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// StorylineIndexMonitor следит, чтобы HNSW-поиск сюжетов не терял соседей
+// относительно точного перебора (деградация recall = дубли сюжетов).
+type StorylineIndexMonitor struct {
+	storylineRepo repository.StorylineRepositoryInterface
+}
+
+func NewStorylineIndexMonitor(storylineRepo repository.StorylineRepositoryInterface) *StorylineIndexMonitor {
+	return &StorylineIndexMonitor{storylineRepo: storylineRepo}
+}
+
+// CheckRecall сравнивает ANN и точный поиск на выборке и пишет в лог деградацию.
+func (m *StorylineIndexMonitor) CheckRecall() (float64, error) {
+	recall, err := m.storylineRepo.CheckANNRecall(config.ANNRecallSampleSize, config.MatchTopK)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check ANN recall: %w", err)
+	}
+	if recall < config.ANNRecallMinRatio {
+		log.Warnf("Storyline ANN recall degraded: %.3f < %.2f (mode %s); raise HNSWEfSearch or set STORYLINE_SEARCH_MODE=exact",
+			recall, config.ANNRecallMinRatio, config.StorylineSearchMode())
+	} else {
+		log.Infof("Storyline ANN recall: %.3f", recall)
+	}
+	return recall, nil
+}
+
+func (m *StorylineIndexMonitor) StartRecallChecker() {
+	ticker := time.NewTicker(24 * time.Hour)
+	go func() {
+		for {
+			if _, err := m.CheckRecall(); err != nil {
+				log.Errorf("Error checking storyline ANN recall: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStorylineIndexMonitor_CheckRecall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	monitor := NewStorylineIndexMonitor(storylineRepo)
+
+	storylineRepo.EXPECT().CheckANNRecall(50, 5).Return(0.6, nil)
+	recall, err := monitor.CheckRecall()
+	require.NoError(t, err)
+	assert.InDelta(t, 0.6, recall, 1e-9)
+
+	storylineRepo.EXPECT().CheckANNRecall(50, 5).Return(0.0, errors.New("db down"))
+	_, err = monitor.CheckRecall()
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+// ErrStorylineNotFound - сюжет удалён (например, слит в другой) или не существовал.
+var ErrStorylineNotFound = errors.New("storyline not found")
+
+// StorylineTimeline - сюжет и его наблюдения по дням для хроники.
+type StorylineTimeline struct {
+	Storyline    repository.Storyline
+	Observations []repository.Observation // последние config.StorylineTimelineMaxDays дней
+	TotalDays    int
+	// SourceURLs - Meta.URL исходных постов дней по message_id: у записей лент
+	// ссылка не строится по id.
+	SourceURLs map[int64]string
+}
+
+// StorylineSearchService ищет сюжеты по тексту читателя: запрос эмбеддится
+// query-моделью и сравнивается с doc-эмбеддингами сюжетов всех каналов.
+type StorylineSearchService struct {
+	mlRepo        repository.MLRepositoryInterface
+	storylineRepo repository.StorylineRepositoryInterface
+	messageRepo   repository.MessageRepositoryInterface
+}
+
+func NewStorylineSearchService(
+	mlRepo repository.MLRepositoryInterface,
+	storylineRepo repository.StorylineRepositoryInterface,
+	messageRepo repository.MessageRepositoryInterface,
+) *StorylineSearchService {
+	return &StorylineSearchService{mlRepo: mlRepo, storylineRepo: storylineRepo, messageRepo: messageRepo}
+}
+
+// Find возвращает ближайшие к запросу сюжеты с близостью не ниже config.StorylineSearchMinSim.
+func (s *StorylineSearchService) Find(text string) ([]repository.ScoredStoryline, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(text) > config.StorylineSearchMaxQueryLen {
+		return nil, fmt.Errorf("запрос длиннее %d символов", config.StorylineSearchMaxQueryLen)
+	}
+
+	vecs, err := s.mlRepo.EmbedQueries([]string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed storyline query: %w", err)
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("embedding count mismatch: got %d for 1 query", len(vecs))
+	}
+
+	scored, err := s.storylineRepo.SearchNearestAll(vecs[0], config.StorylineSearchTopK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search storylines: %w", err)
+	}
+	var result []repository.ScoredStoryline
+	for _, sc := range scored {
+		if sc.Similarity >= config.StorylineSearchMinSim {
+			result = append(result, sc)
+		}
+	}
+	return result, nil
+}
+
+// Timeline возвращает хронику сюжета; ErrStorylineNotFound, если сюжета нет.
+func (s *StorylineSearchService) Timeline(storylineID int64) (*StorylineTimeline, error) {
+	storyline, err := s.storylineRepo.GetStoryline(storylineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storyline: %w", err)
+	}
+	if storyline == nil {
+		return nil, ErrStorylineNotFound
+	}
+	observations, err := s.storylineRepo.GetObservations(storylineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get observations: %w", err)
+	}
+
+	total := len(observations)
+	if total > config.StorylineTimelineMaxDays {
+		observations = observations[total-config.StorylineTimelineMaxDays:]
+	}
+
+	var sourceIDs []int64
+	for _, o := range observations {
+		if len(o.SourceMessageIDs) > 0 {
+			sourceIDs = append(sourceIDs, slices.Min(o.SourceMessageIDs))
+		}
+	}
+	sourceURLs := make(map[int64]string)
+	if len(sourceIDs) > 0 {
+		messages, err := s.messageRepo.GetMessagesByIDs(storyline.ChannelID, sourceIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source messages: %w", err)
+		}
+		for _, m := range messages {
+			if m.Meta.URL != "" {
+				sourceURLs[int64(m.MessageID)] = m.Meta.URL
+			}
+		}
+	}
+	return &StorylineTimeline{Storyline: *storyline, Observations: observations, TotalDays: total, SourceURLs: sourceURLs}, nil
+}
+
+// FormatStorylineResults - найденные сюжеты простым текстом, пронумерованные
+// так же, как кнопки хроники под сообщением.
+func FormatStorylineResults(query string, results []repository.ScoredStoryline) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("По запросу «%s» сюжетов не найдено.", query)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Сюжеты по запросу «%s»:\n", query))
+	for i, r := range results {
+		b.WriteString(fmt.Sprintf("\n%d. %s\n%s\n", i+1, r.Storyline.Title, storylineMeta(r.Storyline)))
+		if r.Storyline.State != "" {
+			b.WriteString(r.Storyline.State + "\n")
+		}
+	}
+	b.WriteString("\nХроника сюжета - по кнопке с его номером.")
+	return b.String()
+}
+
+// Format - хроника сюжета простым текстом: день, тип изменения, что произошло и ссылка на пост.
+func (t *StorylineTimeline) Format() string {
+	s := t.Storyline
+
+	var b strings.Builder
+	b.WriteString(s.Title + "\n" + storylineMeta(s) + "\n")
+	if s.State != "" {
+		b.WriteString("\n" + s.State + "\n")
+	}
+	if len(t.Observations) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\nХроника")
+	if t.TotalDays > len(t.Observations) {
+		b.WriteString(fmt.Sprintf(" (последние %d из %d дней)", len(t.Observations), t.TotalDays))
+	}
+	b.WriteString(":\n")
+	for _, o := range t.Observations {
+		b.WriteString(fmt.Sprintf("\n%s - %s, сообщений: %d\n", o.ObsDate.Format("02.01.2006"), changeTypeLabel(o.ChangeType), o.MessageCount))
+		if o.DeltaSummary != "" {
+			b.WriteString(o.DeltaSummary + "\n")
+		}
+		if len(o.SourceMessageIDs) > 0 {
+			id := slices.Min(o.SourceMessageIDs)
+			if url := PostURL(s.ChannelID, id, t.SourceURLs[id]); url != "" {
+				b.WriteString(url + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// storylineMeta - строка «канал · статус · рубрика · даты».
+func storylineMeta(s repository.Storyline) string {
+	return fmt.Sprintf("%s · %s · %s · %s - %s",
+		ChannelLabel(s.ChannelID), storylineStatusLabel(s.Status), categoryLabel(s.Category),
+		s.FirstSeen.Format("02.01.2006"), s.LastSeen.Format("02.01.2006"))
+}
+
+func storylineStatusLabel(status string) string {
+	switch status {
+	case "active":
+		return "развивается"
+	case "dormant":
+		return "затих"
+	case "closed":
+		return "завершён"
+	default:
+		return status
+	}
+}
+
+func changeTypeLabel(changeType string) string {
+	switch changeType {
+	case "new":
+		return "новый сюжет"
+	case "escalation":
+		return "обострение"
+	case "deescalation":
+		return "спад"
+	case "revived":
+		return "возвращение"
+	case "recurring_noise":
+		return "фон"
+	case "ongoing":
+		return "продолжение"
+	default:
+		return changeType
+	}
+}
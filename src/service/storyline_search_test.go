@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestStorylineSearchService_Find(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	svc := NewStorylineSearchService(mlRepo, storylineRepo, nil)
+
+	vec := []float32{0.1, 0.2}
+	mlRepo.EXPECT().EmbedQueries([]string{"цены на бензин"}).Return([][]float32{vec}, nil)
+	storylineRepo.EXPECT().SearchNearestAll(vec, config.StorylineSearchTopK).Return([]repository.ScoredStoryline{
+		{Storyline: repository.Storyline{ID: 1, Status: "closed"}, Similarity: 0.52},
+		{Storyline: repository.Storyline{ID: 2, Status: "active"}, Similarity: config.StorylineSearchMinSim},
+		{Storyline: repository.Storyline{ID: 3, Status: "active"}, Similarity: 0.12},
+	}, nil)
+
+	results, err := svc.Find("  цены на бензин ")
+	require.NoError(t, err)
+	require.Len(t, results, 2, "нерелевантный сюжет отсечён порогом")
+	assert.Equal(t, int64(1), results[0].Storyline.ID)
+	assert.Equal(t, int64(2), results[1].Storyline.ID)
+}
+
+func TestStorylineSearchService_FindValidatesQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewStorylineSearchService(mock_repository.NewMockMLRepositoryInterface(ctrl), mock_repository.NewMockStorylineRepositoryInterface(ctrl), nil)
+
+	_, err := svc.Find("   ")
+	assert.True(t, errors.Is(err, ErrEmptySearchQuery))
+
+	_, err = svc.Find(strings.Repeat("я", config.StorylineSearchMaxQueryLen+1))
+	assert.Error(t, err)
+}
+
+func TestStorylineSearchService_Timeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	messageRepo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewStorylineSearchService(mock_repository.NewMockMLRepositoryInterface(ctrl), storylineRepo, messageRepo)
+
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	storyline := &repository.Storyline{
+		ID: 5, ChannelID: 1754252633, Title: "Цены на бензин", State: "Биржевые цены обновили рекорд",
+		Category: "экономика", Status: "dormant", FirstSeen: day, LastSeen: day.AddDate(0, 0, config.StorylineTimelineMaxDays+1),
+	}
+	var observations []repository.Observation
+	for i := 0; i < config.StorylineTimelineMaxDays+2; i++ {
+		observations = append(observations, repository.Observation{StorylineID: 5, ChannelID: 1754252633, ObsDate: day.AddDate(0, 0, i), ChangeType: "ongoing", MessageCount: 1})
+	}
+	last := &observations[len(observations)-1]
+	last.ChangeType = "escalation"
+	last.MessageCount = 4
+	last.DeltaSummary = "Правительство обсуждает запрет экспорта"
+	last.SourceMessageIDs = []int64{812, 805}
+
+	storylineRepo.EXPECT().GetStoryline(int64(5)).Return(storyline, nil)
+	storylineRepo.EXPECT().GetObservations(int64(5)).Return(observations, nil)
+	messageRepo.EXPECT().GetMessagesByIDs(int64(1754252633), []int64{805}).Return([]repository.Message{{ChannelID: 1754252633, MessageID: 805}}, nil)
+
+	timeline, err := svc.Timeline(5)
+	require.NoError(t, err)
+	assert.Len(t, timeline.Observations, config.StorylineTimelineMaxDays)
+	assert.Equal(t, day.AddDate(0, 0, 2), timeline.Observations[0].ObsDate, "старые дни отброшены")
+
+	text := timeline.Format()
+	assert.Contains(t, text, "@topor_live · затих · экономика · 01.10.2026 - 01.11.2026")
+	assert.Contains(t, text, "(последние 30 из 32 дней)")
+	assert.Contains(t, text, "01.11.2026 - обострение, сообщений: 4\nПравительство обсуждает запрет экспорта\nhttps://t.me/topor_live/805\n")
+}
+
+func TestStorylineSearchService_TimelineFeedLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	useTestChannels(t, nil, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	messageRepo := mock_repository.NewMockMessageRepositoryInterface(ctrl)
+	svc := NewStorylineSearchService(mock_repository.NewMockMLRepositoryInterface(ctrl), storylineRepo, messageRepo)
+
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	storylineRepo.EXPECT().GetStoryline(int64(7)).Return(&repository.Storyline{ID: 7, ChannelID: -1, Title: "Ремонт набережной", Status: "active", FirstSeen: day, LastSeen: day}, nil)
+	storylineRepo.EXPECT().GetObservations(int64(7)).Return([]repository.Observation{
+		{StorylineID: 7, ChannelID: -1, ObsDate: day, ChangeType: "new", MessageCount: 1, SourceMessageIDs: []int64{4242}},
+	}, nil)
+	messageRepo.EXPECT().GetMessagesByIDs(int64(-1), []int64{4242}).Return([]repository.Message{
+		{ChannelID: -1, MessageID: 4242, Meta: repository.MessageMeta{URL: "https://news.example.ru/embankment"}},
+	}, nil)
+
+	timeline, err := svc.Timeline(7)
+	require.NoError(t, err)
+	text := timeline.Format()
+	assert.Contains(t, text, "city_news · ")
+	// у записи ленты ссылка - адрес материала, а не t.me
+	assert.Contains(t, text, "https://news.example.ru/embankment\n")
+}
+
+func TestStorylineSearchService_TimelineNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	svc := NewStorylineSearchService(mock_repository.NewMockMLRepositoryInterface(ctrl), storylineRepo, nil)
+
+	storylineRepo.EXPECT().GetStoryline(int64(9)).Return(nil, nil)
+
+	_, err := svc.Timeline(9)
+	assert.True(t, errors.Is(err, ErrStorylineNotFound))
+}
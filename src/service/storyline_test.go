@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Ra1ze505/goNewsBot/src/config"
 	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
 	"github.com/Ra1ze505/goNewsBot/src/repository"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,8 @@ func TestProcessDay_NewStoryline(t *testing.T) {
 	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
 	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
 	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v1,render@v1").AnyTimes()
 	processor := NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
 
 	day := time.Date(2026, 6, 20, 12, 0, 0, 0, time.UTC)
@@ -29,7 +32,7 @@ func TestProcessDay_NewStoryline(t *testing.T) {
 		{Title: "Событие", Summary: "Описание", Category: "происшествия", Importance: 4, SourceMessageNumbers: []int{1}},
 	}, nil)
 	mlRepo.EXPECT().EmbedQueries([]string{"Событие\nОписание"}).Return([][]float32{embedding}, nil)
-	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, 5).Return(nil, nil)
+	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return(nil, nil)
 	mlRepo.EXPECT().WriteDelta(gomock.Any()).Return("новое состояние", "что нового", nil)
 	mlRepo.EXPECT().EmbedDocuments([]string{"Событие\nновое состояние"}).Return([][]float32{embedding}, nil)
 
@@ -67,6 +70,8 @@ func TestProcessDay_MatchExistingHighSimilarity(t *testing.T) {
 	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
 	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
 	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v1,render@v1").AnyTimes()
 	processor := NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
 
 	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
@@ -79,7 +84,7 @@ func TestProcessDay_MatchExistingHighSimilarity(t *testing.T) {
 		{Title: "Развитие", Summary: "Детали", Category: "политика", Importance: 3, SourceMessageNumbers: []int{1}},
 	}, nil)
 	mlRepo.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{embedding}, nil)
-	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, 5).Return([]repository.ScoredStoryline{
+	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return([]repository.ScoredStoryline{
 		{Storyline: existing, Similarity: 0.92},
 	}, nil)
 	storylineRepo.EXPECT().GetStats(int64(42), gomock.Any(), 14).Return(repository.StorylineStats{
@@ -103,6 +108,67 @@ func TestProcessDay_MatchExistingHighSimilarity(t *testing.T) {
 	assert.Equal(t, "дайджест", digest)
 }
 
+func TestProcessDay_GrayZoneWithoutBudgetUsesMidpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	processor := NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
+
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	msgs := []repository.MessageInput{
+		{MessageID: 5, Text: "Развитие сюжета"},
+		{MessageID: 6, Text: "Что-то новое"},
+	}
+	embedding := make([]float32, 256)
+	existing := repository.Storyline{ID: 42, ChannelID: 123, Title: "Существующий", State: "старое", Category: "политика", LastSeen: day.AddDate(0, 0, -1)}
+
+	// вызовы ML учитываются за канал и день прогона
+	mlRepo.EXPECT().ForDay(int64(123), day).Return(mlRepo)
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v2,render@v1").AnyTimes()
+	mlRepo.EXPECT().ExtractTopics(msgs).Return([]repository.CandidateTopic{
+		{Title: "Развитие", Summary: "Детали", Category: "политика", Importance: 3, SourceMessageNumbers: []int{1}},
+		{Title: "Новое", Summary: "Другое", Category: "общество", Importance: 2, SourceMessageNumbers: []int{2}},
+	}, nil)
+	mlRepo.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{embedding, embedding}, nil)
+	// оба кандидата в серой зоне: первый выше середины, второй ниже
+	gomock.InOrder(
+		storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return([]repository.ScoredStoryline{
+			{Storyline: existing, Similarity: 0.45},
+		}, nil),
+		storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return([]repository.ScoredStoryline{
+			{Storyline: existing, Similarity: 0.40},
+		}, nil),
+	)
+	storylineRepo.EXPECT().GetStats(int64(42), gomock.Any(), 14).Return(repository.StorylineStats{
+		DaysSeen: 3, MedianCount: 2, MedianImportance: 3,
+	}, nil).Times(3)
+	mlRepo.EXPECT().ConfirmMatch(gomock.Any(), gomock.Any()).Return(int64(0), false, repository.ErrMLBudgetExceeded).Times(2)
+	mlRepo.EXPECT().WriteDelta(gomock.Any()).Return("состояние", "дельта", nil).Times(2)
+	mlRepo.EXPECT().EmbedDocuments(gomock.Any()).Return([][]float32{embedding}, nil).Times(2)
+	storylineRepo.EXPECT().UpdateStoryline(gomock.Any()).DoAndReturn(func(s *repository.Storyline) error {
+		assert.Equal(t, int64(42), s.ID)
+		return nil
+	})
+	storylineRepo.EXPECT().CreateStoryline(gomock.Any()).DoAndReturn(func(s *repository.Storyline) (int64, error) {
+		assert.Equal(t, "Новое", s.Title)
+		return 43, nil
+	})
+	storylineRepo.EXPECT().SaveObservation(gomock.Any()).DoAndReturn(func(o *repository.Observation) error {
+		assert.Equal(t, "extract@v1,match@v1,delta@v2,render@v1", o.PromptVersion)
+		return nil
+	}).Times(2)
+	storylineRepo.EXPECT().MarkDormant(int64(123), gomock.Any()).Return(nil)
+	storylineRepo.EXPECT().MarkClosed(int64(123), gomock.Any()).Return(nil)
+	mlRepo.EXPECT().RenderDigest(gomock.Any()).Return("дайджест", nil)
+
+	digest, err := processor.ProcessDay(123, day, msgs)
+	require.NoError(t, err)
+	assert.Equal(t, "дайджест", digest)
+}
+
 func TestClassifyChangeType(t *testing.T) {
 	// эскалация по объёму
 	assert.Equal(t, "escalation", classifyChangeType(repository.StorylineStats{MedianCount: 2, MedianImportance: 2, DaysSeen: 3}, 5, 2))
@@ -118,10 +184,10 @@ func TestClassifyChangeType(t *testing.T) {
 
 func TestBuildDigestGroups(t *testing.T) {
 	entries := []digestEntry{
-		{title: "A", changeType: "new", deltaSummary: "x"},
+		{title: "A", changeType: "new", deltaSummary: "x", sourceIDs: []int64{12, 10, 11}},
 		{title: "B", changeType: "escalation", deltaSummary: "y"},
-		{title: "C", changeType: "ongoing", deltaSummary: "z"},
-		{title: "D", changeType: "ongoing", deltaSummary: ""},
+		{title: "C", changeType: "ongoing", deltaSummary: "z", sourceIDs: []int64{20}},
+		{title: "D", changeType: "ongoing", deltaSummary: "", sourceIDs: []int64{30}},
 		{title: "E", changeType: "recurring_noise", category: "происшествия"},
 		{title: "F", changeType: "recurring_noise", category: "происшествия"},
 	}
@@ -130,4 +196,141 @@ func TestBuildDigestGroups(t *testing.T) {
 	assert.Len(t, groups.Escalation, 1)
 	assert.Len(t, groups.Ongoing, 1) // D отброшен из-за пустой дельты
 	assert.Equal(t, []string{"происшествия"}, groups.RecurringNoise)
+
+	// ссылка - на самое раннее сообщение, метки только у попавших в дайджест сюжетов с источниками
+	assert.Equal(t, "S1", groups.New[0].Ref)
+	assert.Equal(t, int64(10), groups.New[0].SourceMessageID)
+	assert.Empty(t, groups.Escalation[0].Ref)
+	assert.Equal(t, "S2", groups.Ongoing[0].Ref)
+}
+
+func TestLinkSources(t *testing.T) {
+	groups := repository.DigestGroups{
+		New:     []repository.DigestItem{{Title: "A", Ref: "S1", SourceMessageID: 10}},
+		Ongoing: []repository.DigestItem{{Title: "C", Ref: "S2", SourceMessageID: 20}},
+	}
+	digest := "*A* суть [[S1]]\n*C* развитие [[S2]]\nлишняя метка [[S9]]"
+
+	assert.Equal(t,
+		"*A* суть [источник](https://t.me/topor_live/10)\n*C* развитие [источник](https://t.me/topor_live/20)\nлишняя метка",
+		linkSources(digest, 1754252633, groups))
+	// канал без username в config.Channels - метки просто убираются
+	assert.Equal(t, "*A* суть\n*C* развитие\nлишняя метка", linkSources(digest, 123, groups))
+}
+
+func TestLinkSources_FeedUsesStoredURL(t *testing.T) {
+	useTestChannels(t, nil, map[int64]config.Feed{-1: {Name: "city_news"}})
+
+	groups := withSourceURLs(repository.DigestGroups{
+		New:     []repository.DigestItem{{Title: "A", Ref: "S1", SourceMessageID: 10}},
+		Ongoing: []repository.DigestItem{{Title: "C", Ref: "S2", SourceMessageID: 20}},
+	}, []repository.MessageInput{
+		{MessageID: 10, Meta: repository.MessageMeta{URL: "https://news.example.ru/a"}},
+		{MessageID: 20},
+	})
+	assert.Equal(t, "https://news.example.ru/a", groups.New[0].SourceURL)
+
+	// запись без адреса остаётся без ссылки, а не получает t.me по хэшу
+	assert.Equal(t, "*A* суть [источник](https://news.example.ru/a)\n*C* развитие",
+		linkSources("*A* суть [[S1]]\n*C* развитие [[S2]]", -1, groups))
+}
+
+func TestProcessDay_RevivesDormantStoryline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v1,render@v1").AnyTimes()
+	processor := NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
+
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	msgs := []repository.MessageInput{{MessageID: 5, Text: "Переговоры возобновились"}}
+	embedding := make([]float32, 256)
+
+	dormant := repository.Storyline{ID: 42, ChannelID: 123, Title: "Переговоры", State: "пауза", Status: "dormant", LastSeen: day.AddDate(0, 0, -12)}
+
+	mlRepo.EXPECT().ExtractTopics(msgs).Return([]repository.CandidateTopic{
+		{Title: "Переговоры", Summary: "Возобновились", Importance: 3, SourceMessageNumbers: []int{1}},
+	}, nil)
+	mlRepo.EXPECT().EmbedQueries(gomock.Any()).Return([][]float32{embedding}, nil)
+	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, day.AddDate(0, 0, -90)).Return([]repository.ScoredStoryline{
+		{Storyline: dormant, Similarity: 0.9},
+	}, nil)
+	storylineRepo.EXPECT().GetStats(int64(42), gomock.Any(), 14).Return(repository.StorylineStats{DaysSeen: 0}, nil)
+	mlRepo.EXPECT().WriteDelta(gomock.Any()).DoAndReturn(func(in repository.DeltaInput) (string, string, error) {
+		assert.Equal(t, "revived", in.ChangeType)
+		assert.Equal(t, 12, in.GapDays)
+		return "возобновились", "переговоры снова идут", nil
+	})
+	mlRepo.EXPECT().EmbedDocuments(gomock.Any()).Return([][]float32{embedding}, nil)
+	storylineRepo.EXPECT().UpdateStoryline(gomock.Any()).DoAndReturn(func(s *repository.Storyline) error {
+		assert.Equal(t, "active", s.Status)
+		return nil
+	})
+	storylineRepo.EXPECT().SaveObservation(gomock.Any()).DoAndReturn(func(o *repository.Observation) error {
+		assert.Equal(t, "revived", o.ChangeType)
+		return nil
+	})
+	storylineRepo.EXPECT().MarkDormant(int64(123), gomock.Any()).Return(nil)
+	storylineRepo.EXPECT().MarkClosed(int64(123), gomock.Any()).Return(nil)
+	mlRepo.EXPECT().RenderDigest(gomock.Any()).DoAndReturn(func(groups repository.DigestGroups) (string, error) {
+		assert.Empty(t, groups.New)
+		require.Len(t, groups.Revived, 1)
+		assert.Equal(t, "Переговоры", groups.Revived[0].Title)
+		return "дайджест", nil
+	})
+
+	_, err := processor.ProcessDay(123, day, msgs)
+	require.NoError(t, err)
+}
+
+func TestApplyRevivalPenalty(t *testing.T) {
+	scored := []repository.ScoredStoryline{
+		{Storyline: repository.Storyline{ID: 1, Status: "closed"}, Similarity: 0.85},
+		{Storyline: repository.Storyline{ID: 2, Status: "dormant"}, Similarity: 0.84},
+		{Storyline: repository.Storyline{ID: 3, Status: "active"}, Similarity: 0.83},
+	}
+
+	out := applyRevivalPenalty(scored)
+
+	require.Len(t, out, 3)
+	assert.Equal(t, int64(3), out[0].Storyline.ID) // при близких оценках выигрывает активный
+	assert.Equal(t, int64(2), out[1].Storyline.ID)
+	assert.Equal(t, int64(1), out[2].Storyline.ID)
+	assert.InDelta(t, 0.79, out[2].Similarity, 1e-9)
+	assert.InDelta(t, 0.85, scored[0].Similarity, 1e-9) // вход не мутируется
+}
+
+func TestMatchCandidate_PenaltyAppliedBeforeTopK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
+	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	processor := NewStorylineProcessor(nil, storylineRepo, mlRepo)
+
+	day := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	embedding := make([]float32, 256)
+
+	// активный сюжет шестой по сырой близости, но после штрафа обходит закрытые
+	var scored []repository.ScoredStoryline
+	for i := 0; i < config.MatchTopK; i++ {
+		scored = append(scored, repository.ScoredStoryline{
+			Storyline:  repository.Storyline{ID: int64(10 + i), Status: "closed"},
+			Similarity: 0.60 - float64(i)*0.01,
+		})
+	}
+	scored = append(scored, repository.ScoredStoryline{
+		Storyline:  repository.Storyline{ID: 42, Status: "active"},
+		Similarity: 0.555,
+	})
+	storylineRepo.EXPECT().SearchNearest(int64(123), embedding, config.MatchTopK+config.MatchRevivalMargin, gomock.Any()).Return(scored, nil)
+
+	matched, err := processor.matchCandidate(123, day, repository.CandidateTopic{Title: "Переговоры"}, embedding)
+	require.NoError(t, err)
+	require.NotNil(t, matched)
+	assert.Equal(t, int64(42), matched.ID)
 }
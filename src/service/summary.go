@@ -56,7 +56,7 @@ func (s *SummaryService) startSummaryFetcher(ctx context.Context) {
 }
 
 func (s *SummaryService) processAllChannels() error {
-	for peerID, _ := range config.Channels {
+	for peerID := range config.ChannelNames() {
 		if err := s.ProcessChannelSummaries(peerID); err != nil {
 			log.Errorf("Error processing summary for channel with peer_id %d: %v", peerID, err)
 			continue
@@ -98,15 +98,17 @@ func (s *SummaryService) ProcessChannelSummaries(peerID int64) error {
 
 	log.Infof("Processing %d messages for channel %d", len(messages), peerID)
 
-	summary, err := s.processor.ProcessDay(peerID, targetDay, messages)
+	summary, groups, err := s.processor.ProcessDayWithGroups(peerID, targetDay, messages)
 	if err != nil {
 		return fmt.Errorf("failed to generate summary for channel %d: %w", peerID, err)
 	}
 
 	if err := s.summaryRepo.SaveSummary(&repository.Summary{
-		ChannelID: peerID,
-		Summary:   summary,
-		CreatedAt: time.Now().UTC(),
+		ChannelID:     peerID,
+		Summary:       summary,
+		PromptVersion: s.processor.PromptVersion(),
+		Groups:        &groups,
+		CreatedAt:     time.Now().UTC(),
 	}); err != nil {
 		return fmt.Errorf("failed to save summary for channel %d: %w", peerID, err)
 	}
@@ -26,6 +26,8 @@ func TestSummaryService_ProcessChannelSummaries(t *testing.T) {
 	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
 	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
 	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v1,render@v1").AnyTimes()
 	service := newTestSummaryService(summaryRepo, storylineRepo, mlRepo)
 
 	tests := []struct {
@@ -49,7 +51,12 @@ func TestSummaryService_ProcessChannelSummaries(t *testing.T) {
 					Return([]repository.MessageInput{{MessageID: 1, Text: "message1"}}, nil)
 				mlRepo.EXPECT().ExtractTopics(gomock.Any()).Return(nil, nil)
 				mlRepo.EXPECT().RenderDigest(gomock.Any()).Return("digest", nil)
-				summaryRepo.EXPECT().SaveSummary(gomock.Any()).Return(nil)
+				summaryRepo.EXPECT().SaveSummary(gomock.Any()).DoAndReturn(func(s *repository.Summary) error {
+					assert.Equal(t, "extract@v1,match@v1,delta@v1,render@v1", s.PromptVersion)
+					// группы сохраняются и для пустого дня, чтобы перерендер не путал его со старыми дайджестами
+					assert.NotNil(t, s.Groups)
+					return nil
+				})
 			},
 			expectedError: nil,
 		},
@@ -126,6 +133,8 @@ func TestSummaryService_StartSummaryFetcher(t *testing.T) {
 	summaryRepo := mock_repository.NewMockSummaryRepositoryInterface(ctrl)
 	storylineRepo := mock_repository.NewMockStorylineRepositoryInterface(ctrl)
 	mlRepo := mock_repository.NewMockMLRepositoryInterface(ctrl)
+	mlRepo.EXPECT().ForDay(gomock.Any(), gomock.Any()).Return(mlRepo).AnyTimes()
+	mlRepo.EXPECT().PromptVersion().Return("extract@v1,match@v1,delta@v1,render@v1").AnyTimes()
 	processor := NewStorylineProcessor(summaryRepo, storylineRepo, mlRepo)
 	messagesFetched := make(chan struct{})
 	forceRegenerateChannel := make(chan struct{})
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewSessionStorage - хранилище сессии MTProto. С SESSION_ENCRYPTION_KEY сессия
+// лежит в telegram_sessions в зашифрованном виде (миграция 0017), и при первом
+// запуске туда переносится session.json; без ключа - по-прежнему файл.
+func NewSessionStorage(ctx context.Context, db *sql.DB) (telegram.SessionStorage, error) {
+	file := &telegram.FileSessionStorage{Path: filepath.Join(config.SessionDir, "session.json")}
+
+	rawKey := os.Getenv("SESSION_ENCRYPTION_KEY")
+	if rawKey == "" {
+		log.Warnf("SESSION_ENCRYPTION_KEY is not set, Telegram session is kept in %s", file.Path)
+		return file, nil
+	}
+	key, err := repository.ParseSessionKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := repository.NewSessionRepository(db, key, repository.SessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := importFileSession(ctx, storage, file); err != nil {
+		return nil, errors.Wrap(err, "import session file")
+	}
+	return storage, nil
+}
+
+// importFileSession переносит сессию из файла, если в базе её ещё нет.
+func importFileSession(ctx context.Context, storage repository.SessionRepositoryInterface, file telegram.SessionStorage) error {
+	if _, err := storage.LoadSession(ctx); !errors.Is(err, session.ErrNotFound) {
+		return err
+	}
+	data, err := file.LoadSession(ctx)
+	if errors.Is(err, session.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := storage.StoreSession(ctx, data); err != nil {
+		return err
+	}
+	log.Info("Telegram session imported from file into the database")
+	return nil
+}
+
+// Шаги входа в аккаунт из бота.
+const (
+	LoginStepCode     = "code"     // ждём код из Telegram
+	LoginStepPassword = "password" // ждём пароль двухэтапной проверки
+	LoginStepDone     = "done"     // сессия сохранена
+)
+
+var (
+	ErrLoginNotStarted  = errors.New("вход не начат или истёк, начните заново")
+	ErrLoginInvalidCode = errors.New("неверный код, попробуйте ещё раз")
+	ErrLoginInvalidPass = errors.New("неверный пароль, попробуйте ещё раз")
+)
+
+// loginClient - методы auth.Client, которые нужны входу.
+type loginClient interface {
+	SendCode(ctx context.Context, phone string, options auth.SendCodeOptions) (tg.AuthSentCodeClass, error)
+	SignIn(ctx context.Context, phone, code, codeHash string) (*tg.AuthAuthorization, error)
+	Password(ctx context.Context, password string) (*tg.AuthAuthorization, error)
+}
+
+// TelegramLogin ведёт вход в MTProto-аккаунт, читающий каналы, из бота:
+// телефон, код, пароль 2FA. Клиент входа работает в своей горутине, пока
+// админ отвечает на шаги, на сессии в памяти: в общее хранилище она
+// копируется только после успешного входа, чтобы отменённый или неудачный
+// вход не затёр рабочую сессию.
+type TelegramLogin struct {
+	sessions telegram.SessionStorage
+	// onLogin вызывается после успешного входа; nil - ничего не делать.
+	onLogin func()
+
+	mu      sync.Mutex
+	attempt *loginAttempt
+}
+
+type loginAttempt struct {
+	inputs chan string
+	steps  chan loginResult
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+type loginResult struct {
+	step string
+	err  error
+}
+
+func NewTelegramLogin(sessions telegram.SessionStorage, onLogin func()) *TelegramLogin {
+	return &TelegramLogin{sessions: sessions, onLogin: onLogin}
+}
+
+// Start отправляет код на phone и возвращает следующий шаг. Предыдущий
+// незавершённый вход отменяется.
+func (l *TelegramLogin) Start(phone string) (string, error) {
+	l.mu.Lock()
+	if l.attempt != nil {
+		l.attempt.cancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.TelegramLoginTimeoutMinutes*time.Minute)
+	attempt := &loginAttempt{
+		inputs: make(chan string),
+		steps:  make(chan loginResult, 1),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	l.attempt = attempt
+	l.mu.Unlock()
+
+	go l.run(ctx, attempt, phone)
+	return l.next(attempt)
+}
+
+// Submit передаёт ответ на текущий шаг (код или пароль) и возвращает следующий.
+func (l *TelegramLogin) Submit(input string) (string, error) {
+	l.mu.Lock()
+	attempt := l.attempt
+	l.mu.Unlock()
+	if attempt == nil {
+		return "", ErrLoginNotStarted
+	}
+
+	select {
+	case attempt.inputs <- input:
+	case <-attempt.done:
+		return "", ErrLoginNotStarted
+	}
+	return l.next(attempt)
+}
+
+// Cancel прерывает незавершённый вход.
+func (l *TelegramLogin) Cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.attempt != nil {
+		l.attempt.cancel()
+		l.attempt = nil
+	}
+}
+
+func (l *TelegramLogin) next(attempt *loginAttempt) (string, error) {
+	select {
+	case res := <-attempt.steps:
+		return res.step, res.err
+	case <-attempt.done:
+		// результат мог прийти одновременно с завершением
+		select {
+		case res := <-attempt.steps:
+			return res.step, res.err
+		default:
+			return "", ErrLoginNotStarted
+		}
+	}
+}
+
+func (l *TelegramLogin) run(ctx context.Context, attempt *loginAttempt, phone string) {
+	defer close(attempt.done)
+	defer attempt.cancel()
+
+	memory := &session.StorageMemory{}
+	client, waiter, err := createTgClient(memory, nil)
+	if err == nil {
+		err = waiter.Run(ctx, func(ctx context.Context) error {
+			return client.Run(ctx, func(ctx context.Context) error {
+				return loginFlow(ctx, client.Auth(), attempt, phone)
+			})
+		})
+	}
+	if err == nil {
+		err = saveLoginSession(context.Background(), memory, l.sessions)
+	}
+	if err != nil {
+		log.Errorf("Telegram login failed: %v", err)
+		attempt.report(loginResult{err: err})
+		return
+	}
+
+	log.Info("Telegram login completed, session saved")
+	attempt.report(loginResult{step: LoginStepDone})
+	if l.onLogin != nil {
+		l.onLogin()
+	}
+}
+
+// saveLoginSession копирует сессию успешного входа в общее хранилище.
+func saveLoginSession(ctx context.Context, from *session.StorageMemory, to telegram.SessionStorage) error {
+	data, err := from.LoadSession(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load login session")
+	}
+	return errors.Wrap(to.StoreSession(ctx, data), "store login session")
+}
+
+// report отдаёт результат шага, не блокируясь, если его уже никто не ждёт.
+func (a *loginAttempt) report(res loginResult) {
+	select {
+	case a.steps <- res:
+	default:
+	}
+}
+
+// wait - ответ админа на текущий шаг.
+func (a *loginAttempt) wait(ctx context.Context) (string, error) {
+	select {
+	case input := <-a.inputs:
+		return input, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func loginFlow(ctx context.Context, client loginClient, attempt *loginAttempt, phone string) error {
+	sent, err := client.SendCode(ctx, phone, auth.SendCodeOptions{})
+	if err != nil {
+		return errors.Wrap(err, "send code")
+	}
+	sentCode, ok := sent.(*tg.AuthSentCode)
+	if !ok {
+		// AuthSentCodeSuccess: Telegram авторизовал без кода
+		return nil
+	}
+
+	attempt.report(loginResult{step: LoginStepCode})
+	for {
+		code, err := attempt.wait(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = client.SignIn(ctx, phone, loginCodeDigits(code), sentCode.PhoneCodeHash)
+		if errors.Is(err, auth.ErrPasswordAuthNeeded) {
+			break
+		}
+		if tgerr.Is(err, "PHONE_CODE_INVALID") {
+			attempt.report(loginResult{step: LoginStepCode, err: ErrLoginInvalidCode})
+			continue
+		}
+		return err
+	}
+
+	attempt.report(loginResult{step: LoginStepPassword})
+	for {
+		password, err := attempt.wait(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = client.Password(ctx, password)
+		if errors.Is(err, auth.ErrPasswordInvalid) {
+			attempt.report(loginResult{step: LoginStepPassword, err: ErrLoginInvalidPass})
+			continue
+		}
+		return err
+	}
+}
+
+// loginCodeDigits оставляет в коде только цифры: код просят прислать с
+// пробелами, потому что присланный в чат целиком Telegram сразу аннулирует.
+func loginCodeDigits(code string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, code)
+}
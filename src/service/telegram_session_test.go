@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// fakeLoginClient принимает код "12345" и пароль "hunter2".
+type fakeLoginClient struct {
+	needPassword bool
+	codes        []string
+}
+
+func (f *fakeLoginClient) SendCode(ctx context.Context, phone string, options auth.SendCodeOptions) (tg.AuthSentCodeClass, error) {
+	return &tg.AuthSentCode{PhoneCodeHash: "hash"}, nil
+}
+
+func (f *fakeLoginClient) SignIn(ctx context.Context, phone, code, codeHash string) (*tg.AuthAuthorization, error) {
+	f.codes = append(f.codes, code)
+	if code != "12345" {
+		return nil, tgerr.New(400, "PHONE_CODE_INVALID")
+	}
+	if f.needPassword {
+		return nil, auth.ErrPasswordAuthNeeded
+	}
+	return &tg.AuthAuthorization{}, nil
+}
+
+func (f *fakeLoginClient) Password(ctx context.Context, password string) (*tg.AuthAuthorization, error) {
+	if password != "hunter2" {
+		return nil, auth.ErrPasswordInvalid
+	}
+	return &tg.AuthAuthorization{}, nil
+}
+
+func newTestLoginAttempt() *loginAttempt {
+	return &loginAttempt{
+		inputs: make(chan string),
+		steps:  make(chan loginResult, 1),
+		done:   make(chan struct{}),
+		cancel: func() {},
+	}
+}
+
+func TestLoginFlow_CodeRetryAndPassword(t *testing.T) {
+	client := &fakeLoginClient{needPassword: true}
+	attempt := newTestLoginAttempt()
+
+	result := make(chan error, 1)
+	go func() { result <- loginFlow(context.Background(), client, attempt, "+79991234567") }()
+
+	res := <-attempt.steps
+	assert.Equal(t, loginResult{step: LoginStepCode}, res)
+
+	attempt.inputs <- "5 4 3 2 1"
+	res = <-attempt.steps
+	assert.Equal(t, LoginStepCode, res.step)
+	assert.ErrorIs(t, res.err, ErrLoginInvalidCode)
+
+	attempt.inputs <- "1 2 3 4 5"
+	res = <-attempt.steps
+	assert.Equal(t, loginResult{step: LoginStepPassword}, res)
+
+	attempt.inputs <- "wrong"
+	res = <-attempt.steps
+	assert.ErrorIs(t, res.err, ErrLoginInvalidPass)
+
+	attempt.inputs <- "hunter2"
+	require.NoError(t, <-result)
+	// пробелы, которыми админ разделяет цифры, в Telegram не уходят
+	assert.Equal(t, []string{"54321", "12345"}, client.codes)
+}
+
+func TestLoginFlow_Cancelled(t *testing.T) {
+	attempt := newTestLoginAttempt()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := make(chan error, 1)
+	go func() { result <- loginFlow(ctx, &fakeLoginClient{}, attempt, "+79991234567") }()
+
+	<-attempt.steps
+	cancel()
+	assert.ErrorIs(t, <-result, context.Canceled)
+}
+
+func TestTelegramLogin_SubmitWithoutStart(t *testing.T) {
+	login := NewTelegramLogin(nil, nil)
+	_, err := login.Submit("12345")
+	assert.ErrorIs(t, err, ErrLoginNotStarted)
+}
+
+// memorySession - telegram.SessionStorage в памяти вместо session.json.
+type memorySession struct {
+	data []byte
+}
+
+func (m *memorySession) LoadSession(ctx context.Context) ([]byte, error) {
+	if m.data == nil {
+		return nil, session.ErrNotFound
+	}
+	return m.data, nil
+}
+
+func (m *memorySession) StoreSession(ctx context.Context, data []byte) error {
+	m.data = data
+	return nil
+}
+
+func TestImportFileSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	storage := mock_repository.NewMockSessionRepositoryInterface(ctrl)
+	file := &memorySession{data: []byte("from file")}
+
+	// в базе пусто - сессия из файла переносится
+	storage.EXPECT().LoadSession(ctx).Return(nil, session.ErrNotFound)
+	storage.EXPECT().StoreSession(ctx, []byte("from file")).Return(nil)
+	require.NoError(t, importFileSession(ctx, storage, file))
+
+	// в базе уже есть сессия - файл не трогается
+	storage.EXPECT().LoadSession(ctx).Return([]byte("from db"), nil)
+	require.NoError(t, importFileSession(ctx, storage, file))
+
+	// нет ни базы, ни файла
+	storage.EXPECT().LoadSession(ctx).Return(nil, session.ErrNotFound)
+	require.NoError(t, importFileSession(ctx, storage, &memorySession{}))
+
+	// ошибка расшифровки не затирается файлом
+	storage.EXPECT().LoadSession(ctx).Return(nil, errors.New("decrypt session"))
+	assert.Error(t, importFileSession(ctx, storage, file))
+}
+
+func TestSaveLoginSession(t *testing.T) {
+	ctx := context.Background()
+	shared := &memorySession{data: []byte("working")}
+
+	// вход не дошёл до сессии - рабочая сессия не затирается
+	assert.Error(t, saveLoginSession(ctx, &session.StorageMemory{}, shared))
+	assert.Equal(t, []byte("working"), shared.data)
+
+	login := &session.StorageMemory{}
+	require.NoError(t, login.StoreSession(ctx, []byte("logged in")))
+	require.NoError(t, saveLoginSession(ctx, login, shared))
+	assert.Equal(t, []byte("logged in"), shared.data)
+}
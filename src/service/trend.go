@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+)
+
+const (
+	trendPeriodDays      = 7
+	trendTopLimit        = 5
+	trendEscalationLimit = 3
+	trendClosedLimit     = 5
+)
+
+// CategoryTrend - доля рубрики в сообщениях за неделю и за предыдущую неделю.
+type CategoryTrend struct {
+	Category  string
+	Share     float64
+	PrevShare float64
+}
+
+// TrendReport - недельный отчёт «что было главным» по каналу.
+type TrendReport struct {
+	ChannelID   int64
+	From        time.Time // включительно
+	To          time.Time // не включительно
+	Top         []repository.StorylineTrend
+	Escalations []repository.StorylineTrend
+	Categories  []CategoryTrend
+	Closed      []repository.StorylineTrend
+}
+
+// TrendService строит аналитику канала по storyline_observations.
+type TrendService struct {
+	trendRepo repository.TrendRepositoryInterface
+}
+
+func NewTrendService(trendRepo repository.TrendRepositoryInterface) *TrendService {
+	return &TrendService{trendRepo: trendRepo}
+}
+
+// WeeklyReport собирает отчёт за 7 полных UTC-дней до now.
+func (s *TrendService) WeeklyReport(channelID int64, now time.Time) (*TrendReport, error) {
+	to := truncateToDay(now)
+	from := to.AddDate(0, 0, -trendPeriodDays)
+	prevFrom := from.AddDate(0, 0, -trendPeriodDays)
+
+	top, err := s.trendRepo.TopStorylines(channelID, from, to, trendTopLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top storylines: %w", err)
+	}
+	escalations, err := s.trendRepo.TopEscalations(channelID, from, to, trendEscalationLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalated storylines: %w", err)
+	}
+	shares, err := s.trendRepo.CategoryShares(channelID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category shares: %w", err)
+	}
+	prevShares, err := s.trendRepo.CategoryShares(channelID, prevFrom, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous category shares: %w", err)
+	}
+	// Сюжет закрывается через ClosedAfterDays после last_seen,
+	// значит за неделю закрылись те, чей last_seen на столько же дней раньше.
+	closed, err := s.trendRepo.ClosedStorylines(channelID,
+		from.AddDate(0, 0, -config.ClosedAfterDays), to.AddDate(0, 0, -config.ClosedAfterDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closed storylines: %w", err)
+	}
+	if len(closed) > trendClosedLimit {
+		closed = closed[:trendClosedLimit]
+	}
+
+	return &TrendReport{
+		ChannelID:   channelID,
+		From:        from,
+		To:          to,
+		Top:         top,
+		Escalations: escalations,
+		Categories:  categoryTrends(shares, prevShares),
+		Closed:      closed,
+	}, nil
+}
+
+// WeeklyReportText - WeeklyReport, сразу отформатированный для Telegram.
+func (s *TrendService) WeeklyReportText(channelID int64, now time.Time) (string, error) {
+	report, err := s.WeeklyReport(channelID, now)
+	if err != nil {
+		return "", err
+	}
+	return report.Format(), nil
+}
+
+// IsEmpty - за период не было ни одного наблюдения.
+func (r *TrendReport) IsEmpty() bool {
+	return len(r.Top) == 0 && len(r.Closed) == 0
+}
+
+// Format рендерит отчёт в Markdown для Telegram.
+func (r *TrendReport) Format() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("*Главное за неделю %s–%s*",
+		r.From.Format("02.01"), r.To.AddDate(0, 0, -1).Format("02.01")))
+	if name, ok := config.ChannelNames()[r.ChannelID]; ok {
+		b.WriteString(" (" + name + ")")
+	}
+	b.WriteString("\n")
+
+	if r.IsEmpty() {
+		b.WriteString("\nЗа неделю сюжетов не было.")
+		return b.String()
+	}
+
+	if len(r.Top) > 0 {
+		b.WriteString("\n*Главные сюжеты*\n")
+		for i, t := range r.Top {
+			b.WriteString(fmt.Sprintf("%d. %s — %d сообщ. за %d дн., важность до %d\n",
+				i+1, t.Title, t.TotalMessages, t.DaysSeen, t.MaxImportance))
+		}
+	}
+
+	if len(r.Categories) > 0 {
+		b.WriteString("\n*Рубрики* (доля сообщений, к прошлой неделе)\n")
+		for _, c := range r.Categories {
+			b.WriteString(fmt.Sprintf("%s: %.0f%% (%s)\n", categoryLabel(c.Category), c.Share*100, formatShareDelta(c.Share-c.PrevShare)))
+		}
+	}
+
+	if len(r.Escalations) > 0 {
+		b.WriteString("\n*Эскалации*\n")
+		for _, t := range r.Escalations {
+			b.WriteString(fmt.Sprintf("— %s: эскалация %d дн. из %d\n", t.Title, t.EscalationDays, t.DaysSeen))
+		}
+	}
+
+	if len(r.Closed) > 0 {
+		b.WriteString("\n*Сошли с повестки*\n")
+		for _, t := range r.Closed {
+			b.WriteString(fmt.Sprintf("— %s (%s–%s)\n", t.Title, t.FirstSeen.Format("02.01"), t.LastSeen.Format("02.01")))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// categoryTrends переводит объёмы рубрик в доли и сопоставляет с прошлой неделей.
+func categoryTrends(shares, prevShares []repository.CategoryShare) []CategoryTrend {
+	total := 0
+	for _, c := range shares {
+		total += c.TotalMessages
+	}
+	prevTotal := 0
+	prev := make(map[string]int, len(prevShares))
+	for _, c := range prevShares {
+		prevTotal += c.TotalMessages
+		prev[c.Category] = c.TotalMessages
+	}
+	if total == 0 {
+		return nil
+	}
+
+	trends := make([]CategoryTrend, 0, len(shares))
+	for _, c := range shares {
+		t := CategoryTrend{Category: c.Category, Share: float64(c.TotalMessages) / float64(total)}
+		if prevTotal > 0 {
+			t.PrevShare = float64(prev[c.Category]) / float64(prevTotal)
+		}
+		trends = append(trends, t)
+	}
+	return trends
+}
+
+func categoryLabel(category string) string {
+	if category == "" {
+		return "без рубрики"
+	}
+	return category
+}
+
+// formatShareDelta печатает изменение доли в процентных пунктах.
+func formatShareDelta(delta float64) string {
+	pp := math.Round(delta * 100)
+	switch {
+	case pp > 0:
+		return fmt.Sprintf("+%.0f п.п.", pp)
+	case pp < 0:
+		return fmt.Sprintf("%.0f п.п.", pp)
+	default:
+		return "без изменений"
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTrendService_WeeklyReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trendRepo := mock_repository.NewMockTrendRepositoryInterface(ctrl)
+	trendService := NewTrendService(trendRepo)
+
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC) // воскресенье
+	to := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+
+	trendRepo.EXPECT().TopStorylines(int64(1429590454), from, to, 5).Return([]repository.StorylineTrend{
+		{StorylineID: 1, Title: "Переговоры", TotalMessages: 30, DaysSeen: 6, MaxImportance: 4, EscalationDays: 2},
+		{StorylineID: 2, Title: "Паводок", TotalMessages: 12, DaysSeen: 3, MaxImportance: 3},
+	}, nil)
+	trendRepo.EXPECT().TopEscalations(int64(1429590454), from, to, 3).Return([]repository.StorylineTrend{
+		{StorylineID: 1, Title: "Переговоры", EscalationDays: 2, DaysSeen: 6},
+	}, nil)
+	trendRepo.EXPECT().CategoryShares(int64(1429590454), from, to).Return([]repository.CategoryShare{
+		{Category: "политика", TotalMessages: 30},
+		{Category: "происшествия", TotalMessages: 10},
+	}, nil)
+	trendRepo.EXPECT().CategoryShares(int64(1429590454), from.AddDate(0, 0, -7), from).Return([]repository.CategoryShare{
+		{Category: "политика", TotalMessages: 10},
+		{Category: "происшествия", TotalMessages: 10},
+	}, nil)
+	trendRepo.EXPECT().ClosedStorylines(int64(1429590454), from.AddDate(0, 0, -30), to.AddDate(0, 0, -30)).Return([]repository.StorylineTrend{
+		{StorylineID: 3, Title: "Забастовка", FirstSeen: from.AddDate(0, 0, -60), LastSeen: from.AddDate(0, 0, -29)},
+	}, nil)
+
+	report, err := trendService.WeeklyReport(1429590454, now)
+	require.NoError(t, err)
+	require.Len(t, report.Categories, 2)
+	assert.InDelta(t, 0.75, report.Categories[0].Share, 1e-9)
+	assert.InDelta(t, 0.5, report.Categories[0].PrevShare, 1e-9)
+
+	text := report.Format()
+	assert.Contains(t, text, "Главное за неделю 11.10–17.10")
+	assert.Contains(t, text, "kontext_channel")
+	assert.Contains(t, text, "1. Переговоры — 30 сообщ. за 6 дн., важность до 4")
+	assert.Contains(t, text, "политика: 75% (+25 п.п.)")
+	assert.Contains(t, text, "происшествия: 25% (-25 п.п.)")
+	assert.Contains(t, text, "Переговоры: эскалация 2 дн. из 6")
+	assert.Contains(t, text, "Забастовка")
+}
+
+func TestTrendReport_FormatEmpty(t *testing.T) {
+	report := &TrendReport{
+		ChannelID: 1,
+		From:      time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+	}
+	assert.True(t, report.IsEmpty())
+	assert.Equal(t, "*Главное за неделю 11.10–17.10*\n\nЗа неделю сюжетов не было.", report.Format())
+}
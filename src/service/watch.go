@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/textutil"
+	log "github.com/sirupsen/logrus"
+	tele "gopkg.in/telebot.v4"
+)
+
+// WatchService проверяет новые сообщения каналов на подписки читателей (/watch)
+// и присылает совпавший отрывок со ссылкой на пост. Уведомления читателю ограничены
+// config.WatchMaxPerHour за скользящий час.
+type WatchService struct {
+	watchRepo repository.WatchRepositoryInterface
+	bot       BotSender
+	limiter   *watchLimiter
+}
+
+func NewWatchService(watchRepo repository.WatchRepositoryInterface, bot BotSender) *WatchService {
+	return &WatchService{
+		watchRepo: watchRepo,
+		bot:       bot,
+		limiter:   newWatchLimiter(config.WatchMaxPerHour, time.Hour),
+	}
+}
+
+// ParseWatchPattern разбирает аргумент /watch: "/regex/" - регулярное выражение,
+// иначе слово или фраза. Возвращает шаблон в том виде, в каком он хранится.
+func ParseWatchPattern(input string) (pattern string, isRegex bool, err error) {
+	input = strings.TrimSpace(input)
+	if utf8.RuneCountInString(input) > config.WatchMaxPatternLen {
+		return "", false, fmt.Errorf("шаблон длиннее %d символов", config.WatchMaxPatternLen)
+	}
+	if len(input) > 2 && strings.HasPrefix(input, "/") && strings.HasSuffix(input, "/") {
+		pattern = input[1 : len(input)-1]
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return "", false, fmt.Errorf("некорректное регулярное выражение: %w", err)
+		}
+		return pattern, true, nil
+	}
+	if len(textutil.Tokenize(input)) == 0 {
+		return "", false, errors.New("нужно слово или фраза")
+	}
+	return strings.Join(strings.Fields(input), " "), false, nil
+}
+
+// Notify проверяет сообщения канала, впервые сохранённые MessageService.
+func (s *WatchService) Notify(channelID int64, messages []*repository.Message) {
+	subs, err := s.watchRepo.GetAllSubscriptions()
+	if err != nil {
+		log.Errorf("Error getting watch subscriptions: %v", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	matchers := make([]*watchMatcher, 0, len(subs))
+	for _, sub := range subs {
+		m, err := newWatchMatcher(sub)
+		if err != nil {
+			log.Errorf("Skipping watch subscription %d: %v", sub.ID, err)
+			continue
+		}
+		matchers = append(matchers, m)
+	}
+
+	now := time.Now().UTC()
+	for _, msg := range messages {
+		tokens := textutil.Tokenize(msg.MessageText)
+		stems := make([]string, len(tokens))
+		for i, t := range tokens {
+			stems[i] = textutil.StemRussian(t.Word)
+		}
+
+		// одно уведомление на читателя и сообщение, даже если совпало несколько подписок
+		notified := make(map[int64]bool)
+		for _, m := range matchers {
+			if notified[m.sub.ChatID] || msg.MessageDate.Before(m.sub.CreatedAt) {
+				continue
+			}
+			start, end, ok := m.match(msg.MessageText, tokens, stems)
+			if !ok {
+				continue
+			}
+			notified[m.sub.ChatID] = true
+			s.send(m.sub, channelID, msg, start, end, now)
+		}
+	}
+}
+
+func (s *WatchService) send(sub repository.WatchSubscription, channelID int64, msg *repository.Message, start, end int, now time.Time) {
+	allowed, skipped := s.limiter.allow(sub.ChatID, now)
+	if !allowed {
+		return
+	}
+
+	var b strings.Builder
+	if skipped > 0 {
+		b.WriteString(fmt.Sprintf("Пропущено совпадений из-за лимита %d в час: %d\n\n", config.WatchMaxPerHour, skipped))
+	}
+	b.WriteString(fmt.Sprintf("🔔 %s в %s:\n\n%s", FormatWatchPattern(sub), ChannelLabel(channelID), watchExcerpt(msg.MessageText, start, end)))
+	if url := PostURL(channelID, int64(msg.MessageID), msg.Meta.URL); url != "" {
+		b.WriteString("\n\n" + url)
+	}
+
+	if _, err := s.bot.Send(&tele.User{ID: sub.ChatID}, b.String()); err != nil {
+		log.Errorf("Error sending watch match to user %d: %v", sub.ChatID, err)
+	}
+}
+
+// FormatWatchPattern - подписка так, как её вводит читатель.
+func FormatWatchPattern(sub repository.WatchSubscription) string {
+	if sub.IsRegex {
+		return "/" + sub.Pattern + "/"
+	}
+	return "«" + sub.Pattern + "»"
+}
+
+// watchExcerpt вырезает до config.WatchExcerptRunes символов вокруг совпадения [start, end).
+func watchExcerpt(text string, start, end int) string {
+	runes := []rune(text)
+	from := utf8.RuneCountInString(text[:start])
+	matchLen := utf8.RuneCountInString(text[start:end])
+
+	// совпадение примерно в первой трети отрывка
+	lo := max(0, from-(config.WatchExcerptRunes-matchLen)/3)
+	hi := min(len(runes), lo+config.WatchExcerptRunes)
+	lo = max(0, min(lo, hi-config.WatchExcerptRunes))
+
+	excerpt := strings.TrimSpace(string(runes[lo:hi]))
+	if lo > 0 {
+		excerpt = "…" + excerpt
+	}
+	if hi < len(runes) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+// watchTerm - слово подписки и его основа.
+type watchTerm struct {
+	word, stem string
+}
+
+type watchMatcher struct {
+	sub   repository.WatchSubscription
+	re    *regexp.Regexp
+	terms []watchTerm
+}
+
+func newWatchMatcher(sub repository.WatchSubscription) (*watchMatcher, error) {
+	m := &watchMatcher{sub: sub}
+	if sub.IsRegex {
+		re, err := regexp.Compile("(?i)" + sub.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+		return m, nil
+	}
+	for _, t := range textutil.Tokenize(sub.Pattern) {
+		m.terms = append(m.terms, watchTerm{word: t.Word, stem: textutil.StemRussian(t.Word)})
+	}
+	if len(m.terms) == 0 {
+		return nil, fmt.Errorf("empty pattern %q", sub.Pattern)
+	}
+	return m, nil
+}
+
+// match возвращает байтовые границы первого совпадения в text. Слова фразы должны
+// идти подряд; слово совпадает, если равны основы или основа слова текста равна
+// слову подписки (Snowball режет "газпром" до "газпр", а "газпрома" - до "газпром").
+func (m *watchMatcher) match(text string, tokens []textutil.Token, stems []string) (int, int, bool) {
+	if m.re != nil {
+		loc := m.re.FindStringIndex(text)
+		if loc == nil {
+			return 0, 0, false
+		}
+		return loc[0], loc[1], true
+	}
+
+	for i := 0; i+len(m.terms) <= len(tokens); i++ {
+		ok := true
+		for j, term := range m.terms {
+			word, stem := tokens[i+j].Word, stems[i+j]
+			if word != term.word && stem != term.stem && stem != term.word {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return tokens[i].Start, tokens[i+len(m.terms)-1].End, true
+		}
+	}
+	return 0, 0, false
+}
+
+// watchLimiter - скользящее окно уведомлений на читателя (в памяти, сбрасывается при рестарте).
+type watchLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	sent    map[int64][]time.Time
+	skipped map[int64]int
+}
+
+func newWatchLimiter(limit int, window time.Duration) *watchLimiter {
+	return &watchLimiter{
+		limit:   limit,
+		window:  window,
+		sent:    make(map[int64][]time.Time),
+		skipped: make(map[int64]int),
+	}
+}
+
+// allow отмечает отправку, если лимит не исчерпан; skipped - сколько уведомлений
+// было пропущено с прошлой разрешённой отправки.
+func (l *watchLimiter) allow(chatID int64, now time.Time) (allowed bool, skipped int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.sent[chatID]
+	cut := sort.Search(len(recent), func(i int) bool { return recent[i].After(now.Add(-l.window)) })
+	recent = recent[cut:]
+
+	if len(recent) >= l.limit {
+		l.sent[chatID] = recent
+		l.skipped[chatID]++
+		return false, 0
+	}
+	l.sent[chatID] = append(recent, now)
+	skipped = l.skipped[chatID]
+	delete(l.skipped, chatID)
+	return true, skipped
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+	mock_repository "github.com/Ra1ze505/goNewsBot/src/mocks/repository"
+	mock_telebot "github.com/Ra1ze505/goNewsBot/src/mocks/telebot"
+	"github.com/Ra1ze505/goNewsBot/src/repository"
+	"github.com/Ra1ze505/goNewsBot/src/textutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	tele "gopkg.in/telebot.v4"
+)
+
+func matchWatch(t *testing.T, sub repository.WatchSubscription, text string) (string, bool) {
+	t.Helper()
+	m, err := newWatchMatcher(sub)
+	require.NoError(t, err)
+	tokens := textutil.Tokenize(text)
+	stems := make([]string, len(tokens))
+	for i, tok := range tokens {
+		stems[i] = textutil.StemRussian(tok.Word)
+	}
+	start, end, ok := m.match(text, tokens, stems)
+	if !ok {
+		return "", false
+	}
+	return text[start:end], true
+}
+
+func TestWatchMatcher_WordForms(t *testing.T) {
+	sub := repository.WatchSubscription{Pattern: "газпром"}
+
+	for _, text := range []string{
+		"Акции Газпрома выросли",
+		"Сделка с газпромом сорвалась",
+		"ГАЗПРОМ объявил дивиденды",
+	} {
+		_, ok := matchWatch(t, sub, text)
+		assert.True(t, ok, text)
+	}
+
+	_, ok := matchWatch(t, sub, "Газпромнефть отчиталась")
+	assert.False(t, ok, "другое слово с тем же началом не совпадает")
+}
+
+func TestWatchMatcher_Phrase(t *testing.T) {
+	sub := repository.WatchSubscription{Pattern: "центральный банк"}
+
+	matched, ok := matchWatch(t, sub, "Решение Центрального банка по ставке")
+	assert.True(t, ok)
+	assert.Equal(t, "Центрального банка", matched)
+
+	_, ok = matchWatch(t, sub, "Банк центральный не менял ставку")
+	assert.False(t, ok, "слова фразы должны идти подряд")
+}
+
+func TestWatchMatcher_Regex(t *testing.T) {
+	matched, ok := matchWatch(t, repository.WatchSubscription{Pattern: `сбер(банк)?`, IsRegex: true}, "Новости СБЕРБАНКА")
+	assert.True(t, ok)
+	assert.Equal(t, "СБЕРБАНК", matched)
+}
+
+func TestParseWatchPattern(t *testing.T) {
+	pattern, isRegex, err := ParseWatchPattern("  Центральный   банк ")
+	require.NoError(t, err)
+	assert.Equal(t, "Центральный банк", pattern)
+	assert.False(t, isRegex)
+
+	pattern, isRegex, err = ParseWatchPattern("/сбер(банк)?/")
+	require.NoError(t, err)
+	assert.Equal(t, "сбер(банк)?", pattern)
+	assert.True(t, isRegex)
+
+	_, _, err = ParseWatchPattern("/сбер(/")
+	assert.Error(t, err)
+	_, _, err = ParseWatchPattern("!!!")
+	assert.Error(t, err)
+	_, _, err = ParseWatchPattern(strings.Repeat("а", config.WatchMaxPatternLen+1))
+	assert.Error(t, err)
+}
+
+func TestWatchExcerpt(t *testing.T) {
+	text := strings.Repeat("до ", 200) + "Газпром" + strings.Repeat(" после", 200)
+	start := strings.Index(text, "Газпром")
+
+	excerpt := watchExcerpt(text, start, start+len("Газпром"))
+
+	assert.Contains(t, excerpt, "Газпром")
+	assert.True(t, strings.HasPrefix(excerpt, "…"))
+	assert.True(t, strings.HasSuffix(excerpt, "…"))
+	assert.LessOrEqual(t, len([]rune(excerpt)), config.WatchExcerptRunes+2)
+
+	short := "Коротко о Газпроме"
+	i := strings.Index(short, "Газпроме")
+	assert.Equal(t, short, watchExcerpt(short, i, i+len("Газпроме")))
+}
+
+func TestWatchLimiter(t *testing.T) {
+	l := newWatchLimiter(2, time.Hour)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	ok, _ := l.allow(1, now)
+	assert.True(t, ok)
+	ok, _ = l.allow(1, now.Add(time.Minute))
+	assert.True(t, ok)
+	ok, _ = l.allow(1, now.Add(2*time.Minute))
+	assert.False(t, ok)
+	ok, _ = l.allow(2, now.Add(2*time.Minute))
+	assert.True(t, ok, "лимит у каждого читателя свой")
+
+	// через час первое уведомление выходит из окна
+	ok, skipped := l.allow(1, now.Add(time.Hour+time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestWatchService_NotifySendsOncePerUserAndMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	watchRepo := mock_repository.NewMockWatchRepositoryInterface(ctrl)
+	bot := mock_telebot.NewMockBot(ctrl)
+
+	subscribed := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	watchRepo.EXPECT().GetAllSubscriptions().Return([]repository.WatchSubscription{
+		{ID: 1, ChatID: 555, Pattern: "газпром", CreatedAt: subscribed},
+		{ID: 2, ChatID: 555, Pattern: "газ", IsRegex: true, CreatedAt: subscribed},
+		{ID: 3, ChatID: 777, Pattern: "нефть", CreatedAt: subscribed},
+	}, nil)
+	bot.EXPECT().Send(&tele.User{ID: 555}, gomock.Any()).DoAndReturn(func(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error) {
+		text := what.(string)
+		assert.Contains(t, text, "«газпром» в @topor_live")
+		assert.Contains(t, text, "Акции Газпрома выросли")
+		assert.Contains(t, text, "https://t.me/topor_live/42")
+		return &tele.Message{}, nil
+	})
+
+	s := NewWatchService(watchRepo, bot)
+	s.Notify(1754252633, []*repository.Message{
+		{ChannelID: 1754252633, MessageID: 42, MessageText: "Акции Газпрома выросли", MessageDate: subscribed.Add(time.Minute)},
+		// сообщение до подписки не проверяется
+		{ChannelID: 1754252633, MessageID: 41, MessageText: "Газпром и нефть", MessageDate: subscribed.Add(-time.Minute)},
+	})
+}
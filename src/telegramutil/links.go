@@ -0,0 +1,52 @@
+package telegramutil
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+var markdownLinkRe = regexp.MustCompile(`\[([^\[\]\n]+)\]\((https?://[^\s()]+)\)`)
+
+// LinkEntities replaces Markdown links [label](url) with their labels and returns
+// text_link entities for them. It is used when a message is sent without parse mode,
+// so that links survive a Markdown parsing failure. Offsets are in UTF-16 code units.
+func LinkEntities(text string) (string, tele.Entities) {
+	matches := markdownLinkRe.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	var entities tele.Entities
+	offset, last := 0, 0
+	for _, m := range matches {
+		before := text[last:m[0]]
+		label := text[m[2]:m[3]]
+		b.WriteString(before)
+		offset += utf16Len(before)
+		b.WriteString(label)
+		entities = append(entities, tele.MessageEntity{
+			Type:   tele.EntityTextLink,
+			Offset: offset,
+			Length: utf16Len(label),
+			URL:    text[m[4]:m[5]],
+		})
+		offset += utf16Len(label)
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String(), entities
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// PostURL returns the public link to a message of a channel with the given username.
+func PostURL(username string, messageID int64) string {
+	return fmt.Sprintf("https://t.me/%s/%d", username, messageID)
+}
@@ -0,0 +1,39 @@
+package telegramutil
+
+import (
+	"testing"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+func TestLinkEntitiesWithoutLinksUnchanged(t *testing.T) {
+	text, entities := LinkEntities("Просто текст [без ссылки]")
+
+	if text != "Просто текст [без ссылки]" {
+		t.Fatalf("expected unchanged text, got %q", text)
+	}
+	if entities != nil {
+		t.Fatalf("expected no entities, got %v", entities)
+	}
+}
+
+func TestLinkEntitiesUsesUTF16Offsets(t *testing.T) {
+	text, entities := LinkEntities("🆕 Выборы [источник](https://t.me/topor_live/12)\nДалее [ещё](https://t.me/topor_live/13).")
+
+	if text != "🆕 Выборы источник\nДалее ещё." {
+		t.Fatalf("unexpected text %q", text)
+	}
+	expected := tele.Entities{
+		// the emoji takes two UTF-16 code units
+		{Type: tele.EntityTextLink, Offset: 10, Length: 8, URL: "https://t.me/topor_live/12"},
+		{Type: tele.EntityTextLink, Offset: 25, Length: 3, URL: "https://t.me/topor_live/13"},
+	}
+	if len(entities) != len(expected) {
+		t.Fatalf("expected %d entities, got %d", len(expected), len(entities))
+	}
+	for i := range expected {
+		if entities[i] != expected[i] {
+			t.Fatalf("entity %d: expected %+v, got %+v", i, expected[i], entities[i])
+		}
+	}
+}
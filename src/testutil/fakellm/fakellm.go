@@ -0,0 +1,308 @@
+// Package fakellm - детерминированный OpenAI-совместимый LLM-сервер для тестов
+// конвейера: чат-комплишены отвечают по скриптованным фикстурам, эмбеддинги
+// считаются хешированным мешком слов (близкие по словам тексты - близкие векторы).
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/Ra1ze505/goNewsBot/src/config"
+)
+
+// Rule - скриптованный ответ чат-комплишена. Срабатывает первое правило, у которого
+// System входит в системный промпт, а все строки User - в пользовательский.
+type Rule struct {
+	Name     string          `json:"name"`
+	System   string          `json:"system"`
+	User     []string        `json:"user"`
+	Response json.RawMessage `json:"response"` // строка отдаётся как есть, объект/массив - как JSON-текст
+}
+
+// Fixtures - набор правил; правила проверяются по порядку.
+type Fixtures struct {
+	Chat []Rule `json:"chat"`
+}
+
+// LoadFixtures читает фикстуры из JSON-файла.
+func LoadFixtures(path string) (Fixtures, error) {
+	var f Fixtures
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Request - записанный запрос чат-комплишена.
+type Request struct {
+	Rule   string // имя сработавшего правила
+	Model  string
+	System string
+	User   string
+}
+
+// Server - in-process фейк /chat/completions и /embeddings.
+type Server struct {
+	server *httptest.Server
+	rules  []Rule
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// New запускает сервер; он останавливается в t.Cleanup.
+func New(t testing.TB, fixtures Fixtures) *Server {
+	t.Helper()
+	s := &Server{rules: fixtures.Chat}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat/completions", s.handleChat)
+	mux.HandleFunc("/embeddings", s.handleEmbeddings)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// URL - базовый URL для OpenAI-клиента (со слешем на конце).
+func (s *Server) URL() string {
+	return s.server.URL + "/"
+}
+
+// Setenv направляет NewMLRepository на фейк: все стадии через провайдера yandex
+// с базовым URL сервера (YANDEX_OPENAI_BASE_URL) и дефолтными моделями.
+func (s *Server) Setenv(t testing.TB) {
+	t.Helper()
+	t.Setenv("YANDEX_OPENAI_BASE_URL", s.URL())
+	t.Setenv("YANDEX_FOLDER_ID", "fake-folder")
+	t.Setenv("YANDEX_API_KEY", "fake-key")
+	t.Setenv("YANDEX_MODEL_URI", "")
+	t.Setenv("YANDEX_EMBED_DOC_URI", "")
+	t.Setenv("YANDEX_EMBED_QUERY_URI", "")
+	t.Setenv("LLM_PROVIDER", "")
+	for _, stage := range config.LLMStages {
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_PROVIDER", "")
+		t.Setenv("LLM_"+strings.ToUpper(stage)+"_MODEL", "")
+	}
+}
+
+// Requests возвращает записанные чат-запросы в порядке поступления.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor возвращает чат-запросы, на которые ответило правило с именем rule.
+func (s *Server) RequestsFor(rule string) []Request {
+	var result []Request
+	for _, r := range s.Requests() {
+		if r.Rule == rule {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+type chatRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var system, user string
+	for _, m := range req.Messages {
+		switch m.Role {
+		case "system":
+			system += m.Content
+		case "user":
+			user += m.Content
+		}
+	}
+
+	rule, ok := s.match(system, user)
+	if !ok {
+		writeError(w, http.StatusNotFound, "fakellm: no fixture for prompt: "+truncate(user, 200))
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Rule: rule.Name, Model: req.Model, System: system, User: user})
+	s.mu.Unlock()
+
+	content, err := responseText(rule.Response)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, map[string]any{
+		"id":      "fake",
+		"object":  "chat.completion",
+		"model":   req.Model,
+		"choices": []map[string]any{{"index": 0, "finish_reason": "stop", "message": map[string]string{"role": "assistant", "content": content}}},
+		"usage":   usage(Tokens(system)+Tokens(user), Tokens(content)),
+	})
+}
+
+func (s *Server) match(system, user string) (Rule, bool) {
+	for _, rule := range s.rules {
+		if !strings.Contains(system, rule.System) {
+			continue
+		}
+		matched := true
+		for _, u := range rule.User {
+			if !strings.Contains(user, u) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func responseText(raw json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	if len(raw) == 0 {
+		return "", fmt.Errorf("fakellm: empty fixture response")
+	}
+	return string(raw), nil
+}
+
+type embeddingRequest struct {
+	Model string          `json:"model"`
+	Input json.RawMessage `json:"input"`
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req embeddingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var texts []string
+	var single string
+	if err := json.Unmarshal(req.Input, &single); err == nil {
+		texts = []string{single}
+	} else if err := json.Unmarshal(req.Input, &texts); err != nil {
+		writeError(w, http.StatusBadRequest, "fakellm: input must be a string or an array of strings")
+		return
+	}
+
+	data := make([]map[string]any, len(texts))
+	tokens := 0
+	for i, text := range texts {
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": Embed(text)}
+		tokens += Tokens(text)
+	}
+	writeJSON(w, map[string]any{"object": "list", "model": req.Model, "data": data, "usage": usage(tokens, 0)})
+}
+
+// Tokens - условное число токенов текста для поля usage: по одному на слово.
+func Tokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+func usage(prompt, completion int) map[string]int {
+	return map[string]int{"prompt_tokens": prompt, "completion_tokens": completion, "total_tokens": prompt + completion}
+}
+
+// Embed - детерминированный эмбеддинг размерности config.EmbeddingDim: каждое слово
+// (в нижнем регистре, от 3 символов) хешируется в координату со знаком, вектор нормируется.
+// Косинусная близость двух текстов примерно равна доле общих слов.
+func Embed(text string) []float32 {
+	vec := make([]float64, config.EmbeddingDim)
+	for _, word := range words(text) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		sum := h.Sum32()
+		sign := 1.0
+		if sum&(1<<31) != 0 {
+			sign = -1
+		}
+		vec[int(sum%uint32(config.EmbeddingDim))] += sign
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(vec))
+	if norm == 0 {
+		return out
+	}
+	for i, v := range vec {
+		out[i] = float32(v / norm)
+	}
+	return out
+}
+
+// Cosine - косинусная близость, как её считает pgvector (1 - <=>).
+func Cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	result := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) >= 3 {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": message}})
+}
@@ -0,0 +1,73 @@
+package fakellm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAnswersFirstMatchingRule(t *testing.T) {
+	server := New(t, Fixtures{Chat: []Rule{
+		{Name: "json", System: "аналитик", User: []string{"рубль", "ставка"}, Response: json.RawMessage(`{"ok":true}`)},
+		{Name: "text", System: "аналитик", Response: json.RawMessage(`"просто текст"`)},
+	}})
+	client := openai.NewClient(option.WithBaseURL(server.URL()), option.WithAPIKey("k"))
+
+	ask := func(user string) string {
+		resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "m",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.SystemMessage("Ты аналитик"), openai.UserMessage(user)},
+		})
+		require.NoError(t, err)
+		return resp.Choices[0].Message.Content
+	}
+
+	assert.Equal(t, `{"ok":true}`, ask("рубль и ставка"))
+	assert.Equal(t, "просто текст", ask("только рубль"))
+	require.Len(t, server.Requests(), 2)
+	assert.Equal(t, "json", server.Requests()[0].Rule)
+	assert.Len(t, server.RequestsFor("text"), 1)
+}
+
+func TestServerFailsOnUnscriptedPrompt(t *testing.T) {
+	server := New(t, Fixtures{})
+	client := openai.NewClient(option.WithBaseURL(server.URL()), option.WithAPIKey("k"), option.WithMaxRetries(0))
+
+	_, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Model:    "m",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("что угодно")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no fixture for prompt")
+}
+
+func TestEmbeddingsAreDeterministicBagOfWords(t *testing.T) {
+	server := New(t, Fixtures{})
+	client := openai.NewClient(option.WithBaseURL(server.URL()), option.WithAPIKey("k"))
+
+	resp, err := client.Embeddings.New(context.Background(), openai.EmbeddingNewParams{
+		Model: "emb",
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: []string{"Переговоры в Женеве", "переговоры, Женеве!"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, int64(5), resp.Usage.PromptTokens)
+	assert.InDelta(t, 1.0, Cosine(toFloat32(resp.Data[0].Embedding), toFloat32(resp.Data[1].Embedding)), 1e-6)
+
+	assert.Equal(t, Embed("ключевая ставка"), Embed("Ключевая ставка"))
+	assert.InDelta(t, 0.0, Cosine(Embed("ключевая ставка"), Embed("паводок якутии")), 1e-6)
+	assert.InDelta(t, 0.5, Cosine(Embed("ключевая ставка"), Embed("ключевая новость")), 1e-6)
+}
+
+func toFloat32(in []float64) []float32 {
+	out := make([]float32, len(in))
+	for i, v := range in {
+		out[i] = float32(v)
+	}
+	return out
+}
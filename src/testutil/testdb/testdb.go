@@ -0,0 +1,91 @@
+// Package testdb поднимает изолированную схему в тестовом Postgres и применяет
+// к ней db/migrations. Тесты, которым нужна БД, пропускаются без TEST_DATABASE_URL.
+package testdb
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Open создаёт схему e2e_<timestamp>, применяет к ней все миграции по порядку и
+// возвращает подключение с search_path на эту схему. Схема удаляется в t.Cleanup.
+// Postgres должен иметь пакет pgvector (>= 0.5 для HNSW-индекса из 0004).
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	// Расширение ставим в public заранее, иначе 0002 создаст его внутри временной схемы.
+	if _, err := admin.Exec(`CREATE EXTENSION IF NOT EXISTS vector SCHEMA public`); err != nil {
+		t.Fatalf("failed to create vector extension: %v", err)
+	}
+
+	schema := fmt.Sprintf("e2e_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Logf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema+",public"))
+	if err != nil {
+		t.Fatalf("failed to open schema connection: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, path := range migrations(t) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read migration %s: %v", path, err)
+		}
+		if _, err := db.Exec(string(data)); err != nil {
+			t.Fatalf("failed to apply migration %s: %v", filepath.Base(path), err)
+		}
+	}
+	return db
+}
+
+// withSearchPath добавляет search_path в DSN (URL или key=value); lib/pq передаёт
+// неизвестные параметры серверу как runtime-параметры.
+func withSearchPath(dsn, searchPath string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return fmt.Sprintf("%s search_path=%s", dsn, searchPath)
+	}
+	q := u.Query()
+	q.Set("search_path", searchPath)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func migrations(t testing.TB) []string {
+	t.Helper()
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "..", "db", "migrations")
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no migrations found in %s", dir)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,179 @@
+// Package textutil contains language helpers shared by the bot services.
+package textutil
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Token is a word of a text with its byte offsets in the original string.
+type Token struct {
+	Word       string // lower-cased, ё folded into е
+	Start, End int
+}
+
+// Tokenize splits text into letter/digit words.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, Token{Word: normalize(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Word: normalize(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+func normalize(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+// Suffix groups of the Snowball Russian stemmer
+// (https://snowballstem.org/algorithms/russian/stemmer.html).
+// Group 1 endings must be preceded by а or я, which stays in the stem.
+var (
+	perfectiveGerund1 = endings("в", "вши", "вшись")
+	perfectiveGerund2 = endings("ив", "ивши", "ившись", "ыв", "ывши", "ывшись")
+	adjective         = endings("ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею")
+	participle1 = endings("ем", "нн", "вш", "ющ", "щ")
+	participle2 = endings("ивш", "ывш", "ующ")
+	reflexive   = endings("ся", "сь")
+	verb1       = endings("ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно")
+	verb2       = endings("ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю")
+	noun = endings("а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я")
+	derivational = endings("ост", "ость")
+	superlative  = endings("ейш", "ейше")
+)
+
+// endings converts suffixes to runes, longest first, so that the longest match wins.
+func endings(list ...string) [][]rune {
+	result := make([][]rune, len(list))
+	for i, s := range list {
+		result[i] = []rune(s)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return len(result[i]) > len(result[j]) })
+	return result
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// StemRussian returns the Snowball stem of a lower-case Russian word; other words
+// are returned as is. It lets "Газпрома" and "газпромом" match a "газпром" watch.
+func StemRussian(word string) string {
+	w := []rune(normalize(word))
+
+	// RV is the region after the first vowel, R2 is the standard Snowball R2.
+	rv := len(w)
+	for i, r := range w {
+		if isVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	if rv >= len(w) {
+		return string(w)
+	}
+	r2 := regionAfterVowelConsonant(w, regionAfterVowelConsonant(w, 0))
+
+	// Step 1.
+	if n, ok := matchEnding(w, rv, perfectiveGerund1, true); ok {
+		w = w[:len(w)-n]
+	} else if n, ok := matchEnding(w, rv, perfectiveGerund2, false); ok {
+		w = w[:len(w)-n]
+	} else {
+		if n, ok := matchEnding(w, rv, reflexive, false); ok {
+			w = w[:len(w)-n]
+		}
+		if n, ok := matchEnding(w, rv, adjective, false); ok {
+			w = w[:len(w)-n]
+			if n, ok := matchEnding(w, rv, participle1, true); ok {
+				w = w[:len(w)-n]
+			} else if n, ok := matchEnding(w, rv, participle2, false); ok {
+				w = w[:len(w)-n]
+			}
+		} else if n, ok := matchEnding(w, rv, verb1, true); ok {
+			w = w[:len(w)-n]
+		} else if n, ok := matchEnding(w, rv, verb2, false); ok {
+			w = w[:len(w)-n]
+		} else if n, ok := matchEnding(w, rv, noun, false); ok {
+			w = w[:len(w)-n]
+		}
+	}
+
+	// Step 2.
+	if len(w) > rv && w[len(w)-1] == 'и' {
+		w = w[:len(w)-1]
+	}
+
+	// Step 3.
+	if n, ok := matchEnding(w, r2, derivational, false); ok {
+		w = w[:len(w)-n]
+	}
+
+	// Step 4.
+	if n, ok := matchEnding(w, rv, superlative, false); ok {
+		w = w[:len(w)-n]
+	}
+	switch {
+	case len(w)-2 >= rv && w[len(w)-1] == 'н' && w[len(w)-2] == 'н':
+		w = w[:len(w)-1]
+	case len(w) > rv && w[len(w)-1] == 'ь':
+		w = w[:len(w)-1]
+	}
+	return string(w)
+}
+
+// regionAfterVowelConsonant returns the start of the region after the first
+// non-vowel following a vowel, searching from position from.
+func regionAfterVowelConsonant(w []rune, from int) int {
+	for i := from + 1; i < len(w); i++ {
+		if !isVowel(w[i]) && isVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// matchEnding finds the longest ending lying inside the region starting at region.
+// With afterAOrYa the ending must be preceded by а or я inside the region.
+func matchEnding(w []rune, region int, list [][]rune, afterAOrYa bool) (int, bool) {
+	for _, e := range list {
+		start := len(w) - len(e)
+		if start < region || !hasSuffix(w, e) {
+			continue
+		}
+		if afterAOrYa && (start-1 < region || (w[start-1] != 'а' && w[start-1] != 'я')) {
+			continue
+		}
+		return len(e), true
+	}
+	return 0, false
+}
+
+func hasSuffix(w, suffix []rune) bool {
+	if len(suffix) > len(w) {
+		return false
+	}
+	for i := range suffix {
+		if w[len(w)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
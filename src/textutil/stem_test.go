@@ -0,0 +1,58 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStemRussian(t *testing.T) {
+	// Snowball over-stems some nominatives ("газпром" -> "газпр"); the watch matcher
+	// compensates by also comparing stems with the term as typed.
+	cases := map[string]string{
+		"газпром":     "газпр",
+		"газпрома":    "газпром",
+		"газпромом":   "газпром",
+		"москва":      "москв",
+		"москве":      "москв",
+		"москвой":     "москв",
+		"президента":  "президент",
+		"президентом": "президент",
+		"вавилонской": "вавилонск",
+		"важнейшие":   "важн",
+		"новостей":    "новост",
+		"ёлка":        "елк",
+		"бывшими":     "бывш",
+		"улыбаясь":    "улыб",
+		"iphone":      "iphone",
+		"и":           "и",
+	}
+	for word, want := range cases {
+		assert.Equal(t, want, StemRussian(word), word)
+	}
+}
+
+func TestStemRussian_FormsShareStem(t *testing.T) {
+	for _, forms := range [][]string{
+		{"сбербанк", "сбербанка", "сбербанку", "сбербанком", "Сбербанке"},
+		{"санкции", "санкций", "санкциями", "санкциях"},
+		{"Путин", "Путина", "Путину", "Путиным"},
+	} {
+		stem := StemRussian(forms[0])
+		for _, f := range forms[1:] {
+			assert.Equal(t, stem, StemRussian(f), f)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "Акции «Газпрома» выросли на 5%"
+	tokens := Tokenize(text)
+
+	words := make([]string, len(tokens))
+	for i, tok := range tokens {
+		words[i] = tok.Word
+	}
+	assert.Equal(t, []string{"акции", "газпрома", "выросли", "на", "5"}, words)
+	assert.Equal(t, "Газпрома", text[tokens[1].Start:tokens[1].End])
+}